@@ -17,9 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/3scale-ops/marin3r/pkg/util/cron"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -39,6 +44,15 @@ const (
 	// tainted
 	RollbackFailedCondition string = "RollbackFailed"
 
+	// VersionPinnedCondition indicates that the EnvoyConfig object
+	// has a pinned version in its spec
+	VersionPinnedCondition string = "VersionPinned"
+
+	// PublishingHeldCondition indicates that the EnvoyConfig object has
+	// a new revision waiting to be published because publishing is paused
+	// or outside of the publish schedule
+	PublishingHeldCondition string = "PublishingHeld"
+
 	/* State */
 
 	//InSyncState indicates that a EnvoyConfig object has its resources spec
@@ -52,6 +66,100 @@ const (
 	// RollbackFailedState indicates that there is no untainted revision that
 	// can be pusblished in the xds server cache
 	RollbackFailedState string = "RollbackFailed"
+
+	// PinnedState indicates that a EnvoyConfig object is publishing the
+	// revision with the version pinned in its spec, regardless of the resources spec
+	PinnedState string = "Pinned"
+
+	// RollingOutState indicates that a EnvoyConfig object is publishing a
+	// new revision to a subset of the Envoy clients as part of a canary rollout
+	RollingOutState string = "RollingOut"
+
+	// PartialRollbackState indicates that a EnvoyConfig object is publishing the
+	// revision for its resources spec, with some of the resource types rolled back
+	// to a previous revision because the Envoy clients are rejecting them
+	PartialRollbackState string = "PartialRollback"
+
+	// PausedState indicates that a EnvoyConfig object has a new revision
+	// that is not being published because publishing is paused
+	PausedState string = "Paused"
+
+	// WaitingForScheduleState indicates that a EnvoyConfig object has a new
+	// revision waiting for the next window of its publish schedule
+	WaitingForScheduleState string = "WaitingForSchedule"
+
+	// WaitingForApprovalState indicates that a EnvoyConfig object has a new
+	// revision that is waiting to be approved before it is published
+	WaitingForApprovalState string = "WaitingForApproval"
+
+	/* Annotations */
+
+	// ResyncAtAnnotation requests the discovery service to close the xDS streams
+	// of the EnvoyConfig's node ID that were opened before the given time, in RFC3339
+	// format, so the Envoy clients reconnect and receive a full push of the resources
+	ResyncAtAnnotation string = "marin3r.3scale.net/resync-at"
+
+	// ResyncPodsAnnotation holds a comma separated list of Pod names that limits
+	// the resync requested with the ResyncAtAnnotation to the streams of those Pods
+	ResyncPodsAnnotation string = "marin3r.3scale.net/resync-pods"
+
+	// UntaintAtAnnotation clears the taint of an EnvoyConfigRevision if it was tainted
+	// before the given time, in RFC3339 format. When set in an EnvoyConfig, it applies
+	// to all the EnvoyConfigRevisions of the EnvoyConfig.
+	UntaintAtAnnotation string = "marin3r.3scale.net/untaint-at"
+
+	// ChangeCauseAnnotation describes the cause of the last change to the EnvoyConfig. It
+	// is copied to the EnvoyConfigRevision created for the resources of the change.
+	ChangeCauseAnnotation string = "marin3r.3scale.net/change-cause"
+
+	// ChangedByAnnotation records the user that last changed the spec of the EnvoyConfig,
+	// or of an EnvoyConfigFragment. It is set by the admission webhook. In EnvoyConfigRevisions
+	// it holds the comma separated list of the users that changed the EnvoyConfig or the
+	// fragments since the previous revision. It is not set in the revision when the change
+	// does not come from them, like changes to ConfigMaps, as the author is unknown.
+	ChangedByAnnotation string = "marin3r.3scale.net/changed-by"
+
+	// InputsAnnotation records in EnvoyConfigRevisions a hash of the spec of the EnvoyConfig,
+	// and of each of the EnvoyConfigFragments, that the revision is generated from, so the
+	// authors of the next revision can be told apart from the authors of the previous ones.
+	InputsAnnotation string = "marin3r.3scale.net/inputs"
+
+	// SourceAnnotation identifies the source the EnvoyConfig is generated from, like
+	// a Git repository. It is copied to the EnvoyConfigRevisions.
+	SourceAnnotation string = "marin3r.3scale.net/source"
+
+	// SourceCommitAnnotation is the commit of the source the EnvoyConfig is generated
+	// from. It is copied to the EnvoyConfigRevisions.
+	SourceCommitAnnotation string = "marin3r.3scale.net/source-commit"
+
+	/* Defaults */
+
+	// DefaultMaxRevisions is the default maximum number of EnvoyConfigRevisions
+	// kept for an EnvoyConfig
+	DefaultMaxRevisions int = 10
+
+	// DefaultMaxArchivedRevisions is the default maximum number of revisions
+	// kept in the revision archive ConfigMap
+	DefaultMaxArchivedRevisions int = 50
+
+	// DefaultCanaryBakeTime is the default minimum duration of a canary step
+	DefaultCanaryBakeTime time.Duration = 5 * time.Minute
+
+	// DefaultTaintNACKThreshold is the default number of NACKs after
+	// which an Envoy client is considered to be failing a revision
+	DefaultTaintNACKThreshold int64 = 5
+
+	// DefaultTaintFailingPodsPercentage is the default percentage of failing
+	// Envoy clients that causes a revision to be tainted
+	DefaultTaintFailingPodsPercentage int = 100
+
+	// DefaultTaintMinObservedPods is the default minimum number of Envoy
+	// clients that need to be observed before a revision can be tainted
+	DefaultTaintMinObservedPods int = 1
+
+	// maxTaintRetryBackoffExponent caps the exponential backoff of the
+	// taint retries to 2^6 times the configured retry time
+	maxTaintRetryBackoffExponent int = 6
 )
 
 // EnvoyConfigSpec defines the desired state of EnvoyConfig
@@ -81,6 +189,414 @@ type EnvoyConfigSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	Resources []Resource `json:"resources,omitempty"`
+	// Parameters enables templating of the resources. When parameters are set, the value
+	// of each resource is rendered as a Go template, with the parameters as data, before
+	// generating the revisions. For example: "address": "{{ .upstream_host }}". These
+	// parameters take precedence over the ones from spec.parametersFrom.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// ParametersFrom is a list of ConfigMaps and Secrets, in the namespace of the EnvoyConfig,
+	// whose keys are used as template parameters. When a key exists in more than one source,
+	// the last one takes precedence. Changes to the sources generate new revisions. Note that
+	// the rendered resources, including values from Secrets, are stored in the revisions: the
+	// revisions rendered with Secrets are marked with the secret-parameters annotation and
+	// the values from Secrets are redacted in the render and diff debug APIs.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	ParametersFrom []ParametersSource `json:"parametersFrom,omitempty"`
+	// Patches is a list of patches to apply to the resources, so a base set of resources
+	// can be shared and customized. Patches are applied in order, after rendering templates
+	// and before validating the resources and generating the revisions.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Patches []ResourcePatch `json:"patches,omitempty"`
+	// RevisionHistory configures how many EnvoyConfigRevisions are kept
+	// for the EnvoyConfig and for how long
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	RevisionHistory *RevisionHistory `json:"revisionHistory,omitempty"`
+	// PinnedVersion forces the discovery service to publish the EnvoyConfigRevision
+	// with the given version, even if it does not match the current resources spec or
+	// it is tainted. The revision must exist. Automatic rollbacks are disabled
+	// while a version is pinned. Remove the field to resume normal publishing.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	PinnedVersion *string `json:"pinnedVersion,omitempty"`
+	// RolloutStrategy configures how new revisions are published to the Envoy
+	// clients. New revisions are published to all the clients at once if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+	// TaintPolicy configures when a published revision is tainted because it is
+	// being rejected by the Envoy clients. A revision is tainted if all the Envoy
+	// clients that have requested a resource type reject it 5 or more times if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	TaintPolicy *TaintPolicy `json:"taintPolicy,omitempty"`
+	// PublishingMode determines what is rolled back when a revision is tainted because
+	// the Envoy clients are rejecting some of its resource types. With "Revision", the
+	// default, the whole revision is rolled back. With "PerType", only the rejected types
+	// are rolled back and the newest healthy version of each type is published, as long
+	// as the references between types are satisfied (routes must only reference existing
+	// clusters and listeners must only reference existing route configurations). The
+	// whole revision is rolled back otherwise.
+	// +kubebuilder:validation:Enum=Revision;PerType
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	PublishingMode *PublishingMode `json:"publishingMode,omitempty"`
+	// Paused stops the publication of new revisions. Revisions are still created for the
+	// changes in the resources spec, but the published revision is not switched to them
+	// until publishing is resumed. Tainted revisions are still rolled back while paused.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Paused *bool `json:"paused,omitempty"`
+	// PublishSchedule restricts the publication of new revisions to the windows of
+	// the given schedule. New revisions wait for the next window to be published.
+	// Tainted revisions are still rolled back outside of the windows.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	PublishSchedule *PublishSchedule `json:"publishSchedule,omitempty"`
+	// ApprovalRequired makes new revisions wait to be published until they are approved.
+	// A revision is approved by setting the "marin3r.3scale.net/approved-version" annotation
+	// in the EnvoyConfigRevision to its version. The approver is recorded in the revision
+	// by the admission webhook, which denies approvals from the user that changed the
+	// EnvoyConfig. Only approved revisions are published, also when rolling back. The
+	// revision that is published when approval is enabled keeps being published until the
+	// first revision is approved.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	ApprovalRequired *bool `json:"approvalRequired,omitempty"`
+}
+
+// PublishSchedule defines the windows in which new revisions can be published
+type PublishSchedule struct {
+	// Schedule is a standard 5 field cron expression (minute, hour, day of month,
+	// month and day of week) with the times at which the publish windows open
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Schedule string `json:"schedule"`
+	// Duration is how long the publish windows stay open
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Duration metav1.Duration `json:"duration"`
+	// TimeZone is the IANA name of the time zone of the schedule. Defaults to UTC.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+}
+
+// GetLocation returns the time zone of the schedule
+func (ps *PublishSchedule) GetLocation() (*time.Location, error) {
+	if ps.TimeZone == nil {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(*ps.TimeZone)
+}
+
+// Window returns true if the given time is within a publish window. If it is not,
+// the time at which the next window opens is also returned (the zero time if
+// there is none).
+func (ps *PublishSchedule) Window(now time.Time) (bool, time.Time, error) {
+	schedule, err := cron.Parse(ps.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	loc, err := ps.GetLocation()
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	// the window is open if the schedule was activated within the last 'duration'
+	next := schedule.Next(now.In(loc).Add(-ps.Duration.Duration))
+	if !next.IsZero() && !next.After(now) {
+		return true, time.Time{}, nil
+	}
+	return false, next, nil
+}
+
+// PublishingMode is the mode used to publish the revisions of an EnvoyConfig
+type PublishingMode string
+
+const (
+	// RevisionPublishingMode publishes all the resource types from the same revision
+	RevisionPublishingMode PublishingMode = "Revision"
+	// PerTypePublishingMode publishes each resource type from its newest healthy revision
+	PerTypePublishingMode PublishingMode = "PerType"
+)
+
+// TaintPolicy configures when a revision is tainted. The thresholds are evaluated
+// separately for each resource type, using the statistics of the Envoy clients
+// that have requested that type.
+type TaintPolicy struct {
+	TaintThresholds `json:",inline"`
+	// Overrides sets different thresholds for specific resource types. The
+	// thresholds that are not set in an override are taken from the policy.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Overrides []TaintPolicyOverride `json:"overrides,omitempty"`
+	// RetryAfter, if set, clears the taint of a revision after a backoff so the
+	// revision is evaluated again. The backoff starts with the given duration and
+	// doubles with each retry of the same revision, up to 64 times the given duration.
+	// Tainted revisions are never retried automatically if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	RetryAfter *metav1.Duration `json:"retryAfter,omitempty"`
+}
+
+// TaintPolicyOverride sets the thresholds of the taint policy for a resource type
+type TaintPolicyOverride struct {
+	TaintThresholds `json:",inline"`
+	// Type is the resource type the override applies to
+	// +kubebuilder:validation:Enum=listener;route;scopedRoute;cluster;endpoint;secret;runtime;extensionConfig;
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Type envoy.Type `json:"type"`
+}
+
+// TaintThresholds are the thresholds that determine when a revision is tainted
+type TaintThresholds struct {
+	// NACKThreshold is the number of times an Envoy client has to reject a
+	// revision to be considered failing. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	NACKThreshold *int64 `json:"nackThreshold,omitempty"`
+	// FailingPodsPercentage is the percentage of failing Envoy clients that
+	// causes the revision to be tainted. Defaults to 100.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	FailingPodsPercentage *int `json:"failingPodsPercentage,omitempty"`
+	// MinObservedPods is the minimum number of Envoy clients that need to be
+	// observed before the revision can be tainted. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	MinObservedPods *int `json:"minObservedPods,omitempty"`
+}
+
+// GetNACKThreshold returns the number of NACKs after
+// which an Envoy client is considered failing
+func (t *TaintThresholds) GetNACKThreshold() int64 {
+	if t == nil || t.NACKThreshold == nil {
+		return DefaultTaintNACKThreshold
+	}
+	return *t.NACKThreshold
+}
+
+// GetFailingPodsPercentage returns the percentage of failing
+// Envoy clients that causes the revision to be tainted
+func (t *TaintThresholds) GetFailingPodsPercentage() int {
+	if t == nil || t.FailingPodsPercentage == nil {
+		return DefaultTaintFailingPodsPercentage
+	}
+	return *t.FailingPodsPercentage
+}
+
+// GetMinObservedPods returns the minimum number of Envoy clients
+// that need to be observed before the revision can be tainted
+func (t *TaintThresholds) GetMinObservedPods() int {
+	if t == nil || t.MinObservedPods == nil {
+		return DefaultTaintMinObservedPods
+	}
+	return *t.MinObservedPods
+}
+
+// GetRetryBackoff returns the time to wait before clearing the taint of a revision that
+// has already been retried the given number of times. It returns false if tainted revisions
+// should not be retried. It can be called on a nil TaintPolicy.
+func (p *TaintPolicy) GetRetryBackoff(retries int) (time.Duration, bool) {
+	if p == nil || p.RetryAfter == nil {
+		return 0, false
+	}
+	if retries > maxTaintRetryBackoffExponent {
+		retries = maxTaintRetryBackoffExponent
+	}
+	return p.RetryAfter.Duration << retries, true
+}
+
+// ForType returns the thresholds in effect for the given resource type, with
+// the defaults applied. It can be called on a nil TaintPolicy.
+func (p *TaintPolicy) ForType(rType envoy.Type) *TaintThresholds {
+	var base, override *TaintThresholds
+	if p != nil {
+		base = &p.TaintThresholds
+		for idx := range p.Overrides {
+			if p.Overrides[idx].Type == rType {
+				override = &p.Overrides[idx].TaintThresholds
+				break
+			}
+		}
+	}
+
+	t := &TaintThresholds{
+		NACKThreshold:         pointer.New(base.GetNACKThreshold()),
+		FailingPodsPercentage: pointer.New(base.GetFailingPodsPercentage()),
+		MinObservedPods:       pointer.New(base.GetMinObservedPods()),
+	}
+	if override != nil {
+		if override.NACKThreshold != nil {
+			t.NACKThreshold = override.NACKThreshold
+		}
+		if override.FailingPodsPercentage != nil {
+			t.FailingPodsPercentage = override.FailingPodsPercentage
+		}
+		if override.MinObservedPods != nil {
+			t.MinObservedPods = override.MinObservedPods
+		}
+	}
+	return t
+}
+
+// RolloutStrategy configures the publication of new revisions
+type RolloutStrategy struct {
+	// Canary publishes new revisions progressively, to an increasing subset of
+	// the Envoy clients, while the previous revision keeps serving the rest of them
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Canary *CanaryRolloutStrategy `json:"canary,omitempty"`
+}
+
+// CanaryRolloutStrategy configures the steps of a canary rollout. Each step publishes
+// the new revision to a subset of the Envoy clients and waits until all of them have
+// acknowledged it and the bake time has elapsed before moving on to the next step. A step
+// does not progress while no connected client is selected as canary. If any of the canary
+// clients rejects the new revision as many times as the NACK threshold of the taint policy,
+// the revision is tainted and the rollout aborted. The new revision is published to all the
+// clients after the last step.
+type CanaryRolloutStrategy struct {
+	// Steps of the rollout
+	// +kubebuilder:validation:MinItems=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Steps []CanaryStep `json:"steps"`
+}
+
+// CanaryStep is a step of a canary rollout. Exactly one of Percentage
+// or Selector must be set.
+type CanaryStep struct {
+	// Percentage of the Envoy clients that receive the new revision. The clients
+	// are selected using a hash of the 'pod_name' in their node metadata, so the
+	// clients selected for a percentage are also selected for any bigger percentage.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Percentage *int `json:"percentage,omitempty"`
+	// Selector selects the Pods, in the namespace of the EnvoyConfig,
+	// that receive the new revision
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// BakeTime is the minimum duration of the step, counted from its start. The step
+	// is promoted once the bake time has elapsed and all the canary clients have
+	// acknowledged the new revision. Defaults to 5m.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	BakeTime *metav1.Duration `json:"bakeTime,omitempty"`
+}
+
+// GetBakeTime returns the bake time of the step
+func (step *CanaryStep) GetBakeTime() time.Duration {
+	if step.BakeTime == nil {
+		return DefaultCanaryBakeTime
+	}
+	return step.BakeTime.Duration
+}
+
+// RevisionHistory is the policy that determines which EnvoyConfigRevisions are
+// pruned. The revision for the current resources and the published revision are
+// never pruned.
+type RevisionHistory struct {
+	// MaxRevisions is the maximum number of revisions to keep. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	MaxRevisions *int `json:"maxRevisions,omitempty"`
+	// MaxAge is the maximum age of the revisions to keep, counted from the last time
+	// the revision was published or, if never published, from its creation. Revisions
+	// are not pruned based on age if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+	// KeepTainted, if true, excludes tainted revisions from pruning, even if that
+	// makes the number of revisions exceed MaxRevisions. Keeping tainted revisions
+	// avoids publishing again a config that is known to fail if it is reapplied.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	KeepTainted *bool `json:"keepTainted,omitempty"`
+	// Archive, if set, stores the revisions that are pruned in a ConfigMap
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Archive *RevisionArchive `json:"archive,omitempty"`
+}
+
+// RevisionArchive configures the archival of pruned EnvoyConfigRevisions. Each
+// archived revision is stored as a gzip compressed key in the binaryData of the ConfigMap.
+type RevisionArchive struct {
+	// ConfigMapName is the name of the ConfigMap that holds the archived
+	// revisions. Defaults to '<envoyconfig-name>-revision-archive'.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	ConfigMapName *string `json:"configMapName,omitempty"`
+	// MaxRevisions is the maximum number of revisions kept in the archive. The
+	// oldest revisions are removed from the archive first. Defaults to 50.
+	// +kubebuilder:validation:Minimum=1
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	MaxRevisions *int `json:"maxRevisions,omitempty"`
+}
+
+// ParametersSource selects a ConfigMap or a Secret to take template parameters from
+type ParametersSource struct {
+	// ConfigMapRef selects a ConfigMap whose data keys are used as parameters
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+	// SecretRef selects a Secret whose data keys are used as parameters
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+}
+
+// PatchType is the type of a resource patch
+type PatchType string
+
+const (
+	// JSONPatchType is a JSON Patch (RFC 6902)
+	JSONPatchType PatchType = "JSONPatch"
+	// ProtoMergePatchType is a partial resource that is merged into
+	// the target resource using protobuf merge semantics
+	ProtoMergePatchType PatchType = "ProtoMerge"
+)
+
+// ResourcePatch is a patch for one of the resources of the EnvoyConfig, targeted by type and name
+type ResourcePatch struct {
+	// Type is the type of the resource to patch
+	// +kubebuilder:validation:Enum=listener;route;scopedRoute;cluster;endpoint;runtime;extensionConfig;
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Type envoy.Type `json:"type"`
+	// Name is the name of the resource to patch
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Name string `json:"name"`
+	// PatchType is the type of the patch. A "JSONPatch" is a list of JSON Patch operations, with
+	// paths using the proto field names of the resource. A "ProtoMerge" patch is a partial
+	// resource that is merged into the target with protobuf merge semantics: fields set in the
+	// patch replace the ones in the target, messages are merged recursively and items in lists
+	// are appended, as lists have no merge keys. Note that typed configs are replaced as a whole.
+	// Defaults to "ProtoMerge".
+	// +kubebuilder:validation:Enum=JSONPatch;ProtoMerge
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	PatchType *PatchType `json:"patchType,omitempty"`
+	// Patch is the patch document, in JSON or YAML
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Patch string `json:"patch"`
+}
+
+// GetPatchType returns the type of the patch
+func (rp *ResourcePatch) GetPatchType() PatchType {
+	if rp.PatchType == nil {
+		return ProtoMergePatchType
+	}
+	return *rp.PatchType
 }
 
 // EnvoyConfigStatus defines the observed state of EnvoyConfig
@@ -111,8 +627,34 @@ type EnvoyConfigStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	// +optional
 	ConfigRevisions []ConfigRevisionRef `json:"revisions,omitempty"`
+	// Rollout holds the progress of the canary rollout in progress, if any
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// TypeRevisions holds the version of the revision each resource type is
+	// published from, when the publishing mode is PerType
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	TypeRevisions []TypeRevision `json:"typeRevisions,omitempty"`
 }
 
+// RolloutStatus is the progress of a canary rollout
+type RolloutStatus struct {
+	// Version is the version being rolled out
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Version string `json:"version"`
+	// Step is the index of the current step in the list of steps of the rollout
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Step int `json:"step"`
+	// StepStartedAt is the time the current step started
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	StepStartedAt metav1.Time `json:"stepStartedAt"`
+}
+
+// ProvenanceAnnotations are the annotations of an EnvoyConfig that describe
+// who changed it and why, which are copied to its EnvoyConfigRevisions
+var ProvenanceAnnotations = []string{ChangeCauseAnnotation, ChangedByAnnotation, SourceAnnotation, SourceCommitAnnotation}
+
 // ConfigRevisionRef holds a reference to EnvoyConfigRevision object
 type ConfigRevisionRef struct {
 	// Version is a hash of the EnvoyResources field
@@ -122,6 +664,26 @@ type ConfigRevisionRef struct {
 	// holds the configuration matching the Version field.
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Ref corev1.ObjectReference `json:"ref"`
+	// PublishedAt is the last time the revision was published
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	PublishedAt *metav1.Time `json:"publishedAt,omitempty"`
+	// ChangeCause is the cause of the change that created the revision
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	ChangeCause string `json:"changeCause,omitempty"`
+	// ChangedBy is the user that made the change that created the revision
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	ChangedBy string `json:"changedBy,omitempty"`
+	// Source is the source the revision was generated from
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Source string `json:"source,omitempty"`
+	// SourceCommit is the commit of the source the revision was generated from
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	SourceCommit string `json:"sourceCommit,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -163,10 +725,178 @@ func (ec *EnvoyConfig) GetSerialization() envoy_serializer.Serialization {
 	return envoy_serializer.Serialization(*ec.Spec.Serialization)
 }
 
+// GetResources returns the resources of the EnvoyConfig, converting them
+// from the deprecated spec.envoyResources field if required
+func (ec *EnvoyConfig) GetResources() ([]Resource, error) {
+	if ec.Spec.EnvoyResources != nil {
+		return ec.Spec.EnvoyResources.Resources(ec.GetSerialization())
+	}
+	return ec.Spec.Resources, nil
+}
+
+// IsTemplated returns true if the resources of the EnvoyConfig are
+// templates that need to be rendered with the parameters
+func (ec *EnvoyConfig) IsTemplated() bool {
+	return len(ec.Spec.Parameters) > 0 || len(ec.Spec.ParametersFrom) > 0
+}
+
+// GetSecretParametersSources returns the names of the Secrets the
+// template parameters are taken from
+func (ec *EnvoyConfig) GetSecretParametersSources() []string {
+	names := []string{}
+	for _, src := range ec.Spec.ParametersFrom {
+		if src.SecretRef != nil {
+			names = append(names, src.SecretRef.Name)
+		}
+	}
+	return names
+}
+
 // GetEnvoyResourcesVersion returns the hash of the resources in the spec which
 // univoquely identifies the version of the resources.
 func (ec *EnvoyConfig) GetEnvoyResourcesVersion() string {
-	return reconcilerutil.Hash(ec.Spec.Resources)
+	return ResourcesVersion(ec.Spec.Resources)
+}
+
+// GetResyncAt returns the time set in the resync annotation, or nil if
+// no resync has been requested
+func (ec *EnvoyConfig) GetResyncAt() (*time.Time, error) {
+	return getTimeAnnotation(ec, ResyncAtAnnotation)
+}
+
+// GetUntaintAt returns the time set in the untaint annotation, or nil if
+// no untaint has been requested
+func (ec *EnvoyConfig) GetUntaintAt() (*time.Time, error) {
+	return getTimeAnnotation(ec, UntaintAtAnnotation)
+}
+
+// getTimeAnnotation returns the RFC3339 time set in the given
+// annotation of the object, or nil if the annotation is not set
+func getTimeAnnotation(o metav1.Object, annotation string) (*time.Time, error) {
+	value, ok := o.GetAnnotations()[annotation]
+	if !ok {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for annotation '%s': %w", annotation, err)
+	}
+	return &t, nil
+}
+
+// GetProvenance returns the provenance annotations set in the EnvoyConfig
+func (ec *EnvoyConfig) GetProvenance() map[string]string {
+	provenance := map[string]string{}
+	for _, key := range ProvenanceAnnotations {
+		if value, ok := ec.GetAnnotations()[key]; ok {
+			provenance[key] = value
+		}
+	}
+	return provenance
+}
+
+// InputsHash returns a hash of the fields of the spec that the resources
+// of the EnvoyConfig are generated from
+func (ec *EnvoyConfig) InputsHash() string {
+	return reconcilerutil.Hash([]interface{}{
+		ec.Spec.Resources, ec.Spec.EnvoyResources, ec.Spec.Parameters, ec.Spec.ParametersFrom, ec.Spec.Patches,
+	})
+}
+
+// GetResyncPods returns the Pods the resync is limited to. An
+// empty list means that the resync applies to all the Pods.
+func (ec *EnvoyConfig) GetResyncPods() []string {
+	pods := []string{}
+	for _, pod := range strings.Split(ec.GetAnnotations()[ResyncPodsAnnotation], ",") {
+		if pod = strings.TrimSpace(pod); pod != "" {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+// GetPinnedVersion returns the pinned version, or an empty
+// string if no version is pinned
+func (ec *EnvoyConfig) GetPinnedVersion() string {
+	if ec.Spec.PinnedVersion == nil {
+		return ""
+	}
+	return *ec.Spec.PinnedVersion
+}
+
+// IsPaused returns true if the publication of new revisions is paused
+func (ec *EnvoyConfig) IsPaused() bool {
+	return ec.Spec.Paused != nil && *ec.Spec.Paused
+}
+
+// RequiresApproval returns true if new revisions have to be approved before being published
+func (ec *EnvoyConfig) RequiresApproval() bool {
+	return ec.Spec.ApprovalRequired != nil && *ec.Spec.ApprovalRequired
+}
+
+// GetPublishingMode returns the publishing mode of the EnvoyConfig
+func (ec *EnvoyConfig) GetPublishingMode() PublishingMode {
+	if ec.Spec.PublishingMode == nil {
+		return RevisionPublishingMode
+	}
+	return *ec.Spec.PublishingMode
+}
+
+// GetCanarySteps returns the steps of the canary rollout
+// strategy, or nil if the canary strategy is not enabled
+func (ec *EnvoyConfig) GetCanarySteps() []CanaryStep {
+	if ec.Spec.RolloutStrategy == nil || ec.Spec.RolloutStrategy.Canary == nil {
+		return nil
+	}
+	return ec.Spec.RolloutStrategy.Canary.Steps
+}
+
+// GetMaxRevisions returns the maximum number of revisions to keep
+func (ec *EnvoyConfig) GetMaxRevisions() int {
+	if ec.Spec.RevisionHistory == nil || ec.Spec.RevisionHistory.MaxRevisions == nil {
+		return DefaultMaxRevisions
+	}
+	return *ec.Spec.RevisionHistory.MaxRevisions
+}
+
+// GetMaxRevisionAge returns the maximum age of the revisions to
+// keep, or nil if revisions should not be pruned based on age
+func (ec *EnvoyConfig) GetMaxRevisionAge() *time.Duration {
+	if ec.Spec.RevisionHistory == nil || ec.Spec.RevisionHistory.MaxAge == nil {
+		return nil
+	}
+	return &ec.Spec.RevisionHistory.MaxAge.Duration
+}
+
+// KeepTaintedRevisions returns true if tainted revisions should not be pruned
+func (ec *EnvoyConfig) KeepTaintedRevisions() bool {
+	if ec.Spec.RevisionHistory == nil || ec.Spec.RevisionHistory.KeepTainted == nil {
+		return false
+	}
+	return *ec.Spec.RevisionHistory.KeepTainted
+}
+
+// IsRevisionArchiveEnabled returns true if pruned revisions should be archived
+func (ec *EnvoyConfig) IsRevisionArchiveEnabled() bool {
+	return ec.Spec.RevisionHistory != nil && ec.Spec.RevisionHistory.Archive != nil
+}
+
+// GetRevisionArchiveConfigMapName returns the name of the ConfigMap where
+// pruned revisions are archived
+func (ec *EnvoyConfig) GetRevisionArchiveConfigMapName() string {
+	if !ec.IsRevisionArchiveEnabled() || ec.Spec.RevisionHistory.Archive.ConfigMapName == nil {
+		return fmt.Sprintf("%s-revision-archive", ec.GetName())
+	}
+	return *ec.Spec.RevisionHistory.Archive.ConfigMapName
+}
+
+// GetMaxArchivedRevisions returns the maximum number of revisions
+// kept in the revision archive
+func (ec *EnvoyConfig) GetMaxArchivedRevisions() int {
+	if !ec.IsRevisionArchiveEnabled() || ec.Spec.RevisionHistory.Archive.MaxRevisions == nil {
+		return DefaultMaxArchivedRevisions
+	}
+	return *ec.Spec.RevisionHistory.Archive.MaxRevisions
 }
 
 // Default implements defaulting for the EnvoyConfig resource
@@ -19,12 +19,13 @@ package v1alpha1
 import (
 	"reflect"
 	"testing"
+	"time"
 
-	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -110,7 +111,7 @@ func TestEnvoyConfig_GetEnvoyResourcesVersion(t *testing.T) {
 					},
 				}
 			},
-			reconcilerutil.Hash([]Resource{}),
+			"5f8cb5778f",
 		},
 	}
 
@@ -124,51 +125,291 @@ func TestEnvoyConfig_GetEnvoyResourcesVersion(t *testing.T) {
 	}
 }
 
+func TestEnvoyConfig_GetResyncAt(t *testing.T) {
+	cases := []struct {
+		testName                   string
+		envoyConfigRevisionFactory func() *EnvoyConfig
+		expectedResult             *time.Time
+		expectedError              bool
+	}{
+		{"Without annotation",
+			func() *EnvoyConfig {
+				return &EnvoyConfig{}
+			},
+			nil,
+			false,
+		},
+		{"With annotation",
+			func() *EnvoyConfig {
+				return &EnvoyConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{ResyncAtAnnotation: "2024-01-01T10:00:00Z"},
+					},
+				}
+			},
+			pointer.New(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+			false,
+		},
+		{"With invalid annotation",
+			func() *EnvoyConfig {
+				return &EnvoyConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{ResyncAtAnnotation: "now"},
+					},
+				}
+			},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			receivedResult, err := tc.envoyConfigRevisionFactory().GetResyncAt()
+			if (err != nil) != tc.expectedError {
+				subT.Errorf("Expected error differs: Expected: %v, Received: %v", tc.expectedError, err)
+			}
+			if !reflect.DeepEqual(receivedResult, tc.expectedResult) {
+				subT.Errorf("Expected result differs: Expected: %v, Received: %v", tc.expectedResult, receivedResult)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_GetProvenance(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{"Without annotations", nil, map[string]string{}},
+		{"Returns only the provenance annotations",
+			map[string]string{ChangeCauseAnnotation: "cause", SourceCommitAnnotation: "abcdef", ResyncPodsAnnotation: "pod-a"},
+			map[string]string{ChangeCauseAnnotation: "cause", SourceCommitAnnotation: "abcdef"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations}}
+			if got := ec.GetProvenance(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProvenance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_GetResyncPods(t *testing.T) {
+	cases := []struct {
+		testName                   string
+		envoyConfigRevisionFactory func() *EnvoyConfig
+		expectedResult             []string
+	}{
+		{"Without annotation",
+			func() *EnvoyConfig {
+				return &EnvoyConfig{}
+			},
+			[]string{},
+		},
+		{"With annotation",
+			func() *EnvoyConfig {
+				return &EnvoyConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{ResyncPodsAnnotation: "pod-a, pod-b,"},
+					},
+				}
+			},
+			[]string{"pod-a", "pod-b"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			receivedResult := tc.envoyConfigRevisionFactory().GetResyncPods()
+			if !reflect.DeepEqual(receivedResult, tc.expectedResult) {
+				subT.Errorf("Expected result differs: Expected: %v, Received: %v", tc.expectedResult, receivedResult)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_RevisionHistory(t *testing.T) {
+	cases := []struct {
+		testName            string
+		spec                EnvoyConfigSpec
+		wantMaxRevisions    int
+		wantMaxAge          *time.Duration
+		wantKeepTainted     bool
+		wantArchive         bool
+		wantArchiveName     string
+		wantMaxArchivedRevs int
+	}{
+		{"Defaults",
+			EnvoyConfigSpec{},
+			DefaultMaxRevisions, nil, false, false, "ec-revision-archive", DefaultMaxArchivedRevisions,
+		},
+		{"With revision history",
+			EnvoyConfigSpec{RevisionHistory: &RevisionHistory{
+				MaxRevisions: pointer.New(3),
+				MaxAge:       &metav1.Duration{Duration: time.Hour},
+				KeepTainted:  pointer.New(true),
+				Archive:      &RevisionArchive{ConfigMapName: pointer.New("archive"), MaxRevisions: pointer.New(5)},
+			}},
+			3, pointer.New(time.Hour), true, true, "archive", 5,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			ec := &EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Name: "ec"}, Spec: tc.spec}
+			if got := ec.GetMaxRevisions(); got != tc.wantMaxRevisions {
+				subT.Errorf("GetMaxRevisions() = %v, want %v", got, tc.wantMaxRevisions)
+			}
+			if got := ec.GetMaxRevisionAge(); !reflect.DeepEqual(got, tc.wantMaxAge) {
+				subT.Errorf("GetMaxRevisionAge() = %v, want %v", got, tc.wantMaxAge)
+			}
+			if got := ec.KeepTaintedRevisions(); got != tc.wantKeepTainted {
+				subT.Errorf("KeepTaintedRevisions() = %v, want %v", got, tc.wantKeepTainted)
+			}
+			if got := ec.IsRevisionArchiveEnabled(); got != tc.wantArchive {
+				subT.Errorf("IsRevisionArchiveEnabled() = %v, want %v", got, tc.wantArchive)
+			}
+			if got := ec.GetRevisionArchiveConfigMapName(); got != tc.wantArchiveName {
+				subT.Errorf("GetRevisionArchiveConfigMapName() = %v, want %v", got, tc.wantArchiveName)
+			}
+			if got := ec.GetMaxArchivedRevisions(); got != tc.wantMaxArchivedRevs {
+				subT.Errorf("GetMaxArchivedRevisions() = %v, want %v", got, tc.wantMaxArchivedRevs)
+			}
+		})
+	}
+}
+
+func TestTaintPolicy_ForType(t *testing.T) {
+	policy := &TaintPolicy{
+		TaintThresholds: TaintThresholds{FailingPodsPercentage: pointer.New(50), MinObservedPods: pointer.New(3)},
+		Overrides: []TaintPolicyOverride{{
+			Type:            envoy.Secret,
+			TaintThresholds: TaintThresholds{NACKThreshold: pointer.New(int64(1)), MinObservedPods: pointer.New(1)},
+		}},
+	}
+	cases := []struct {
+		testName string
+		policy   *TaintPolicy
+		rType    envoy.Type
+		want     *TaintThresholds
+	}{
+		{"Defaults",
+			nil, envoy.Cluster,
+			&TaintThresholds{NACKThreshold: pointer.New(DefaultTaintNACKThreshold), FailingPodsPercentage: pointer.New(DefaultTaintFailingPodsPercentage), MinObservedPods: pointer.New(DefaultTaintMinObservedPods)},
+		},
+		{"Policy thresholds",
+			policy, envoy.Cluster,
+			&TaintThresholds{NACKThreshold: pointer.New(DefaultTaintNACKThreshold), FailingPodsPercentage: pointer.New(50), MinObservedPods: pointer.New(3)},
+		},
+		{"Override thresholds",
+			policy, envoy.Secret,
+			&TaintThresholds{NACKThreshold: pointer.New(int64(1)), FailingPodsPercentage: pointer.New(50), MinObservedPods: pointer.New(1)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			if got := tc.policy.ForType(tc.rType); !reflect.DeepEqual(got, tc.want) {
+				subT.Errorf("ForType() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTaintPolicy_GetRetryBackoff(t *testing.T) {
+	policy := &TaintPolicy{RetryAfter: &metav1.Duration{Duration: time.Minute}}
+	cases := []struct {
+		testName string
+		policy   *TaintPolicy
+		retries  int
+		want     time.Duration
+		wantOK   bool
+	}{
+		{"No policy", nil, 0, 0, false},
+		{"No retries", &TaintPolicy{}, 0, 0, false},
+		{"First retry", policy, 0, time.Minute, true},
+		{"Third retry", policy, 2, 4 * time.Minute, true},
+		{"Capped backoff", policy, 20, 64 * time.Minute, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			got, gotOK := tc.policy.GetRetryBackoff(tc.retries)
+			if got != tc.want || gotOK != tc.wantOK {
+				subT.Errorf("GetRetryBackoff() = (%v, %v), want (%v, %v)", got, gotOK, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestPublishSchedule_Window(t *testing.T) {
+	// 2024-01-01 is a monday
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		testName string
+		schedule *PublishSchedule
+		wantOpen bool
+		wantNext time.Time
+		wantErr  bool
+	}{
+		{"Open", &PublishSchedule{Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			true, time.Time{}, false},
+		{"Closed", &PublishSchedule{Schedule: "0 9 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			false, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), false},
+		{"Opens now", &PublishSchedule{Schedule: "0 10 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+			true, time.Time{}, false},
+		{"With time zone", &PublishSchedule{Schedule: "0 12 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: pointer.New("Europe/Madrid")},
+			false, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), false},
+		{"Invalid schedule", &PublishSchedule{Schedule: "0 9 * *", Duration: metav1.Duration{Duration: time.Hour}},
+			false, time.Time{}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.testName, func(subT *testing.T) {
+			gotOpen, gotNext, err := tc.schedule.Window(now)
+			if (err != nil) != tc.wantErr {
+				subT.Fatalf("Window() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if gotOpen != tc.wantOpen || !gotNext.Equal(tc.wantNext) {
+				subT.Errorf("Window() = (%v, %v), want (%v, %v)", gotOpen, gotNext, tc.wantOpen, tc.wantNext)
+			}
+		})
+	}
+}
+
 func TestEnvoySecretResource_Validate(t *testing.T) {
 	type fields struct {
 		Name string
 		Ref  *corev1.SecretReference
 	}
-	type args struct {
-		namespace string
-	}
 	tests := []struct {
 		name    string
 		fields  fields
-		args    args
 		wantErr bool
 	}{
 		{
-			name:   "Ref.Name cannot be empty in Ref",
-			fields: fields{Name: "aaa", Ref: &corev1.SecretReference{Name: ""}},
-			args: args{
-				namespace: "test",
-			},
+			name:    "Ref.Name cannot be empty in Ref",
+			fields:  fields{Name: "aaa", Ref: &corev1.SecretReference{Name: ""}},
 			wantErr: true,
 		},
 		{
-			name:   "Ref.Namespace can be empty in Ref",
-			fields: fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb"}},
-			args: args{
-				namespace: "test",
-			},
+			name:    "Ref.Namespace can be empty in Ref",
+			fields:  fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb"}},
 			wantErr: false,
 		},
 		{
-			name:   "Ref.Namespace can be set to the resource namespace",
-			fields: fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb", Namespace: "test"}},
-			args: args{
-				namespace: "test",
-			},
+			name:    "Ref.Namespace can be set to the resource namespace",
+			fields:  fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb", Namespace: "test"}},
 			wantErr: false,
 		},
 		{
-			name:   "Fail for any other value of Ref.Namespace",
-			fields: fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb", Namespace: "other"}},
-			args: args{
-				namespace: "test",
-			},
-			wantErr: true,
+			name:    "Ref.Namespace can be set to other namespaces",
+			fields:  fields{Name: "aaa", Ref: &corev1.SecretReference{Name: "bbb", Namespace: "other"}},
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -177,7 +418,7 @@ func TestEnvoySecretResource_Validate(t *testing.T) {
 				Name: tt.fields.Name,
 				Ref:  tt.fields.Ref,
 			}
-			if err := esr.Validate(tt.args.namespace); (err != nil) != tt.wantErr {
+			if err := esr.Validate(); (err != nil) != tt.wantErr {
 				t.Errorf("EnvoySecretResource.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -219,6 +460,18 @@ func TestEnvoySecretResource_GetSecretKey(t *testing.T) {
 			args: args{namespace: "bbb"},
 			want: types.NamespacedName{Name: "aaa", Namespace: "bbb"},
 		},
+		{
+			name: "Returns a key in the namespace of Ref",
+			fields: fields{
+				Name: "test",
+				Ref: &corev1.SecretReference{
+					Name:      "aaa",
+					Namespace: "other",
+				},
+			},
+			args: args{namespace: "ns"},
+			want: types.NamespacedName{Name: "aaa", Namespace: "other"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
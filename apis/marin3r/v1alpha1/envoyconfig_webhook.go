@@ -17,12 +17,19 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/3scale-ops/basereconciler/util"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/3scale-ops/marin3r/pkg/util/cron"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -49,7 +56,7 @@ func (r *EnvoyConfig) ValidateCreate() (admission.Warnings, error) {
 	if err := r.Validate(); err != nil {
 		return nil, err
 	}
-	return nil, nil
+	return append(r.ValidateReferences(), r.ValidateDeprecations()...), nil
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -58,7 +65,7 @@ func (r *EnvoyConfig) ValidateUpdate(old runtime.Object) (admission.Warnings, er
 	if err := r.Validate(); err != nil {
 		return nil, err
 	}
-	return nil, nil
+	return append(r.ValidateReferences(), r.ValidateDeprecations()...), nil
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -70,6 +77,70 @@ func (r *EnvoyConfig) Validate() error {
 		return fmt.Errorf("one and only one of 'spec.EnvoyResources', 'spec.Resources' must be set")
 	}
 
+	if _, err := r.GetResyncAt(); err != nil {
+		return err
+	}
+
+	if _, err := r.GetUntaintAt(); err != nil {
+		return err
+	}
+
+	if r.Spec.PinnedVersion != nil && *r.Spec.PinnedVersion == "" {
+		return fmt.Errorf("'spec.pinnedVersion' cannot be empty")
+	}
+
+	for idx, step := range r.GetCanarySteps() {
+		if (step.Percentage == nil) == (step.Selector == nil) {
+			return fmt.Errorf("exactly one of 'percentage' or 'selector' must be set in 'spec.rolloutStrategy.canary.steps[%d]'", idx)
+		}
+		if step.Selector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(step.Selector); err != nil {
+				return fmt.Errorf("invalid selector in 'spec.rolloutStrategy.canary.steps[%d]': %w", idx, err)
+			}
+		}
+	}
+
+	if r.Spec.TaintPolicy != nil {
+		seen := map[envoy.Type]bool{}
+		for _, o := range r.Spec.TaintPolicy.Overrides {
+			if seen[o.Type] {
+				return fmt.Errorf("duplicated override for type '%s' in 'spec.taintPolicy.overrides'", o.Type)
+			}
+			seen[o.Type] = true
+		}
+		if r.Spec.TaintPolicy.RetryAfter != nil && r.Spec.TaintPolicy.RetryAfter.Duration <= 0 {
+			return fmt.Errorf("'spec.taintPolicy.retryAfter' must be a positive duration")
+		}
+	}
+
+	if ps := r.Spec.PublishSchedule; ps != nil {
+		if _, err := cron.Parse(ps.Schedule); err != nil {
+			return fmt.Errorf("invalid 'spec.publishSchedule.schedule': %w", err)
+		}
+		if ps.Duration.Duration < time.Minute {
+			return fmt.Errorf("'spec.publishSchedule.duration' must be at least 1m")
+		}
+		if _, err := ps.GetLocation(); err != nil {
+			return fmt.Errorf("invalid 'spec.publishSchedule.timeZone': %w", err)
+		}
+	}
+
+	for idx, src := range r.Spec.ParametersFrom {
+		if (src.ConfigMapRef == nil) == (src.SecretRef == nil) {
+			return fmt.Errorf("exactly one of 'configMapRef' or 'secretRef' must be set in 'spec.parametersFrom[%d]'", idx)
+		}
+	}
+
+	for idx, patch := range r.Spec.Patches {
+		if err := patch.Validate(r.GetEnvoyAPIVersion()); err != nil {
+			return fmt.Errorf("spec.patches[%d]: %w", idx, err)
+		}
+	}
+
+	if age := r.GetMaxRevisionAge(); age != nil && *age <= 0 {
+		return fmt.Errorf("'spec.revisionHistory.maxAge' must be a positive duration")
+	}
+
 	if r.Spec.EnvoyResources != nil {
 		if err := r.ValidateEnvoyResources(); err != nil {
 			return err
@@ -81,6 +152,13 @@ func (r *EnvoyConfig) Validate() error {
 		}
 	}
 
+	// the patches of templated EnvoyConfigs can only be applied once rendered
+	if !r.IsTemplated() {
+		if err := r.DeepCopy().ApplyPatches(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -88,13 +166,36 @@ func (r *EnvoyConfig) Validate() error {
 func (r *EnvoyConfig) ValidateResources() error {
 	errList := []error{}
 
-	for _, res := range r.Spec.Resources {
+	for idx, res := range r.Spec.Resources {
+
+		if res.GenerateFromConfigMap != nil {
+			if res.Type != envoy.Runtime {
+				errList = append(errList, fmt.Errorf("'generateFromConfigMap' can only be used type '%s'", envoy.Runtime))
+			} else if res.Value != nil {
+				errList = append(errList, fmt.Errorf("only one of 'generateFromConfigMap', 'value' allowed for type '%s'", envoy.Runtime))
+			}
+		}
+
+		if res.GenerateFromCertificate != nil {
+			if res.Type != envoy.Secret {
+				errList = append(errList, fmt.Errorf("'generateFromCertificate' can only be used type '%s'", envoy.Secret))
+			} else if res.GenerateFromTlsSecret != nil || res.GenerateFromOpaqueSecret != nil {
+				errList = append(errList, fmt.Errorf("only one of 'generateFromCertificate', 'generateFromTlsSecret', 'generateFromOpaqueSecret' allowed for type '%s'", envoy.Secret))
+			}
+			if res.GenerateFromCertificate.Name == "" {
+				errList = append(errList, fmt.Errorf("spec.resources[%d].generateFromCertificate: 'name' cannot be empty", idx))
+			}
+		}
+
+		if res.SecretNamespace != nil && res.GenerateFromTlsSecret == nil && res.GenerateFromOpaqueSecret == nil {
+			errList = append(errList, fmt.Errorf("'secretNamespace' can only be used with 'generateFromTlsSecret' or 'generateFromOpaqueSecret'"))
+		}
 
 		switch res.Type {
 
 		case envoy.Secret:
-			if res.GenerateFromTlsSecret == nil && res.GenerateFromOpaqueSecret == nil {
-				errList = append(errList, fmt.Errorf("one of 'generateFromTlsSecret', 'generateFromOpaqueSecret' must be set for type '%s'", envoy.Secret))
+			if res.GenerateFromTlsSecret == nil && res.GenerateFromOpaqueSecret == nil && res.GenerateFromCertificate == nil {
+				errList = append(errList, fmt.Errorf("one of 'generateFromTlsSecret', 'generateFromOpaqueSecret', 'generateFromCertificate' must be set for type '%s'", envoy.Secret))
 			}
 			if res.Value != nil {
 				errList = append(errList, fmt.Errorf("'value' cannot be used for type '%s'", envoy.Secret))
@@ -111,8 +212,8 @@ func (r *EnvoyConfig) ValidateResources() error {
 				errList = append(errList, fmt.Errorf("one of 'generateFromEndpointSlice', 'value' must be set for type '%s'", envoy.Secret))
 			}
 			if res.Value != nil {
-				if err := envoy_resources.Validate(string(res.Value.Raw), envoy_serializer.JSON, r.GetEnvoyAPIVersion(), envoy.Type(res.Type)); err != nil {
-					errList = append(errList, err)
+				if err := r.validateValue(res); err != nil {
+					errList = append(errList, fmt.Errorf("spec.resources[%d].value: %w", idx, err))
 				}
 			}
 			if res.GenerateFromTlsSecret != nil {
@@ -133,14 +234,32 @@ func (r *EnvoyConfig) ValidateResources() error {
 				errList = append(errList, fmt.Errorf("'blueprint' cannot be empty for type '%s'", envoy.Secret))
 			}
 			if res.Value != nil {
-				if err := envoy_resources.Validate(string(res.Value.Raw), envoy_serializer.JSON, r.GetEnvoyAPIVersion(), envoy.Type(res.Type)); err != nil {
-					errList = append(errList, err)
+				if err := r.validateValue(res); err != nil {
+					errList = append(errList, fmt.Errorf("spec.resources[%d].value: %w", idx, err))
 				}
-			} else {
+			} else if res.GenerateFromConfigMap == nil {
 				errList = append(errList, fmt.Errorf("'value' cannot be empty for type '%s'", res.Type))
 			}
 		}
 
+		for i, ds := range res.DataSourcesFrom {
+			if res.Value == nil {
+				errList = append(errList, fmt.Errorf("spec.resources[%d]: 'dataSourcesFrom' requires 'value'", idx))
+				break
+			}
+			if ds.Name == "" || ds.Key == "" {
+				errList = append(errList, fmt.Errorf("spec.resources[%d].dataSourcesFrom[%d]: 'name' and 'key' cannot be empty", idx, i))
+			}
+			if (ds.Path == nil) == (ds.Placeholder == nil) {
+				errList = append(errList, fmt.Errorf("spec.resources[%d].dataSourcesFrom[%d]: exactly one of 'path' or 'placeholder' must be set", idx, i))
+			} else if ds.Path != nil && !strings.HasPrefix(*ds.Path, "/") {
+				errList = append(errList, fmt.Errorf("spec.resources[%d].dataSourcesFrom[%d]: 'path' must be a JSON pointer starting with '/'", idx, i))
+			}
+		}
+
+		if res.TTL != nil && res.TTL.Duration < MinResourceTTL {
+			errList = append(errList, fmt.Errorf("'ttl' must be at least %s", MinResourceTTL))
+		}
 	}
 
 	if len(errList) > 0 {
@@ -149,6 +268,157 @@ func (r *EnvoyConfig) ValidateResources() error {
 	return nil
 }
 
+// validateValue validates the value of a resource against its schema. The values of templated
+// EnvoyConfigs that contain template actions can only be validated once rendered, so only the
+// syntax of the template is validated for them.
+func (r *EnvoyConfig) validateValue(res Resource) error {
+	if r.IsTemplated() && strings.Contains(string(res.Value.Raw), "{{") {
+		_, err := template.New("").Parse(string(res.Value.Raw))
+		return err
+	}
+	return envoy_resources.Validate(string(res.Value.Raw), envoy_serializer.JSON, r.GetEnvoyAPIVersion(), envoy.Type(res.Type))
+}
+
+// ValidateReferences returns a warning for each reference between the resources of the
+// EnvoyConfig that is not satisfied within the EnvoyConfig, like a route to a cluster that
+// is not defined. These are not errors, as the references can be satisfied by resources
+// defined elsewhere, like the static resources in the Envoy bootstrap. Only the resources
+// in spec.resources are checked.
+func (r *EnvoyConfig) ValidateReferences() admission.Warnings {
+	warnings := admission.Warnings{}
+	for _, ref := range envoy_resources.DanglingReferences(r.resourceDefinitions(r.Spec.Resources)) {
+		warnings = append(warnings, fmt.Sprintf("%s: %s '%s' is not defined in the EnvoyConfig", ref.Path, ref.Type, ref.Name))
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// ValidateDeprecations returns a warning for each deprecated field or enum value in use
+// in the resources of the EnvoyConfig, so they can be replaced before upgrading to an
+// Envoy version that no longer supports them. Only the resources in spec.resources are checked.
+func (r *EnvoyConfig) ValidateDeprecations() admission.Warnings {
+	warnings := admission.Warnings{}
+	for _, def := range r.resourceDefinitions(r.Spec.Resources) {
+		if def.Resource == nil {
+			continue
+		}
+		for _, d := range envoy_resources.Deprecations(def.Resource) {
+			msg := fmt.Sprintf("%s: '%s' is deprecated", envoy_resources.JoinPath(def.Path, d.Path), d.Name)
+			if d.Version != "" {
+				msg = fmt.Sprintf("%s since Envoy API version %s", msg, d.Version)
+			}
+			warnings = append(warnings, msg)
+		}
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return warnings
+}
+
+// ValidatePolicies evaluates the rules of the given EnvoyConfigPolicies against the resources
+// of the EnvoyConfig. Resources that do not satisfy a rule with the 'Deny' action cause an error,
+// and the ones that do not satisfy a rule with the 'Warn' action a warning. Both name the policy
+// and the rule. Resources generated from other Kubernetes objects are not evaluated.
+func (r *EnvoyConfig) ValidatePolicies(policies []EnvoyConfigPolicy) (admission.Warnings, error) {
+	resources, err := r.GetResources()
+	if err != nil {
+		return nil, err
+	}
+	definitions := r.resourceDefinitions(resources)
+
+	warnings := admission.Warnings{}
+	errList := []error{}
+	for _, policy := range policies {
+		for _, rule := range policy.Spec.Rules {
+			violations := []string{}
+			expression, err := envoy_resources.NewExpression(rule.Type, rule.Expression)
+			if err != nil {
+				violations = append(violations, fmt.Sprintf("invalid expression: %s", err))
+			} else {
+				for _, def := range definitions {
+					if def.Type != rule.Type || def.Resource == nil {
+						continue
+					}
+					name := cache_v3.GetResourceName(def.Resource)
+					if ok, err := expression.Eval(def.Resource); err != nil {
+						violations = append(violations, fmt.Sprintf("%s '%s': unable to evaluate expression: %s", def.Type, name, err))
+					} else if !ok {
+						violations = append(violations, fmt.Sprintf("%s '%s': %s", def.Type, name, rule.GetMessage()))
+					}
+				}
+			}
+
+			for _, v := range violations {
+				msg := fmt.Sprintf("policy '%s', rule '%s': %s", policy.GetName(), rule.Name, v)
+				if rule.GetAction() == WarnPolicyAction {
+					warnings = append(warnings, msg)
+				} else {
+					errList = append(errList, errors.New(msg))
+				}
+			}
+		}
+	}
+
+	if len(errList) > 0 {
+		return nil, NewMultiError(errList)
+	}
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+	return warnings, nil
+}
+
+// resourceDefinitions decodes the given resources of the EnvoyConfig. Resources
+// that cannot be decoded are skipped, as they are reported by ValidateResources.
+func (r *EnvoyConfig) resourceDefinitions(resources []Resource) []envoy_resources.Definition {
+	return ResourceDefinitions(resources, r.GetEnvoyAPIVersion())
+}
+
+// ResourceDefinitions decodes the given resources into definitions that can be checked for
+// dangling references. The resources generated from other Kubernetes objects only define their
+// name. Certificates with the TlsCertificate blueprint also define the validation context that
+// is published with their issuing CA, as the CA is only known once the Secret is read.
+// Resources that cannot be decoded are skipped.
+func ResourceDefinitions(resources []Resource, version envoy.APIVersion) []envoy_resources.Definition {
+	decoder := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version)
+	generator := envoy_resources.NewGenerator(version)
+
+	definitions := []envoy_resources.Definition{}
+	for idx, res := range resources {
+		def := envoy_resources.Definition{Type: res.Type, Path: fmt.Sprintf("spec.resources[%d].value", idx)}
+		switch {
+		case res.Value != nil:
+			def.Resource = generator.New(res.Type)
+			if err := decoder.Unmarshal(string(res.Value.Raw), def.Resource); err != nil {
+				continue
+			}
+		case res.GenerateFromTlsSecret != nil:
+			def.Name = *res.GenerateFromTlsSecret
+		case res.GenerateFromOpaqueSecret != nil:
+			def.Name = res.GenerateFromOpaqueSecret.Alias
+		case res.GenerateFromCertificate != nil:
+			def.Name = res.GenerateFromCertificate.Name
+		case res.GenerateFromEndpointSlices != nil:
+			def.Name = res.GenerateFromEndpointSlices.ClusterName
+		case res.GenerateFromConfigMap != nil:
+			def.Name = *res.GenerateFromConfigMap
+		default:
+			continue
+		}
+		definitions = append(definitions, def)
+
+		if res.GenerateFromCertificate != nil && res.GetBlueprint() == TlsCertificate {
+			definitions = append(definitions, envoy_resources.Definition{
+				Type: res.Type, Path: def.Path, Name: def.Name + CertificateAuthoritySuffix,
+			})
+		}
+	}
+	return definitions
+}
+
 // Validate EnvoyResources against schema
 func (r *EnvoyConfig) ValidateEnvoyResources() error {
 	errList := []error{}
@@ -190,7 +460,7 @@ func (r *EnvoyConfig) ValidateEnvoyResources() error {
 	}
 
 	for _, secret := range r.Spec.EnvoyResources.Secrets {
-		if err := secret.Validate(r.GetNamespace()); err != nil {
+		if err := secret.Validate(); err != nil {
 			errList = append(errList, err)
 		}
 	}
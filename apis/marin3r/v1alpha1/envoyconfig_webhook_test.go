@@ -17,11 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -47,6 +50,68 @@ func TestEnvoyConfig_ValidateResources(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Succeeds: runtime with ttl",
+			r: &EnvoyConfig{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "runtime",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name": "runtime", "layer": {"kill_switch": true}}`),
+						},
+						TTL: &metav1.Duration{Duration: 30 * time.Second},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails: ttl below the minimum",
+			r: &EnvoyConfig{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "runtime",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name": "runtime", "layer": {"kill_switch": true}}`),
+						},
+						TTL: &metav1.Duration{Duration: 1 * time.Second},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Succeeds: cluster with xdstp name",
+			r: &EnvoyConfig{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name": "xdstp://authority.example.com/envoy.config.cluster.v3.Cluster/cluster"}`),
+						},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails: xdstp name with wrong resource type",
+			r: &EnvoyConfig{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name": "xdstp://authority.example.com/envoy.config.listener.v3.Listener/cluster"}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Fails: incorrect timeout",
 			r: &EnvoyConfig{
@@ -241,6 +306,166 @@ func TestEnvoyConfig_ValidateResources(t *testing.T) {
 	}
 }
 
+func TestEnvoyConfig_ValidateReferences(t *testing.T) {
+	raw := func(value string) *runtime.RawExtension { return &runtime.RawExtension{Raw: []byte(value)} }
+	tests := []struct {
+		name      string
+		resources []Resource
+		want      []string
+	}{
+		{
+			name: "All the references are defined",
+			resources: []Resource{
+				{Type: envoy.Route, Value: raw(`{"name":"local","virtual_hosts":[{"name":"vh","domains":["*"],"routes":[{"match":{"prefix":"/"},"route":{"cluster":"a"}}]}]}`)},
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{}}}}`)},
+				{Type: envoy.Endpoint, GenerateFromEndpointSlices: &GenerateFromEndpointSlices{ClusterName: "a"}},
+			},
+			want: nil,
+		},
+		{
+			name: "The CA of certificates is defined along with the certificate",
+			resources: []Resource{
+				{Type: envoy.Listener, Value: raw(`{"name":"https","address":{"socket_address":{"address":"0.0.0.0","port_value":8443}},"filter_chains":[{"filters":[],"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext",` +
+					`"common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"cert","sds_config":{"ads":{},"resource_api_version":"V3"}}],"validation_context_sds_secret_config":{"name":"cert-ca","sds_config":{"ads":{},"resource_api_version":"V3"}}}}}}]}`)},
+				{Type: envoy.Secret, GenerateFromCertificate: &CertificateReference{Kind: CertManagerCertificate, Name: "cert"}},
+			},
+			want: nil,
+		},
+		{
+			name: "The CA of certificates is not defined with the validation context blueprint",
+			resources: []Resource{
+				{Type: envoy.Listener, Value: raw(`{"name":"https","address":{"socket_address":{"address":"0.0.0.0","port_value":8443}},"filter_chains":[{"filters":[],"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext",` +
+					`"common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"cert","sds_config":{"ads":{},"resource_api_version":"V3"}}],"validation_context_sds_secret_config":{"name":"cert-ca","sds_config":{"ads":{},"resource_api_version":"V3"}}}}}}]}`)},
+				{Type: envoy.Secret, GenerateFromCertificate: &CertificateReference{Kind: CertManagerCertificate, Name: "cert"}, Blueprint: pointer.New(TlsValidationContext)},
+			},
+			want: []string{
+				"spec.resources[0].value.filter_chains[0].transport_socket.typed_config.common_tls_context.validation_context_sds_secret_config.name: secret 'cert-ca' is not defined in the EnvoyConfig",
+			},
+		},
+		{
+			name: "Warns about undefined references",
+			resources: []Resource{
+				{Type: envoy.Route, Value: raw(`{"name":"local","virtual_hosts":[{"name":"vh","domains":["*"],"routes":[{"match":{"prefix":"/"},"route":{"cluster":"a"}}]}]}`)},
+				{Type: envoy.Cluster, Value: raw(`{"name":"b","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{}}}}`)},
+			},
+			want: []string{
+				"spec.resources[0].value.virtual_hosts[0].routes[0].route.cluster: cluster 'a' is not defined in the EnvoyConfig",
+				"spec.resources[1].value.name: endpoint 'b' is not defined in the EnvoyConfig",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EnvoyConfig{Spec: EnvoyConfigSpec{NodeID: "test", Resources: tt.resources}}
+			if got := r.ValidateReferences(); !reflect.DeepEqual([]string(got), tt.want) {
+				t.Errorf("EnvoyConfig.ValidateReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_ValidateDeprecations(t *testing.T) {
+	raw := func(value string) *runtime.RawExtension { return &runtime.RawExtension{Raw: []byte(value)} }
+	tests := []struct {
+		name      string
+		resources []Resource
+		want      []string
+	}{
+		{
+			name: "No deprecated fields in use",
+			resources: []Resource{
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{},"resource_api_version":"V3"}}}`)},
+				{Type: envoy.Endpoint, GenerateFromEndpointSlices: &GenerateFromEndpointSlices{ClusterName: "a"}},
+			},
+			want: nil,
+		},
+		{
+			name: "Warns about deprecated fields",
+			resources: []Resource{
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","type":"STRICT_DNS","max_requests_per_connection":1}`)},
+				{Type: envoy.Cluster, Value: raw(`{"name":"b","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{},"resource_api_version":"V2"}}}`)},
+			},
+			want: []string{
+				"spec.resources[0].value.max_requests_per_connection: 'envoy.config.cluster.v3.Cluster.max_requests_per_connection' is deprecated since Envoy API version 3.0",
+				"spec.resources[1].value.eds_cluster_config.eds_config.resource_api_version: 'envoy.config.core.v3.V2' is deprecated since Envoy API version 3.0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EnvoyConfig{Spec: EnvoyConfigSpec{NodeID: "test", Resources: tt.resources}}
+			if got := r.ValidateDeprecations(); !reflect.DeepEqual([]string(got), tt.want) {
+				t.Errorf("EnvoyConfig.ValidateDeprecations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_ValidatePolicies(t *testing.T) {
+	raw := func(value string) *runtime.RawExtension { return &runtime.RawExtension{Raw: []byte(value)} }
+	policies := []EnvoyConfigPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "clusters"},
+			Spec: EnvoyConfigPolicySpec{Rules: []PolicyRule{
+				{Name: "timeouts", Type: envoy.Cluster, Expression: "has(object.connect_timeout)"},
+				{Name: "circuit-breakers", Type: envoy.Cluster, Expression: "has(object.circuit_breakers)",
+					Message: pointer.New("clusters should set circuit breakers"), Action: pointer.New(WarnPolicyAction)},
+			}},
+		},
+	}
+	tests := []struct {
+		name         string
+		spec         EnvoyConfigSpec
+		wantWarnings []string
+		wantErr      bool
+	}{
+		{
+			name: "Satisfies all the rules",
+			spec: EnvoyConfigSpec{NodeID: "test", Resources: []Resource{
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","connect_timeout":"1s","circuit_breakers":{}}`)},
+				{Type: envoy.Route, Value: raw(`{"name":"local"}`)},
+			}},
+			wantWarnings: nil,
+			wantErr:      false,
+		},
+		{
+			name: "Warns about rules with the 'Warn' action",
+			spec: EnvoyConfigSpec{NodeID: "test", Resources: []Resource{
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","connect_timeout":"1s"}`)},
+			}},
+			wantWarnings: []string{"policy 'clusters', rule 'circuit-breakers': cluster 'a': clusters should set circuit breakers"},
+			wantErr:      false,
+		},
+		{
+			name: "Fails for rules with the 'Deny' action",
+			spec: EnvoyConfigSpec{NodeID: "test", Resources: []Resource{
+				{Type: envoy.Cluster, Value: raw(`{"name":"a","circuit_breakers":{}}`)},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "Evaluates resources in spec.envoyResources",
+			spec: EnvoyConfigSpec{NodeID: "test", Serialization: pointer.New(envoy_serializer.YAML), EnvoyResources: &EnvoyResources{
+				Clusters: []EnvoyResource{{Value: "name: a\ncircuit_breakers: {}"}},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EnvoyConfig{Spec: tt.spec}
+			got, err := r.ValidatePolicies(policies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EnvoyConfig.ValidatePolicies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual([]string(got), tt.wantWarnings) {
+				t.Errorf("EnvoyConfig.ValidatePolicies() = %v, want %v", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
 func TestEnvoyConfig_ValidateEnvoyResources(t *testing.T) {
 	type fields struct {
 		TypeMeta   metav1.TypeMeta
@@ -353,6 +578,403 @@ func TestEnvoyConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Ok, templated resources",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID:     "test",
+					Parameters: map[string]string{"timeout": "2s"},
+					ParametersFrom: []ParametersSource{
+						{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}},
+					},
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"{{ .name }}","type":"STRICT_DNS","connect_timeout":"{{ .timeout }}"}`),
+						},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, parametersFrom with both a ConfigMap and a Secret",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					ParametersFrom: []ParametersSource{{
+						ConfigMapRef: &corev1.LocalObjectReference{Name: "params"},
+						SecretRef:    &corev1.LocalObjectReference{Name: "params"},
+					}},
+					Resources: []Resource{},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Secret from another namespace",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                  "secret",
+						GenerateFromTlsSecret: pointer.New("wildcard"),
+						SecretNamespace:       pointer.New("certs"),
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, secretNamespace without a Secret reference",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:            "cluster",
+						Value:           &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+						SecretNamespace: pointer.New("certs"),
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Secret generated from a certificate",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                    "secret",
+						GenerateFromCertificate: &CertificateReference{Kind: CertManagerCertificate, Name: "cert"},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, both generateFromCertificate and generateFromTlsSecret",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                    "secret",
+						GenerateFromTlsSecret:   pointer.New("secret"),
+						GenerateFromCertificate: &CertificateReference{Kind: DiscoveryServiceCertificateKind, Name: "cert"},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, generateFromCertificate used for a cluster",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                    "cluster",
+						Value:                   &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+						GenerateFromCertificate: &CertificateReference{Kind: CertManagerCertificate, Name: "cert"},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Runtime generated from a ConfigMap",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID:    "test",
+					Resources: []Resource{{Type: "runtime", GenerateFromConfigMap: pointer.New("flags")}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, runtime with both value and generateFromConfigMap",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                  "runtime",
+						Value:                 &runtime.RawExtension{Raw: []byte(`{"name":"flags"}`)},
+						GenerateFromConfigMap: pointer.New("flags"),
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, generateFromConfigMap used for a cluster",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:                  "cluster",
+						Value:                 &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+						GenerateFromConfigMap: pointer.New("flags"),
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Data sources from ConfigMaps",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:  "cluster",
+						Value: &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+						DataSourcesFrom: []DataSourceFromConfigMap{
+							{Name: "cm", Key: "key1", Placeholder: pointer.New("key1")},
+							{Name: "cm", Key: "key2", Path: pointer.New("/some/path")},
+						},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, data source with both path and placeholder",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:  "cluster",
+						Value: &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+						DataSourcesFrom: []DataSourceFromConfigMap{
+							{Name: "cm", Key: "key", Placeholder: pointer.New("key"), Path: pointer.New("/some/path")},
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, patch target not found",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type:  "cluster",
+						Value: &runtime.RawExtension{Raw: []byte(`{"name":"cluster1"}`)},
+					}},
+					Patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster2", Patch: "connect_timeout: 1s"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Patches of templated EnvoyConfigs are applied once rendered",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID:     "test",
+					Parameters: map[string]string{"name": "cluster1"},
+					Resources: []Resource{{
+						Type:  "cluster",
+						Value: &runtime.RawExtension{Raw: []byte(`{"name":"{{ .name }}"}`)},
+					}},
+					Patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster1", Patch: "connect_timeout: 1s"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fails, invalid template",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID:     "test",
+					Parameters: map[string]string{"timeout": "2s"},
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","connect_timeout":"{{ .timeout }"}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, templates are not rendered without parameters",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","connect_timeout":"{{ .timeout }}"}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, parameters source without reference",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID:         "test",
+					ParametersFrom: []ParametersSource{{}},
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1"}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, invalid resync annotation",
+			fields: fields{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{ResyncAtAnnotation: "yesterday"},
+				},
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, empty pinned version",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					PinnedVersion: pointer.New(""),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, non positive revision history max age",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					RevisionHistory: &RevisionHistory{MaxAge: &metav1.Duration{Duration: 0}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, duplicated taint policy override",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					TaintPolicy: &TaintPolicy{Overrides: []TaintPolicyOverride{{Type: envoy.Cluster}, {Type: envoy.Cluster}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, non positive taint retry",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					TaintPolicy: &TaintPolicy{RetryAfter: &metav1.Duration{Duration: -time.Minute}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, invalid publish schedule",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					PublishSchedule: &PublishSchedule{Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, publish schedule window too short",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					PublishSchedule: &PublishSchedule{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Second}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, unknown publish schedule time zone",
+			fields: fields{
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+					PublishSchedule: &PublishSchedule{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: pointer.New("Mars/Olympus")},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails, invalid untaint annotation",
+			fields: fields{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{UntaintAtAnnotation: "now"},
+				},
+				Spec: EnvoyConfigSpec{
+					NodeID: "test",
+					Resources: []Resource{{
+						Type: "cluster",
+						Value: &runtime.RawExtension{
+							Raw: []byte(`{"name":"cluster1","type":"STRICT_DNS","connect_timeout":"2s","load_assignment":{"cluster_name":"cluster1"}}`),
+						},
+					}},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "Fail, cannot use EnvoyResources and Resources both",
 			fields: fields{
@@ -0,0 +1,120 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	reconcilerutil "github.com/3scale-ops/basereconciler/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnvoyConfigFragmentSpec defines the desired state of EnvoyConfigFragment
+type EnvoyConfigFragmentSpec struct {
+	// NodeID is the nodeID of the EnvoyConfig, in the same namespace, that
+	// the resources of the fragment are merged into.
+	// +kubebuilder:validation:Pattern:[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	NodeID string `json:"nodeID"`
+	// Resources is the list of envoy resources of the fragment. The names of the
+	// resources must not collide with the ones of the EnvoyConfig or of other fragments.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Resources []Resource `json:"resources"`
+}
+
+// EnvoyConfigFragmentStatus defines the observed state of EnvoyConfigFragment
+type EnvoyConfigFragmentStatus struct {
+	// EnvoyConfig is the name of the EnvoyConfig the fragment is merged into
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	EnvoyConfig *string `json:"envoyConfig,omitempty"`
+	// Included signals if the resources of the fragment are included in the
+	// desired version of the EnvoyConfig
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Included *bool `json:"included,omitempty"`
+	// DesiredVersion is the desired version of the EnvoyConfig that
+	// includes the resources of the fragment
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	DesiredVersion *string `json:"desiredVersion,omitempty"`
+	// Published signals if the resources of the fragment are included in the
+	// currently published revision of the EnvoyConfig
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Published *bool `json:"published,omitempty"`
+	// Conflicts lists the resources of the fragment whose names are already in use by
+	// the EnvoyConfig or by other fragments. A fragment with conflicts is not included.
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Conflicts []string `json:"conflicts,omitempty"`
+}
+
+// IsIncluded returns true if the fragment is included in the desired version of the EnvoyConfig
+func (status *EnvoyConfigFragmentStatus) IsIncluded() bool {
+	return status.Included != nil && *status.Included
+}
+
+// IsPublished returns true if the fragment is included in the published revision of the EnvoyConfig
+func (status *EnvoyConfigFragmentStatus) IsPublished() bool {
+	return status.Published != nil && *status.Published
+}
+
+// +kubebuilder:object:root=true
+
+// EnvoyConfigFragment holds envoy resources that are merged into the EnvoyConfig with the same
+// nodeID in the namespace of the fragment. This allows teams that share the same Envoy to manage
+// their resources in separate objects. Changes to a fragment produce new revisions of the EnvoyConfig.
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=envoyconfigfragments,scope=Namespaced,shortName=ecf
+// +kubebuilder:printcolumn:JSONPath=".spec.nodeID",name=Node ID,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.envoyConfig",name=EnvoyConfig,type=string
+// +kubebuilder:printcolumn:JSONPath=".status.included",name=Included,type=boolean
+// +kubebuilder:printcolumn:JSONPath=".status.published",name=Published,type=boolean
+// +operator-sdk:csv:customresourcedefinitions:displayName="EnvoyConfigFragment"
+type EnvoyConfigFragment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EnvoyConfigFragmentSpec   `json:"spec,omitempty"`
+	Status EnvoyConfigFragmentStatus `json:"status,omitempty"`
+}
+
+// AsEnvoyConfig returns an EnvoyConfig with the resources of the fragment, so the
+// validations of the EnvoyConfig resources can be applied to fragments
+func (ecf *EnvoyConfigFragment) AsEnvoyConfig() *EnvoyConfig {
+	return &EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: ecf.GetName(), Namespace: ecf.GetNamespace()},
+		Spec:       EnvoyConfigSpec{NodeID: ecf.Spec.NodeID, Resources: ecf.Spec.Resources},
+	}
+}
+
+// InputsHash returns a hash of the spec of the fragment
+func (ecf *EnvoyConfigFragment) InputsHash() string {
+	return reconcilerutil.Hash(ecf.Spec)
+}
+
+// +kubebuilder:object:root=true
+
+// EnvoyConfigFragmentList contains a list of EnvoyConfigFragment
+type EnvoyConfigFragmentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnvoyConfigFragment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvoyConfigFragment{}, &EnvoyConfigFragmentList{})
+}
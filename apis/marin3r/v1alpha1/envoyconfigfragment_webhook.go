@@ -0,0 +1,70 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/3scale-ops/basereconciler/util"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (r *EnvoyConfigFragment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-marin3r-3scale-net-v1alpha1-envoyconfigfragment,mutating=false,failurePolicy=fail,sideEffects=None,groups=marin3r.3scale.net,resources=envoyconfigfragments,verbs=create;update,versions=v1alpha1,name=envoyconfigfragment.marin3r.3scale.net-v1alpha1,admissionReviewVersions=v1
+
+var _ webhook.Validator = &EnvoyConfigFragment{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigFragment) ValidateCreate() (admission.Warnings, error) {
+	validationlog.Info("ValidateCreate", "type", "EnvoyConfigFragment", "resource", util.ObjectKey(r).String())
+	return r.Validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigFragment) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	validationlog.Info("ValidateUpdate", "type", "EnvoyConfigFragment", "resource", util.ObjectKey(r).String())
+	return r.Validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigFragment) ValidateDelete() (admission.Warnings, error) { return nil, nil }
+
+// Validate validates the resources of the fragment with the same rules that
+// apply to the resources of EnvoyConfigs. Collisions with the names of the
+// resources of the EnvoyConfig are reported in the status of the fragment.
+// Data sources from ConfigMaps are not supported in fragments.
+func (r *EnvoyConfigFragment) Validate() (admission.Warnings, error) {
+	for idx, res := range r.Spec.Resources {
+		if len(res.DataSourcesFrom) > 0 {
+			return nil, fmt.Errorf("spec.resources[%d]: 'dataSourcesFrom' is not supported in EnvoyConfigFragments", idx)
+		}
+	}
+
+	ec := r.AsEnvoyConfig()
+	if err := ec.ValidateResources(); err != nil {
+		return nil, err
+	}
+	return ec.ValidateDeprecations(), nil
+}
@@ -0,0 +1,63 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+)
+
+func TestEnvoyConfigFragment_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []Resource
+		wantErr   bool
+	}{
+		{
+			name:      "Valid resources",
+			resources: []Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster"}`)}},
+			wantErr:   false,
+		},
+		{
+			name:      "Invalid resources",
+			resources: []Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster","connect_timeout":"1x"}`)}},
+			wantErr:   true,
+		},
+		{
+			name: "Data sources are not supported",
+			resources: []Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster"}`),
+				DataSourcesFrom: []DataSourceFromConfigMap{{Name: "cm", Key: "key", Placeholder: pointer.New("key")}}}},
+			wantErr: true,
+		},
+		{
+			name:      "Resources without value",
+			resources: []Resource{{Type: envoy.Listener}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EnvoyConfigFragment{Spec: EnvoyConfigFragmentSpec{NodeID: "test", Resources: tt.resources}}
+			if _, err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("EnvoyConfigFragment.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
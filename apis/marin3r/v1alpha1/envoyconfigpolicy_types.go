@@ -0,0 +1,132 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// PolicyAction is the action to take when a resource does not satisfy a policy rule
+type PolicyAction string
+
+const (
+	// DenyPolicyAction rejects the EnvoyConfig
+	DenyPolicyAction PolicyAction = "Deny"
+	// WarnPolicyAction accepts the EnvoyConfig and returns a warning to the client
+	WarnPolicyAction PolicyAction = "Warn"
+)
+
+// EnvoyConfigPolicySpec defines the desired state of EnvoyConfigPolicy
+type EnvoyConfigPolicySpec struct {
+	// NamespaceSelector selects the namespaces where the policy is enforced, using
+	// the labels of the Namespace. The policy is enforced in all namespaces if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Rules is the list of rules that the resources of the EnvoyConfigs must satisfy
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +kubebuilder:validation:MinItems=1
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule is a CEL expression that the envoy resources of a given type must satisfy
+type PolicyRule struct {
+	// Name of the rule
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Name string `json:"name"`
+	// Type is the type of the envoy resources the rule applies to
+	// +kubebuilder:validation:Enum=listener;route;scopedRoute;cluster;endpoint;runtime;extensionConfig;
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Type envoy.Type `json:"type"`
+	// Expression is a CEL expression that must evaluate to true for the resource to be
+	// compliant. The resource is available in the 'object' variable, with the type of
+	// the envoy API proto for the resource type, and typed configs are transparently
+	// unpacked. Example: "has(object.connect_timeout) && has(object.circuit_breakers)".
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Expression string `json:"expression"`
+	// Message is returned to the client when a resource does not satisfy the rule.
+	// Defaults to the expression.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Message *string `json:"message,omitempty"`
+	// Action is the action to take when a resource does not satisfy the rule.
+	// Defaults to "Deny".
+	// +kubebuilder:validation:Enum=Deny;Warn
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Action *PolicyAction `json:"action,omitempty"`
+}
+
+// GetMessage returns the message to return when a resource does not satisfy the rule
+func (pr *PolicyRule) GetMessage() string {
+	if pr.Message == nil {
+		return pr.Expression
+	}
+	return *pr.Message
+}
+
+// GetAction returns the action to take when a resource does not satisfy the rule
+func (pr *PolicyRule) GetAction() PolicyAction {
+	if pr.Action == nil {
+		return DenyPolicyAction
+	}
+	return *pr.Action
+}
+
+// +kubebuilder:object:root=true
+
+// EnvoyConfigPolicy holds rules, written as CEL expressions, that the envoy resources of the
+// EnvoyConfigs must satisfy. Policies are enforced in the namespaces selected by the policy, so
+// platform teams can put guardrails on the configurations of application teams. They are enforced
+// by the EnvoyConfig admission webhook, and by the EnvoyConfig controller on the rendered resources
+// before publishing them: resources that violate a 'Deny' rule are not published.
+// +kubebuilder:resource:path=envoyconfigpolicies,scope=Cluster,shortName=ecp
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type=date
+// +operator-sdk:csv:customresourcedefinitions:displayName="EnvoyConfigPolicy"
+type EnvoyConfigPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec EnvoyConfigPolicySpec `json:"spec,omitempty"`
+}
+
+// AppliesTo returns true if the policy is enforced in a namespace with the given labels
+func (ecp *EnvoyConfigPolicy) AppliesTo(namespaceLabels map[string]string) (bool, error) {
+	if ecp.Spec.NamespaceSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ecp.Spec.NamespaceSelector)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(namespaceLabels)), nil
+}
+
+// +kubebuilder:object:root=true
+
+// EnvoyConfigPolicyList contains a list of EnvoyConfigPolicy
+type EnvoyConfigPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnvoyConfigPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EnvoyConfigPolicy{}, &EnvoyConfigPolicyList{})
+}
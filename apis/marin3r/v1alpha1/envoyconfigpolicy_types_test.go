@@ -0,0 +1,72 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnvoyConfigPolicy_AppliesTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		labels   map[string]string
+		want     bool
+		wantErr  bool
+	}{
+		{
+			name:     "Applies to all namespaces without selector",
+			selector: nil,
+			labels:   map[string]string{"env": "dev"},
+			want:     true,
+		},
+		{
+			name:     "Applies to the selected namespaces",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "production"}},
+			labels:   map[string]string{"env": "production"},
+			want:     true,
+		},
+		{
+			name:     "Does not apply to other namespaces",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "production"}},
+			labels:   map[string]string{"env": "dev"},
+			want:     false,
+		},
+		{
+			name: "Fails with an invalid selector",
+			selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: "Unknown"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecp := &EnvoyConfigPolicy{Spec: EnvoyConfigPolicySpec{NamespaceSelector: tt.selector}}
+			got, err := ecp.AppliesTo(tt.labels)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EnvoyConfigPolicy.AppliesTo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("EnvoyConfigPolicy.AppliesTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/3scale-ops/basereconciler/util"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (r *EnvoyConfigPolicy) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-marin3r-3scale-net-v1alpha1-envoyconfigpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=marin3r.3scale.net,resources=envoyconfigpolicies,verbs=create;update,versions=v1alpha1,name=envoyconfigpolicy.marin3r.3scale.net-v1alpha1,admissionReviewVersions=v1
+
+var _ webhook.Validator = &EnvoyConfigPolicy{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigPolicy) ValidateCreate() (admission.Warnings, error) {
+	validationlog.Info("ValidateCreate", "type", "EnvoyConfigPolicy", "resource", util.ObjectKey(r).String())
+	return nil, r.Validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigPolicy) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	validationlog.Info("ValidateUpdate", "type", "EnvoyConfigPolicy", "resource", util.ObjectKey(r).String())
+	return nil, r.Validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *EnvoyConfigPolicy) ValidateDelete() (admission.Warnings, error) { return nil, nil }
+
+// Validate validates the EnvoyConfigPolicy resource. The expressions of
+// the rules are compiled to reject the ones with errors.
+func (r *EnvoyConfigPolicy) Validate() error {
+	errList := []error{}
+
+	if r.Spec.NamespaceSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(r.Spec.NamespaceSelector); err != nil {
+			errList = append(errList, fmt.Errorf("invalid 'spec.namespaceSelector': %w", err))
+		}
+	}
+
+	seen := map[string]bool{}
+	for idx, rule := range r.Spec.Rules {
+		if seen[rule.Name] {
+			errList = append(errList, fmt.Errorf("duplicated rule name '%s' in 'spec.rules'", rule.Name))
+		}
+		seen[rule.Name] = true
+		if _, err := envoy_resources.NewExpression(rule.Type, rule.Expression); err != nil {
+			errList = append(errList, fmt.Errorf("invalid expression in 'spec.rules[%d]': %w", idx, err))
+		}
+	}
+
+	if len(errList) > 0 {
+		return NewMultiError(errList)
+	}
+	return nil
+}
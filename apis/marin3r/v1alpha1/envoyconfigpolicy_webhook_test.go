@@ -0,0 +1,61 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+)
+
+func TestEnvoyConfigPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []PolicyRule
+		wantErr bool
+	}{
+		{
+			name: "Valid rules",
+			rules: []PolicyRule{
+				{Name: "timeouts", Type: envoy.Cluster, Expression: "has(object.connect_timeout)"},
+				{Name: "ports", Type: envoy.Listener, Expression: "object.address.socket_address.port_value < 10000u"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Duplicated rule names",
+			rules: []PolicyRule{
+				{Name: "rule", Type: envoy.Cluster, Expression: "has(object.connect_timeout)"},
+				{Name: "rule", Type: envoy.Cluster, Expression: "has(object.circuit_breakers)"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid expression",
+			rules:   []PolicyRule{{Name: "rule", Type: envoy.Cluster, Expression: "has(object.port_value)"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EnvoyConfigPolicy{Spec: EnvoyConfigPolicySpec{Rules: tt.rules}}
+			if err := r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("EnvoyConfigPolicy.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -17,9 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -38,6 +41,29 @@ const (
 	// problems have been observed with this revision and should not be published
 	RevisionTaintedCondition string = "RevisionTainted"
 
+	// RevisionCanaryCondition is a condition that marks the EnvoyConfigRevision object
+	// as the one that should be published to the canary Envoy clients during a rollout
+	RevisionCanaryCondition string = "RevisionCanary"
+
+	/* Annotations */
+
+	// ApprovedVersionAnnotation approves the publication of an EnvoyConfigRevision when the
+	// EnvoyConfig requires approval. Its value must be the version of the revision.
+	ApprovedVersionAnnotation string = "marin3r.3scale.net/approved-version"
+
+	// ApprovedByAnnotation records the user that approved the EnvoyConfigRevision.
+	// It is set by the admission webhook and cannot be modified.
+	ApprovedByAnnotation string = "marin3r.3scale.net/approved-by"
+
+	// ApprovedAtAnnotation records the time the EnvoyConfigRevision was approved, in
+	// RFC3339 format. It is set by the admission webhook and cannot be modified.
+	ApprovedAtAnnotation string = "marin3r.3scale.net/approved-at"
+
+	// SecretParametersAnnotation marks the EnvoyConfigRevisions whose resources have been
+	// rendered with template parameters from Secrets, holding the comma separated list of
+	// the Secrets. The resources of these revisions are not shown by the diff debug API.
+	SecretParametersAnnotation string = "marin3r.3scale.net/secret-parameters"
+
 	/* Finalizers */
 
 	// EnvoyConfigRevisionFinalizer is the finalizer for EnvoyConfig objects
@@ -73,6 +99,13 @@ type EnvoyConfigRevisionSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	Resources []Resource `json:"resources,omitempty"`
+	// TaintPolicy configures when the revision is tainted because it is being
+	// rejected by the Envoy clients. It is copied from the EnvoyConfig when the
+	// revision is created, but the policy of the owning EnvoyConfig, if any, is
+	// the one in effect.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	TaintPolicy *TaintPolicy `json:"taintPolicy,omitempty"`
 }
 
 // EnvoyConfigRevisionStatus defines the observed state of EnvoyConfigRevision
@@ -97,6 +130,39 @@ type EnvoyConfigRevisionStatus struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	// +optional
 	Tainted *bool `json:"tainted,omitempty"`
+	// UntaintedAt is the last time the taint of the
+	// EnvoyConfigRevision was cleared
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	UntaintedAt *metav1.Time `json:"untaintedAt,omitempty"`
+	// TaintRetries is the number of times the taint of the EnvoyConfigRevision
+	// has been automatically cleared by the retry policy since it was last
+	// untainted through the untaint annotation
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	TaintRetries *int `json:"taintRetries,omitempty"`
+	// TaintedTypes are the resource types that are being rejected by the
+	// Envoy clients, when the revision is tainted because of that
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	TaintedTypes []envoy.Type `json:"taintedTypes,omitempty"`
+	// TypeRevisions holds the resource types that are published from other
+	// revisions, when the EnvoyConfig publishes the resources per type and
+	// some of the types of this revision have been rolled back
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	TypeRevisions []TypeRevision `json:"typeRevisions,omitempty"`
+	// Diff summarizes the changes in the resources of the revision with
+	// respect to the revision that was published before it. The full diff
+	// is available through the debug API of the discovery service.
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Diff *RevisionDiffSummary `json:"diff,omitempty"`
+	// Canary holds the Envoy clients the revision is published to
+	// while it is the canary of a rollout
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Canary *CanaryTarget `json:"canary,omitempty"`
 	// Conditions represent the latest available observations of an object's state
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	// +optional
@@ -119,6 +185,89 @@ func (status *EnvoyConfigRevisionStatus) IsTainted() bool {
 	return *status.Tainted
 }
 
+// GetTaintRetries returns the number of automatic retries of the revision
+func (status *EnvoyConfigRevisionStatus) GetTaintRetries() int {
+	if status.TaintRetries == nil {
+		return 0
+	}
+	return *status.TaintRetries
+}
+
+// Untaint removes the RevisionTaintedCondition and records the time the taint was cleared
+func (status *EnvoyConfigRevisionStatus) Untaint(now time.Time) {
+	meta.RemoveStatusCondition(&status.Conditions, RevisionTaintedCondition)
+	status.Tainted = pointer.New(false)
+	status.TaintedTypes = nil
+	status.UntaintedAt = &metav1.Time{Time: now.Truncate(time.Second)}
+}
+
+// IsTypeTainted returns true if the revision is tainted and the given resource type is
+// being rejected by the Envoy clients. All the types are considered tainted if the revision
+// has been tainted for a reason other than its resource types being rejected.
+func (status *EnvoyConfigRevisionStatus) IsTypeTainted(rType envoy.Type) bool {
+	if !meta.IsStatusConditionTrue(status.Conditions, RevisionTaintedCondition) {
+		return false
+	}
+	if len(status.TaintedTypes) == 0 {
+		return true
+	}
+	for _, t := range status.TaintedTypes {
+		if t == rType {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeRevision references the revision the resources of a type are published from
+type TypeRevision struct {
+	// Type is the resource type
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Type envoy.Type `json:"type"`
+	// Version is the version of the revision the resources of the type are published from
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Version string `json:"version"`
+}
+
+// RevisionDiffSummary summarizes the resources added, removed and changed
+// in a revision with respect to the previously published revision
+type RevisionDiffSummary struct {
+	// PreviousVersion is the version of the revision that was published before this
+	// one. It is empty if no other revision had been published before.
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// PublishedAt is the publication of the revision the diff was calculated for
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	PublishedAt metav1.Time `json:"publishedAt"`
+	// Added is the number of resources added
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Added int `json:"added"`
+	// Removed is the number of resources removed
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Removed int `json:"removed"`
+	// Changed is the number of resources that have changed
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Changed int `json:"changed"`
+	// ChangedTypes are the resource types with added, removed or changed resources
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	ChangedTypes []envoy.Type `json:"changedTypes,omitempty"`
+}
+
+// CanaryTarget selects the Envoy clients, identified by the 'pod_name'
+// in their node metadata, that receive a canary revision
+type CanaryTarget struct {
+	// Percentage of the Envoy clients that receive the canary revision
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Percentage *int `json:"percentage,omitempty"`
+	// Pods is the list of Pods that receive the canary revision
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+}
+
 // VersionTracker tracks the versions of the resources
 // that this revision publishes in the xDS server cache
 type VersionTracker struct {
@@ -171,6 +320,28 @@ func (ecr *EnvoyConfigRevision) GetSerialization() envoy_serializer.Serializatio
 	return envoy_serializer.Serialization(*ecr.Spec.Serialization)
 }
 
+// IsApproved returns true if the revision has been approved for its version
+// and the approver has been recorded by the admission webhook
+func (ecr *EnvoyConfigRevision) IsApproved() bool {
+	annotations := ecr.GetAnnotations()
+	return annotations[ApprovedVersionAnnotation] == ecr.Spec.Version && annotations[ApprovedByAnnotation] != ""
+}
+
+// GetResources returns the resources of the revision, converting them
+// from the deprecated spec.envoyResources field if required
+func (ecr *EnvoyConfigRevision) GetResources() ([]Resource, error) {
+	if ecr.Spec.EnvoyResources != nil {
+		return ecr.Spec.EnvoyResources.Resources(ecr.GetSerialization())
+	}
+	return ecr.Spec.Resources, nil
+}
+
+// GetUntaintAt returns the time set in the untaint annotation, or nil if
+// no untaint has been requested
+func (ecr *EnvoyConfigRevision) GetUntaintAt() (*time.Time, error) {
+	return getTimeAnnotation(ecr, UntaintAtAnnotation)
+}
+
 // Default implements defaulting for the EnvoyConfigRevision resource
 func (ecr *EnvoyConfigRevision) Default() {
 	if ecr.Spec.EnvoyAPI == nil {
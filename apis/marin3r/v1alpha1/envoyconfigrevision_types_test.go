@@ -18,10 +18,13 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestEnvoyConfigRevisionStatus_IsPublished(t *testing.T) {
@@ -92,6 +95,68 @@ func TestEnvoyConfigRevisionStatus_IsTainted(t *testing.T) {
 	}
 }
 
+func TestEnvoyConfigRevisionStatus_Untaint(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 500, time.UTC)
+	status := &EnvoyConfigRevisionStatus{
+		Tainted:    pointer.New(true),
+		Conditions: []metav1.Condition{{Type: RevisionTaintedCondition, Status: metav1.ConditionTrue}},
+	}
+
+	status.Untaint(now)
+	if status.IsTainted() || meta.FindStatusCondition(status.Conditions, RevisionTaintedCondition) != nil {
+		t.Errorf("Untaint() did not clear the taint")
+	}
+	if status.UntaintedAt == nil || !status.UntaintedAt.Time.Equal(now.Truncate(time.Second)) {
+		t.Errorf("Untaint() untaintedAt = %v, want %v", status.UntaintedAt, now.Truncate(time.Second))
+	}
+}
+
+func TestEnvoyConfigRevisionStatus_IsTypeTainted(t *testing.T) {
+	tainted := []metav1.Condition{{Type: RevisionTaintedCondition, Status: metav1.ConditionTrue}}
+	tests := []struct {
+		name   string
+		status EnvoyConfigRevisionStatus
+		rType  envoy.Type
+		want   bool
+	}{
+		{"Not tainted", EnvoyConfigRevisionStatus{TaintedTypes: []envoy.Type{envoy.Listener}}, envoy.Listener, false},
+		{"Tainted as a whole", EnvoyConfigRevisionStatus{Conditions: tainted}, envoy.Cluster, true},
+		{"Tainted type", EnvoyConfigRevisionStatus{Conditions: tainted, TaintedTypes: []envoy.Type{envoy.Listener}}, envoy.Listener, true},
+		{"Healthy type", EnvoyConfigRevisionStatus{Conditions: tainted, TaintedTypes: []envoy.Type{envoy.Listener}}, envoy.Cluster, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.IsTypeTainted(tt.rType); got != tt.want {
+				t.Errorf("IsTypeTainted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfigRevision_IsApproved(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{"Not approved", nil, false},
+		{"Approved", map[string]string{ApprovedVersionAnnotation: "xxxx", ApprovedByAnnotation: "user"}, true},
+		{"Approved for another version", map[string]string{ApprovedVersionAnnotation: "yyyy", ApprovedByAnnotation: "user"}, false},
+		{"Approver not recorded", map[string]string{ApprovedVersionAnnotation: "xxxx"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecr := &EnvoyConfigRevision{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+				Spec:       EnvoyConfigRevisionSpec{Version: "xxxx"},
+			}
+			if got := ecr.IsApproved(); got != tt.want {
+				t.Errorf("IsApproved() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEnvoyConfigRevision_GetEnvoyAPIVersion(t *testing.T) {
 	cases := []struct {
 		testName                   string
@@ -0,0 +1,151 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// document returns the patch document converted to JSON
+func (rp *ResourcePatch) document() ([]byte, error) {
+	doc, err := yaml.YAMLToJSON([]byte(rp.Patch))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse patch: %w", err)
+	}
+	return doc, nil
+}
+
+// Validate validates the patch document without applying it
+func (rp *ResourcePatch) Validate(version envoy.APIVersion) error {
+	if rp.Name == "" {
+		return fmt.Errorf("'name' cannot be empty")
+	}
+	if envoy_resources.NewGenerator(version).New(rp.Type) == nil {
+		return fmt.Errorf("unsupported resource type '%s'", rp.Type)
+	}
+	doc, err := rp.document()
+	if err != nil {
+		return err
+	}
+
+	switch rp.GetPatchType() {
+	case JSONPatchType:
+		if _, err := jsonpatch.DecodePatch(doc); err != nil {
+			return fmt.Errorf("unable to decode JSON patch: %w", err)
+		}
+	case ProtoMergePatchType:
+		res := envoy_resources.NewGenerator(version).New(rp.Type)
+		if err := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version).Unmarshal(string(doc), res); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported patch type '%s'", rp.GetPatchType())
+	}
+	return nil
+}
+
+// Apply applies the patch to the given envoy resource
+func (rp *ResourcePatch) Apply(res envoy.Resource, version envoy.APIVersion) error {
+	if err := rp.Validate(version); err != nil {
+		return err
+	}
+	doc, _ := rp.document()
+
+	switch rp.GetPatchType() {
+	case JSONPatchType:
+		patch, _ := jsonpatch.DecodePatch(doc)
+		original, err := envoy_serializer.NewResourceMarshaller(envoy_serializer.JSON, version).Marshal(res)
+		if err != nil {
+			return err
+		}
+		patched, err := patch.Apply([]byte(original))
+		if err != nil {
+			return err
+		}
+		proto.Reset(res)
+		return envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version).Unmarshal(string(patched), res)
+
+	default:
+		partial := envoy_resources.NewGenerator(version).New(rp.Type)
+		_ = envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version).Unmarshal(string(doc), partial)
+		proto.Merge(res, partial)
+		return nil
+	}
+}
+
+// PatchResources returns a copy of the resources with the patches applied, in order. Each patch
+// is applied to the resources of its type whose name matches, which are validated after being
+// patched. It is an error for a patch not to match any resource. Resources generated from other
+// Kubernetes objects cannot be patched.
+func PatchResources(resources []Resource, patches []ResourcePatch, version envoy.APIVersion) ([]Resource, error) {
+	patched := make([]Resource, 0, len(resources))
+	for _, res := range resources {
+		patched = append(patched, *res.DeepCopy())
+	}
+
+	for idx, patch := range patches {
+		found := false
+		for i, res := range patched {
+			if res.Type != patch.Type {
+				continue
+			}
+			if name, err := res.GetName(version); err != nil || name != patch.Name {
+				continue
+			}
+			found = true
+			if res.Value == nil {
+				return nil, fmt.Errorf("spec.patches[%d]: %s '%s' is generated and cannot be patched", idx, patch.Type, patch.Name)
+			}
+
+			er := envoy_resources.NewGenerator(version).New(res.Type)
+			if err := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version).Unmarshal(string(res.Value.Raw), er); err != nil {
+				return nil, fmt.Errorf("spec.patches[%d]: %w", idx, err)
+			}
+			if err := patch.Apply(er, version); err != nil {
+				return nil, fmt.Errorf("spec.patches[%d]: %w", idx, err)
+			}
+			value, err := envoy_serializer.NewResourceMarshaller(envoy_serializer.JSON, version).Marshal(er)
+			if err != nil {
+				return nil, fmt.Errorf("spec.patches[%d]: %w", idx, err)
+			}
+			if err := envoy_resources.Validate(value, envoy_serializer.JSON, version, res.Type); err != nil {
+				return nil, fmt.Errorf("spec.patches[%d]: patched %s '%s' is invalid: %w", idx, patch.Type, patch.Name, err)
+			}
+			patched[i].Value = &runtime.RawExtension{Raw: []byte(value)}
+		}
+		if !found {
+			return nil, fmt.Errorf("spec.patches[%d]: %s '%s' not found", idx, patch.Type, patch.Name)
+		}
+	}
+
+	return patched, nil
+}
+
+// ApplyPatches applies spec.patches to the resources of the EnvoyConfig. The patched
+// resources replace spec.resources in the given object, which is not persisted, and
+// spec.patches is cleared so the patches are not applied twice.
+func (ec *EnvoyConfig) ApplyPatches() error {
+	if len(ec.Spec.Patches) == 0 {
+		return nil
+	}
+
+	resources, err := ec.GetResources()
+	if err != nil {
+		return err
+	}
+
+	patched, err := PatchResources(resources, ec.Spec.Patches, ec.GetEnvoyAPIVersion())
+	if err != nil {
+		return err
+	}
+	ec.Spec.Resources = patched
+	ec.Spec.EnvoyResources = nil
+	ec.Spec.Patches = nil
+	return nil
+}
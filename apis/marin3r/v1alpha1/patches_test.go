@@ -0,0 +1,185 @@
+package v1alpha1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-test/deep"
+)
+
+func TestResourcePatch_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   ResourcePatch
+		wantErr bool
+	}{
+		{
+			name:    "Valid proto merge patch",
+			patch:   ResourcePatch{Type: envoy.Cluster, Name: "cluster", Patch: `{"connect_timeout":"1s"}`},
+			wantErr: false,
+		},
+		{
+			name:    "Valid proto merge patch in yaml",
+			patch:   ResourcePatch{Type: envoy.Cluster, Name: "cluster", Patch: "connect_timeout: 1s"},
+			wantErr: false,
+		},
+		{
+			name: "Valid proto merge patch with explicit type",
+			patch: ResourcePatch{Type: envoy.Cluster, Name: "cluster", PatchType: pointer.New(ProtoMergePatchType),
+				Patch: `{"connect_timeout":"1s"}`},
+			wantErr: false,
+		},
+		{
+			name: "Fails, unknown patch type",
+			patch: ResourcePatch{Type: envoy.Cluster, Name: "cluster", PatchType: pointer.New(PatchType("StrategicMerge")),
+				Patch: `{"connect_timeout":"1s"}`},
+			wantErr: true,
+		},
+		{
+			name: "Valid JSON patch",
+			patch: ResourcePatch{Type: envoy.Cluster, Name: "cluster", PatchType: pointer.New(JSONPatchType),
+				Patch: `[{"op":"replace","path":"/connect_timeout","value":"1s"}]`},
+			wantErr: false,
+		},
+		{
+			name:    "Fails, unknown field in proto merge patch",
+			patch:   ResourcePatch{Type: envoy.Cluster, Name: "cluster", Patch: `{"timeout":"1s"}`},
+			wantErr: true,
+		},
+		{
+			name: "Fails, invalid JSON patch",
+			patch: ResourcePatch{Type: envoy.Cluster, Name: "cluster", PatchType: pointer.New(JSONPatchType),
+				Patch: `{"connect_timeout":"1s"}`},
+			wantErr: true,
+		},
+		{
+			name:    "Fails, empty name",
+			patch:   ResourcePatch{Type: envoy.Cluster, Patch: `{"connect_timeout":"1s"}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.patch.Validate(envoy.APIv3); (err != nil) != tt.wantErr {
+				t.Errorf("ResourcePatch.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatchResources(t *testing.T) {
+	resources := []Resource{
+		{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster1","connect_timeout":"2s"}`)},
+		{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster2","connect_timeout":"2s"}`)},
+		{Type: envoy.Listener, Value: k8sutil.StringtoRawExtension(`{"name":"listener","address":{"socket_address":{"address":"0.0.0.0","port_value":8080}},"filter_chains":[{"filters":[{"name":"a"}]}]}`)},
+		{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("secret")},
+	}
+
+	tests := []struct {
+		name    string
+		patches []ResourcePatch
+		want    []Resource
+		wantErr string
+	}{
+		{
+			name:    "Applies a proto merge patch",
+			patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster2", Patch: "connect_timeout: 1s\nper_connection_buffer_limit_bytes: 1024"}},
+			want: []Resource{
+				resources[0],
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster2","connect_timeout":"1s","per_connection_buffer_limit_bytes":1024}`)},
+				resources[2],
+				resources[3],
+			},
+		},
+		{
+			name: "Applies JSON patches in order",
+			patches: []ResourcePatch{
+				{Type: envoy.Listener, Name: "listener", PatchType: pointer.New(JSONPatchType),
+					Patch: `[{"op":"add","path":"/filter_chains/0/filters/-","value":{"name":"b"}}]`},
+				{Type: envoy.Listener, Name: "listener", PatchType: pointer.New(JSONPatchType),
+					Patch: `[{"op":"add","path":"/filter_chains/0/filters/0","value":{"name":"c"}}]`},
+			},
+			want: []Resource{
+				resources[0],
+				resources[1],
+				{Type: envoy.Listener, Value: k8sutil.StringtoRawExtension(`{"name":"listener","address":{"socket_address":{"address":"0.0.0.0","port_value":8080}},"filter_chains":[{"filters":[{"name":"c"},{"name":"a"},{"name":"b"}]}]}`)},
+				resources[3],
+			},
+		},
+		{
+			name:    "Fails, target not found",
+			patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster3", Patch: "connect_timeout: 1s"}},
+			wantErr: "spec.patches[0]: cluster 'cluster3' not found",
+		},
+		{
+			name:    "Fails, generated resources cannot be patched",
+			patches: []ResourcePatch{{Type: envoy.Secret, Name: "secret", Patch: "{}"}},
+			wantErr: "spec.patches[0]: secret 'secret' is generated and cannot be patched",
+		},
+		{
+			name: "Fails, JSON patch operation fails",
+			patches: []ResourcePatch{
+				{Type: envoy.Cluster, Name: "cluster1", Patch: "connect_timeout: 1s"},
+				{Type: envoy.Cluster, Name: "cluster1", PatchType: pointer.New(JSONPatchType),
+					Patch: `[{"op":"remove","path":"/lb_policy"}]`},
+			},
+			wantErr: "spec.patches[1]: error in remove for path: '/lb_policy': unable to remove nonexistent key: lb_policy: missing value",
+		},
+		{
+			name: "Fails, the patched resource is invalid",
+			patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster1", PatchType: pointer.New(JSONPatchType),
+				Patch: `[{"op":"replace","path":"/connect_timeout","value":"-1s"}]`}},
+			wantErr: "spec.patches[0]: patched cluster 'cluster1' is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PatchResources(resources, tt.patches, envoy.APIv3)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("PatchResources() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("PatchResources() error = %v", err)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
+				t.Errorf("PatchResources() = diff %v", diff)
+			}
+		})
+	}
+}
+
+func TestEnvoyConfig_ApplyPatches(t *testing.T) {
+	ec := &EnvoyConfig{Spec: EnvoyConfigSpec{
+		NodeID: "test",
+		EnvoyResources: &EnvoyResources{
+			Clusters: []EnvoyResource{{Value: `{"name":"cluster","connect_timeout":"2s"}`}},
+		},
+		Patches: []ResourcePatch{{Type: envoy.Cluster, Name: "cluster", Patch: "connect_timeout: 1s"}},
+	}}
+
+	if err := ec.ApplyPatches(); err != nil {
+		t.Fatalf("EnvoyConfig.ApplyPatches() error = %v", err)
+	}
+	want := EnvoyConfigSpec{
+		NodeID:    "test",
+		Resources: []Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster","connect_timeout":"1s"}`)}},
+	}
+	if diff := deep.Equal(ec.Spec, want); len(diff) > 0 {
+		t.Errorf("EnvoyConfig.ApplyPatches() = diff %v", diff)
+	}
+
+	// applying the patches again is a no-op
+	if err := ec.ApplyPatches(); err != nil {
+		t.Fatalf("EnvoyConfig.ApplyPatches() error = %v", err)
+	}
+	if diff := deep.Equal(ec.Spec, want); len(diff) > 0 {
+		t.Errorf("EnvoyConfig.ApplyPatches() = diff %v", diff)
+	}
+}
@@ -1,15 +1,22 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"time"
 
+	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
 	"sigs.k8s.io/yaml"
 )
 
@@ -26,6 +33,26 @@ const (
 
 const defaultBlueprint Blueprint = TlsCertificate
 
+// CertificateKind is an enum of the kinds of certificate
+// objects secrets can be generated from
+type CertificateKind string
+
+const (
+	// CertManagerCertificate is a cert-manager.io/v1 Certificate
+	CertManagerCertificate CertificateKind = "Certificate"
+	// DiscoveryServiceCertificateKind is an operator.marin3r.3scale.net/v1alpha1 DiscoveryServiceCertificate
+	DiscoveryServiceCertificateKind CertificateKind = "DiscoveryServiceCertificate"
+)
+
+// CertificateAuthoritySuffix is appended to the name of a certificate to name
+// the validation context secret generated from its issuing CA
+const CertificateAuthoritySuffix string = "-ca"
+
+// MinResourceTTL is the minimum TTL allowed for a resource. It needs
+// to be well above the interval at which the discovery service sends
+// the heartbeats so the resources do not expire in healthy conditions.
+const MinResourceTTL time.Duration = 15 * time.Second
+
 // Resource holds serialized representation of an envoy
 // resource
 type Resource struct {
@@ -48,17 +75,74 @@ type Resource struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	GenerateFromOpaqueSecret *SecretKeySelector `json:"generateFromOpaqueSecret,omitempty"`
+	// A reference to a cert-manager Certificate or a DiscoveryServiceCertificate, in the
+	// namespace of the EnvoyConfig. The secret is named after the certificate and it is
+	// generated from the Kubernetes Secret the certificate is written to once the certificate
+	// is ready, so renewals are picked up. With the "tlsCertificate" blueprint, if the Secret
+	// has a "ca.crt" key, a "validationContext" secret with the issuing CA is also generated,
+	// named after the certificate with the "-ca" suffix. With the "validationContext" blueprint
+	// only the CA is generated, from "ca.crt" or, if not present, from "tls.crt".
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	GenerateFromCertificate *CertificateReference `json:"generateFromCertificate,omitempty"`
+	// The namespace of the Kubernetes Secret in "generateFromTlsSecret" or "generateFromOpaqueSecret".
+	// Defaults to the namespace of the EnvoyConfig. Secrets from other namespaces can only be used
+	// if a SecretReferenceGrant in the namespace of the Secret allows it, and the discovery service
+	// is configured to watch Secrets in that namespace.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	SecretNamespace *string `json:"secretNamespace,omitempty"`
 	// Specifies a label selector to watch for EndpointSlices that will
 	// be used to generate the endpoint resource
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	GenerateFromEndpointSlices *GenerateFromEndpointSlices `json:"generateFromEndpointSlices,omitempty"`
+	// The name of a Kubernetes ConfigMap whose keys are used to generate a runtime
+	// layer, named after the ConfigMap. Values are coerced to booleans, numbers and
+	// fractional percents (e.g. "25%"). The content of the ConfigMap is not part of
+	// the version of the revision: changes to the ConfigMap are pushed to the Envoy
+	// clients as runtime updates of the published revision.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	GenerateFromConfigMap *string `json:"generateFromConfigMap,omitempty"`
+	// DataSourcesFrom inlines the content of ConfigMap keys in envoy DataSource fields
+	// of the value, like the source code of a Lua filter or a local JWKS. Changes to the
+	// ConfigMaps generate new revisions.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	DataSourcesFrom []DataSourceFromConfigMap `json:"dataSourcesFrom,omitempty"`
 	// Blueprint specifies a template to generate a configuration proto. It is currently
 	// only supported to generate secret configuration resources from k8s Secrets
 	// +kubebuilder:validation:Enum=tlsCertificate;validationContext;
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	Blueprint *Blueprint `json:"blueprint,omitempty"`
+	// TTL is the time to live of the resource in the Envoy clients. If set, the
+	// discovery service sends periodic heartbeats to refresh the TTL of the resource
+	// while it is healthy. If the heartbeats stop arriving (for example because the
+	// discovery service is down), Envoy removes the resource once the TTL expires.
+	// This is useful to automatically revert runtime based emergency switches. The TTL
+	// must be at least 15s.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// ResourcesVersion returns the hash of the JSON serialization of the resources. Unset
+// fields are omitted from the serialization, so adding fields to the Resource type does
+// not change the version of the resources that do not use them.
+func ResourcesVersion(resources []Resource) string {
+	if resources == nil {
+		resources = []Resource{}
+	}
+	b, err := json.Marshal(resources)
+	if err != nil {
+		// values are validated by the webhook, so this should never happen
+		return reconcilerutil.Hash(resources)
+	}
+	hasher := fnv.New32a()
+	hasher.Write(b)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32()))
 }
 
 func (r *Resource) GetBlueprint() Blueprint {
@@ -80,6 +164,39 @@ func (r *Resource) SecretRef() (string, error) {
 	return "", fmt.Errorf("secret reference not set")
 }
 
+// GetSecretNamespace returns the namespace of the Kubernetes Secret the resource is
+// generated from, given the namespace of the EnvoyConfig
+func (r *Resource) GetSecretNamespace(namespace string) string {
+	if r.SecretNamespace != nil && *r.SecretNamespace != "" {
+		return *r.SecretNamespace
+	}
+	return namespace
+}
+
+// GetName returns the name of the envoy resource. Resources with a value
+// are decoded to read the name from the resource itself.
+func (r *Resource) GetName(version envoy.APIVersion) (string, error) {
+	switch {
+	case r.Value != nil:
+		res := envoy_resources.NewGenerator(version).New(r.Type)
+		if err := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, version).Unmarshal(string(r.Value.Raw), res); err != nil {
+			return "", err
+		}
+		return cache_v3.GetResourceName(res), nil
+	case r.GenerateFromTlsSecret != nil:
+		return *r.GenerateFromTlsSecret, nil
+	case r.GenerateFromOpaqueSecret != nil:
+		return r.GenerateFromOpaqueSecret.Alias, nil
+	case r.GenerateFromCertificate != nil:
+		return r.GenerateFromCertificate.Name, nil
+	case r.GenerateFromEndpointSlices != nil:
+		return r.GenerateFromEndpointSlices.ClusterName, nil
+	case r.GenerateFromConfigMap != nil:
+		return *r.GenerateFromConfigMap, nil
+	}
+	return "", fmt.Errorf("unable to get the name of the resource")
+}
+
 type SecretKeySelector struct {
 	// The name of the secret in the pod's namespace to select from.
 	Name string `json:"name"`
@@ -89,6 +206,52 @@ type SecretKeySelector struct {
 	Alias string `json:"alias"`
 }
 
+// CertificateReference is a reference to a certificate object
+type CertificateReference struct {
+	// Kind of the certificate object, either a cert-manager "Certificate"
+	// or a "DiscoveryServiceCertificate"
+	// +kubebuilder:validation:Enum=Certificate;DiscoveryServiceCertificate
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Kind CertificateKind `json:"kind"`
+	// Name of the certificate object
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Name string `json:"name"`
+}
+
+// DataSourceFromConfigMap selects a ConfigMap key whose content is inlined in an envoy
+// DataSource of the resource value. Keys in the "data" of the ConfigMap are inlined as
+// "inline_string" and keys in "binaryData" as "inline_bytes". The DataSource is located
+// either by path or by placeholder.
+type DataSourceFromConfigMap struct {
+	// Name of the ConfigMap, in the namespace of the EnvoyConfig
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Name string `json:"name"`
+	// Key of the ConfigMap
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Key string `json:"key"`
+	// Path is a JSON pointer (RFC 6901) to the DataSource within the value, using the proto
+	// field names. For example: "/typed_config/default_source_code". The DataSource is
+	// replaced if it already exists.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Path *string `json:"path,omitempty"`
+	// Placeholder replaces all the DataSources of the value whose "inline_string" is the
+	// placeholder wrapped in "$()". For example, with placeholder "lua-script", the DataSource
+	// {"inline_string": "$(lua-script)"} is replaced.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	Placeholder *string `json:"placeholder,omitempty"`
+}
+
+// GetPlaceholder returns the placeholder string that identifies the
+// DataSources to replace in the value
+func (ds *DataSourceFromConfigMap) GetPlaceholder() string {
+	if ds.Placeholder == nil {
+		return ""
+	}
+	return fmt.Sprintf("$(%s)", *ds.Placeholder)
+}
+
 type GenerateFromEndpointSlices struct {
 	Selector    *metav1.LabelSelector `json:"selector"`
 	ClusterName string                `json:"clusterName"`
@@ -184,11 +347,15 @@ func (in *EnvoyResources) Resources(serialization envoy_serializer.Serialization
 		resources = append(resources, r)
 	}
 	for _, deprecatedResource := range in.Secrets {
+		// the name is kept as the name of the envoy secret, only the namespace of the reference is used
 		r := Resource{
 			Type:                  envoy.Secret,
-			GenerateFromTlsSecret: &deprecatedResource.Name,
+			GenerateFromTlsSecret: pointer.New(deprecatedResource.Name),
 			Blueprint:             pointer.New(TlsCertificate),
 		}
+		if deprecatedResource.Ref != nil && deprecatedResource.Ref.Namespace != "" {
+			r.SecretNamespace = pointer.New(deprecatedResource.Ref.Namespace)
+		}
 		resources = append(resources, r)
 	}
 
@@ -238,15 +405,15 @@ func (res *EnvoyResource) Resource(rType envoy.Type, serialization envoy_seriali
 }
 
 // EnvoySecretResource holds a reference to a k8s Secret from where
-// to take a secret from. Only Secrets within the same namespace can
-// be referred.
+// to take a secret from. Secrets from other namespaces can only be
+// referred if a SecretReferenceGrant in their namespace allows it.
 type EnvoySecretResource struct {
 	// Name of the envoy tslCerticate secret resource. The certificate will be fetched
 	// from a Kubernetes Secrets of type 'kubernetes.io/tls' with this same name.
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	Name string `json:"name"`
-	// DEPRECATED: this field is deprecated and it's value will be ignored. The 'name' of the
-	// Kubernetes Secret must match the 'name' field.
+	// DEPRECATED: this field is deprecated. Its 'name' is ignored, as the name of the Kubernetes
+	// Secret must match the 'name' field, and its 'namespace' selects the namespace of the Secret.
 	// +operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors="urn:alm:descriptor:io.kubernetes:SecretReference"
 	// +optional
 	Ref *corev1.SecretReference `json:"ref,omitempty"`
@@ -254,19 +421,21 @@ type EnvoySecretResource struct {
 
 func (esr *EnvoySecretResource) GetSecretKey(namespace string) types.NamespacedName {
 	if esr.Ref != nil {
+		if esr.Ref.Namespace != "" {
+			namespace = esr.Ref.Namespace
+		}
 		return types.NamespacedName{Name: esr.Ref.Name, Namespace: namespace}
 	}
 	return types.NamespacedName{Name: esr.Name, Namespace: namespace}
 }
 
-func (esr *EnvoySecretResource) Validate(namespace string) error {
+// Validate validates the reference to the Secret. Secrets from other namespaces can be referred,
+// but they are only used if a SecretReferenceGrant in the namespace of the Secret allows it.
+func (esr *EnvoySecretResource) Validate() error {
 	if esr.Ref != nil {
 		if esr.Ref.Name == "" {
 			return fmt.Errorf("'%T.ref.name' cannot be empty", esr)
 		}
-		if esr.Ref.Namespace != "" && esr.Ref.Namespace != namespace {
-			return fmt.Errorf("only Secrets from the same namespace '%s' can be referred", namespace)
-		}
 	}
 	return nil
 }
@@ -4,9 +4,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func TestEnvoyResources_Resources(t *testing.T) {
@@ -76,6 +78,23 @@ func TestEnvoyResources_Resources(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Converts secrets with a reference keeping the name of the envoy secret",
+			fields: fields{
+				Secrets: []EnvoySecretResource{
+					{Name: "secret", Ref: &corev1.SecretReference{Name: "referred"}},
+					{Name: "other", Ref: &corev1.SecretReference{Name: "referred", Namespace: "certs"}},
+				},
+			},
+			args: args{
+				serialization: envoy_serializer.JSON,
+			},
+			want: []Resource{
+				{Type: "secret", GenerateFromTlsSecret: pointer.New("secret"), Blueprint: pointer.New(TlsCertificate)},
+				{Type: "secret", GenerateFromTlsSecret: pointer.New("other"), Blueprint: pointer.New(TlsCertificate), SecretNamespace: pointer.New("certs")},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -100,3 +119,87 @@ func TestEnvoyResources_Resources(t *testing.T) {
 		})
 	}
 }
+
+func TestResource_GetName(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource Resource
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Resource with a value",
+			resource: Resource{Type: envoy.Listener, Value: k8sutil.StringtoRawExtension(`{"name":"listener"}`)},
+			want:     "listener",
+		},
+		{
+			name:     "Endpoint resource with a value",
+			resource: Resource{Type: envoy.Endpoint, Value: k8sutil.StringtoRawExtension(`{"cluster_name":"cluster"}`)},
+			want:     "cluster",
+		},
+		{
+			name:     "Secret from a tls Secret",
+			resource: Resource{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("cert")},
+			want:     "cert",
+		},
+		{
+			name:     "Secret from an opaque Secret",
+			resource: Resource{Type: envoy.Secret, GenerateFromOpaqueSecret: &SecretKeySelector{Name: "secret", Key: "key", Alias: "alias"}},
+			want:     "alias",
+		},
+		{
+			name:     "Endpoint from EndpointSlices",
+			resource: Resource{Type: envoy.Endpoint, GenerateFromEndpointSlices: &GenerateFromEndpointSlices{ClusterName: "cluster"}},
+			want:     "cluster",
+		},
+		{
+			name:     "Fails for a value that cannot be decoded",
+			resource: Resource{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"unknown":"field"}`)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.resource.GetName(envoy.APIv3)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Resource.GetName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Resource.GetName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourcesVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []Resource
+		want      string
+	}{
+		{
+			name:      "Empty and nil resources have the same version",
+			resources: nil,
+			want:      "5f8cb5778f",
+		},
+		{
+			name:      "Empty resources",
+			resources: []Resource{},
+			want:      "5f8cb5778f",
+		},
+		{
+			// the version must not change when fields are added to the Resource type
+			name:      "Unset fields are not part of the version",
+			resources: []Resource{{Type: envoy.Endpoint, Value: k8sutil.StringtoRawExtension(`{"cluster_name": "correct_endpoint"}`)}},
+			want:      "6665498b4f",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResourcesVersion(tt.resources); got != tt.want {
+				t.Errorf("ResourcesVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretReferenceGrantSpec defines the desired state of SecretReferenceGrant
+type SecretReferenceGrantSpec struct {
+	// From is the list of namespaces whose EnvoyConfigs can refer
+	// to Secrets in the namespace of the SecretReferenceGrant
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +kubebuilder:validation:MinItems=1
+	From []SecretReferenceGrantFrom `json:"from"`
+	// To is the list of Secrets that can be referred. All the Secrets
+	// in the namespace can be referred if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	To []SecretReferenceGrantTo `json:"to,omitempty"`
+}
+
+// SecretReferenceGrantFrom describes the EnvoyConfigs that are allowed to refer to the Secrets
+type SecretReferenceGrantFrom struct {
+	// Namespace of the EnvoyConfigs
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Namespace string `json:"namespace"`
+}
+
+// SecretReferenceGrantTo describes the Secrets that can be referred
+type SecretReferenceGrantTo struct {
+	// Name of the Secret
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	Name string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+
+// SecretReferenceGrant allows the EnvoyConfigs of other namespaces to generate envoy secrets from
+// the Kubernetes Secrets in the namespace of the SecretReferenceGrant, in a similar way to the
+// ReferenceGrant of the Gateway API. This allows, for example, keeping wildcard certificates in a
+// dedicated namespace. References to Secrets in other namespaces are rejected unless a grant
+// in the namespace of the Secret allows them.
+// +kubebuilder:resource:path=secretreferencegrants,scope=Namespaced,shortName=srg
+// +kubebuilder:printcolumn:JSONPath=".metadata.creationTimestamp",name="Age",type=date
+// +operator-sdk:csv:customresourcedefinitions:displayName="SecretReferenceGrant"
+type SecretReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretReferenceGrantSpec `json:"spec,omitempty"`
+}
+
+// Permits returns true if the grant allows the EnvoyConfigs of the
+// given namespace to refer to the Secret with the given name
+func (srg *SecretReferenceGrant) Permits(namespace, secretName string) bool {
+	from := false
+	for _, f := range srg.Spec.From {
+		if f.Namespace == namespace {
+			from = true
+			break
+		}
+	}
+	if !from {
+		return false
+	}
+
+	if len(srg.Spec.To) == 0 {
+		return true
+	}
+	for _, t := range srg.Spec.To {
+		if t.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// SecretReferenceGrantList contains a list of SecretReferenceGrant
+type SecretReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretReferenceGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretReferenceGrant{}, &SecretReferenceGrantList{})
+}
@@ -0,0 +1,76 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSecretReferenceGrant_Permits(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       SecretReferenceGrantSpec
+		namespace  string
+		secretName string
+		want       bool
+	}{
+		{
+			name:       "Permits all the Secrets if 'to' is unset",
+			spec:       SecretReferenceGrantSpec{From: []SecretReferenceGrantFrom{{Namespace: "gateways"}}},
+			namespace:  "gateways",
+			secretName: "wildcard",
+			want:       true,
+		},
+		{
+			name: "Permits the listed Secrets",
+			spec: SecretReferenceGrantSpec{
+				From: []SecretReferenceGrantFrom{{Namespace: "other"}, {Namespace: "gateways"}},
+				To:   []SecretReferenceGrantTo{{Name: "wildcard"}},
+			},
+			namespace:  "gateways",
+			secretName: "wildcard",
+			want:       true,
+		},
+		{
+			name: "Does not permit other Secrets",
+			spec: SecretReferenceGrantSpec{
+				From: []SecretReferenceGrantFrom{{Namespace: "gateways"}},
+				To:   []SecretReferenceGrantTo{{Name: "wildcard"}},
+			},
+			namespace:  "gateways",
+			secretName: "other",
+			want:       false,
+		},
+		{
+			name:       "Does not permit other namespaces",
+			spec:       SecretReferenceGrantSpec{From: []SecretReferenceGrantFrom{{Namespace: "gateways"}}},
+			namespace:  "other",
+			secretName: "wildcard",
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srg := &SecretReferenceGrant{ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "certs"}, Spec: tt.spec}
+			if got := srg.Permits(tt.namespace, tt.secretName); got != tt.want {
+				t.Errorf("SecretReferenceGrant.Permits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -28,10 +28,106 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRolloutStrategy) DeepCopyInto(out *CanaryRolloutStrategy) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]CanaryStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRolloutStrategy.
+func (in *CanaryRolloutStrategy) DeepCopy() *CanaryRolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryStep) DeepCopyInto(out *CanaryStep) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int)
+		**out = **in
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BakeTime != nil {
+		in, out := &in.BakeTime, &out.BakeTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryStep.
+func (in *CanaryStep) DeepCopy() *CanaryStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryTarget) DeepCopyInto(out *CanaryTarget) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int)
+		**out = **in
+	}
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryTarget.
+func (in *CanaryTarget) DeepCopy() *CanaryTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateReference) DeepCopyInto(out *CertificateReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateReference.
+func (in *CertificateReference) DeepCopy() *CertificateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConfigRevisionRef) DeepCopyInto(out *ConfigRevisionRef) {
 	*out = *in
 	out.Ref = in.Ref
+	if in.PublishedAt != nil {
+		in, out := &in.PublishedAt, &out.PublishedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigRevisionRef.
@@ -44,6 +140,31 @@ func (in *ConfigRevisionRef) DeepCopy() *ConfigRevisionRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataSourceFromConfigMap) DeepCopyInto(out *DataSourceFromConfigMap) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+	if in.Placeholder != nil {
+		in, out := &in.Placeholder, &out.Placeholder
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSourceFromConfigMap.
+func (in *DataSourceFromConfigMap) DeepCopy() *DataSourceFromConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(DataSourceFromConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyConfig) DeepCopyInto(out *EnvoyConfig) {
 	*out = *in
@@ -71,6 +192,127 @@ func (in *EnvoyConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigFragment) DeepCopyInto(out *EnvoyConfigFragment) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigFragment.
+func (in *EnvoyConfigFragment) DeepCopy() *EnvoyConfigFragment {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigFragment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyConfigFragment) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigFragmentList) DeepCopyInto(out *EnvoyConfigFragmentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnvoyConfigFragment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigFragmentList.
+func (in *EnvoyConfigFragmentList) DeepCopy() *EnvoyConfigFragmentList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigFragmentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyConfigFragmentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigFragmentSpec) DeepCopyInto(out *EnvoyConfigFragmentSpec) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]Resource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigFragmentSpec.
+func (in *EnvoyConfigFragmentSpec) DeepCopy() *EnvoyConfigFragmentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigFragmentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigFragmentStatus) DeepCopyInto(out *EnvoyConfigFragmentStatus) {
+	*out = *in
+	if in.EnvoyConfig != nil {
+		in, out := &in.EnvoyConfig, &out.EnvoyConfig
+		*out = new(string)
+		**out = **in
+	}
+	if in.Included != nil {
+		in, out := &in.Included, &out.Included
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DesiredVersion != nil {
+		in, out := &in.DesiredVersion, &out.DesiredVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.Published != nil {
+		in, out := &in.Published, &out.Published
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigFragmentStatus.
+func (in *EnvoyConfigFragmentStatus) DeepCopy() *EnvoyConfigFragmentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigFragmentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyConfigList) DeepCopyInto(out *EnvoyConfigList) {
 	*out = *in
@@ -103,6 +345,91 @@ func (in *EnvoyConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigPolicy) DeepCopyInto(out *EnvoyConfigPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigPolicy.
+func (in *EnvoyConfigPolicy) DeepCopy() *EnvoyConfigPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyConfigPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigPolicyList) DeepCopyInto(out *EnvoyConfigPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EnvoyConfigPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigPolicyList.
+func (in *EnvoyConfigPolicyList) DeepCopy() *EnvoyConfigPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EnvoyConfigPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoyConfigPolicySpec) DeepCopyInto(out *EnvoyConfigPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]PolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigPolicySpec.
+func (in *EnvoyConfigPolicySpec) DeepCopy() *EnvoyConfigPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoyConfigPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyConfigRevision) DeepCopyInto(out *EnvoyConfigRevision) {
 	*out = *in
@@ -187,6 +514,11 @@ func (in *EnvoyConfigRevisionSpec) DeepCopyInto(out *EnvoyConfigRevisionSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TaintPolicy != nil {
+		in, out := &in.TaintPolicy, &out.TaintPolicy
+		*out = new(TaintPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigRevisionSpec.
@@ -221,6 +553,35 @@ func (in *EnvoyConfigRevisionStatus) DeepCopyInto(out *EnvoyConfigRevisionStatus
 		*out = new(bool)
 		**out = **in
 	}
+	if in.UntaintedAt != nil {
+		in, out := &in.UntaintedAt, &out.UntaintedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.TaintRetries != nil {
+		in, out := &in.TaintRetries, &out.TaintRetries
+		*out = new(int)
+		**out = **in
+	}
+	if in.TaintedTypes != nil {
+		in, out := &in.TaintedTypes, &out.TaintedTypes
+		*out = make([]envoy.Type, len(*in))
+		copy(*out, *in)
+	}
+	if in.TypeRevisions != nil {
+		in, out := &in.TypeRevisions, &out.TypeRevisions
+		*out = make([]TypeRevision, len(*in))
+		copy(*out, *in)
+	}
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = new(RevisionDiffSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryTarget)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -265,8 +626,69 @@ func (in *EnvoyConfigSpec) DeepCopyInto(out *EnvoyConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-}
-
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ParametersFrom != nil {
+		in, out := &in.ParametersFrom, &out.ParametersFrom
+		*out = make([]ParametersSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Patches != nil {
+		in, out := &in.Patches, &out.Patches
+		*out = make([]ResourcePatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RevisionHistory != nil {
+		in, out := &in.RevisionHistory, &out.RevisionHistory
+		*out = new(RevisionHistory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PinnedVersion != nil {
+		in, out := &in.PinnedVersion, &out.PinnedVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(RolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TaintPolicy != nil {
+		in, out := &in.TaintPolicy, &out.TaintPolicy
+		*out = new(TaintPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PublishingMode != nil {
+		in, out := &in.PublishingMode, &out.PublishingMode
+		*out = new(PublishingMode)
+		**out = **in
+	}
+	if in.Paused != nil {
+		in, out := &in.Paused, &out.Paused
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PublishSchedule != nil {
+		in, out := &in.PublishSchedule, &out.PublishSchedule
+		*out = new(PublishSchedule)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApprovalRequired != nil {
+		in, out := &in.ApprovalRequired, &out.ApprovalRequired
+		*out = new(bool)
+		**out = **in
+	}
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfigSpec.
 func (in *EnvoyConfigSpec) DeepCopy() *EnvoyConfigSpec {
 	if in == nil {
@@ -305,6 +727,18 @@ func (in *EnvoyConfigStatus) DeepCopyInto(out *EnvoyConfigStatus) {
 	if in.ConfigRevisions != nil {
 		in, out := &in.ConfigRevisions, &out.ConfigRevisions
 		*out = make([]ConfigRevisionRef, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TypeRevisions != nil {
+		in, out := &in.TypeRevisions, &out.TypeRevisions
+		*out = make([]TypeRevision, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -450,6 +884,77 @@ func (in *GenerateFromEndpointSlices) DeepCopy() *GenerateFromEndpointSlices {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParametersSource) DeepCopyInto(out *ParametersSource) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParametersSource.
+func (in *ParametersSource) DeepCopy() *ParametersSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ParametersSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyRule) DeepCopyInto(out *PolicyRule) {
+	*out = *in
+	if in.Message != nil {
+		in, out := &in.Message, &out.Message
+		*out = new(string)
+		**out = **in
+	}
+	if in.Action != nil {
+		in, out := &in.Action, &out.Action
+		*out = new(PolicyAction)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyRule.
+func (in *PolicyRule) DeepCopy() *PolicyRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PublishSchedule) DeepCopyInto(out *PublishSchedule) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.TimeZone != nil {
+		in, out := &in.TimeZone, &out.TimeZone
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PublishSchedule.
+func (in *PublishSchedule) DeepCopy() *PublishSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(PublishSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resource) DeepCopyInto(out *Resource) {
 	*out = *in
@@ -468,16 +973,43 @@ func (in *Resource) DeepCopyInto(out *Resource) {
 		*out = new(SecretKeySelector)
 		**out = **in
 	}
+	if in.GenerateFromCertificate != nil {
+		in, out := &in.GenerateFromCertificate, &out.GenerateFromCertificate
+		*out = new(CertificateReference)
+		**out = **in
+	}
+	if in.SecretNamespace != nil {
+		in, out := &in.SecretNamespace, &out.SecretNamespace
+		*out = new(string)
+		**out = **in
+	}
 	if in.GenerateFromEndpointSlices != nil {
 		in, out := &in.GenerateFromEndpointSlices, &out.GenerateFromEndpointSlices
 		*out = new(GenerateFromEndpointSlices)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.GenerateFromConfigMap != nil {
+		in, out := &in.GenerateFromConfigMap, &out.GenerateFromConfigMap
+		*out = new(string)
+		**out = **in
+	}
+	if in.DataSourcesFrom != nil {
+		in, out := &in.DataSourcesFrom, &out.DataSourcesFrom
+		*out = make([]DataSourceFromConfigMap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Blueprint != nil {
 		in, out := &in.Blueprint, &out.Blueprint
 		*out = new(Blueprint)
 		**out = **in
 	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Resource.
@@ -490,6 +1022,143 @@ func (in *Resource) DeepCopy() *Resource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcePatch) DeepCopyInto(out *ResourcePatch) {
+	*out = *in
+	if in.PatchType != nil {
+		in, out := &in.PatchType, &out.PatchType
+		*out = new(PatchType)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcePatch.
+func (in *ResourcePatch) DeepCopy() *ResourcePatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcePatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionArchive) DeepCopyInto(out *RevisionArchive) {
+	*out = *in
+	if in.ConfigMapName != nil {
+		in, out := &in.ConfigMapName, &out.ConfigMapName
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxRevisions != nil {
+		in, out := &in.MaxRevisions, &out.MaxRevisions
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionArchive.
+func (in *RevisionArchive) DeepCopy() *RevisionArchive {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionArchive)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionDiffSummary) DeepCopyInto(out *RevisionDiffSummary) {
+	*out = *in
+	in.PublishedAt.DeepCopyInto(&out.PublishedAt)
+	if in.ChangedTypes != nil {
+		in, out := &in.ChangedTypes, &out.ChangedTypes
+		*out = make([]envoy.Type, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionDiffSummary.
+func (in *RevisionDiffSummary) DeepCopy() *RevisionDiffSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionDiffSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevisionHistory) DeepCopyInto(out *RevisionHistory) {
+	*out = *in
+	if in.MaxRevisions != nil {
+		in, out := &in.MaxRevisions, &out.MaxRevisions
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.KeepTainted != nil {
+		in, out := &in.KeepTainted, &out.KeepTainted
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Archive != nil {
+		in, out := &in.Archive, &out.Archive
+		*out = new(RevisionArchive)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevisionHistory.
+func (in *RevisionHistory) DeepCopy() *RevisionHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(RevisionHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	in.StepStartedAt.DeepCopyInto(&out.StepStartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeySelector) DeepCopyInto(out *SecretKeySelector) {
 	*out = *in
@@ -505,6 +1174,208 @@ func (in *SecretKeySelector) DeepCopy() *SecretKeySelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReferenceGrant) DeepCopyInto(out *SecretReferenceGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReferenceGrant.
+func (in *SecretReferenceGrant) DeepCopy() *SecretReferenceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReferenceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretReferenceGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReferenceGrantFrom) DeepCopyInto(out *SecretReferenceGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReferenceGrantFrom.
+func (in *SecretReferenceGrantFrom) DeepCopy() *SecretReferenceGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReferenceGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReferenceGrantList) DeepCopyInto(out *SecretReferenceGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecretReferenceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReferenceGrantList.
+func (in *SecretReferenceGrantList) DeepCopy() *SecretReferenceGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReferenceGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretReferenceGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReferenceGrantSpec) DeepCopyInto(out *SecretReferenceGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]SecretReferenceGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]SecretReferenceGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReferenceGrantSpec.
+func (in *SecretReferenceGrantSpec) DeepCopy() *SecretReferenceGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReferenceGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReferenceGrantTo) DeepCopyInto(out *SecretReferenceGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReferenceGrantTo.
+func (in *SecretReferenceGrantTo) DeepCopy() *SecretReferenceGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReferenceGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaintPolicy) DeepCopyInto(out *TaintPolicy) {
+	*out = *in
+	in.TaintThresholds.DeepCopyInto(&out.TaintThresholds)
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = make([]TaintPolicyOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RetryAfter != nil {
+		in, out := &in.RetryAfter, &out.RetryAfter
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaintPolicy.
+func (in *TaintPolicy) DeepCopy() *TaintPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(TaintPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaintPolicyOverride) DeepCopyInto(out *TaintPolicyOverride) {
+	*out = *in
+	in.TaintThresholds.DeepCopyInto(&out.TaintThresholds)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaintPolicyOverride.
+func (in *TaintPolicyOverride) DeepCopy() *TaintPolicyOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(TaintPolicyOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TaintThresholds) DeepCopyInto(out *TaintThresholds) {
+	*out = *in
+	if in.NACKThreshold != nil {
+		in, out := &in.NACKThreshold, &out.NACKThreshold
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FailingPodsPercentage != nil {
+		in, out := &in.FailingPodsPercentage, &out.FailingPodsPercentage
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinObservedPods != nil {
+		in, out := &in.MinObservedPods, &out.MinObservedPods
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TaintThresholds.
+func (in *TaintThresholds) DeepCopy() *TaintThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(TaintThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TypeRevision) DeepCopyInto(out *TypeRevision) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TypeRevision.
+func (in *TypeRevision) DeepCopy() *TypeRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(TypeRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VersionTracker) DeepCopyInto(out *VersionTracker) {
 	*out = *in
@@ -45,6 +45,8 @@ const (
 	DefaultProbePort uint32 = 8384
 	// DefaultXdsServerPort is the default port where the discovery service xds server port listens
 	DefaultXdsServerPort uint32 = 18000
+	// DefaultDebugAPIPort is the default port where the discovery service debug API listens
+	DefaultDebugAPIPort uint32 = 8385
 	// DefaultRootCertificateDuration is the default root CA certificate duration
 	DefaultRootCertificateDuration string = "26280h" // 3 years
 	// DefaultRootCertificateSecretNamePrefix is the default prefix for the Secret
@@ -103,6 +105,19 @@ type DiscoveryServiceSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	ProbePort *uint32 `json:"probePort,omitempty"`
+	// DebugAPIPort is the port where the debug API is served. The debug API
+	// requires clients to authenticate with a certificate issued by the
+	// discovery service CA. Defaults to 8385.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	DebugAPIPort *uint32 `json:"debugAPIPort,omitempty"`
+	// DebugAPIClients is the list of common names of the client certificates
+	// authorized to use the debug API. Certificates can be issued for the debug
+	// clients with DiscoveryServiceCertificates signed by the discovery service CA.
+	// The debug API rejects all requests if unset.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	DebugAPIClients []string `json:"debugAPIClients,omitempty"`
 	// ServiceConfig configures the way the DiscoveryService endpoints are exposed
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
@@ -111,6 +126,13 @@ type DiscoveryServiceSpec struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	PodPriorityClass *string `json:"podPriorityClass,omitempty"`
+	// SecretNamespaces is a list of additional namespaces where the discovery service watches
+	// Secrets and SecretReferenceGrants, so EnvoyConfigs can generate envoy secrets from Secrets
+	// in other namespaces. The ServiceAccount of the discovery service needs to be granted
+	// permissions to get, list and watch Secrets and SecretReferenceGrants in those namespaces.
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	SecretNamespaces []string `json:"secretNamespaces,omitempty"`
 }
 
 // DiscoveryServiceStatus defines the observed state of DiscoveryService
@@ -277,6 +299,14 @@ func (d *DiscoveryService) GetProbePort() uint32 {
 	return DefaultProbePort
 }
 
+// GetDebugAPIPort returns the port the debug API server will listen at
+func (d *DiscoveryService) GetDebugAPIPort() uint32 {
+	if d.Spec.DebugAPIPort != nil {
+		return *d.Spec.DebugAPIPort
+	}
+	return DefaultDebugAPIPort
+}
+
 // GetServiceConfig returns the Service configuration for the discovery service servers
 func (d *DiscoveryService) GetServiceConfig() *ServiceConfig {
 	if d.Spec.ServiceConfig != nil {
@@ -380,6 +380,12 @@ type InitManager struct {
 	// +operator-sdk:csv:customresourcedefinitions:type=spec
 	// +optional
 	Image *string `json:"image,omitempty"`
+	// XdstpAuthorities is the list of xDS federation authorities, in the
+	// form '<authority>=<host>:<port>', the init manager configures Envoy
+	// to fetch xdstp:// resources from
+	// +operator-sdk:csv:customresourcedefinitions:type=spec
+	// +optional
+	XdstpAuthorities []string `json:"xdstpAuthorities,omitempty"`
 }
 
 func (im *InitManager) GetImage() string {
@@ -355,6 +355,16 @@ func (in *DiscoveryServiceSpec) DeepCopyInto(out *DiscoveryServiceSpec) {
 		*out = new(uint32)
 		**out = **in
 	}
+	if in.DebugAPIPort != nil {
+		in, out := &in.DebugAPIPort, &out.DebugAPIPort
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.DebugAPIClients != nil {
+		in, out := &in.DebugAPIClients, &out.DebugAPIClients
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.ServiceConfig != nil {
 		in, out := &in.ServiceConfig, &out.ServiceConfig
 		*out = new(ServiceConfig)
@@ -365,6 +375,11 @@ func (in *DiscoveryServiceSpec) DeepCopyInto(out *DiscoveryServiceSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.SecretNamespaces != nil {
+		in, out := &in.SecretNamespaces, &out.SecretNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryServiceSpec.
@@ -620,6 +635,11 @@ func (in *InitManager) DeepCopyInto(out *InitManager) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.XdstpAuthorities != nil {
+		in, out := &in.XdstpAuthorities, &out.XdstpAuthorities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InitManager.
@@ -31,18 +31,21 @@ import (
 	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
 	marin3rcontroller "github.com/3scale-ops/marin3r/controllers/marin3r"
 	"github.com/3scale-ops/marin3r/pkg/discoveryservice"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/debugapi"
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 	"github.com/go-logr/logr"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	corev1 "k8s.io/api/core/v1"
 	apimachineryruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
@@ -63,16 +66,19 @@ var (
 	}
 
 	xdssPort                     int
+	debugAPIPort                 int
+	debugAPIClients              []string
 	xdssTLSServerCertificatePath string
 	xdssTLSClientCertificatePath string
 	xdssTLSCACertificatePath     string
+	secretNamespaces             []string
 	dsScheme                     = apimachineryruntime.NewScheme()
 )
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(dsScheme))
 	utilruntime.Must(marin3rv1alpha1.AddToScheme(dsScheme))
-	utilruntime.Must(marin3rv1alpha1.AddToScheme(dsScheme))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(dsScheme))
 
 	// +kubebuilder:scaffold:scheme
 
@@ -80,12 +86,17 @@ func init() {
 
 	// Discovery service flags
 	discoveryServiceCmd.Flags().IntVar(&xdssPort, "xdss-port", int(operatorv1alpha1.DefaultXdsServerPort), "The port where the xDS will listen.")
+	discoveryServiceCmd.Flags().IntVar(&debugAPIPort, "debug-api-port", int(operatorv1alpha1.DefaultDebugAPIPort), "The port where the debug API will listen.")
+	discoveryServiceCmd.Flags().StringSliceVar(&debugAPIClients, "debug-api-clients", []string{},
+		"The comma separated list of common names of the client certificates authorized to use the debug API.")
 	discoveryServiceCmd.Flags().StringVar(&xdssTLSServerCertificatePath, "server-certificate-path", "/etc/marin3r/tls/server",
 		fmt.Sprintf("The path where the server certificate '%s' and key '%s' files are located", certificateFile, certificateKeyFile))
 	discoveryServiceCmd.Flags().StringVar(&xdssTLSCACertificatePath, "ca-certificate-path", "/etc/marin3r/tls/ca",
 		fmt.Sprintf("The path where the CA certificate '%s' and key '%s' files are located", certificateFile, certificateKeyFile))
 	discoveryServiceCmd.Flags().StringVar(&xdssTLSClientCertificatePath, "client-certificate-path", "/etc/marin3r/tls/client",
 		fmt.Sprintf("The path where the client certificate '%s' and key '%s' files are located", certificateFile, certificateKeyFile))
+	discoveryServiceCmd.Flags().StringSliceVar(&secretNamespaces, "secret-namespaces", []string{},
+		"Additional namespaces where Secrets and SecretReferenceGrants are watched, for EnvoyConfigs that refer to Secrets in other namespaces.")
 
 }
 
@@ -109,6 +120,7 @@ func runDiscoveryService(cmd *cobra.Command, args []string) {
 			DefaultNamespaces: map[string]cache.Config{
 				os.Getenv("WATCH_NAMESPACE"): {},
 			},
+			ByObject: secretsCacheOptions(os.Getenv("WATCH_NAMESPACE"), secretNamespaces),
 		},
 	})
 	if err != nil {
@@ -122,19 +134,7 @@ func runDiscoveryService(cmd *cobra.Command, args []string) {
 	xdss := discoveryservice.NewXdsServer(
 		ctx,
 		uint(xdssPort),
-		&tls.Config{
-			MinVersion:               tls.VersionTLS12,
-			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
-			PreferServerCipherSuites: true,
-			CipherSuites: []uint16{
-				// Sadly, these 2 non 256 are required to use http2 in go
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-			},
-			Certificates: []tls.Certificate{loadCertificate(xdssTLSServerCertificatePath, setupLog)},
-			ClientAuth:   tls.RequireAndVerifyClientCert,
-			ClientCAs:    loadCA(xdssTLSCACertificatePath, setupLog),
-		},
+		serverTLSConfig(),
 		setupLog,
 	)
 
@@ -152,10 +152,15 @@ func runDiscoveryService(cmd *cobra.Command, args []string) {
 		}
 	}()
 
+	recorder := mgr.GetEventRecorderFor("marin3r-discovery-service")
+
 	// Start controllers
 	if err := (&marin3rcontroller.EnvoyConfigReconciler{
 		Reconciler: reconciler.NewFromManager(mgr).
 			WithLogger(ctrl.Log.WithName("controllers").WithName("envoyconfig")),
+		Streams:        xdss.GetStreamCloser(envoy.APIv3),
+		Recorder:       recorder,
+		DiscoveryStats: xdss.GetDiscoveryStats(envoy.APIv3),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "envoyconfig")
 		os.Exit(1)
@@ -167,11 +172,30 @@ func runDiscoveryService(cmd *cobra.Command, args []string) {
 		XdsCache:       xdss.GetCache(envoy.APIv3),
 		APIVersion:     envoy.APIv3,
 		DiscoveryStats: xdss.GetDiscoveryStats(envoy.APIv3),
+		Canaries:       xdss.GetCanaryRouter(envoy.APIv3),
+		Streams:        xdss.GetStreamCloser(envoy.APIv3),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", fmt.Sprintf("envoyconfigrevision_%s", string(envoy.APIv3)))
 		os.Exit(1)
 	}
 
+	// Start the debug API, which authenticates clients with certificates
+	// issued by the discovery service CA and only authorizes the ones with
+	// the given common names, so Envoy client certificates are not accepted
+	if err := mgr.Add(debugapi.NewServer(
+		uint(debugAPIPort),
+		serverTLSConfig(),
+		debugAPIClients,
+		mgr.GetClient(),
+		xdss.GetCache(envoy.APIv3),
+		xdss.GetStreamCloser(envoy.APIv3),
+		recorder,
+		ctrl.Log.WithName("debugapi"),
+	)); err != nil {
+		setupLog.Error(err, "unable to set up debug API")
+		os.Exit(1)
+	}
+
 	// register healthz and readyz checks
 	if err := mgr.AddHealthzCheck("gRPC", xdssHealthzCheck(ctrl.Log.WithName("XdssHealthzCheck"))); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -206,6 +230,22 @@ func runDiscoveryService(cmd *cobra.Command, args []string) {
 	setupLog.Info("Controller has shut down")
 }
 
+func serverTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:               tls.VersionTLS12,
+		CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
+		PreferServerCipherSuites: true,
+		CipherSuites: []uint16{
+			// Sadly, these 2 non 256 are required to use http2 in go
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		},
+		Certificates: []tls.Certificate{loadCertificate(xdssTLSServerCertificatePath, setupLog)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    loadCA(xdssTLSCACertificatePath, setupLog),
+	}
+}
+
 func xdssHealthzCheck(logger logr.Logger) healthz.Checker {
 	return func(_ *http.Request) error {
 
@@ -264,3 +304,21 @@ func loadCA(directory string, logger logr.Logger) *x509.CertPool {
 	}
 	return certPool
 }
+
+// secretsCacheOptions returns the cache options to watch Secrets and SecretReferenceGrants in
+// the given namespaces, in addition to the watch namespace, so EnvoyConfigs can refer to Secrets
+// in other namespaces. The discovery service must be granted permissions in those namespaces.
+func secretsCacheOptions(watchNamespace string, namespaces []string) map[client.Object]cache.ByObject {
+	if len(namespaces) == 0 || watchNamespace == cache.AllNamespaces {
+		return nil
+	}
+
+	config := map[string]cache.Config{watchNamespace: {}}
+	for _, ns := range namespaces {
+		config[ns] = cache.Config{}
+	}
+	return map[client.Object]cache.ByObject{
+		&corev1.Secret{}:                        {Namespaces: config},
+		&marin3rv1alpha1.SecretReferenceGrant{}: {Namespaces: config},
+	}
+}
@@ -47,6 +47,7 @@ var (
 	initmgrAdminAccessLogPath       string
 	initmgrAPIVersion               string
 	initmgrEnvoyImage               string
+	initmgrXdstpAuthorities         []string
 )
 
 var (
@@ -75,6 +76,8 @@ func init() {
 	initManagerServiceCmd.Flags().StringVar(&initmgrAdminAccessLogPath, "admin-access-log-path", defaults.EnvoyAdminAccessLogPath, "Path for the admin access logs.")
 	initManagerServiceCmd.Flags().StringVar(&initmgrAPIVersion, "api-version", "v3", "Envoy API version to use.")
 	initManagerServiceCmd.Flags().StringVar(&initmgrEnvoyImage, "envoy-image", "", "Envoy image being used.")
+	initManagerServiceCmd.Flags().StringArrayVar(&initmgrXdstpAuthorities, "xdstp-authority", []string{},
+		"An xDS federation authority, in the form '<authority>=<host>:<port>', to fetch xdstp:// resources from. Can be repeated.")
 }
 
 func runInitManager(cmd *cobra.Command, args []string) {
@@ -100,6 +103,16 @@ func runInitManager(cmd *cobra.Command, args []string) {
 		os.Exit(-1)
 	}
 
+	authorities := make([]envoy_bootstrap_options.Authority, 0, len(initmgrXdstpAuthorities))
+	for _, a := range initmgrXdstpAuthorities {
+		authority, err := parseAuthority(a)
+		if err != nil {
+			setupLog.Error(err, "error parsing '--xdstp-authority' flag")
+			os.Exit(-1)
+		}
+		authorities = append(authorities, authority)
+	}
+
 	bootstrap := envoy_bootstrap.NewConfig(envoyAPI, envoy_bootstrap_options.ConfigOptions{
 		NodeID:                      initmgrNodeID,
 		Cluster:                     initmgrCluster,
@@ -116,8 +129,15 @@ func runInitManager(cmd *cobra.Command, args []string) {
 			"pod_name":      os.Getenv("POD_NAME"),
 			"pod_namespace": os.Getenv("POD_NAMESPACE"),
 			"host_name":     os.Getenv("HOST_NAME"),
+			"pod_ip":        os.Getenv("POD_IP"),
+			"host_ip":       os.Getenv("HOST_IP"),
 			"envoy_image":   initmgrEnvoyImage,
 		},
+		Locality: envoy_bootstrap_options.Locality{
+			Region: os.Getenv("LOCALITY_REGION"),
+			Zone:   os.Getenv("LOCALITY_ZONE"),
+		},
+		Authorities: authorities,
 	})
 
 	config, err := bootstrap.GenerateStatic()
@@ -192,3 +212,23 @@ func parseBindAddress(address string) (string, uint32, error) {
 
 	return host, uint32(port), nil
 }
+
+func parseAuthority(authority string) (envoy_bootstrap_options.Authority, error) {
+
+	name, address, ok := strings.Cut(authority, "=")
+	if !ok || name == "" {
+		return envoy_bootstrap_options.Authority{}, fmt.Errorf("wrong authority specification '%s', expected '<authority>=<host>:<port>'", authority)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return envoy_bootstrap_options.Authority{}, fmt.Errorf("wrong authority address '%s': %w", address, err)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return envoy_bootstrap_options.Authority{}, fmt.Errorf("unable to parse port value in authority '%s'", authority)
+	}
+
+	return envoy_bootstrap_options.Authority{Name: name, XdsHost: host, XdsPort: uint32(p)}, nil
+}
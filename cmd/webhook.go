@@ -37,7 +37,10 @@ import (
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
 	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/webhooks/changerecorder"
 	"github.com/3scale-ops/marin3r/pkg/webhooks/podv1mutator"
+	"github.com/3scale-ops/marin3r/pkg/webhooks/policyenforcer"
+	"github.com/3scale-ops/marin3r/pkg/webhooks/revisionapproval"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -137,12 +140,50 @@ func runWebhook(cmd *cobra.Command, args []string) {
 		},
 	})
 
+	// Register the EnvoyConfigRevision approval webhook
+	ctrl.Log.Info("registering the envoyconfigrevision approval webhook with webhook server")
+	hookServer.Register(revisionapproval.MutatePath, &webhook.Admission{
+		Handler: &revisionapproval.RevisionApprover{
+			Decoder:  admission.NewDecoder(mgr.GetScheme()),
+			Recorder: mgr.GetEventRecorderFor("marin3r-webhook"),
+		},
+	})
+
+	// Register the EnvoyConfig change recorder webhook
+	ctrl.Log.Info("registering the envoyconfig change recorder webhook with webhook server")
+	hookServer.Register(changerecorder.MutatePath, &webhook.Admission{
+		Handler: &changerecorder.ChangeRecorder{
+			Decoder: admission.NewDecoder(mgr.GetScheme()),
+		},
+	})
+
+	// Register the EnvoyConfig policy enforcer webhook
+	ctrl.Log.Info("registering the envoyconfig policy enforcer webhook with webhook server")
+	hookServer.Register(policyenforcer.ValidatePath, &webhook.Admission{
+		Handler: &policyenforcer.PolicyEnforcer{
+			Client:  mgr.GetClient(),
+			Decoder: admission.NewDecoder(mgr.GetScheme()),
+		},
+	})
+
 	// Register the EnvoyConfig v1alpha1 webhooks
 	if err = (&marin3rv1alpha1.EnvoyConfig{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "EnvoyConfig", "version", "v1alpha1")
 		os.Exit(1)
 	}
 
+	// Register the EnvoyConfigPolicy v1alpha1 webhooks
+	if err = (&marin3rv1alpha1.EnvoyConfigPolicy{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "EnvoyConfigPolicy", "version", "v1alpha1")
+		os.Exit(1)
+	}
+
+	// Register the EnvoyConfigFragment v1alpha1 webhooks
+	if err = (&marin3rv1alpha1.EnvoyConfigFragment{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "EnvoyConfigFragment", "version", "v1alpha1")
+		os.Exit(1)
+	}
+
 	// Register the EnvoyDeployment validating webhook
 	if err = (&operatorv1alpha1.EnvoyDeployment{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "EnvoyDeployment")
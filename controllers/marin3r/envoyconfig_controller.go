@@ -18,19 +18,30 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/3scale-ops/basereconciler/reconciler"
 	reconciler_util "github.com/3scale-ops/basereconciler/util"
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
 	envoyconfig "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // EnvoyConfigReconciler reconciles a EnvoyConfig object
 type EnvoyConfigReconciler struct {
 	*reconciler.Reconciler
+	Streams        xdss.StreamCloser
+	Recorder       record.EventRecorder
+	DiscoveryStats *stats.Stats
 }
 
 // Reconcile progresses EnvoyConfig resources to its desired state
@@ -38,6 +49,14 @@ type EnvoyConfigReconciler struct {
 // +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigrevisions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigfragments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigfragments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="core",namespace=placeholder,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="core",namespace=placeholder,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="core",namespace=placeholder,resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="core",namespace=placeholder,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=marin3r.3scale.net,resources=envoyconfigpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups="core",resources=namespaces,verbs=get;list;watch
 
 func (r *EnvoyConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
@@ -66,16 +85,75 @@ func (r *EnvoyConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	logger = logger.WithValues("nodeID", ec.Spec.NodeID, "envoyAPI", ec.GetEnvoyAPIVersion())
 
+	// close the xDS streams of the node if a resync has been requested
+	var resyncAfter time.Duration
+	if r.Streams != nil {
+		var err error
+		if resyncAfter, err = envoyconfig.ReconcileResync(r.Streams, r.Recorder, ec, time.Now()); err != nil {
+			logger.Error(err, "unable to resync xDS streams")
+		}
+	}
+
+	// the inputs have to be recorded before the spec is modified by the preprocessing
+	inputs := envoyconfig.NewInputs(ec)
+
+	// render, inline, patch and merge the fragments into the resources
+	fragments, fragmentStatuses, err := envoyconfig.Preprocess(ctx, r.Client, ec)
+	if err != nil {
+		logger.Error(err, "unable to preprocess the resources of the EnvoyConfig")
+		var perr *envoyconfig.PreprocessError
+		if r.Recorder != nil && errors.As(err, &perr) {
+			r.Recorder.Eventf(ec, corev1.EventTypeWarning, perr.Reason, "%s", perr)
+		}
+		return ctrl.Result{}, err
+	}
+
+	// evaluate the policies against the resources before a revision is created for them
+	warnings, err := envoyconfig.EnforcePolicies(ctx, r.Client, ec)
+	if err != nil {
+		logger.Error(err, "unable to enforce the EnvoyConfigPolicies")
+		var perr *envoyconfig.PolicyError
+		if r.Recorder != nil && errors.As(err, &perr) {
+			r.Recorder.Eventf(ec, corev1.EventTypeWarning, "PolicyDenied", "%s", perr)
+		}
+		return ctrl.Result{}, err
+	}
+	for _, warning := range warnings {
+		logger.Info("EnvoyConfigPolicy warning", "warning", warning)
+	}
+
 	revisionReconciler := envoyconfig.NewRevisionReconciler(
-		ctx, logger, r.Client, r.Scheme, ec,
+		ctx, logger, r.Client, r.Scheme, ec, r.DiscoveryStats,
 	)
+	inputs.AddFragments(fragments, fragmentStatuses)
+	revisionReconciler.SetInputs(inputs)
 
 	reconcilerResult, err := revisionReconciler.Reconcile()
 	if reconcilerResult.Requeue || err != nil {
 		return reconcilerResult, err
 	}
 
-	if ok := envoyconfig.IsStatusReconciled(ec, revisionReconciler.GetCacheState(), revisionReconciler.PublishedVersion(), revisionReconciler.GetRevisionList()); !ok {
+	var published *marin3rv1alpha1.EnvoyConfigRevision
+	for idx, ecr := range revisionReconciler.GetRevisionList().Items {
+		if ecr.Spec.Version == revisionReconciler.PublishedVersion() {
+			published = &revisionReconciler.GetRevisionList().Items[idx]
+		}
+	}
+	for _, ecf := range fragments {
+		desired, ok := fragmentStatuses[ecf.GetName()]
+		if !ok {
+			continue
+		}
+		if ok := envoyconfig.IsFragmentStatusReconciled(&ecf, desired, revisionReconciler.DesiredVersion(), published); !ok {
+			if err := r.Client.Status().Update(ctx, &ecf); err != nil {
+				logger.Error(err, "unable to update EnvoyConfigFragment status", "fragment", ecf.GetName())
+				return ctrl.Result{}, err
+			}
+			logger.Info("status updated for EnvoyConfigFragment resource", "fragment", ecf.GetName())
+		}
+	}
+
+	if ok := envoyconfig.IsStatusReconciled(ec, revisionReconciler.GetCacheState(), revisionReconciler.DesiredVersion(), revisionReconciler.PublishedVersion(), revisionReconciler.GetRevisionList(), revisionReconciler.GetRollout(), revisionReconciler.GetTypeRevisions()); !ok {
 		if err := r.Client.Status().Update(ctx, ec); err != nil {
 			logger.Error(err, "unable to update EnvoyConfig status")
 			return ctrl.Result{}, err
@@ -84,7 +162,10 @@ func (r *EnvoyConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return reconcile.Result{}, nil
 	}
 
-	return ctrl.Result{}, nil
+	if resyncAfter == 0 || (reconcilerResult.RequeueAfter > 0 && reconcilerResult.RequeueAfter < resyncAfter) {
+		resyncAfter = reconcilerResult.RequeueAfter
+	}
+	return ctrl.Result{RequeueAfter: resyncAfter}, nil
 }
 
 // SetupWithManager adds the controller to the manager
@@ -92,5 +173,59 @@ func (r *EnvoyConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&marin3rv1alpha1.EnvoyConfig{}).
 		Owns(&marin3rv1alpha1.EnvoyConfigRevision{}).
+		Watches(&marin3rv1alpha1.EnvoyConfigFragment{}, r.FragmentsEventHandler()).
+		Watches(&corev1.ConfigMap{}, r.SourcesEventHandler()).
+		Watches(&corev1.Secret{}, r.SourcesEventHandler()).
 		Complete(r)
 }
+
+// SourcesEventHandler returns an EventHandler that generates reconcile requests for the
+// EnvoyConfigs that take template parameters or data sources from ConfigMaps or Secrets
+func (r *EnvoyConfigReconciler) SourcesEventHandler() handler.EventHandler {
+	return r.FilteredEventHandler(
+		&marin3rv1alpha1.EnvoyConfigList{},
+		func(event client.Object, o client.Object) bool {
+			ec := o.(*marin3rv1alpha1.EnvoyConfig)
+			if ec.GetNamespace() != event.GetNamespace() {
+				return false
+			}
+			for _, src := range ec.Spec.ParametersFrom {
+				switch event.(type) {
+				case *corev1.ConfigMap:
+					if src.ConfigMapRef != nil && src.ConfigMapRef.Name == event.GetName() {
+						return true
+					}
+				case *corev1.Secret:
+					if src.SecretRef != nil && src.SecretRef.Name == event.GetName() {
+						return true
+					}
+				}
+			}
+			if _, ok := event.(*corev1.ConfigMap); ok {
+				for _, res := range ec.Spec.Resources {
+					for _, ds := range res.DataSourcesFrom {
+						if ds.Name == event.GetName() {
+							return true
+						}
+					}
+				}
+			}
+			return false
+		},
+		logr.Discard(),
+	)
+}
+
+// FragmentsEventHandler returns an EventHandler that generates reconcile requests
+// for the EnvoyConfigs targeted by EnvoyConfigFragments
+func (r *EnvoyConfigReconciler) FragmentsEventHandler() handler.EventHandler {
+	return r.FilteredEventHandler(
+		&marin3rv1alpha1.EnvoyConfigList{},
+		func(event client.Object, o client.Object) bool {
+			ecf := event.(*marin3rv1alpha1.EnvoyConfigFragment)
+			ec := o.(*marin3rv1alpha1.EnvoyConfig)
+			return ecf.GetNamespace() == ec.GetNamespace() && ecf.Spec.NodeID == ec.Spec.NodeID
+		},
+		logr.Discard(),
+	)
+}
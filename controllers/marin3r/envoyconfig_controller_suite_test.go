@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
 	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
@@ -110,7 +109,7 @@ var _ = Describe("EnvoyConfig controller", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				// Validate the cache for the nodeID
-				wantRevision := reconcilerutil.Hash(ec.Spec.Resources)
+				wantRevision := ec.GetEnvoyResourcesVersion()
 				wantSnap := xdss_v3.NewSnapshot().SetResources(envoy.Endpoint, []envoy.Resource{
 					&envoy_config_endpoint_v3.ClusterLoadAssignment{ClusterName: "endpoint"},
 				})
@@ -134,6 +133,56 @@ var _ = Describe("EnvoyConfig controller", func() {
 		})
 	})
 
+	Context("with EnvoyConfigPolicies", func() {
+		var ec *marin3rv1alpha1.EnvoyConfig
+		var policy *marin3rv1alpha1.EnvoyConfigPolicy
+
+		BeforeEach(func() {
+			By("creating an EnvoyConfigPolicy that selects the namespace")
+			policy = &marin3rv1alpha1.EnvoyConfigPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "policy-" + nodeID},
+				Spec: marin3rv1alpha1.EnvoyConfigPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+					},
+					Rules: []marin3rv1alpha1.PolicyRule{{
+						Name:       "cluster-name",
+						Type:       envoy.Endpoint,
+						Expression: "object.cluster_name != ''",
+					}},
+				},
+			}
+			err := k8sClient.Create(context.Background(), policy)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("creating a v3 EnvoyConfig")
+			ec = &marin3rv1alpha1.EnvoyConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: namespace},
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{
+					EnvoyAPI: pointer.New(envoy.APIv3),
+					NodeID:   nodeID,
+					Resources: []marin3rv1alpha1.Resource{
+						{Type: envoy.Endpoint, Value: k8sutil.StringtoRawExtension("{\"cluster_name\": \"endpoint\"}")},
+					}},
+			}
+			err = k8sClient.Create(context.Background(), ec)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := k8sClient.Delete(context.Background(), policy)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should publish the resources that satisfy the policies", func() {
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), types.NamespacedName{Name: "ec", Namespace: namespace}, ec)
+				Expect(err).ToNot(HaveOccurred())
+				return ec.Status.PublishedVersion != nil && *ec.Status.PublishedVersion != ""
+			}, 60*time.Second, 5*time.Second).Should(BeTrue())
+		})
+	})
+
 	Context("self-healing", func() {
 		var ec *marin3rv1alpha1.EnvoyConfig
 
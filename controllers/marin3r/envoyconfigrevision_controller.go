@@ -46,19 +46,29 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
+// certManagerCertificateNameAnnotation is set by cert-manager in the
+// Secrets it writes, with the name of the Certificate
+const certManagerCertificateNameAnnotation = "cert-manager.io/certificate-name"
+
 // EnvoyConfigRevisionReconciler reconciles a EnvoyConfigRevision object
 type EnvoyConfigRevisionReconciler struct {
 	*reconciler.Reconciler
 	XdsCache       xdss.Cache
 	APIVersion     envoy.APIVersion
 	DiscoveryStats *stats.Stats
+	Canaries       xdss.CanaryRouter
+	Streams        xdss.StreamCloser
 }
 
 // Reconcile progresses EnvoyConfigRevision resources to its desired state
 // +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigrevisions/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="core",namespace=placeholder,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="core",namespace=placeholder,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=secretreferencegrants,verbs=get;list;watch
 // +kubebuilder:rbac:groups="discovery.k8s.io",namespace=placeholder,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=operator.marin3r.3scale.net,namespace=placeholder,resources=discoveryservicecertificates,verbs=get;list;watch
+// +kubebuilder:rbac:groups="cert-manager.io",namespace=placeholder,resources=certificates,verbs=get
 func (r *EnvoyConfigRevisionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
 	ctx, logger := r.Logger(ctx, "name", req.Name, "namespace", req.Namespace)
@@ -79,11 +89,16 @@ func (r *EnvoyConfigRevisionReconciler) Reconcile(ctx context.Context, req ctrl.
 			}
 			return nil
 		}),
+		// evaluate the revision with the taint policy of its EnvoyConfig
+		reconciler.WithInMemoryInitializationFunc(envoyconfigrevision.TaintPolicyFromOwner),
 		// set finalizer
 		reconciler.WithFinalizer(marin3rv1alpha1.EnvoyConfigRevisionFinalizer),
 		// cleanup logic
 		reconciler.WithFinalizationFunc(func(context.Context, client.Client) error {
 			envoyconfigrevision.CleanupLogic(ecr, r.XdsCache, r.DiscoveryStats, logger)
+			if r.Canaries != nil {
+				envoyconfigrevision.CleanupCanary(r.Canaries, r.Streams, r.XdsCache, ecr, time.Now(), logger)
+			}
 			logger.Info("finalized EnvoyConfigRevision resource")
 			return nil
 		}),
@@ -93,20 +108,36 @@ func (r *EnvoyConfigRevisionReconciler) Reconcile(ctx context.Context, req ctrl.
 	}
 
 	var vt *marin3rv1alpha1.VersionTracker = nil
+	var cacheReconciler envoyconfigrevision.CacheReconciler
 
 	// If this ecr has the RevisionPublishedCondition set to "True" pusblish the resources
-	// to the xds server cache
-	if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+	// to the xds server cache. Canary revisions are published under the canary key of the node ID.
+	published := meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition)
+	canary := r.Canaries != nil && envoyconfigrevision.IsCanary(ecr)
+	if published || canary {
 		var err error
+		snapshotKey := ecr.Spec.NodeID
+		if canary {
+			snapshotKey = xdss.CanarySnapshotKey(ecr.Spec.NodeID)
+		}
 		decoder := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, r.APIVersion)
 
-		cacheReconciler := envoyconfigrevision.NewCacheReconciler(
+		cacheReconciler = envoyconfigrevision.NewCacheReconciler(
 			ctx, logger, r.Client, r.XdsCache,
 			decoder,
 			envoy_resources.NewGenerator(r.APIVersion),
 		)
 
-		vt, err = cacheReconciler.Reconcile(ctx, req.NamespacedName, ecr.Spec.Resources, ecr.Spec.NodeID, ecr.Spec.Version)
+		// the resource types rolled back to other revisions are taken from those revisions
+		resources := ecr.Spec.Resources
+		if published {
+			if resources, err = envoyconfigrevision.ComposeResources(ctx, r.Client, ecr); err != nil {
+				logger.Error(err, "unable to compose resources from other revisions")
+				return ctrl.Result{}, err
+			}
+		}
+
+		vt, err = cacheReconciler.Reconcile(ctx, req.NamespacedName, resources, snapshotKey, ecr.Spec.Version)
 
 		// If a type errors.StatusError is returned it means that the config in spec.resources is wrong
 		// and cannot be written into the xDS cache. This is true for any error loading all types of resources
@@ -125,18 +156,47 @@ func (r *EnvoyConfigRevisionReconciler) Reconcile(ctx context.Context, req ctrl.
 		}
 	}
 
-	if ok := envoyconfigrevision.IsStatusReconciled(ecr, vt, r.XdsCache, r.DiscoveryStats); !ok {
+	if r.Canaries != nil {
+		if canary {
+			envoyconfigrevision.ReconcileCanary(r.Canaries, r.Streams, ecr, time.Now(), logger)
+		} else {
+			envoyconfigrevision.CleanupCanary(r.Canaries, r.Streams, r.XdsCache, ecr, time.Now(), logger)
+		}
+	}
+
+	// clear the taint if requested and reset the stats of the revision
+	// the first time this replica sees that it has been untainted
+	untainted, retryAfter := envoyconfigrevision.IsUntaintReconciled(ecr, time.Now(), logger)
+	if envoyconfigrevision.ReconcileStatsReset(ecr, r.DiscoveryStats) {
+		logger.Info("reset stats of untainted revision", "Version", ecr.Spec.Version)
+	}
+
+	ok := envoyconfigrevision.IsStatusReconciled(ecr, vt, r.XdsCache, r.DiscoveryStats)
+
+	// summarize the changes with respect to the previously published revision
+	if published && vt != nil {
+		diffOk, err := cacheReconciler.IsDiffReconciled(ecr)
+		if err != nil {
+			logger.Error(err, "unable to calculate the diff with the previously published revision")
+		}
+		ok = ok && diffOk
+	}
+
+	if !ok || !untainted {
 		if err := r.Client.Status().Update(ctx, ecr); err != nil {
 			logger.Error(err, "unable to update EnvoyConfigRevision status")
 		}
 		logger.Info("status updated for EnvoyConfigRevision resource")
 	}
 
-	if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
-		return ctrl.Result{Requeue: true, RequeueAfter: 30 * time.Second}, nil
+	if published || canary {
+		if retryAfter == 0 || retryAfter > 30*time.Second {
+			retryAfter = 30 * time.Second
+		}
+		return ctrl.Result{Requeue: true, RequeueAfter: retryAfter}, nil
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: retryAfter}, nil
 
 }
 
@@ -203,14 +263,17 @@ func (r *EnvoyConfigRevisionReconciler) SecretsEventHandler() handler.EventHandl
 				return false
 			}
 			ecr := o.(*marin3rv1alpha1.EnvoyConfigRevision)
-			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) || envoyconfigrevision.IsCanary(ecr) {
 				// check if the k8s Secret is relevant for this EnvoyConfigRevision
 				for _, s := range ecr.Spec.Resources {
-					if s.Type == envoy.Secret {
+					if s.Type == envoy.Secret && s.GetSecretNamespace(ecr.GetNamespace()) == secret.GetNamespace() {
 						if (s.GenerateFromTlsSecret != nil && *s.GenerateFromTlsSecret == secret.GetName()) ||
 							(s.GenerateFromOpaqueSecret != nil && s.GenerateFromOpaqueSecret.Name == secret.GetName()) {
 							return true
 						}
+						if s.GenerateFromCertificate != nil && isCertificateSecret(secret, *s.GenerateFromCertificate) {
+							return true
+						}
 					}
 
 				}
@@ -221,6 +284,65 @@ func (r *EnvoyConfigRevisionReconciler) SecretsEventHandler() handler.EventHandl
 	)
 }
 
+// isCertificateSecret returns true if the Secret is written by the referenced certificate. cert-manager
+// annotates the Secrets it writes with the name of the Certificate, and DiscoveryServiceCertificates
+// are the controllers of their Secrets.
+func isCertificateSecret(secret *corev1.Secret, ref marin3rv1alpha1.CertificateReference) bool {
+	switch ref.Kind {
+	case marin3rv1alpha1.CertManagerCertificate:
+		return secret.GetAnnotations()[certManagerCertificateNameAnnotation] == ref.Name
+	case marin3rv1alpha1.DiscoveryServiceCertificateKind:
+		owner := metav1.GetControllerOf(secret)
+		return owner != nil && owner.Kind == string(ref.Kind) && owner.Name == ref.Name
+	}
+	return false
+}
+
+// SecretReferenceGrantsEventHandler returns an EventHandler that generates reconcile
+// requests for SecretReferenceGrants, so revisions are regenerated when grants change
+func (r *EnvoyConfigRevisionReconciler) SecretReferenceGrantsEventHandler() handler.EventHandler {
+	return r.FilteredEventHandler(
+		&marin3rv1alpha1.EnvoyConfigRevisionList{},
+		func(event client.Object, o client.Object) bool {
+			ecr := o.(*marin3rv1alpha1.EnvoyConfigRevision)
+			if ecr.GetNamespace() == event.GetNamespace() {
+				return false
+			}
+			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) || envoyconfigrevision.IsCanary(ecr) {
+				// check if the revision refers to Secrets in the namespace of the grant
+				for _, s := range ecr.Spec.Resources {
+					if s.Type == envoy.Secret && s.GetSecretNamespace(ecr.GetNamespace()) == event.GetNamespace() {
+						return true
+					}
+				}
+			}
+			return false
+		},
+		logr.Discard(),
+	)
+}
+
+// ConfigMapsEventHandler returns an EventHandler that generates
+// reconcile requests for ConfigMaps
+func (r *EnvoyConfigRevisionReconciler) ConfigMapsEventHandler() handler.EventHandler {
+	return r.FilteredEventHandler(
+		&marin3rv1alpha1.EnvoyConfigRevisionList{},
+		func(event client.Object, o client.Object) bool {
+			ecr := o.(*marin3rv1alpha1.EnvoyConfigRevision)
+			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) || envoyconfigrevision.IsCanary(ecr) {
+				// check if the k8s ConfigMap is relevant for this EnvoyConfigRevision
+				for _, r := range ecr.Spec.Resources {
+					if r.Type == envoy.Runtime && r.GenerateFromConfigMap != nil && *r.GenerateFromConfigMap == event.GetName() {
+						return true
+					}
+				}
+			}
+			return false
+		},
+		logr.Discard(),
+	)
+}
+
 // EndpointSlicesEventHandler returns an EventHandler that generates
 // reconcile requests for EndpointSlices
 func (r *EnvoyConfigRevisionReconciler) EndpointSlicesEventHandler() handler.EventHandler {
@@ -229,7 +351,7 @@ func (r *EnvoyConfigRevisionReconciler) EndpointSlicesEventHandler() handler.Eve
 		func(event client.Object, o client.Object) bool {
 			endpointSlice := event.(*discoveryv1.EndpointSlice)
 			ecr := o.(*marin3rv1alpha1.EnvoyConfigRevision)
-			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+			if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) || envoyconfigrevision.IsCanary(ecr) {
 				// check if the k8s EndpointSlice is relevant for this EnvoyConfigRevision
 				for _, r := range ecr.Spec.Resources {
 					if r.Type == envoy.Endpoint && r.GenerateFromEndpointSlices != nil {
@@ -259,6 +381,8 @@ func (r *EnvoyConfigRevisionReconciler) SetupWithManager(mgr ctrl.Manager) error
 		For(&marin3rv1alpha1.EnvoyConfigRevision{}).
 		WithEventFilter(filterByAPIVersionPredicate(r.APIVersion, filterByAPIVersion)).
 		Watches(&corev1.Secret{}, r.SecretsEventHandler()).
+		Watches(&corev1.ConfigMap{}, r.ConfigMapsEventHandler()).
+		Watches(&marin3rv1alpha1.SecretReferenceGrant{}, r.SecretReferenceGrantsEventHandler()).
 		Watches(&discoveryv1.EndpointSlice{}, r.EndpointSlicesEventHandler()).
 		Complete(r)
 }
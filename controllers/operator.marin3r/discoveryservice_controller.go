@@ -26,13 +26,16 @@ import (
 	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
 	"github.com/3scale-ops/marin3r/pkg/reconcilers/operator/discoveryservice/generators"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 // DiscoveryServiceReconciler reconciles a DiscoveryService object
@@ -51,12 +54,29 @@ type DiscoveryServiceReconciler struct {
 // +kubebuilder:rbac:groups="core",namespace=placeholder,resources=pods,verbs=list;watch;get
 // +kubebuilder:rbac:groups="core",namespace=placeholder,resources=secrets,verbs=get;list;watch;create;update;patch
 // +kubebuilder:rbac:groups="discovery.k8s.io",namespace=placeholder,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups="cert-manager.io",namespace=placeholder,resources=certificates,verbs=get
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
 
 func (r *DiscoveryServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 
 	ctx, _ = r.Logger(ctx, "name", req.Name, "namespace", req.Namespace)
 	ds := &operatorv1alpha1.DiscoveryService{}
-	result := r.ManageResourceLifecycle(ctx, req, ds)
+	result := r.ManageResourceLifecycle(ctx, req, ds,
+		// set finalizer
+		reconciler.WithFinalizer(operatorv1alpha1.Finalizer),
+		// the cluster scoped resources cannot be owned by the
+		// DiscoveryService, so they need to be deleted explicitly
+		reconciler.WithFinalizationFunc(func(ctx context.Context, cl client.Client) error {
+			gen := generators.GeneratorOptions{InstanceName: ds.GetName(), Namespace: ds.GetNamespace()}
+			for _, obj := range []client.Object{gen.ClusterRoleBinding(), gen.ClusterRole()} {
+				if err := cl.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+			return nil
+		}),
+	)
 	if result.ShouldReturn() {
 		return result.Values()
 	}
@@ -74,11 +94,14 @@ func (r *DiscoveryServiceReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		XdsServerPort:                     int32(ds.GetXdsServerPort()),
 		MetricsServerPort:                 int32(ds.GetMetricsPort()),
 		ProbePort:                         int32(ds.GetProbePort()),
+		DebugAPIPort:                      int32(ds.GetDebugAPIPort()),
+		DebugAPIClients:                   ds.Spec.DebugAPIClients,
 		ServiceType:                       operatorv1alpha1.ClusterIPType,
 		DeploymentImage:                   ds.GetImage(),
 		DeploymentResources:               ds.Resources(),
 		Debug:                             ds.Debug(),
 		PodPriorityClass:                  ds.GetPriorityClass(),
+		SecretNamespaces:                  ds.Spec.SecretNamespaces,
 	}
 
 	serverCertHash, err := r.calculateServerCertificateHash(ctx, types.NamespacedName{Name: gen.ServerCertName(), Namespace: gen.Namespace})
@@ -101,6 +124,10 @@ func (r *DiscoveryServiceReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if result.ShouldReturn() {
 		return result.Values()
 	}
+
+	if err := r.reconcileClusterResources(ctx, gen); err != nil {
+		return ctrl.Result{}, err
+	}
 	// requeue if the server certificate is not ready
 	if serverCertHash == "" {
 		return ctrl.Result{Requeue: true}, nil
@@ -122,6 +149,43 @@ func (r *DiscoveryServiceReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	return ctrl.Result{}, nil
 }
 
+// reconcileClusterResources reconciles the ClusterRole and ClusterRoleBinding of the
+// discovery service. They are not owned by the DiscoveryService, as cluster scoped
+// resources cannot have namespaced owners, and are deleted when it is finalized.
+func (r *DiscoveryServiceReconciler) reconcileClusterResources(ctx context.Context, gen generators.GeneratorOptions) error {
+	logger := logr.FromContextOrDiscard(ctx)
+
+	desiredRole := gen.ClusterRole()
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: desiredRole.GetName()}}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.SetLabels(desiredRole.GetLabels())
+		role.Rules = desiredRole.Rules
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("ClusterRole reconciled", "name", role.GetName(), "operation", op)
+	}
+
+	desiredBinding := gen.ClusterRoleBinding()
+	binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: desiredBinding.GetName()}}
+	op, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.SetLabels(desiredBinding.GetLabels())
+		binding.RoleRef = desiredBinding.RoleRef
+		binding.Subjects = desiredBinding.Subjects
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		logger.Info("ClusterRoleBinding reconciled", "name", binding.GetName(), "operation", op)
+	}
+	return nil
+}
+
 func (r *DiscoveryServiceReconciler) calculateServerCertificateHash(ctx context.Context, key types.NamespacedName) (string, error) {
 	// Fetch the server certificate to calculate the hash and
 	// populate the deployment's label.
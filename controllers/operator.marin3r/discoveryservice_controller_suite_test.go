@@ -148,6 +148,30 @@ var _ = Describe("DiscoveryService controller", func() {
 				}, 60*time.Second, 5*time.Second).ShouldNot(HaveOccurred())
 			}
 
+			By("waiting for the discovery service ClusterRole to be created")
+			{
+				cr := &rbacv1.ClusterRole{}
+				Eventually(func() error {
+					return k8sClient.Get(
+						context.Background(),
+						types.NamespacedName{Name: "marin3r-" + namespace + "-instance"},
+						cr,
+					)
+				}, 60*time.Second, 5*time.Second).ShouldNot(HaveOccurred())
+			}
+
+			By("waiting for the discovery service ClusterRoleBinding to be created")
+			{
+				crb := &rbacv1.ClusterRoleBinding{}
+				Eventually(func() error {
+					return k8sClient.Get(
+						context.Background(),
+						types.NamespacedName{Name: "marin3r-" + namespace + "-instance"},
+						crb,
+					)
+				}, 60*time.Second, 5*time.Second).ShouldNot(HaveOccurred())
+			}
+
 			By("waiting for the discovery service Deployment to be created")
 			{
 				dep := &appsv1.Deployment{}
@@ -0,0 +1,172 @@
+package debugapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/revisions"
+	reconcilers "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfigrevision"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RenderedDiff is the diff between the resources published
+// by two EnvoyConfigRevisions
+type RenderedDiff struct {
+	NodeID string `json:"nodeID"`
+	// From is the version the diff is calculated against. It is
+	// empty if there is no previously published revision.
+	From      string                               `json:"from,omitempty"`
+	To        string                               `json:"to"`
+	Resources map[envoy.Type]RenderedResourcesDiff `json:"resources"`
+}
+
+// RenderedResourcesDiff holds the resources of a given type
+// that have been added, removed or changed
+type RenderedResourcesDiff struct {
+	Added   []RenderedChange `json:"added,omitempty"`
+	Removed []RenderedChange `json:"removed,omitempty"`
+	Changed []RenderedChange `json:"changed,omitempty"`
+}
+
+// RenderedChange is a resource that differs between two revisions, with its
+// value before and after the change. Before is empty for added resources and
+// after is empty for removed resources. The values of the revisions rendered
+// with template parameters from Secrets are omitted, and Redacted is set.
+type RenderedChange struct {
+	Name     string          `json:"name"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+	Redacted bool            `json:"redacted,omitempty"`
+}
+
+// Diff calculates the diff between the resources published by the 'from' and 'to'
+// EnvoyConfigRevisions. A nil 'from' revision is considered empty. Sensitive data
+// within secret resources is redacted in the returned diff, and the resources of
+// revisions rendered with template parameters from Secrets are not shown, as the
+// values of the Secrets at the time the revisions were rendered are unknown.
+func Diff(ctx context.Context, logger logr.Logger, cl client.Client, xdsCache xdss.Cache,
+	from, to *marin3rv1alpha1.EnvoyConfigRevision) (*RenderedDiff, error) {
+
+	cacheReconciler := reconcilers.NewCacheReconciler(ctx, logger, cl, xdsCache,
+		envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, to.GetEnvoyAPIVersion()),
+		envoy_resources.NewGenerator(to.GetEnvoyAPIVersion()),
+	)
+
+	toSnap, err := cacheReconciler.GenerateRevisionSnapshot(to)
+	if err != nil {
+		return nil, err
+	}
+	var fromSnap xdss.Snapshot
+	fromVersion := ""
+	if from != nil {
+		if fromSnap, err = cacheReconciler.GenerateRevisionSnapshot(from); err != nil {
+			return nil, err
+		}
+		fromVersion = from.Spec.Version
+	}
+
+	rendered := &RenderedDiff{
+		NodeID:    to.Spec.NodeID,
+		From:      fromVersion,
+		To:        to.Spec.Version,
+		Resources: map[envoy.Type]RenderedResourcesDiff{},
+	}
+
+	m := envoy_serializer.NewResourceMarshaller(envoy_serializer.JSON, to.GetEnvoyAPIVersion())
+	render := func(ecr *marin3rv1alpha1.EnvoyConfigRevision, snap xdss.Snapshot, rType envoy.Type, name string) (json.RawMessage, error) {
+		if _, ok := ecr.GetAnnotations()[marin3rv1alpha1.SecretParametersAnnotation]; ok {
+			return nil, nil
+		}
+		j, err := m.Marshal(redact(snap.GetResources(rType)[name]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialize %s '%s': %w", rType, name, err)
+		}
+		return json.RawMessage(j), nil
+	}
+
+	for rType, rd := range reconcilers.DiffSnapshots(fromSnap, toSnap) {
+		rr := RenderedResourcesDiff{}
+		for _, name := range rd.Added {
+			after, err := render(to, toSnap, rType, name)
+			if err != nil {
+				return nil, err
+			}
+			rr.Added = append(rr.Added, RenderedChange{Name: name, After: after, Redacted: after == nil})
+		}
+		for _, name := range rd.Removed {
+			before, err := render(from, fromSnap, rType, name)
+			if err != nil {
+				return nil, err
+			}
+			rr.Removed = append(rr.Removed, RenderedChange{Name: name, Before: before, Redacted: before == nil})
+		}
+		for _, name := range rd.Changed {
+			before, err := render(from, fromSnap, rType, name)
+			if err != nil {
+				return nil, err
+			}
+			after, err := render(to, toSnap, rType, name)
+			if err != nil {
+				return nil, err
+			}
+			rr.Changed = append(rr.Changed, RenderedChange{Name: name, Before: before, After: after, Redacted: before == nil || after == nil})
+		}
+		rendered.Resources[rType] = rr
+	}
+
+	return rendered, nil
+}
+
+// diffHandler returns the diff of an EnvoyConfigRevision with the revision that was
+// published before it, or with the revision passed in the 'from' query parameter
+func (s *Server) diffHandler(w http.ResponseWriter, r *http.Request) {
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+	key := types.NamespacedName{Name: r.PathValue("name"), Namespace: r.PathValue("namespace")}
+	if err := s.client.Get(r.Context(), key, ecr); err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var from *marin3rv1alpha1.EnvoyConfigRevision
+	var err error
+	if version := r.URL.Query().Get("from"); version != "" {
+		from, err = revisions.Get(r.Context(), s.client, ecr.GetNamespace(), filters.ByNodeID(ecr.Spec.NodeID),
+			filters.ByVersion(version), filters.ByEnvoyAPI(ecr.GetEnvoyAPIVersion()))
+		if err != nil {
+			if revisions.ErrorIsNoMatchesForFilter(err) {
+				s.writeError(w, http.StatusNotFound, fmt.Errorf("no EnvoyConfigRevision found for version '%s'", version))
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	} else {
+		if from, err = reconcilers.PreviousRevision(r.Context(), s.client, ecr); err != nil {
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	logger := s.logger.WithValues("name", ecr.GetName(), "namespace", ecr.GetNamespace())
+	diff, err := Diff(r.Context(), logger, s.client, s.xdsCache, from, ecr)
+	if err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, diff)
+}
@@ -0,0 +1,134 @@
+package debugapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testEnvoyConfigRevision(version string, publishedAt time.Time, resources ...marin3rv1alpha1.Resource) *marin3rv1alpha1.EnvoyConfigRevision {
+	return &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node-" + version,
+			Namespace: "default",
+			Labels: map[string]string{
+				filters.NodeIDTag:   "node",
+				filters.VersionTag:  version,
+				filters.EnvoyAPITag: envoy.APIv3.String(),
+			},
+		},
+		Spec:   marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: version, EnvoyAPI: pointer.New(envoy.APIv3), Resources: resources},
+		Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{LastPublishedAt: &metav1.Time{Time: publishedAt}},
+	}
+}
+
+func testDiffRevisions() (*marin3rv1alpha1.EnvoyConfigRevision, *marin3rv1alpha1.EnvoyConfigRevision) {
+	t0 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	from := testEnvoyConfigRevision("aaaa", t0,
+		marin3rv1alpha1.Resource{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "a"}`)},
+		marin3rv1alpha1.Resource{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "b"}`)},
+	)
+	to := testEnvoyConfigRevision("bbbb", t0.Add(time.Hour),
+		marin3rv1alpha1.Resource{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "a", "alt_stat_name": "changed"}`)},
+		marin3rv1alpha1.Resource{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("secret")},
+	)
+	return from, to
+}
+
+func TestDiff(t *testing.T) {
+	from, to := testDiffRevisions()
+	redactedSecret := json.RawMessage(`{"name":"secret","tls_certificate":{"certificate_chain":{"inline_bytes":"Y2VydA=="},"private_key":{"inline_string":"[REDACTED]"}}}`)
+
+	tests := []struct {
+		name    string
+		from    *marin3rv1alpha1.EnvoyConfigRevision
+		to      *marin3rv1alpha1.EnvoyConfigRevision
+		want    *RenderedDiff
+		wantErr bool
+	}{
+		{
+			name: "Diff with the previous revision, with the secrets redacted",
+			from: from,
+			to:   to,
+			want: &RenderedDiff{
+				NodeID: "node",
+				From:   "aaaa",
+				To:     "bbbb",
+				Resources: map[envoy.Type]RenderedResourcesDiff{
+					envoy.Cluster: {
+						Removed: []RenderedChange{{Name: "b", Before: json.RawMessage(`{"name":"b"}`)}},
+						Changed: []RenderedChange{{Name: "a", Before: json.RawMessage(`{"name":"a"}`), After: json.RawMessage(`{"name":"a","alt_stat_name":"changed"}`)}},
+					},
+					envoy.Secret: {
+						Added: []RenderedChange{{Name: "secret", After: redactedSecret}},
+					},
+				},
+			},
+		},
+		{
+			name: "Everything is added without a previous revision",
+			from: nil,
+			to:   to,
+			want: &RenderedDiff{
+				NodeID: "node",
+				To:     "bbbb",
+				Resources: map[envoy.Type]RenderedResourcesDiff{
+					envoy.Cluster: {
+						Added: []RenderedChange{{Name: "a", After: json.RawMessage(`{"name":"a","alt_stat_name":"changed"}`)}},
+					},
+					envoy.Secret: {
+						Added: []RenderedChange{{Name: "secret", After: redactedSecret}},
+					},
+				},
+			},
+		},
+		{
+			name: "Does not show the resources of revisions rendered with parameters from Secrets",
+			from: from,
+			to: func() *marin3rv1alpha1.EnvoyConfigRevision {
+				to := to.DeepCopy()
+				to.SetAnnotations(map[string]string{marin3rv1alpha1.SecretParametersAnnotation: "params"})
+				return to
+			}(),
+			want: &RenderedDiff{
+				NodeID: "node",
+				From:   "aaaa",
+				To:     "bbbb",
+				Resources: map[envoy.Type]RenderedResourcesDiff{
+					envoy.Cluster: {
+						Removed: []RenderedChange{{Name: "b", Before: json.RawMessage(`{"name":"b"}`)}},
+						Changed: []RenderedChange{{Name: "a", Before: json.RawMessage(`{"name":"a"}`), Redacted: true}},
+					},
+					envoy.Secret: {
+						Added: []RenderedChange{{Name: "secret", Redacted: true}},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(s).WithObjects(testTlsSecret()).Build()
+			got, err := Diff(context.TODO(), ctrl.Log.WithName("test"), cl, xdss_v3.NewCache(), tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); len(diff) > 0 {
+				t.Errorf("Diff() diff = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package debugapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/3scale-ops/marin3r/pkg/envoy/templates"
+	envoyconfig "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig"
+	reconcilers "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfigrevision"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// RedactedValue is the value that replaces sensitive data in the rendered snapshots
+const RedactedValue string = "[REDACTED]"
+
+var renderedTypes = []envoy.Type{envoy.Endpoint, envoy.Cluster, envoy.Route, envoy.ScopedRoute,
+	envoy.Listener, envoy.Secret, envoy.Runtime, envoy.ExtensionConfig}
+
+// RenderedSnapshot is the snapshot that the discovery service would
+// generate for an EnvoyConfig
+type RenderedSnapshot struct {
+	NodeID    string                           `json:"nodeID"`
+	EnvoyAPI  envoy.APIVersion                 `json:"envoyAPI"`
+	Resources map[envoy.Type]RenderedResources `json:"resources"`
+}
+
+// RenderedResources holds the resources of a given type of a
+// RenderedSnapshot, along with the version hash of the type
+type RenderedResources struct {
+	Version   string            `json:"version"`
+	Resources []json.RawMessage `json:"resources"`
+}
+
+// Render generates the snapshot for the given EnvoyConfig in the same way the
+// EnvoyConfig and EnvoyConfigRevision controllers do, but without writing it to
+// the xDS cache. Sensitive data within secret resources, and the values of the
+// template parameters taken from Secrets, are redacted in the returned snapshot.
+func Render(ctx context.Context, logger logr.Logger, cl client.Client, xdsCache xdss.Cache,
+	ec *marin3rv1alpha1.EnvoyConfig) (*RenderedSnapshot, error) {
+
+	ec = ec.DeepCopy()
+	secretValues, err := templates.SecretValues(ctx, cl, ec)
+	if err != nil {
+		return nil, err
+	}
+	if ec.Spec.EnvoyResources != nil {
+		resources, err := (ec.Spec.EnvoyResources).Resources(ec.GetSerialization())
+		if err != nil {
+			return nil, err
+		}
+		ec.Spec.Resources = resources
+		ec.Spec.EnvoyResources = nil
+	}
+
+	if _, _, err := envoyconfig.Preprocess(ctx, cl, ec); err != nil {
+		return nil, err
+	}
+
+	cacheReconciler := reconcilers.NewCacheReconciler(ctx, logger, cl, xdsCache,
+		envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, ec.GetEnvoyAPIVersion()),
+		envoy_resources.NewGenerator(ec.GetEnvoyAPIVersion()),
+	)
+
+	snap, err := cacheReconciler.GenerateSnapshot(types.NamespacedName{Name: ec.GetName(), Namespace: ec.GetNamespace()}, ec.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderSnapshot(snap, ec.Spec.NodeID, ec.GetEnvoyAPIVersion(), secretValues)
+}
+
+func renderSnapshot(snap xdss.Snapshot, nodeID string, version envoy.APIVersion, secretValues []string) (*RenderedSnapshot, error) {
+	m := envoy_serializer.NewResourceMarshaller(envoy_serializer.JSON, version)
+
+	rendered := &RenderedSnapshot{
+		NodeID:    nodeID,
+		EnvoyAPI:  version,
+		Resources: map[envoy.Type]RenderedResources{},
+	}
+
+	for _, rType := range renderedTypes {
+		resources := snap.GetResources(rType)
+		names := make([]string, 0, len(resources))
+		for name := range resources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		rr := RenderedResources{
+			Version:   snap.GetVersion(rType),
+			Resources: make([]json.RawMessage, 0, len(names)),
+		}
+		for _, name := range names {
+			j, err := m.Marshal(redact(resources[name]))
+			if err != nil {
+				return nil, fmt.Errorf("unable to serialize %s '%s': %w", rType, name, err)
+			}
+			r, err := redactValues([]byte(j), secretValues)
+			if err != nil {
+				return nil, fmt.Errorf("unable to redact %s '%s': %w", rType, name, err)
+			}
+			rr.Resources = append(rr.Resources, json.RawMessage(r))
+		}
+		rendered.Resources[rType] = rr
+	}
+
+	return rendered, nil
+}
+
+// redact returns a copy of the resource with private keys, passwords
+// and other sensitive secret data replaced by RedactedValue
+func redact(res envoy.Resource) envoy.Resource {
+	secret, ok := res.(*envoy_extensions_transport_sockets_tls_v3.Secret)
+	if !ok {
+		return res
+	}
+
+	redacted := proto.Clone(secret).(*envoy_extensions_transport_sockets_tls_v3.Secret)
+	switch t := redacted.GetType().(type) {
+	case *envoy_extensions_transport_sockets_tls_v3.Secret_TlsCertificate:
+		if t.TlsCertificate.GetPrivateKey() != nil {
+			t.TlsCertificate.PrivateKey = redactedDataSource()
+		}
+		if t.TlsCertificate.GetPassword() != nil {
+			t.TlsCertificate.Password = redactedDataSource()
+		}
+	case *envoy_extensions_transport_sockets_tls_v3.Secret_SessionTicketKeys:
+		for i := range t.SessionTicketKeys.GetKeys() {
+			t.SessionTicketKeys.Keys[i] = redactedDataSource()
+		}
+	case *envoy_extensions_transport_sockets_tls_v3.Secret_GenericSecret:
+		if t.GenericSecret.GetSecret() != nil {
+			t.GenericSecret.Secret = redactedDataSource()
+		}
+	}
+
+	return redacted
+}
+
+// redactValues replaces the given values within the strings of the serialized resource
+// with RedactedValue. Numbers and booleans that match a value are replaced as a whole.
+func redactValues(j []byte, values []string) ([]byte, error) {
+	if len(values) == 0 {
+		return j, nil
+	}
+	// replace the longest values first, so values that contain others are fully redacted
+	values = append([]string{}, values...)
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	var walk func(interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, e := range t {
+				t[k] = walk(e)
+			}
+		case []interface{}:
+			for i, e := range t {
+				t[i] = walk(e)
+			}
+		case string:
+			for _, value := range values {
+				t = strings.ReplaceAll(t, value, RedactedValue)
+			}
+			return t
+		case json.Number, bool:
+			for _, value := range values {
+				if fmt.Sprint(t) == value {
+					return RedactedValue
+				}
+			}
+		}
+		return v
+	}
+
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(walk(v))
+}
+
+func redactedDataSource() *envoy_config_core_v3.DataSource {
+	return &envoy_config_core_v3.DataSource{
+		Specifier: &envoy_config_core_v3.DataSource_InlineString{InlineString: RedactedValue},
+	}
+}
+
+// renderHandler renders the EnvoyConfig, in json or yaml, sent in the request body
+func (s *Server) renderHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ec := &marin3rv1alpha1.EnvoyConfig{}
+	if err := yaml.UnmarshalStrict(body, ec); err != nil {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("unable to decode EnvoyConfig: %w", err))
+		return
+	}
+	if ec.GetNamespace() == "" {
+		s.writeError(w, http.StatusBadRequest, fmt.Errorf("metadata.namespace is required"))
+		return
+	}
+	if err := ec.Validate(); err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	s.render(w, r, ec)
+}
+
+// renderByNameHandler renders an EnvoyConfig that already exists in the cluster
+func (s *Server) renderByNameHandler(w http.ResponseWriter, r *http.Request) {
+	ec, ok := s.getEnvoyConfig(w, r)
+	if !ok {
+		return
+	}
+
+	s.render(w, r, ec)
+}
+
+func (s *Server) render(w http.ResponseWriter, r *http.Request, ec *marin3rv1alpha1.EnvoyConfig) {
+	logger := s.logger.WithValues("name", ec.GetName(), "namespace", ec.GetNamespace())
+	rendered, err := Render(r.Context(), logger, s.client, s.xdsCache, ec)
+	if err != nil {
+		s.writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, rendered)
+}
@@ -0,0 +1,202 @@
+package debugapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var s *runtime.Scheme = scheme.Scheme
+
+func init() {
+	s.AddKnownTypes(marin3rv1alpha1.GroupVersion,
+		&marin3rv1alpha1.EnvoyConfig{},
+		&marin3rv1alpha1.EnvoyConfigFragment{},
+		&marin3rv1alpha1.EnvoyConfigFragmentList{},
+		&marin3rv1alpha1.EnvoyConfigRevision{},
+		&marin3rv1alpha1.EnvoyConfigRevisionList{},
+	)
+}
+
+func testTlsSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+	}
+}
+
+func testEnvoyConfig() *marin3rv1alpha1.EnvoyConfig {
+	return &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID: "node",
+			Resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "cluster"}`)},
+				{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("secret")},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  client.Client
+		ec      *marin3rv1alpha1.EnvoyConfig
+		want    *RenderedSnapshot
+		wantErr bool
+	}{
+		{
+			name:   "Renders the snapshot with the secrets redacted",
+			client: fake.NewClientBuilder().WithObjects(testTlsSecret()).Build(),
+			ec:     testEnvoyConfig(),
+			want: &RenderedSnapshot{
+				NodeID:   "node",
+				EnvoyAPI: envoy.APIv3,
+				Resources: map[envoy.Type]RenderedResources{
+					envoy.Endpoint: {Version: xdss_v3.NewSnapshot().GetVersion(envoy.Endpoint), Resources: []json.RawMessage{}},
+					envoy.Cluster: {
+						Version: xdss_v3.NewSnapshot().SetResources(envoy.Cluster, []envoy.Resource{
+							&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+						}).GetVersion(envoy.Cluster),
+						Resources: []json.RawMessage{json.RawMessage(`{"name":"cluster"}`)},
+					},
+					envoy.Route:       {Version: xdss_v3.NewSnapshot().GetVersion(envoy.Route), Resources: []json.RawMessage{}},
+					envoy.ScopedRoute: {Version: xdss_v3.NewSnapshot().GetVersion(envoy.ScopedRoute), Resources: []json.RawMessage{}},
+					envoy.Listener:    {Version: xdss_v3.NewSnapshot().GetVersion(envoy.Listener), Resources: []json.RawMessage{}},
+					envoy.Secret: {
+						// the version is calculated with the real, non redacted, secret
+						Version: xdss_v3.NewSnapshot().SetResources(envoy.Secret, []envoy.Resource{
+							&envoy_extensions_transport_sockets_tls_v3.Secret{
+								Name: "secret",
+								Type: &envoy_extensions_transport_sockets_tls_v3.Secret_TlsCertificate{
+									TlsCertificate: &envoy_extensions_transport_sockets_tls_v3.TlsCertificate{
+										PrivateKey: &envoy_config_core_v3.DataSource{
+											Specifier: &envoy_config_core_v3.DataSource_InlineBytes{InlineBytes: []byte("key")},
+										},
+										CertificateChain: &envoy_config_core_v3.DataSource{
+											Specifier: &envoy_config_core_v3.DataSource_InlineBytes{InlineBytes: []byte("cert")},
+										}}}}}).GetVersion(envoy.Secret),
+						Resources: []json.RawMessage{
+							json.RawMessage(`{"name":"secret","tls_certificate":{"certificate_chain":{"inline_bytes":"Y2VydA=="},"private_key":{"inline_string":"[REDACTED]"}}}`),
+						},
+					},
+					envoy.Runtime:         {Version: xdss_v3.NewSnapshot().GetVersion(envoy.Runtime), Resources: []json.RawMessage{}},
+					envoy.ExtensionConfig: {Version: xdss_v3.NewSnapshot().GetVersion(envoy.ExtensionConfig), Resources: []json.RawMessage{}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Fails if a referred secret does not exist",
+			client:  fake.NewClientBuilder().Build(),
+			ec:      testEnvoyConfig(),
+			wantErr: true,
+		},
+		{
+			name:   "Fails if a resource cannot be loaded",
+			client: fake.NewClientBuilder().Build(),
+			ec: &marin3rv1alpha1.EnvoyConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{
+					NodeID: "node",
+					Resources: []marin3rv1alpha1.Resource{
+						{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"giberish": "cluster"}`)},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(context.TODO(), ctrl.Log.WithName("test"), tt.client, xdss_v3.NewCache(), tt.ec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); len(diff) > 0 {
+				t.Errorf("Render() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRender_Preprocessing(t *testing.T) {
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID: "node",
+			Resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "cluster"}`)},
+			},
+			Patches: []marin3rv1alpha1.ResourcePatch{
+				{Type: envoy.Cluster, Name: "cluster", Patch: `{"alt_stat_name": "patched"}`},
+			},
+		},
+	}
+
+	got, err := Render(context.TODO(), ctrl.Log.WithName("test"), fake.NewClientBuilder().Build(), xdss_v3.NewCache(), ec)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	cluster := map[string]interface{}{}
+	if err := json.Unmarshal(got.Resources[envoy.Cluster].Resources[0], &cluster); err != nil {
+		t.Fatalf("unable to decode rendered cluster: %v", err)
+	}
+	if diff := cmp.Diff(cluster, map[string]interface{}{"name": "cluster", "alt_stat_name": "patched"}); len(diff) > 0 {
+		t.Errorf("Render() diff = %v", diff)
+	}
+	if len(ec.Spec.Resources) != 1 || ec.Spec.Patches == nil {
+		t.Errorf("Render() modified the passed EnvoyConfig")
+	}
+}
+
+func TestRender_SecretParameters(t *testing.T) {
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID: "node",
+			ParametersFrom: []marin3rv1alpha1.ParametersSource{
+				{SecretRef: &corev1.LocalObjectReference{Name: "params"}},
+			},
+			Resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name": "cluster", "alt_stat_name": "token-{{ .token }}"}`)},
+			},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "params", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}).Build()
+
+	got, err := Render(context.TODO(), ctrl.Log.WithName("test"), cl, xdss_v3.NewCache(), ec)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	cluster := map[string]interface{}{}
+	if err := json.Unmarshal(got.Resources[envoy.Cluster].Resources[0], &cluster); err != nil {
+		t.Fatalf("unable to decode rendered cluster: %v", err)
+	}
+	if diff := cmp.Diff(cluster, map[string]interface{}{"name": "cluster", "alt_stat_name": "token-" + RedactedValue}); len(diff) > 0 {
+		t.Errorf("Render() diff = %v", diff)
+	}
+}
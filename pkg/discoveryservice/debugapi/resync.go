@@ -0,0 +1,32 @@
+package debugapi
+
+import (
+	"net/http"
+	"time"
+
+	envoyconfig "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig"
+)
+
+// ResyncResult is the result of a resync request
+type ResyncResult struct {
+	NodeID string `json:"nodeID"`
+	// Pods holds the pod names of the xDS streams that have been closed
+	Pods []string `json:"pods"`
+}
+
+// resyncHandler closes the xDS streams of the node ID of an EnvoyConfig so the
+// Envoy clients reconnect and get a full push of the resources. The resync can be
+// limited to specific pods using one or more 'pod' query parameters.
+func (s *Server) resyncHandler(w http.ResponseWriter, r *http.Request) {
+	ec, ok := s.getEnvoyConfig(w, r)
+	if !ok {
+		return
+	}
+
+	pods := r.URL.Query()["pod"]
+	closed := envoyconfig.Resync(s.streams, s.recorder, ec, time.Now(), pods...)
+	s.logger.Info("resync requested through the debug API", "name", ec.GetName(), "namespace", ec.GetNamespace(),
+		"nodeID", ec.Spec.NodeID, "pods", closed)
+
+	s.writeJSON(w, http.StatusOK, ResyncResult{NodeID: ec.Spec.NodeID, Pods: closed})
+}
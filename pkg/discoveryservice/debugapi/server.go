@@ -0,0 +1,160 @@
+package debugapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// maxRequestBodySize is the maximum size of the bodies accepted by the API
+	maxRequestBodySize int64 = 10 << 20
+	shutdownTimeout          = 5 * time.Second
+)
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Server is an HTTPS server that exposes debugging endpoints of the
+// discovery service. Clients are authenticated using TLS client
+// certificates, so the tls.Config passed to the server is expected
+// to require and verify client certificates. As the same CA issues
+// the certificates of the Envoy clients, only the certificates with
+// one of the allowed common names are authorized to use the API.
+type Server struct {
+	port           uint
+	tlsConfig      *tls.Config
+	allowedClients []string
+	client         client.Client
+	xdsCache       xdss.Cache
+	streams        xdss.StreamCloser
+	recorder       record.EventRecorder
+	logger         logr.Logger
+}
+
+// NewServer returns a new debug API Server
+func NewServer(port uint, tlsConfig *tls.Config, allowedClients []string, client client.Client, xdsCache xdss.Cache,
+	streams xdss.StreamCloser, recorder record.EventRecorder, logger logr.Logger) *Server {
+	return &Server{
+		port:           port,
+		tlsConfig:      tlsConfig,
+		allowedClients: allowedClients,
+		client:         client,
+		xdsCache:       xdsCache,
+		streams:        streams,
+		recorder:       recorder,
+		logger:         logger,
+	}
+}
+
+// Start runs the debug API server until the context is cancelled. It
+// implements sigs.k8s.io/controller-runtime/pkg/manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Handler:   s.authorize(s.Handler()),
+		TLSConfig: s.tlsConfig,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error(err, "error shutting down the debug API server")
+		}
+	}()
+
+	s.logger.Info(fmt.Sprintf("Debug API listening on port %v", s.port))
+	if err := srv.ServeTLS(lis, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection implements sigs.k8s.io/controller-runtime/pkg/manager.LeaderElectionRunnable.
+// The API runs in every replica of the discovery service.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the http.Handler that serves the debug API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/render", s.renderHandler)
+	mux.HandleFunc("GET /api/v1/namespaces/{namespace}/envoyconfigs/{name}/render", s.renderByNameHandler)
+	mux.HandleFunc("POST /api/v1/namespaces/{namespace}/envoyconfigs/{name}/resync", s.resyncHandler)
+	mux.HandleFunc("GET /api/v1/namespaces/{namespace}/envoyconfigrevisions/{name}/diff", s.diffHandler)
+	return mux
+}
+
+// authorize only lets through the requests authenticated with a client
+// certificate whose common name is one of the allowed clients
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			s.writeError(w, http.StatusUnauthorized, fmt.Errorf("a client certificate is required"))
+			return
+		}
+		cn := r.TLS.PeerCertificates[0].Subject.CommonName
+		if !slices.Contains(s.allowedClients, cn) {
+			s.writeError(w, http.StatusForbidden, fmt.Errorf("client '%s' is not allowed to use the debug API", cn))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// getEnvoyConfig gets the EnvoyConfig referred in the request path. If
+// the EnvoyConfig cannot be retrieved, the error is written to the response.
+func (s *Server) getEnvoyConfig(w http.ResponseWriter, r *http.Request) (*marin3rv1alpha1.EnvoyConfig, bool) {
+	ec := &marin3rv1alpha1.EnvoyConfig{}
+	key := types.NamespacedName{Name: r.PathValue("name"), Namespace: r.PathValue("namespace")}
+	if err := s.client.Get(r.Context(), key, ec); err != nil {
+		if apierrors.IsNotFound(err) {
+			s.writeError(w, http.StatusNotFound, err)
+			return nil, false
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return ec, true
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error(err, "error writing debug API response")
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, err error) {
+	s.writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,183 @@
+package debugapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestServer_Handler(t *testing.T) {
+	from, to := testDiffRevisions()
+	server := NewServer(0, nil, nil,
+		fake.NewClientBuilder().WithScheme(s).WithObjects(testTlsSecret(), testEnvoyConfig(), from, to).Build(),
+		xdss_v3.NewCache(), xdss_v3.NewStreamTracker(), record.NewFakeRecorder(10), ctrl.Log.WithName("test"))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "Renders an EnvoyConfig by name",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigs/config/render",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Returns not found for unknown EnvoyConfigs",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigs/unknown/render",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Renders the EnvoyConfig in the request body",
+			method: http.MethodPost,
+			path:   "/api/v1/render",
+			body: `
+apiVersion: marin3r.3scale.net/v1alpha1
+kind: EnvoyConfig
+metadata:
+  name: test
+  namespace: default
+spec:
+  nodeID: test
+  resources:
+    - type: cluster
+      value: {"name": "cluster"}
+`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Fails if the EnvoyConfig in the request body has no namespace",
+			method: http.MethodPost,
+			path:   "/api/v1/render",
+			body: `
+apiVersion: marin3r.3scale.net/v1alpha1
+kind: EnvoyConfig
+metadata:
+  name: test
+spec:
+  nodeID: test
+`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Fails if the EnvoyConfig in the request body is not valid",
+			method: http.MethodPost,
+			path:   "/api/v1/render",
+			body: `
+apiVersion: marin3r.3scale.net/v1alpha1
+kind: EnvoyConfig
+metadata:
+  name: test
+  namespace: default
+spec:
+  nodeID: test
+  resources:
+    - type: cluster
+      value: {"giberish": "cluster"}
+`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Resyncs the streams of an EnvoyConfig",
+			method:     http.MethodPost,
+			path:       "/api/v1/namespaces/default/envoyconfigs/config/resync?pod=pod-a",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Returns not found when resyncing unknown EnvoyConfigs",
+			method:     http.MethodPost,
+			path:       "/api/v1/namespaces/default/envoyconfigs/unknown/resync",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Returns the diff with the previously published revision",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigrevisions/node-bbbb/diff",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Returns the diff with the given version",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigrevisions/node-aaaa/diff?from=bbbb",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Returns not found for unknown versions",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigrevisions/node-bbbb/diff?from=cccc",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Returns not found for unknown EnvoyConfigRevisions",
+			method:     http.MethodGet,
+			path:       "/api/v1/namespaces/default/envoyconfigrevisions/unknown/diff",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Server.Handler() status = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_authorize(t *testing.T) {
+	server := NewServer(0, nil, []string{"marin3r-debug-client"}, nil, nil, nil, nil, ctrl.Log.WithName("test"))
+	handler := server.authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		tls        *tls.ConnectionState
+		wantStatus int
+	}{
+		{
+			name: "Authorizes an allowed client",
+			tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "marin3r-debug-client"}},
+			}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Forbids other clients of the discovery service CA",
+			tls: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "envoy-sidecar-client-cert"}},
+			}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "Rejects requests without a client certificate",
+			tls:        nil,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/envoyconfigs/config/render", nil)
+			req.TLS = tt.tls
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Server.authorize() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
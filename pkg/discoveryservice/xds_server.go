@@ -47,6 +47,8 @@ const (
 	grpcMaxConnectionAgeGrace                         = 300   // 5 min
 	grpcKeepaliveEnforcementPolicyMinTime             = 50
 	grpcKeepaliveEnforcementPolicyPermitWithoutStream = false
+	// interval at which heartbeats are sent for the resources that have a TTL
+	xdsHeartbeatInterval = 5 * time.Second
 )
 
 var (
@@ -63,6 +65,8 @@ type XdsServer struct {
 	snapshotCacheV3  cache_v3.SnapshotCache
 	callbacksV3      *xdss_v3.Callbacks
 	discoveryStatsV3 *stats.Stats
+	streamTrackerV3  *xdss_v3.StreamTracker
+	canaryHashV3     *xdss_v3.CanaryHash
 }
 
 // NewXdsServer creates a new XdsServer object fron the given params
@@ -76,10 +80,17 @@ func NewXdsServer(ctx context.Context, xDSPort uint, tlsConfig *tls.Config, logg
 	// prometheus registry
 	metrics.Registry.MustRegister(discoveryStatsV3)
 
-	snapshotCacheV3 := cache_v3.NewSnapshotCache(
+	// the canary clients of a node ID are served from a different snapshot
+	canaryHashV3 := xdss_v3.NewCanaryHash()
+
+	// resources with a TTL get periodic heartbeats so they only
+	// expire in the Envoy clients if the discovery service is gone
+	snapshotCacheV3 := cache_v3.NewSnapshotCacheWithHeartbeating(
+		ctx,
 		true,
-		cache_v3.IDHash{},
+		canaryHashV3,
 		clogger{Logger: xdsLogger.WithName("cache").WithName("v3")},
+		xdsHeartbeatInterval,
 	)
 
 	callbacksV3 := &xdss_v3.Callbacks{
@@ -87,7 +98,16 @@ func NewXdsServer(ctx context.Context, xDSPort uint, tlsConfig *tls.Config, logg
 		Logger: xdsLogger.WithName("server").WithName("v3"),
 	}
 
-	srvV3 := server_v3.NewServer(ctx, snapshotCacheV3, callbacksV3)
+	// node placeholders in the resources are resolved for each stream before
+	// sending the responses. Requests for xdstp:// glob collections are also
+	// resolved before passing them to the snapshot cache.
+	srvV3 := server_v3.NewServer(ctx,
+		xdss_v3.NewNodeAwareCache(
+			xdss_v3.NewXdstpCache(snapshotCacheV3),
+			xdsLogger.WithName("render").WithName("v3"),
+		),
+		callbacksV3,
+	)
 
 	return &XdsServer{
 		ctx:              ctx,
@@ -97,6 +117,8 @@ func NewXdsServer(ctx context.Context, xDSPort uint, tlsConfig *tls.Config, logg
 		snapshotCacheV3:  snapshotCacheV3,
 		callbacksV3:      callbacksV3,
 		discoveryStatsV3: discoveryStatsV3,
+		streamTrackerV3:  xdss_v3.NewStreamTracker(),
+		canaryHashV3:     canaryHashV3,
 	}
 }
 
@@ -118,6 +140,8 @@ func (xdss *XdsServer) Start(client kubernetes.Interface, namespace string) erro
 			MaxConnectionAge:      grpcMaxConnectionAge * time.Second,
 			MaxConnectionAgeGrace: grpcMaxConnectionAgeGrace * time.Second,
 		}),
+		// track the xDS streams so they can be closed on demand
+		grpc.StreamInterceptor(xdss.streamTrackerV3.StreamServerInterceptor()),
 	)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", xdss.xDSPort))
@@ -190,6 +214,17 @@ func (xdss *XdsServer) GetDiscoveryStats(version envoy.APIVersion) *stats.Stats
 	return xdss.discoveryStatsV3
 }
 
+// GetStreamCloser returns the StreamCloser for the xDS streams
+func (xdss *XdsServer) GetStreamCloser(version envoy.APIVersion) xdss.StreamCloser {
+	return xdss.streamTrackerV3
+}
+
+// GetCanaryRouter returns the CanaryRouter that selects the
+// clients that receive the canary snapshot of a node ID
+func (xdss *XdsServer) GetCanaryRouter(version envoy.APIVersion) xdss.CanaryRouter {
+	return xdss.canaryHashV3
+}
+
 type clogger struct {
 	Logger logr.Logger
 }
@@ -76,6 +76,8 @@ func TestXdsServer_Start(t *testing.T) {
 			snapshotCacheV3,
 			&xdss_v3.Callbacks{Logger: ctrl.Log},
 			stats.New(),
+			xdss_v3.NewStreamTracker(),
+			xdss_v3.NewCanaryHash(),
 		}
 
 		go func() {
@@ -105,6 +107,8 @@ func TestXdsServer_GetCache(t *testing.T) {
 				snapshotCacheV3,
 				&xdss_v3.Callbacks{Logger: ctrl.Log},
 				stats.New(),
+				xdss_v3.NewStreamTracker(),
+				xdss_v3.NewCanaryHash(),
 			},
 			xdss_v3.NewCache(),
 			envoy.APIv3,
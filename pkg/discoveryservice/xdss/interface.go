@@ -2,6 +2,8 @@ package discoveryservice
 
 import (
 	"context"
+	"hash/fnv"
+	"time"
 
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 )
@@ -27,7 +29,67 @@ type Cache interface {
 type Snapshot interface {
 	Consistent() error
 	SetResources(envoy.Type, []envoy.Resource) Snapshot
+	SetTTL(envoy.Type, envoy.Resource, time.Duration) Snapshot
 	GetResources(envoy.Type) map[string]envoy.Resource
 	GetVersion(envoy.Type) string
 	SetVersion(envoy.Type, string)
 }
+
+// StreamCloser closes the xDS streams of the Envoy clients so they
+// reconnect to the discovery service and receive a full push of the
+// resources.
+type StreamCloser interface {
+	// CloseStreams closes the streams of the given node ID that were opened
+	// before the given time. If pod names are passed, only the streams of those
+	// pods are closed. It returns the pod names of the closed streams.
+	CloseStreams(nodeID string, openedBefore time.Time, pods ...string) []string
+}
+
+// Canary selects the Envoy clients of a node ID that receive the
+// snapshot stored under CanarySnapshotKey(nodeID) instead of the node ID's snapshot
+type Canary struct {
+	// Version is the version of the canary revision
+	Version string
+	// Percentage of the clients, selected by the hash of the pod name
+	Percentage *int
+	// Pods is an explicit list of the pod names of the selected clients
+	Pods []string
+}
+
+// Selects returns true if the given pod is selected by the canary. When a percentage is set, pods are
+// assigned to one of 100 buckets using a hash of their name, so the pods selected for a percentage are
+// also selected for any bigger percentage.
+func (c Canary) Selects(pod string) bool {
+	if c.Percentage != nil {
+		h := fnv.New32a()
+		h.Write([]byte(pod))
+		if int(h.Sum32()%100) < *c.Percentage {
+			return true
+		}
+	}
+	for _, p := range c.Pods {
+		if p == pod {
+			return true
+		}
+	}
+	return false
+}
+
+// CanaryRouter routes the Envoy clients of a node ID to the
+// canary snapshot of the node ID, if any
+type CanaryRouter interface {
+	// SetCanary sets the canary for the given node ID. It returns true if
+	// the set of clients routed to the canary snapshot might have changed.
+	SetCanary(nodeID string, canary Canary) bool
+	// ClearCanary removes the canary of the given node ID if it has the given
+	// version. It returns true if a canary has been removed.
+	ClearCanary(nodeID, version string) bool
+	// IsCanary returns true if the given pod is routed to the canary snapshot
+	IsCanary(nodeID, pod string) bool
+}
+
+// CanarySnapshotKey returns the key under which the
+// canary snapshot of a node ID is stored in the Cache
+func CanarySnapshotKey(nodeID string) string {
+	return "canary/" + nodeID
+}
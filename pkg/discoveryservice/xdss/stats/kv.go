@@ -13,6 +13,19 @@ const (
 	cleanupInterval   = 300 * time.Second
 )
 
+// the node, resource type, version and pod parts of the keys are escaped so
+// values holding ':', like xdstp:// resource names, can be used in the keys
+var (
+	keyEscaper   = strings.NewReplacer("%", "%25", ":", "%3A")
+	keyUnescaper = strings.NewReplacer("%3A", ":", "%25", "%")
+)
+
+// EscapeKeyValue returns the given value as it is written in the keys of the cache. It
+// can be used to build filters for values that might hold ':' characters.
+func EscapeKeyValue(value string) string {
+	return keyEscaper.Replace(value)
+}
+
 type Key struct {
 	NodeID       string
 	ResourceType string
@@ -34,16 +47,22 @@ func NewKey(nodeID, rType, version, podID, statName string) *Key {
 func NewKeyFromString(key string) *Key {
 	values := strings.Split(key, ":")
 	return &Key{
-		NodeID:       values[0],
-		ResourceType: values[1],
-		Version:      values[2],
-		PodID:        values[3],
+		NodeID:       keyUnescaper.Replace(values[0]),
+		ResourceType: keyUnescaper.Replace(values[1]),
+		Version:      keyUnescaper.Replace(values[2]),
+		PodID:        keyUnescaper.Replace(values[3]),
 		StatName:     strings.Join(values[4:], ":"),
 	}
 }
 
 func (k *Key) String() string {
-	return strings.Join([]string{k.NodeID, k.ResourceType, k.Version, k.PodID, k.StatName}, ":")
+	return strings.Join([]string{
+		EscapeKeyValue(k.NodeID),
+		EscapeKeyValue(k.ResourceType),
+		EscapeKeyValue(k.Version),
+		EscapeKeyValue(k.PodID),
+		k.StatName,
+	}, ":")
 }
 
 func (s *Stats) GetString(nodeID, rtype, version, podID, statName string) (string, error) {
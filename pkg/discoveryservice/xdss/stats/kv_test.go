@@ -70,6 +70,19 @@ func TestNewKeyFromString(t *testing.T) {
 				StatName:     "something:something_else",
 			},
 		},
+		{
+			name: "Returns a key struct with escaped values",
+			args: args{
+				key: "node:xdstp%3A//authority/envoy.config.cluster.v3.Cluster/foo%253A:aaaa:pod-xxxx:nack_counter",
+			},
+			want: &Key{
+				NodeID:       "node",
+				ResourceType: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo%3A",
+				Version:      "aaaa",
+				PodID:        "pod-xxxx",
+				StatName:     "nack_counter",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -104,6 +117,17 @@ func TestKey_String(t *testing.T) {
 			},
 			want: "node1:endpoint:aaaa:pod-xxxx:stat1",
 		},
+		{
+			name: "Escapes the values holding ':' characters",
+			fields: fields{
+				NodeID:       "node1",
+				ResourceType: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo",
+				Version:      "aaaa",
+				PodID:        "pod-xxxx",
+				Key:          "stat1",
+			},
+			want: "node1:xdstp%3A//authority/envoy.config.cluster.v3.Cluster/foo:aaaa:pod-xxxx:stat1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -2,7 +2,6 @@ package stats
 
 import (
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/3scale-ops/marin3r/pkg/util/clock"
@@ -35,8 +34,12 @@ func (s *Stats) WriteResponseNonce(nodeID, rType, version, podID, nonce string)
 	s.SetStringWithExpiration(nodeID, rType, version, podID, "nonce:"+nonce, "", 10*time.Second)
 }
 
-func (s *Stats) ReportNACK(nodeID, rType, podID, nonce string) (int64, error) {
-	keys := s.FilterKeys(nodeID, rType, podID, "nonce:"+nonce)
+// ReportNACK increments the NACK counters for the version of the resource type that was sent
+// in the response with the given nonce. If the xdstp:// names of the rejected resources are passed,
+// NACK counters keyed on the full xdstp names are also incremented, so failures can be tracked per
+// resource collection.
+func (s *Stats) ReportNACK(nodeID, rType, podID, nonce string, xdstpNames ...string) (int64, error) {
+	keys := s.FilterKeys(EscapeKeyValue(nodeID), EscapeKeyValue(rType), EscapeKeyValue(podID), "nonce:"+nonce)
 	if len(keys) != 1 {
 		return 0, fmt.Errorf("error reporting failure: unexpected number of nonces in the cache")
 	}
@@ -53,15 +56,23 @@ func (s *Stats) ReportNACK(nodeID, rType, podID, nonce string) (int64, error) {
 	s.IncrementCounter(nodeID, rType, version, podID, "nack_counter", 1)
 	// aggregated counter, with lower cardinality, to expose as prometheus metric
 	s.IncrementCounter(nodeID, rType, "*", podID, "nack_counter", 1)
+	for _, name := range xdstpNames {
+		s.IncrementCounter(nodeID, name, version, podID, "nack_counter", 1)
+	}
 	return s.GetCounter(nodeID, rType, version, podID, "nack_counter")
 }
 
-func (s *Stats) ReportACK(nodeID, rType, version, podID string) {
+// ReportACK increments the ACK counters for the given version of the resource type. If xdstp://
+// resource names are passed, ACK counters keyed on the full xdstp names are also incremented.
+func (s *Stats) ReportACK(nodeID, rType, version, podID string, xdstpNames ...string) {
 	s.IncrementCounter(nodeID, rType, version, podID, "ack_counter", 1)
 	// aggregated counter, with lower cardinality, to expose as prometheus metric
 	s.IncrementCounter(nodeID, rType, "*", podID, "ack_counter", 1)
 	// add stat with timestamp to expose info metric
 	s.SetInt64(nodeID, rType, version, podID, "info", s.clock.Now().UnixMilli())
+	for _, name := range xdstpNames {
+		s.IncrementCounter(nodeID, name, version, podID, "ack_counter", 1)
+	}
 }
 
 func (s *Stats) ReportRequest(nodeID, rType, podID string) {
@@ -86,10 +97,10 @@ func (s *Stats) GetSubscribedPods(nodeID, rType string) map[string]int8 {
 
 	filters := []string{"request_counter"}
 	if nodeID != "" {
-		filters = append(filters, nodeID)
+		filters = append(filters, EscapeKeyValue(nodeID))
 	}
 	if rType != "" {
-		filters = append(filters, rType)
+		filters = append(filters, EscapeKeyValue(rType))
 	}
 	items := s.FilterKeys(filters...)
 
@@ -103,19 +114,30 @@ func (s *Stats) GetSubscribedPods(nodeID, rType string) map[string]int8 {
 	return m
 }
 
-func (s *Stats) GetPercentageFailing(nodeID, rType, version string) float64 {
+// GetFailingPods returns the number of pods subscribed to the given resource type that have
+// rejected the given version at least 'nackThreshold' times, and the total number of pods
+// subscribed to the resource type
+func (s *Stats) GetFailingPods(nodeID, rType, version string, nackThreshold int64) (int, int) {
 
 	failing := 0
 	pods := s.GetSubscribedPods(nodeID, rType)
 	for pod := range pods {
-		if v, err := s.GetCounter(nodeID, rType, version, pod, "nack_counter"); err == nil && v >= 5 {
+		if v, err := s.GetCounter(nodeID, rType, version, pod, "nack_counter"); err == nil && v >= nackThreshold {
 			failing++
 		}
 	}
 
-	val := float64(failing) / float64(len(pods))
-	if math.IsNaN(val) {
-		return 0
+	return failing, len(pods)
+}
+
+// ResetCounters deletes the ACK and NACK counters of the given version of the resource type
+// for all the pods, so the version is evaluated again from scratch
+func (s *Stats) ResetCounters(nodeID, rType, version string) {
+	for k := range s.FilterKeys(EscapeKeyValue(nodeID), EscapeKeyValue(rType), EscapeKeyValue(version)) {
+		key := NewKeyFromString(k)
+		if key.NodeID == nodeID && key.ResourceType == rType && key.Version == version &&
+			(key.StatName == "ack_counter" || key.StatName == "nack_counter") {
+			s.store.Delete(k)
+		}
 	}
-	return val
 }
@@ -2,6 +2,7 @@ package stats
 
 import (
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -48,10 +49,11 @@ func TestStats_WriteResponseNonce(t *testing.T) {
 
 func TestStats_ReportNACK(t *testing.T) {
 	type args struct {
-		nodeID string
-		rType  string
-		podID  string
-		nonce  string
+		nodeID     string
+		rType      string
+		podID      string
+		nonce      string
+		xdstpNames []string
 	}
 	tests := []struct {
 		name       string
@@ -96,11 +98,31 @@ func TestStats_ReportNACK(t *testing.T) {
 				"node:endpoint:*:pod-xxxx:nack_counter":    {Object: int64(1), Expiration: int64(defaultExpiration)},
 			},
 		},
+		{
+			name: "Increments NACK counters keyed on xdstp names",
+			cacheItems: map[string]kv.Item{
+				"node:endpoint:aaaa:pod-xxxx:nonce:xyz": {Object: "", Expiration: int64(defaultExpiration)},
+			},
+			args: args{
+				nodeID:     "node",
+				rType:      "endpoint",
+				podID:      "pod-xxxx",
+				nonce:      "xyz",
+				xdstpNames: []string{"xdstp://authority/envoy.config.endpoint.v3.ClusterLoadAssignment/foo"},
+			},
+			want: map[string]kv.Item{
+				"node:endpoint:aaaa:pod-xxxx:nonce:xyz":    {Object: "", Expiration: int64(defaultExpiration)},
+				"node:endpoint:aaaa:pod-xxxx:nack_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
+				"node:endpoint:*:pod-xxxx:nack_counter":    {Object: int64(1), Expiration: int64(defaultExpiration)},
+				"node:xdstp%3A//authority/envoy.config.endpoint.v3.ClusterLoadAssignment/foo:aaaa:pod-xxxx:nack_counter": {
+					Object: int64(1), Expiration: int64(defaultExpiration)},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := Stats{store: kv.NewFrom(defaultExpiration, cleanupInterval, tt.cacheItems)}
-			_, err := s.ReportNACK(tt.args.nodeID, tt.args.rType, tt.args.podID, tt.args.nonce)
+			_, err := s.ReportNACK(tt.args.nodeID, tt.args.rType, tt.args.podID, tt.args.nonce, tt.args.xdstpNames...)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Stats.ReportNACK() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -324,96 +346,50 @@ func TestStats_GetSubscribedPods(t *testing.T) {
 	}
 }
 
-func TestStats_GetPercentageFailing(t *testing.T) {
-	type args struct {
-		nodeID  string
-		rType   string
-		version string
+func TestStats_GetFailingPods(t *testing.T) {
+	s := Stats{store: kv.NewFrom(defaultExpiration, cleanupInterval, map[string]kv.Item{
+		"node:endpoint:*:pod-aaaa:request_counter": {Object: int64(2), Expiration: int64(defaultExpiration)},
+		"node:endpoint:*:pod-bbbb:request_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
+		"node:endpoint:*:pod-cccc:request_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
+		"node:endpoint:xxxx:pod-aaaa:nack_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
+		"node:endpoint:xxxx:pod-bbbb:nack_counter": {Object: int64(3), Expiration: int64(defaultExpiration)},
+	})}
+
+	failing, total := s.GetFailingPods("node", "endpoint", "xxxx", 1)
+	if failing != 2 || total != 3 {
+		t.Errorf("Stats.GetFailingPods() = (%v, %v), want (2, 3)", failing, total)
 	}
-	tests := []struct {
-		name       string
-		cacheItems map[string]kv.Item
-		args       args
-		want       float64
-	}{
-		{
-			name: "Returns 50%",
-			cacheItems: map[string]kv.Item{
-				"node:endpoint:*:pod-aaaa:request_counter": {Object: int64(2), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-bbbb:request_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-cccc:request_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-dddd:request_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-aaaa:nack_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-bbbb:nack_counter": {Object: int64(10), Expiration: int64(defaultExpiration)},
-			},
-			args: args{
-				nodeID:  "node",
-				rType:   "endpoint",
-				version: "xxxx",
-			},
-			want: 0.5,
-		},
-		{
-			name: "Returns 100%",
-			cacheItems: map[string]kv.Item{
-				"node:endpoint:*:pod-aaaa:request_counter": {Object: int64(2), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-bbbb:request_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-cccc:request_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-dddd:request_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-aaaa:nack_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-bbbb:nack_counter": {Object: int64(10), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-cccc:nack_counter": {Object: int64(10), Expiration: int64(defaultExpiration)},
-				"node:endpoint:xxxx:pod-dddd:nack_counter": {Object: int64(10), Expiration: int64(defaultExpiration)},
-			},
-			args: args{
-				nodeID:  "node",
-				rType:   "endpoint",
-				version: "xxxx",
-			},
-			want: 1,
-		},
-		{
-			name: "Returns 0%",
-			cacheItems: map[string]kv.Item{
-				"node:endpoint:*:pod-aaaa:request_counter": {Object: int64(2), Expiration: int64(defaultExpiration)},
-				"node:endpoint:*:pod-bbbb:request_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
-			},
-			args: args{
-				nodeID:  "node",
-				rType:   "endpoint",
-				version: "xxxx",
-			},
-			want: 0,
-		},
-		{
-			name:       "Returns 0%",
-			cacheItems: map[string]kv.Item{},
-			args: args{
-				nodeID:  "node",
-				rType:   "endpoint",
-				version: "xxxx",
-			},
-			want: 0,
-		},
-		{
-			name: "Returns 0% if NaN",
-			cacheItems: map[string]kv.Item{
-				"node:endpoint:xxxx:pod-aaaa:nack_counter": {Object: int64(1), Expiration: int64(defaultExpiration)},
-			},
-			args: args{
-				nodeID:  "node",
-				rType:   "endpoint",
-				version: "xxxx",
-			},
-			want: 0,
-		},
+	failing, total = s.GetFailingPods("node", "endpoint", "xxxx", 3)
+	if failing != 1 || total != 3 {
+		t.Errorf("Stats.GetFailingPods() = (%v, %v), want (1, 3)", failing, total)
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := Stats{store: kv.NewFrom(defaultExpiration, cleanupInterval, tt.cacheItems)}
-			if got := s.GetPercentageFailing(tt.args.nodeID, tt.args.rType, tt.args.version); got != tt.want {
-				t.Errorf("Stats.GetPercentageFailing() = %v, want %v", got, tt.want)
-			}
-		})
+}
+
+func TestStats_ResetCounters(t *testing.T) {
+	s := Stats{store: kv.NewFrom(defaultExpiration, cleanupInterval, map[string]kv.Item{
+		"node:endpoint:*:pod-aaaa:request_counter": {Object: int64(2), Expiration: int64(defaultExpiration)},
+		"node:endpoint:*:pod-aaaa:nack_counter":    {Object: int64(5), Expiration: int64(defaultExpiration)},
+		"node:endpoint:xxxx:pod-aaaa:nack_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
+		"node:endpoint:xxxx:pod-aaaa:ack_counter":  {Object: int64(1), Expiration: int64(defaultExpiration)},
+		"node:endpoint:xxxx:pod-aaaa:info":         {Object: int64(1), Expiration: int64(defaultExpiration)},
+		"node:endpoint:yyyy:pod-aaaa:nack_counter": {Object: int64(5), Expiration: int64(defaultExpiration)},
+		"node:cluster:xxxx:pod-aaaa:nack_counter":  {Object: int64(5), Expiration: int64(defaultExpiration)},
+	})}
+
+	s.ResetCounters("node", "endpoint", "xxxx")
+	got := []string{}
+	for k := range s.DumpAll() {
+		got = append(got, k)
+	}
+	sort.Strings(got)
+	want := []string{
+		"node:cluster:xxxx:pod-aaaa:nack_counter",
+		"node:endpoint:*:pod-aaaa:nack_counter",
+		"node:endpoint:*:pod-aaaa:request_counter",
+		"node:endpoint:xxxx:pod-aaaa:info",
+		"node:endpoint:yyyy:pod-aaaa:nack_counter",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats.ResetCounters() keys = %v, want %v", got, want)
 	}
 }
@@ -16,10 +16,12 @@ package discoveryservice
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
 	envoy_resources_v3 "github.com/3scale-ops/marin3r/pkg/envoy/resources/v3"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
 	"github.com/3scale-ops/marin3r/pkg/util/backoff"
@@ -67,7 +69,8 @@ func (cb *Callbacks) OnStreamRequest(id int64, req *envoy_service_discovery_v3.D
 	if req.GetResponseNonce() != "" {
 		if req.GetErrorDetail() != nil {
 			log.Info("Discovery NACK")
-			failures, err := cb.Stats.ReportNACK(req.GetNode().GetId(), req.GetTypeUrl(), podName, req.GetResponseNonce())
+			failures, err := cb.Stats.ReportNACK(req.GetNode().GetId(), req.GetTypeUrl(), podName, req.GetResponseNonce(),
+				rejectedNames(xdstpNames(req.GetResourceNames()), req.GetErrorDetail().GetMessage())...)
 			if err != nil {
 				log.Error(err, "error trying to report a response NACK")
 			}
@@ -81,7 +84,8 @@ func (cb *Callbacks) OnStreamRequest(id int64, req *envoy_service_discovery_v3.D
 
 		} else {
 			log.Info("Discovery ACK")
-			cb.Stats.ReportACK(req.GetNode().GetId(), req.GetTypeUrl(), req.GetVersionInfo(), podName)
+			cb.Stats.ReportACK(req.GetNode().GetId(), req.GetTypeUrl(), req.GetVersionInfo(), podName,
+				xdstpNames(req.GetResourceNames())...)
 		}
 
 	} else {
@@ -92,6 +96,30 @@ func (cb *Callbacks) OnStreamRequest(id int64, req *envoy_service_discovery_v3.D
 	return nil
 }
 
+// xdstpNames returns the names in xdstp:// form
+func xdstpNames(names []string) []string {
+	xdstp := []string{}
+	for _, name := range names {
+		if envoy_resources.IsXdstpName(name) {
+			xdstp = append(xdstp, name)
+		}
+	}
+	return xdstp
+}
+
+// rejectedNames returns the names that are mentioned in the error detail of a NACK.
+// Envoy lists the resources that failed to be applied in the error message, so the
+// NACK is only attributed to those and not to all the resources of the request.
+func rejectedNames(names []string, message string) []string {
+	rejected := []string{}
+	for _, name := range names {
+		if strings.Contains(message, name) {
+			rejected = append(rejected, name)
+		}
+	}
+	return rejected
+}
+
 // OnStreamResponse implements go-control-plane/pkgserver/Callbacks.OnStreamResponse
 // OnStreamResponse is called immediately prior to sending a response on a stream.
 func (cb *Callbacks) OnStreamResponse(ctx context.Context, id int64, req *envoy_service_discovery_v3.DiscoveryRequest,
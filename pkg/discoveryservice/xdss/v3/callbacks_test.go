@@ -16,6 +16,7 @@ package discoveryservice
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
@@ -127,6 +128,36 @@ func TestCallbacks_OnStreamRequest(t *testing.T) {
 	}
 }
 
+func Test_rejectedNames(t *testing.T) {
+	names := []string{
+		"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+		"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+	}
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{
+			name:    "Returns the names in the error message",
+			message: "Error adding/updating cluster(s) xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b: invalid",
+			want:    []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b"},
+		},
+		{
+			name:    "Returns no names if the error message does not mention any",
+			message: "unknown error",
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rejectedNames(names, tt.message); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rejectedNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCallbacks_OnStreamResponse(t *testing.T) {
 	type args struct {
 		id       int64
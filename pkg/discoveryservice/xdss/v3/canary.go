@@ -0,0 +1,74 @@
+package discoveryservice
+
+import (
+	"reflect"
+	"sync"
+
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+)
+
+var _ cache_v3.NodeHash = &CanaryHash{}
+var _ xdss.CanaryRouter = &CanaryHash{}
+
+// CanaryHash is a go-control-plane NodeHash that maps the Envoy clients selected as
+// canaries of a node ID to the canary snapshot key of the node ID. Any other client is
+// mapped to its node ID, same as go-control-plane's IDHash. The hash is calculated on each
+// request, so the streams of clients that change from one snapshot to the other need to
+// be closed for the change to take effect in the watches that are already open.
+type CanaryHash struct {
+	mu       sync.RWMutex
+	canaries map[string]xdss.Canary
+}
+
+// NewCanaryHash returns a new CanaryHash
+func NewCanaryHash() *CanaryHash {
+	return &CanaryHash{canaries: map[string]xdss.Canary{}}
+}
+
+// ID implements go-control-plane/pkg/cache/v3.NodeHash
+func (h *CanaryHash) ID(node *envoy_config_core_v3.Node) string {
+	if node == nil {
+		return ""
+	}
+	pod, err := stats.GetStringValueFromMetadata(node.GetMetadata().AsMap(), "pod_name")
+	if err == nil && h.IsCanary(node.GetId(), pod) {
+		return xdss.CanarySnapshotKey(node.GetId())
+	}
+	return node.GetId()
+}
+
+// SetCanary implements xdss.CanaryRouter
+func (h *CanaryHash) SetCanary(nodeID string, canary xdss.Canary) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, ok := h.canaries[nodeID]; ok && reflect.DeepEqual(current, canary) {
+		return false
+	}
+	h.canaries[nodeID] = canary
+	return true
+}
+
+// ClearCanary implements xdss.CanaryRouter
+func (h *CanaryHash) ClearCanary(nodeID, version string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, ok := h.canaries[nodeID]; ok && current.Version == version {
+		delete(h.canaries, nodeID)
+		return true
+	}
+	return false
+}
+
+// IsCanary implements xdss.CanaryRouter
+func (h *CanaryHash) IsCanary(nodeID, pod string) bool {
+	h.mu.RLock()
+	canary, ok := h.canaries[nodeID]
+	h.mu.RUnlock()
+
+	return ok && canary.Selects(pod)
+}
@@ -0,0 +1,90 @@
+package discoveryservice
+
+import (
+	"testing"
+
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+)
+
+func TestCanaryHash_ID(t *testing.T) {
+	tests := []struct {
+		name     string
+		canaries map[string]xdss.Canary
+		node     *envoy_config_core_v3.Node
+		want     string
+	}{
+		{
+			name:     "Returns the node ID if there is no canary",
+			canaries: map[string]xdss.Canary{},
+			node:     podNode("node1", "pod-a"),
+			want:     "node1",
+		},
+		{
+			name:     "Returns the canary key for canary pods",
+			canaries: map[string]xdss.Canary{"node1": {Version: "v2", Pods: []string{"pod-a"}}},
+			node:     podNode("node1", "pod-a"),
+			want:     xdss.CanarySnapshotKey("node1"),
+		},
+		{
+			name:     "Returns the node ID for pods that are not canaries",
+			canaries: map[string]xdss.Canary{"node1": {Version: "v2", Pods: []string{"pod-a"}}},
+			node:     podNode("node1", "pod-b"),
+			want:     "node1",
+		},
+		{
+			name:     "Returns the canary key for all pods with 100%",
+			canaries: map[string]xdss.Canary{"node1": {Version: "v2", Percentage: pointer.New(100)}},
+			node:     podNode("node1", "pod-b"),
+			want:     xdss.CanarySnapshotKey("node1"),
+		},
+		{
+			name:     "Returns the node ID if the node has no pod_name",
+			canaries: map[string]xdss.Canary{"node1": {Version: "v2", Percentage: pointer.New(100)}},
+			node:     &envoy_config_core_v3.Node{Id: "node1"},
+			want:     "node1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &CanaryHash{canaries: tt.canaries}
+			if got := h.ID(tt.node); got != tt.want {
+				t.Errorf("CanaryHash.ID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanaryHash_SetCanary(t *testing.T) {
+	h := NewCanaryHash()
+	canary := xdss.Canary{Version: "v2", Pods: []string{"pod-a"}}
+
+	if !h.SetCanary("node1", canary) {
+		t.Errorf("CanaryHash.SetCanary() = false for a new canary")
+	}
+	if h.SetCanary("node1", canary) {
+		t.Errorf("CanaryHash.SetCanary() = true for an unchanged canary")
+	}
+	if !h.SetCanary("node1", xdss.Canary{Version: "v2", Pods: []string{"pod-a", "pod-b"}}) {
+		t.Errorf("CanaryHash.SetCanary() = false for an updated canary")
+	}
+	if !h.IsCanary("node1", "pod-b") {
+		t.Errorf("CanaryHash.IsCanary() = false for a canary pod")
+	}
+}
+
+func TestCanaryHash_ClearCanary(t *testing.T) {
+	h := NewCanaryHash()
+	h.SetCanary("node1", xdss.Canary{Version: "v2", Pods: []string{"pod-a"}})
+
+	if h.ClearCanary("node1", "v1") {
+		t.Errorf("CanaryHash.ClearCanary() = true for a different version")
+	}
+	if !h.ClearCanary("node1", "v2") {
+		t.Errorf("CanaryHash.ClearCanary() = false for the canary version")
+	}
+	if h.IsCanary("node1", "pod-a") {
+		t.Errorf("CanaryHash.IsCanary() = true after clearing the canary")
+	}
+}
@@ -0,0 +1,130 @@
+package discoveryservice
+
+import (
+	"context"
+
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cache_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+)
+
+var _ cache_v3.Cache = &NodeAwareCache{}
+
+// NodeAwareCache wraps a go-control-plane cache and resolves the node placeholders
+// present in the resources of each response, using the node information of the
+// stream that the response is going to be sent to. This allows serving the same
+// snapshot to all the Envoy clients of a given nodeID while still having per-pod
+// values (like the pod IP or the zone) in the resources.
+type NodeAwareCache struct {
+	cache_v3.Cache
+	logger logr.Logger
+}
+
+// NewNodeAwareCache returns a NodeAwareCache that wraps the given cache
+func NewNodeAwareCache(cache cache_v3.Cache, logger logr.Logger) *NodeAwareCache {
+	return &NodeAwareCache{Cache: cache, logger: logger}
+}
+
+// CreateWatch implements go-control-plane/pkg/cache/v3.ConfigWatcher.CreateWatch
+// The response produced by the wrapped cache is rendered for the requesting node before
+// writing it to the given channel.
+func (c *NodeAwareCache) CreateWatch(req *cache_v3.Request, state stream.StreamState, out chan cache_v3.Response) func() {
+	return proxyWatch(c.Cache, req, state, out, c.render)
+}
+
+// CreateDeltaWatch implements go-control-plane/pkg/cache/v3.ConfigWatcher.CreateDeltaWatch
+// The response produced by the wrapped cache is rendered for the requesting node before
+// writing it to the given channel.
+func (c *NodeAwareCache) CreateDeltaWatch(req *cache_v3.DeltaRequest, state stream.StreamState, out chan cache_v3.DeltaResponse) func() {
+	return proxyDeltaWatch(c.Cache, req, state, out, c.renderDelta)
+}
+
+// Fetch implements go-control-plane/pkg/cache/v3.ConfigFetcher.Fetch
+func (c *NodeAwareCache) Fetch(ctx context.Context, req *cache_v3.Request) (cache_v3.Response, error) {
+	rsp, err := c.Cache.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.render(rsp), nil
+}
+
+func (c *NodeAwareCache) render(rsp cache_v3.Response) cache_v3.Response {
+	raw, ok := rsp.(*cache_v3.RawResponse)
+	if !ok {
+		return rsp
+	}
+
+	node := raw.GetRequest().GetNode()
+	resources := make([]cache_types.ResourceWithTTL, len(raw.Resources))
+	rendered := false
+	for idx, r := range raw.Resources {
+		res := c.renderResource(r.Resource, node, raw.GetRequest().GetTypeUrl())
+		if res != r.Resource {
+			rendered = true
+		}
+		resources[idx] = cache_types.ResourceWithTTL{Resource: res, TTL: r.TTL}
+	}
+
+	if !rendered {
+		return rsp
+	}
+
+	return &cache_v3.RawResponse{
+		Request:   raw.Request,
+		Version:   raw.Version,
+		Resources: resources,
+		Heartbeat: raw.Heartbeat,
+		Ctx:       raw.Ctx,
+	}
+}
+
+// renderDelta renders the resources of incremental xDS responses. The version map
+// keeps the versions of the unrendered resources, which is what the wrapped cache
+// compares with the state of the stream to calculate the next response.
+func (c *NodeAwareCache) renderDelta(rsp cache_v3.DeltaResponse) cache_v3.DeltaResponse {
+	raw, ok := rsp.(*cache_v3.RawDeltaResponse)
+	if !ok {
+		return rsp
+	}
+
+	node := raw.GetDeltaRequest().GetNode()
+	resources := make([]cache_types.Resource, len(raw.Resources))
+	rendered := false
+	for idx, r := range raw.Resources {
+		resources[idx] = c.renderResource(r, node, raw.GetDeltaRequest().GetTypeUrl())
+		if resources[idx] != r {
+			rendered = true
+		}
+	}
+
+	if !rendered {
+		return rsp
+	}
+
+	return &cache_v3.RawDeltaResponse{
+		DeltaRequest:      raw.DeltaRequest,
+		SystemVersionInfo: raw.SystemVersionInfo,
+		Resources:         resources,
+		RemovedResources:  raw.RemovedResources,
+		NextVersionMap:    raw.NextVersionMap,
+		Ctx:               raw.Ctx,
+	}
+}
+
+// renderResource renders the resource for the node. The resource is returned
+// unrendered if rendering fails.
+func (c *NodeAwareCache) renderResource(r cache_types.Resource, node *envoy_config_core_v3.Node, typeURL string) cache_types.Resource {
+	res, unresolved, err := RenderForNode(r, node)
+	if err != nil {
+		c.logger.Error(err, "unable to render resource for node, sending it unrendered",
+			"NodeID", node.GetId(), "TypeURL", typeURL, "Resource", cache_v3.GetResourceName(r))
+		return r
+	}
+	if len(unresolved) > 0 {
+		c.logger.Info("unable to resolve placeholders for node", "NodeID", node.GetId(),
+			"TypeURL", typeURL, "Resource", cache_v3.GetResourceName(r), "Placeholders", unresolved)
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+package discoveryservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestNodeAwareCache_CreateWatch(t *testing.T) {
+	snapshotCache := cache_v3.NewSnapshotCache(true, cache_v3.IDHash{}, nil)
+	snap := NewSnapshot().SetResources(envoy.Endpoint, []envoy.Resource{testEndpoint("${node.metadata.pod_ip}")})
+	if err := snapshotCache.SetSnapshot(context.TODO(), "node", snap.(Snapshot).v3); err != nil {
+		t.Fatalf("error setting snapshot: %s", err)
+	}
+	c := NewNodeAwareCache(snapshotCache, logr.Discard())
+
+	t.Run("Renders the resources of state of the world responses", func(t *testing.T) {
+		req := &cache_v3.Request{Node: testNode(), TypeUrl: resource_v3.EndpointType}
+		out := make(chan cache_v3.Response, 1)
+		cancel := c.CreateWatch(req, stream.NewStreamState(true, map[string]string{}), out)
+		defer cancel()
+
+		select {
+		case rsp := <-out:
+			got := rsp.(*cache_v3.RawResponse).Resources[0].Resource
+			if diff := cmp.Diff(got, testEndpoint("10.0.0.1"), protocmp.Transform()); len(diff) > 0 {
+				t.Errorf("NodeAwareCache.CreateWatch() diff = %v", diff)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("NodeAwareCache.CreateWatch() timed out waiting for a response")
+		}
+	})
+
+	t.Run("Renders the resources of incremental responses", func(t *testing.T) {
+		req := &cache_v3.DeltaRequest{Node: testNode(), TypeUrl: resource_v3.EndpointType}
+		out := make(chan cache_v3.DeltaResponse, 1)
+		cancel := c.CreateDeltaWatch(req, stream.NewStreamState(true, map[string]string{}), out)
+		defer cancel()
+
+		select {
+		case rsp := <-out:
+			raw := rsp.(*cache_v3.RawDeltaResponse)
+			if diff := cmp.Diff(raw.Resources[0], testEndpoint("10.0.0.1"), protocmp.Transform()); len(diff) > 0 {
+				t.Errorf("NodeAwareCache.CreateDeltaWatch() diff = %v", diff)
+			}
+			if _, ok := raw.NextVersionMap["cluster"]; !ok {
+				t.Errorf("NodeAwareCache.CreateDeltaWatch() version map not preserved: %v", raw.NextVersionMap)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("NodeAwareCache.CreateDeltaWatch() timed out waiting for a response")
+		}
+	})
+}
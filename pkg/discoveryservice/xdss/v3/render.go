@@ -0,0 +1,202 @@
+package discoveryservice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// NodePlaceholderPrefix is the prefix of all the placeholders that
+	// get resolved using the information of the requesting node
+	NodePlaceholderPrefix string = "${node."
+)
+
+// nodePlaceholderRegexp matches placeholders in the form:
+//   - ${node.id}
+//   - ${node.cluster}
+//   - ${node.metadata.<key>}
+//   - ${node.locality.region}, ${node.locality.zone}, ${node.locality.sub_zone}
+//
+// The locality of marin3r managed Envoys is read from the "topology.kubernetes.io/region"
+// and "topology.kubernetes.io/zone" labels of the Pod, which must be propagated from the
+// k8s Node by the user as Kubernetes only sets them on Nodes.
+var nodePlaceholderRegexp = regexp.MustCompile(`\$\{node\.(id|cluster|metadata\.[A-Za-z0-9_\-./]+|locality\.(?:region|zone|sub_zone))\}`)
+
+// HasNodePlaceholders returns true if the resource holds any node placeholder
+func HasNodePlaceholders(resource envoy.Resource) bool {
+	// string fields are serialized verbatim in the wire format, also the ones
+	// inside Any fields, so looking into the serialized bytes is enough
+	b, err := proto.Marshal(resource)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(b, []byte(NodePlaceholderPrefix))
+}
+
+// RenderForNode returns a copy of the resource with all the node placeholders
+// resolved with the information of the given node. Placeholders that cannot be resolved
+// are left untouched and returned as the second return value. The resource is returned as
+// is if it does not hold any node placeholder.
+func RenderForNode(resource envoy.Resource, node *envoy_config_core_v3.Node) (envoy.Resource, []string, error) {
+	if !HasNodePlaceholders(resource) {
+		return resource, nil, nil
+	}
+
+	unresolved := []string{}
+	resolve := func(s string) string {
+		return nodePlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+			value, ok := nodeValue(node, nodePlaceholderRegexp.FindStringSubmatch(placeholder)[1])
+			if !ok {
+				unresolved = append(unresolved, placeholder)
+				return placeholder
+			}
+			return value
+		})
+	}
+
+	rendered := proto.Clone(resource)
+	if _, err := renderMessage(rendered.ProtoReflect(), resolve); err != nil {
+		return nil, nil, err
+	}
+	return rendered, unresolved, nil
+}
+
+// nodeValue returns the value of the given path within the node information
+func nodeValue(node *envoy_config_core_v3.Node, path string) (string, bool) {
+	switch {
+	case path == "id":
+		return node.GetId(), node.GetId() != ""
+	case path == "cluster":
+		return node.GetCluster(), node.GetCluster() != ""
+	case path == "locality.region":
+		return node.GetLocality().GetRegion(), node.GetLocality().GetRegion() != ""
+	case path == "locality.zone":
+		return node.GetLocality().GetZone(), node.GetLocality().GetZone() != ""
+	case path == "locality.sub_zone":
+		return node.GetLocality().GetSubZone(), node.GetLocality().GetSubZone() != ""
+	}
+
+	if key, ok := strings.CutPrefix(path, "metadata."); ok {
+		if v, ok := node.GetMetadata().GetFields()[key]; ok {
+			return structValueToString(v)
+		}
+	}
+	return "", false
+}
+
+func structValueToString(v *structpb.Value) (string, bool) {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return k.StringValue, true
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(k.NumberValue, 'f', -1, 64), true
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(k.BoolValue), true
+	}
+	return "", false
+}
+
+// renderMessage walks the given message and applies the resolve function to every string
+// field found, including the ones in messages packed within Any fields. The message is
+// modified in place. Returns true if any field has been changed.
+func renderMessage(m protoreflect.Message, resolve func(string) string) (bool, error) {
+	changed := false
+	var err error
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		var c bool
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				var nv protoreflect.Value
+				if nv, c, err = renderValue(fd, list.Get(i), resolve); err != nil {
+					return false
+				}
+				if c {
+					list.Set(i, nv)
+					changed = true
+				}
+			}
+
+		case fd.IsMap():
+			mm := v.Map()
+			mm.Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				var nv protoreflect.Value
+				if nv, c, err = renderValue(fd.MapValue(), mv, resolve); err != nil {
+					return false
+				}
+				if c {
+					mm.Set(k, nv)
+					changed = true
+				}
+				return true
+			})
+			if err != nil {
+				return false
+			}
+
+		default:
+			var nv protoreflect.Value
+			if nv, c, err = renderValue(fd, v, resolve); err != nil {
+				return false
+			}
+			if c {
+				m.Set(fd, nv)
+				changed = true
+			}
+		}
+		return true
+	})
+
+	return changed, err
+}
+
+func renderValue(fd protoreflect.FieldDescriptor, v protoreflect.Value, resolve func(string) string) (protoreflect.Value, bool, error) {
+	switch fd.Kind() {
+
+	case protoreflect.StringKind:
+		if s := resolve(v.String()); s != v.String() {
+			return protoreflect.ValueOfString(s), true, nil
+		}
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if a, ok := v.Message().Interface().(*anypb.Any); ok {
+			return renderAny(a, resolve)
+		}
+		changed, err := renderMessage(v.Message(), resolve)
+		return v, changed, err
+	}
+
+	return v, false, nil
+}
+
+func renderAny(a *anypb.Any, resolve func(string) string) (protoreflect.Value, bool, error) {
+	if !bytes.Contains(a.GetValue(), []byte(NodePlaceholderPrefix)) {
+		return protoreflect.ValueOfMessage(a.ProtoReflect()), false, nil
+	}
+
+	inner, err := a.UnmarshalNew()
+	if err != nil {
+		// the type is not known, so it cannot be rendered
+		return protoreflect.ValueOfMessage(a.ProtoReflect()), false, nil
+	}
+	changed, err := renderMessage(inner.ProtoReflect(), resolve)
+	if err != nil || !changed {
+		return protoreflect.ValueOfMessage(a.ProtoReflect()), false, err
+	}
+	if err := a.MarshalFrom(inner); err != nil {
+		return protoreflect.Value{}, false, fmt.Errorf("unable to marshal rendered '%s': %w", a.GetTypeUrl(), err)
+	}
+	return protoreflect.ValueOfMessage(a.ProtoReflect()), true, nil
+}
@@ -0,0 +1,151 @@
+package discoveryservice
+
+import (
+	"testing"
+
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_config_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_config_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	envoy_extensions_filters_network_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func testNode() *envoy_config_core_v3.Node {
+	return &envoy_config_core_v3.Node{
+		Id:      "node",
+		Cluster: "cluster",
+		Metadata: &structpb.Struct{Fields: map[string]*structpb.Value{
+			"pod_ip":   structpb.NewStringValue("10.0.0.1"),
+			"pod_name": structpb.NewStringValue("pod-xxxx"),
+			"replicas": structpb.NewNumberValue(3),
+		}},
+		Locality: &envoy_config_core_v3.Locality{Region: "eu-west-1", Zone: "eu-west-1a"},
+	}
+}
+
+func testEndpoint(address string) *envoy_config_endpoint_v3.ClusterLoadAssignment {
+	return &envoy_config_endpoint_v3.ClusterLoadAssignment{
+		ClusterName: "cluster",
+		Endpoints: []*envoy_config_endpoint_v3.LocalityLbEndpoints{{
+			LbEndpoints: []*envoy_config_endpoint_v3.LbEndpoint{{
+				HostIdentifier: &envoy_config_endpoint_v3.LbEndpoint_Endpoint{
+					Endpoint: &envoy_config_endpoint_v3.Endpoint{
+						Address: &envoy_config_core_v3.Address{
+							Address: &envoy_config_core_v3.Address_SocketAddress{
+								SocketAddress: &envoy_config_core_v3.SocketAddress{
+									Address:       address,
+									PortSpecifier: &envoy_config_core_v3.SocketAddress_PortValue{PortValue: 8080},
+								}}}}}}}}},
+	}
+}
+
+func testListener(statPrefix string) *envoy_config_listener_v3.Listener {
+	tcpProxy, _ := anypb.New(&envoy_extensions_filters_network_tcp_proxy_v3.TcpProxy{
+		StatPrefix:       statPrefix,
+		ClusterSpecifier: &envoy_extensions_filters_network_tcp_proxy_v3.TcpProxy_Cluster{Cluster: "cluster"},
+	})
+	return &envoy_config_listener_v3.Listener{
+		Name: "listener",
+		FilterChains: []*envoy_config_listener_v3.FilterChain{{
+			Filters: []*envoy_config_listener_v3.Filter{{
+				Name:       "envoy.filters.network.tcp_proxy",
+				ConfigType: &envoy_config_listener_v3.Filter_TypedConfig{TypedConfig: tcpProxy},
+			}},
+		}},
+	}
+}
+
+func TestRenderForNode(t *testing.T) {
+	type args struct {
+		resource envoy.Resource
+		node     *envoy_config_core_v3.Node
+	}
+	tests := []struct {
+		name           string
+		args           args
+		want           envoy.Resource
+		wantUnresolved []string
+		wantErr        bool
+	}{
+		{
+			name: "Returns the resource untouched if there are no placeholders",
+			args: args{
+				resource: &envoy_config_cluster_v3.Cluster{Name: "cluster"},
+				node:     testNode(),
+			},
+			want:           &envoy_config_cluster_v3.Cluster{Name: "cluster"},
+			wantUnresolved: nil,
+			wantErr:        false,
+		},
+		{
+			name: "Resolves id, cluster and locality placeholders",
+			args: args{
+				resource: &envoy_config_cluster_v3.Cluster{Name: "${node.id}-${node.cluster}-${node.locality.zone}"},
+				node:     testNode(),
+			},
+			want:           &envoy_config_cluster_v3.Cluster{Name: "node-cluster-eu-west-1a"},
+			wantUnresolved: []string{},
+			wantErr:        false,
+		},
+		{
+			name: "Resolves metadata placeholders in nested fields",
+			args: args{
+				resource: testEndpoint("${node.metadata.pod_ip}"),
+				node:     testNode(),
+			},
+			want:           testEndpoint("10.0.0.1"),
+			wantUnresolved: []string{},
+			wantErr:        false,
+		},
+		{
+			name: "Resolves placeholders within Any fields",
+			args: args{
+				resource: testListener("${node.metadata.pod_name}_${node.metadata.replicas}"),
+				node:     testNode(),
+			},
+			want:           testListener("pod-xxxx_3"),
+			wantUnresolved: []string{},
+			wantErr:        false,
+		},
+		{
+			name: "Leaves unresolved placeholders untouched",
+			args: args{
+				resource: &envoy_config_cluster_v3.Cluster{Name: "${node.metadata.unknown}-${node.locality.sub_zone}-${node.id}"},
+				node:     testNode(),
+			},
+			want:           &envoy_config_cluster_v3.Cluster{Name: "${node.metadata.unknown}-${node.locality.sub_zone}-node"},
+			wantUnresolved: []string{"${node.metadata.unknown}", "${node.locality.sub_zone}"},
+			wantErr:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotUnresolved, err := RenderForNode(tt.args.resource, tt.args.node)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RenderForNode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("RenderForNode() got = %v, want %v", got, tt.want)
+			}
+			if diff := cmp.Diff(gotUnresolved, tt.wantUnresolved); len(diff) > 0 {
+				t.Errorf("RenderForNode() unresolved diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRenderForNode_DoesNotModifyTheOriginal(t *testing.T) {
+	resource := &envoy_config_cluster_v3.Cluster{Name: "${node.id}"}
+	if _, _, err := RenderForNode(resource, testNode()); err != nil {
+		t.Fatalf("RenderForNode() error = %v", err)
+	}
+	if resource.Name != "${node.id}" {
+		t.Errorf("RenderForNode() modified the original resource: %v", resource)
+	}
+}
@@ -1,6 +1,9 @@
 package discoveryservice
 
 import (
+	"fmt"
+	"time"
+
 	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
@@ -63,6 +66,22 @@ func (s Snapshot) SetResources(rType envoy.Type, resources []envoy.Resource) xds
 	return s
 }
 
+// SetTTL sets the TTL of a resource that has been previously
+// added to the snapshot using SetResources.
+func (s Snapshot) SetTTL(rType envoy.Type, resource envoy.Resource, ttl time.Duration) xdss.Snapshot {
+
+	item, ok := s.v3.Resources[v3CacheResources(rType)].Items[cache_v3.GetResourceName(resource)]
+	if !ok {
+		return s
+	}
+	item.TTL = &ttl
+	s.v3.Resources[v3CacheResources(rType)].Items[cache_v3.GetResourceName(resource)] = item
+
+	s.SetVersion(rType, s.recalculateVersion(rType))
+
+	return s
+}
+
 // GetResources selects snapshot resources by type.
 func (s Snapshot) GetResources(rType envoy.Type) map[string]envoy.Resource {
 
@@ -91,6 +110,11 @@ func (s Snapshot) recalculateVersion(rType envoy.Type) string {
 	for n, r := range s.v3.Resources[v3CacheResources(rType)].Items {
 		j, _ := encoder.Marshal(r.Resource)
 		resources[n] = string(j)
+		if r.TTL != nil {
+			// a change in the TTL also needs to
+			// produce a change in the version
+			resources[n] += fmt.Sprintf("ttl:%s", r.TTL)
+		}
 	}
 	if len(resources) > 0 {
 		return reconcilerutil.Hash(resources)
@@ -1,7 +1,9 @@
 package discoveryservice
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
@@ -55,6 +57,60 @@ func TestSnapshot_SetResources(t *testing.T) {
 	}
 }
 
+func TestSnapshot_SetTTL(t *testing.T) {
+	type args struct {
+		rType    envoy.Type
+		resource envoy.Resource
+		ttl      time.Duration
+	}
+	tests := []struct {
+		name        string
+		snapshot    xdss.Snapshot
+		args        args
+		wantTTL     *time.Duration
+		wantVersion string
+	}{
+		{
+			name: "Sets the TTL of the resource and recalculates the version",
+			snapshot: NewSnapshot().SetResources(envoy.Cluster, []envoy.Resource{
+				&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+			}),
+			args: args{
+				rType:    envoy.Cluster,
+				resource: &envoy_config_cluster_v3.Cluster{Name: "cluster"},
+				ttl:      30 * time.Second,
+			},
+			wantTTL:     func() *time.Duration { d := 30 * time.Second; return &d }(),
+			wantVersion: "f6f8bb769",
+		},
+		{
+			name: "Does nothing if the resource is not in the snapshot",
+			snapshot: NewSnapshot().SetResources(envoy.Cluster, []envoy.Resource{
+				&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+			}),
+			args: args{
+				rType:    envoy.Cluster,
+				resource: &envoy_config_cluster_v3.Cluster{Name: "other"},
+				ttl:      30 * time.Second,
+			},
+			wantTTL:     nil,
+			wantVersion: "568989d74c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.snapshot.SetTTL(tt.args.rType, tt.args.resource, tt.args.ttl)
+			item := got.(Snapshot).v3.Resources[v3CacheResources(tt.args.rType)].Items["cluster"]
+			if !reflect.DeepEqual(item.TTL, tt.wantTTL) {
+				t.Errorf("Snapshot.SetTTL() ttl = %v, want %v", item.TTL, tt.wantTTL)
+			}
+			if v := got.GetVersion(tt.args.rType); v != tt.wantVersion {
+				t.Errorf("Snapshot.SetTTL() version = %v, want %v", v, tt.wantVersion)
+			}
+		})
+	}
+}
+
 func TestSnapshot_GetResources(t *testing.T) {
 	type args struct {
 		rType envoy.Type
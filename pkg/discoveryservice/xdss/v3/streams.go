@@ -0,0 +1,177 @@
+package discoveryservice
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ xdss.StreamCloser = &StreamTracker{}
+
+// StreamTracker keeps track of the open xDS streams and the Envoy
+// clients they belong to, so streams can be closed on demand to force
+// the clients to reconnect and receive a full push of the resources.
+type StreamTracker struct {
+	mu      sync.Mutex
+	streams map[*trackedStream]struct{}
+}
+
+// NewStreamTracker returns a new StreamTracker
+func NewStreamTracker() *StreamTracker {
+	return &StreamTracker{streams: map[*trackedStream]struct{}{}}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that registers
+// the streams in the tracker. Streams closed by the tracker return an
+// 'Unavailable' status to the clients, which makes Envoy reconnect.
+func (t *StreamTracker) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := context.WithCancel(ss.Context())
+		defer cancel()
+
+		ts := &trackedStream{ServerStream: ss, ctx: ctx, cancel: cancel, openedAt: time.Now()}
+		t.add(ts)
+		defer t.remove(ts)
+
+		errCh := make(chan error, 1)
+		go func() { errCh <- handler(srv, ts) }()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-ctx.Done():
+			// wait for the handler to return, so it does not
+			// use the stream once the interceptor has returned
+			err := <-errCh
+			if ts.closed.Load() {
+				// the stream has been closed by the tracker
+				return status.Error(codes.Unavailable, "stream closed by the discovery service to force a resync")
+			}
+			// the client is gone
+			return err
+		}
+	}
+}
+
+// CloseStreams implements xdss.StreamCloser
+func (t *StreamTracker) CloseStreams(nodeID string, openedBefore time.Time, pods ...string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	closed := []string{}
+	for ts := range t.streams {
+		node, pod := ts.client()
+		if node != nodeID || !ts.openedAt.Before(openedBefore) {
+			continue
+		}
+		if len(pods) > 0 && !contains(pods, pod) {
+			continue
+		}
+		ts.close()
+		delete(t.streams, ts)
+		closed = append(closed, pod)
+	}
+	sort.Strings(closed)
+	return closed
+}
+
+func (t *StreamTracker) add(ts *trackedStream) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.streams[ts] = struct{}{}
+}
+
+func (t *StreamTracker) remove(ts *trackedStream) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streams, ts)
+}
+
+// trackedStream wraps a grpc.ServerStream to allow cancelling it and
+// to learn the identity of the Envoy client from the requests it sends
+type trackedStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	cancel   context.CancelFunc
+	openedAt time.Time
+	closed   atomic.Bool
+
+	mu     sync.Mutex
+	nodeID string
+	pod    string
+}
+
+// close marks the stream as closed before cancelling its context, so the
+// handler can't send any more responses once it sees the cancellation
+func (ts *trackedStream) close() {
+	ts.closed.Store(true)
+	ts.cancel()
+}
+
+func (ts *trackedStream) Context() context.Context {
+	return ts.ctx
+}
+
+func (ts *trackedStream) SendMsg(m interface{}) error {
+	if ts.closed.Load() {
+		return status.Error(codes.Unavailable, "stream closed")
+	}
+	return ts.ServerStream.SendMsg(m)
+}
+
+func (ts *trackedStream) RecvMsg(m interface{}) error {
+	if err := ts.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	// only the first request of a stream is guaranteed to hold the node
+	switch req := m.(type) {
+	case *envoy_service_discovery_v3.DiscoveryRequest:
+		ts.setClient(req.GetNode())
+	case *envoy_service_discovery_v3.DeltaDiscoveryRequest:
+		ts.setClient(req.GetNode())
+	}
+	return nil
+}
+
+func (ts *trackedStream) setClient(node *envoy_config_core_v3.Node) {
+	if node == nil {
+		return
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.nodeID != "" {
+		return
+	}
+	ts.nodeID = node.GetId()
+	ts.pod = "unknown"
+	if pod, err := stats.GetStringValueFromMetadata(node.GetMetadata().AsMap(), "pod_name"); err == nil {
+		ts.pod = pod
+	}
+}
+
+func (ts *trackedStream) client() (string, string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.nodeID, ts.pod
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package discoveryservice
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	node *envoy_config_core_v3.Node
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(*envoy_service_discovery_v3.DiscoveryRequest), &envoy_service_discovery_v3.DiscoveryRequest{Node: s.node})
+	return nil
+}
+
+func podNode(id, pod string) *envoy_config_core_v3.Node {
+	md, _ := structpb.NewStruct(map[string]interface{}{"pod_name": pod})
+	return &envoy_config_core_v3.Node{Id: id, Metadata: md}
+}
+
+func TestStreamTracker_CloseStreams(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodeID       string
+		openedBefore time.Time
+		pods         []string
+		wantClosed   []string
+	}{
+		{
+			name:         "Closes all the streams of a node",
+			nodeID:       "node1",
+			openedBefore: time.Now().Add(time.Hour),
+			wantClosed:   []string{"pod-a", "pod-b"},
+		},
+		{
+			name:         "Closes the streams of the given pods",
+			nodeID:       "node1",
+			openedBefore: time.Now().Add(time.Hour),
+			pods:         []string{"pod-b"},
+			wantClosed:   []string{"pod-b"},
+		},
+		{
+			name:         "Does not close streams opened after the given time",
+			nodeID:       "node1",
+			openedBefore: time.Now().Add(-time.Hour),
+			wantClosed:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tracker := NewStreamTracker()
+			interceptor := tracker.StreamServerInterceptor()
+			errs := map[string]chan error{}
+			ready := make(chan struct{})
+
+			for _, c := range []struct{ node, pod string }{{"node1", "pod-a"}, {"node1", "pod-b"}, {"node2", "pod-c"}} {
+				errCh := make(chan error, 1)
+				errs[c.pod] = errCh
+				ss := &fakeServerStream{ctx: ctx, node: podNode(c.node, c.pod)}
+				go func() {
+					errCh <- interceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+						if err := stream.RecvMsg(&envoy_service_discovery_v3.DiscoveryRequest{}); err != nil {
+							return err
+						}
+						ready <- struct{}{}
+						<-stream.Context().Done()
+						return nil
+					})
+				}()
+			}
+			for i := 0; i < len(errs); i++ {
+				<-ready
+			}
+
+			got := tracker.CloseStreams(tt.nodeID, tt.openedBefore, tt.pods...)
+			if diff := cmp.Diff(got, tt.wantClosed); len(diff) > 0 {
+				t.Errorf("StreamTracker.CloseStreams() diff = %v", diff)
+			}
+
+			for _, pod := range got {
+				select {
+				case err := <-errs[pod]:
+					if status.Code(err) != codes.Unavailable {
+						t.Errorf("StreamTracker.CloseStreams() stream of pod %s returned %v", pod, err)
+					}
+				case <-time.After(time.Second):
+					t.Errorf("StreamTracker.CloseStreams() stream of pod %s was not closed", pod)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamTracker_StreamServerInterceptor(t *testing.T) {
+	tracker := NewStreamTracker()
+	interceptor := tracker.StreamServerInterceptor()
+	ss := &fakeServerStream{ctx: context.Background(), node: podNode("node", "pod")}
+	ready := make(chan struct{})
+	var handlerDone atomic.Bool
+	var sendErr error
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- interceptor(nil, ss, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			if err := stream.RecvMsg(&envoy_service_discovery_v3.DiscoveryRequest{}); err != nil {
+				return err
+			}
+			close(ready)
+			<-stream.Context().Done()
+			// give the interceptor a chance to return early
+			time.Sleep(50 * time.Millisecond)
+			sendErr = stream.SendMsg(&envoy_service_discovery_v3.DiscoveryResponse{})
+			handlerDone.Store(true)
+			return nil
+		})
+	}()
+	<-ready
+
+	tracker.CloseStreams("node", time.Now().Add(time.Hour))
+	select {
+	case err := <-errCh:
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("StreamServerInterceptor() returned %v", err)
+		}
+		if !handlerDone.Load() {
+			t.Errorf("StreamServerInterceptor() returned before the handler")
+		}
+		if status.Code(sendErr) != codes.Unavailable {
+			t.Errorf("StreamServerInterceptor() handler could send on a closed stream: %v", sendErr)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("StreamServerInterceptor() the stream was not closed")
+	}
+}
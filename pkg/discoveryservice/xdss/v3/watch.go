@@ -0,0 +1,60 @@
+package discoveryservice
+
+import (
+	"sync"
+
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+)
+
+// proxyWatch creates a watch in the given cache and passes the response through the
+// transform function before writing it to the out channel. The returned function cancels
+// both the watch in the given cache and the proxying of the response.
+func proxyWatch(cache cache_v3.ConfigWatcher, req *cache_v3.Request, state stream.StreamState, out chan cache_v3.Response,
+	transform func(cache_v3.Response) cache_v3.Response) func() {
+
+	in := make(chan cache_v3.Response, 1)
+	done := make(chan struct{})
+	cancel := cache.CreateWatch(req, state, in)
+
+	go func() {
+		select {
+		case rsp := <-in:
+			out <- transform(rsp)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		if cancel != nil {
+			cancel()
+		}
+	}
+}
+
+// proxyDeltaWatch is the equivalent of proxyWatch for incremental xDS watches
+func proxyDeltaWatch(cache cache_v3.ConfigWatcher, req *cache_v3.DeltaRequest, state stream.StreamState, out chan cache_v3.DeltaResponse,
+	transform func(cache_v3.DeltaResponse) cache_v3.DeltaResponse) func() {
+
+	in := make(chan cache_v3.DeltaResponse, 1)
+	done := make(chan struct{})
+	cancel := cache.CreateDeltaWatch(req, state, in)
+
+	go func() {
+		select {
+		case rsp := <-in:
+			out <- transform(rsp)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		if cancel != nil {
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package discoveryservice
+
+import (
+	"context"
+
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	cache_types "github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ cache_v3.Cache = &XdstpCache{}
+
+// XdstpCache wraps a go-control-plane cache to add support for requests of
+// xdstp:// glob collections (names ending in '/*'), which are not understood
+// by the go-control-plane snapshot cache as it only matches names verbatim.
+// Requests that hold glob collections are passed to the wrapped cache as wildcard
+// requests and the responses are then filtered to contain only the resources that
+// match any of the requested names.
+type XdstpCache struct {
+	cache_v3.Cache
+}
+
+// NewXdstpCache returns an XdstpCache that wraps the given cache
+func NewXdstpCache(cache cache_v3.Cache) *XdstpCache {
+	return &XdstpCache{Cache: cache}
+}
+
+// CreateWatch implements go-control-plane/pkg/cache/v3.ConfigWatcher.CreateWatch
+func (c *XdstpCache) CreateWatch(req *cache_v3.Request, state stream.StreamState, out chan cache_v3.Response) func() {
+	if !hasXdstpGlobs(req.GetResourceNames()) {
+		return c.Cache.CreateWatch(req, state, out)
+	}
+
+	return proxyWatch(c.Cache, wildcardRequest(req), state, out,
+		func(rsp cache_v3.Response) cache_v3.Response { return filterXdstpResponse(req, rsp) },
+	)
+}
+
+// CreateDeltaWatch implements go-control-plane/pkg/cache/v3.ConfigWatcher.CreateDeltaWatch
+// Incremental xDS streams hold the subscribed names in the stream state, so the watch is
+// created in the wrapped cache with a wildcard copy of the state and the response is then
+// filtered to the subscribed names. The version map of the response is not filtered: it
+// holds the versions of all the resources so the wrapped cache does not keep responding
+// with the resources that are filtered out.
+func (c *XdstpCache) CreateDeltaWatch(req *cache_v3.DeltaRequest, state stream.StreamState, out chan cache_v3.DeltaResponse) func() {
+	subscribed := state.GetSubscribedResourceNames()
+	if state.IsWildcard() || !hasXdstpGlobs(keys(subscribed)) {
+		return c.Cache.CreateDeltaWatch(req, state, out)
+	}
+
+	wildcard := state
+	wildcard.SetWildcard(true)
+	return proxyDeltaWatch(c.Cache, req, wildcard, out,
+		func(rsp cache_v3.DeltaResponse) cache_v3.DeltaResponse {
+			return filterXdstpDeltaResponse(keys(subscribed), rsp)
+		},
+	)
+}
+
+// Fetch implements go-control-plane/pkg/cache/v3.ConfigFetcher.Fetch
+func (c *XdstpCache) Fetch(ctx context.Context, req *cache_v3.Request) (cache_v3.Response, error) {
+	if !hasXdstpGlobs(req.GetResourceNames()) {
+		return c.Cache.Fetch(ctx, req)
+	}
+
+	rsp, err := c.Cache.Fetch(ctx, wildcardRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return filterXdstpResponse(req, rsp), nil
+}
+
+func hasXdstpGlobs(names []string) bool {
+	for _, name := range names {
+		if n, err := envoy_resources.ParseXdstpName(name); err == nil && n.IsGlob() {
+			return true
+		}
+	}
+	return false
+}
+
+func wildcardRequest(req *cache_v3.Request) *cache_v3.Request {
+	wildcard := proto.Clone(req).(*cache_v3.Request)
+	wildcard.ResourceNames = nil
+	return wildcard
+}
+
+func keys(m map[string]struct{}) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	return list
+}
+
+// xdstpMatcher returns a function that checks if a resource name matches, either
+// verbatim or as part of a glob collection, any of the given names
+func xdstpMatcher(names []string) func(string) bool {
+	exact := map[string]bool{}
+	globs := []*envoy_resources.XdstpName{}
+	for _, name := range names {
+		if n, err := envoy_resources.ParseXdstpName(name); err == nil && n.IsGlob() {
+			globs = append(globs, n)
+		} else {
+			exact[name] = true
+		}
+	}
+
+	return func(name string) bool {
+		if exact[name] {
+			return true
+		}
+		n, err := envoy_resources.ParseXdstpName(name)
+		if err != nil {
+			return false
+		}
+		for _, glob := range globs {
+			if glob.Matches(n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// filterXdstpResponse returns a response to the original request with only the
+// resources that match, either verbatim or as part of a glob collection, the
+// names in the original request
+func filterXdstpResponse(req *cache_v3.Request, rsp cache_v3.Response) cache_v3.Response {
+	raw, ok := rsp.(*cache_v3.RawResponse)
+	if !ok {
+		return rsp
+	}
+
+	matches := xdstpMatcher(req.GetResourceNames())
+	resources := []cache_types.ResourceWithTTL{}
+	for _, r := range raw.Resources {
+		if matches(cache_v3.GetResourceName(r.Resource)) {
+			resources = append(resources, r)
+		}
+	}
+
+	return &cache_v3.RawResponse{
+		Request:   req,
+		Version:   raw.Version,
+		Resources: resources,
+		Heartbeat: raw.Heartbeat,
+		Ctx:       raw.Ctx,
+	}
+}
+
+// filterXdstpDeltaResponse returns the incremental response with only the resources,
+// and removed resources, that match the subscribed names
+func filterXdstpDeltaResponse(names []string, rsp cache_v3.DeltaResponse) cache_v3.DeltaResponse {
+	raw, ok := rsp.(*cache_v3.RawDeltaResponse)
+	if !ok {
+		return rsp
+	}
+
+	matches := xdstpMatcher(names)
+	resources := []cache_types.Resource{}
+	for _, r := range raw.Resources {
+		if matches(cache_v3.GetResourceName(r)) {
+			resources = append(resources, r)
+		}
+	}
+	removed := []string{}
+	for _, name := range raw.RemovedResources {
+		if matches(name) {
+			removed = append(removed, name)
+		}
+	}
+
+	return &cache_v3.RawDeltaResponse{
+		DeltaRequest:      raw.DeltaRequest,
+		SystemVersionInfo: raw.SystemVersionInfo,
+		Resources:         resources,
+		RemovedResources:  removed,
+		NextVersionMap:    raw.NextVersionMap,
+		Ctx:               raw.Ctx,
+	}
+}
@@ -0,0 +1,194 @@
+package discoveryservice
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resource_v3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestXdstpCache_CreateWatch(t *testing.T) {
+	snapshotCache := cache_v3.NewSnapshotCache(true, cache_v3.IDHash{}, nil)
+	snap := NewSnapshot().SetResources(envoy.Cluster, []envoy.Resource{
+		&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/bar/a"},
+	})
+	if err := snapshotCache.SetSnapshot(context.TODO(), "node", snap.(Snapshot).v3); err != nil {
+		t.Fatalf("error setting snapshot: %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{
+			name:  "Returns all resources for wildcard requests",
+			names: []string{},
+			want: []string{
+				"cluster",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/bar/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+		},
+		{
+			name:  "Returns the resources of a glob collection",
+			names: []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/*"},
+			want: []string{
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+		},
+		{
+			name: "Returns the resources of a glob collection and the ones requested by name",
+			names: []string{
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/*",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/bar/a",
+				"cluster",
+			},
+			want: []string{
+				"cluster",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/bar/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewXdstpCache(snapshotCache)
+			req := &cache_v3.Request{
+				Node:          &envoy_config_core_v3.Node{Id: "node"},
+				TypeUrl:       resource_v3.ClusterType,
+				ResourceNames: tt.names,
+			}
+			out := make(chan cache_v3.Response, 1)
+			cancel := c.CreateWatch(req, stream.NewStreamState(len(tt.names) == 0, map[string]string{}), out)
+			defer cancel()
+
+			select {
+			case rsp := <-out:
+				got := []string{}
+				for _, r := range rsp.(*cache_v3.RawResponse).Resources {
+					got = append(got, cache_v3.GetResourceName(r.Resource))
+				}
+				sort.Strings(got)
+				if diff := cmp.Diff(got, tt.want); len(diff) > 0 {
+					t.Errorf("XdstpCache.CreateWatch() diff = %v", diff)
+				}
+				if rsp.GetRequest() != req {
+					t.Errorf("XdstpCache.CreateWatch() response is not for the original request")
+				}
+			case <-time.After(time.Second):
+				t.Errorf("XdstpCache.CreateWatch() timed out waiting for a response")
+			}
+		})
+	}
+}
+
+func TestXdstpCache_CreateDeltaWatch(t *testing.T) {
+	snapshotCache := cache_v3.NewSnapshotCache(true, cache_v3.IDHash{}, nil)
+	snap := NewSnapshot().SetResources(envoy.Cluster, []envoy.Resource{
+		&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b"},
+		&envoy_config_cluster_v3.Cluster{Name: "xdstp://authority/envoy.config.cluster.v3.Cluster/bar/a"},
+	})
+	if err := snapshotCache.SetSnapshot(context.TODO(), "node", snap.(Snapshot).v3); err != nil {
+		t.Fatalf("error setting snapshot: %s", err)
+	}
+
+	tests := []struct {
+		name        string
+		subscribed  []string
+		versions    map[string]string
+		want        []string
+		wantRemoved []string
+	}{
+		{
+			name:       "Returns the resources of a glob collection",
+			subscribed: []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/*"},
+			versions:   map[string]string{},
+			want: []string{
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+			wantRemoved: []string{},
+		},
+		{
+			name:       "Returns the resources of a glob collection and the ones subscribed by name",
+			subscribed: []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/*", "cluster"},
+			versions:   map[string]string{},
+			want: []string{
+				"cluster",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+			wantRemoved: []string{},
+		},
+		{
+			name:       "Returns the removed resources of a glob collection",
+			subscribed: []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/*"},
+			versions: map[string]string{
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/c": "1",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/bar/c": "1",
+			},
+			want: []string{
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/a",
+				"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/b",
+			},
+			wantRemoved: []string{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo/c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewXdstpCache(snapshotCache)
+			req := &cache_v3.DeltaRequest{
+				Node:                   &envoy_config_core_v3.Node{Id: "node"},
+				TypeUrl:                resource_v3.ClusterType,
+				ResourceNamesSubscribe: tt.subscribed,
+			}
+			state := stream.NewStreamState(false, tt.versions)
+			subscribed := map[string]struct{}{}
+			for _, name := range tt.subscribed {
+				subscribed[name] = struct{}{}
+			}
+			state.SetSubscribedResourceNames(subscribed)
+			out := make(chan cache_v3.DeltaResponse, 1)
+			cancel := c.CreateDeltaWatch(req, state, out)
+			defer cancel()
+
+			select {
+			case rsp := <-out:
+				raw := rsp.(*cache_v3.RawDeltaResponse)
+				got := []string{}
+				for _, r := range raw.Resources {
+					got = append(got, cache_v3.GetResourceName(r))
+				}
+				sort.Strings(got)
+				if diff := cmp.Diff(got, tt.want); len(diff) > 0 {
+					t.Errorf("XdstpCache.CreateDeltaWatch() diff = %v", diff)
+				}
+				if diff := cmp.Diff(raw.RemovedResources, tt.wantRemoved); len(diff) > 0 {
+					t.Errorf("XdstpCache.CreateDeltaWatch() removed diff = %v", diff)
+				}
+				if state.IsWildcard() {
+					t.Errorf("XdstpCache.CreateDeltaWatch() modified the state of the stream")
+				}
+			case <-time.After(time.Second):
+				t.Errorf("XdstpCache.CreateDeltaWatch() timed out waiting for a response")
+			}
+		})
+	}
+}
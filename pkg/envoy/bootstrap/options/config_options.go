@@ -19,4 +19,32 @@ type ConfigOptions struct {
 	AdminPort                   uint32
 	AdminAccessLogPath          string
 	Metadata                    map[string]string
+	Locality                    Locality
+	Authorities                 []Authority
+}
+
+// Authority is an xDS federation authority whose resources, named using
+// xdstp://{authority}/..., are fetched from its own discovery service
+type Authority struct {
+	Name    string
+	XdsHost string
+	XdsPort uint32
+}
+
+// ClusterName returns the name of the static cluster used to connect
+// to the discovery service of the authority
+func (a Authority) ClusterName() string {
+	return XdsClusterName + "_" + a.Name
+}
+
+// Locality identifies where the envoy client is running
+type Locality struct {
+	Region  string
+	Zone    string
+	SubZone string
+}
+
+// IsEmpty returns true if no locality field is set
+func (l Locality) IsEmpty() bool {
+	return l.Region == "" && l.Zone == "" && l.SubZone == ""
 }
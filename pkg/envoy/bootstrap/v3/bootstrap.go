@@ -17,6 +17,8 @@ import (
 	envoy_extensions_upstreams_http_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
+	xds_core_v3 "github.com/cncf/xds/go/xds/core/v3"
+
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -140,48 +142,7 @@ func (c *Config) GenerateStatic() (string, error) {
 		},
 		StaticResources: &envoy_config_bootstrap_v3.Bootstrap_StaticResources{
 			Clusters: []*envoy_config_cluster_v3.Cluster{
-				{
-					Name:           envoy_bootstrap_options.XdsClusterName,
-					ConnectTimeout: durationpb.New(1 * time.Second),
-					ClusterDiscoveryType: &envoy_config_cluster_v3.Cluster_Type{
-						Type: envoy_config_cluster_v3.Cluster_STRICT_DNS,
-					},
-					LoadAssignment: &envoy_config_endpoint_v3.ClusterLoadAssignment{
-						ClusterName: envoy_bootstrap_options.XdsClusterName,
-						Endpoints: []*envoy_config_endpoint_v3.LocalityLbEndpoints{
-							{
-								LbEndpoints: []*envoy_config_endpoint_v3.LbEndpoint{
-									{
-										HostIdentifier: &envoy_config_endpoint_v3.LbEndpoint_Endpoint{
-											Endpoint: &envoy_config_endpoint_v3.Endpoint{
-												Address: &envoy_config_core_v3.Address{
-													Address: &envoy_config_core_v3.Address_SocketAddress{
-														SocketAddress: &envoy_config_core_v3.SocketAddress{
-															Address: c.Options.XdsHost,
-															PortSpecifier: &envoy_config_core_v3.SocketAddress_PortValue{
-																PortValue: c.Options.XdsPort,
-															},
-														},
-													},
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-
-					TransportSocket: &envoy_config_core_v3.TransportSocket{
-						Name: wellknown.TransportSocketTls,
-						ConfigType: &envoy_config_core_v3.TransportSocket_TypedConfig{
-							TypedConfig: tlsContext,
-						},
-					},
-					TypedExtensionProtocolOptions: map[string]*anypb.Any{
-						"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": http2ProtocolOptions,
-					},
-				},
+				xdsCluster(envoy_bootstrap_options.XdsClusterName, c.Options.XdsHost, c.Options.XdsPort, tlsContext, http2ProtocolOptions),
 			},
 		},
 		LayeredRuntime: &envoy_config_bootstrap_v3.LayeredRuntime{
@@ -202,6 +163,28 @@ func (c *Config) GenerateStatic() (string, error) {
 		},
 	}
 
+	for _, authority := range c.Options.Authorities {
+		cfg.StaticResources.Clusters = append(cfg.StaticResources.Clusters,
+			xdsCluster(authority.ClusterName(), authority.XdsHost, authority.XdsPort, tlsContext, http2ProtocolOptions))
+		cfg.ConfigSources = append(cfg.ConfigSources, &envoy_config_core_v3.ConfigSource{
+			Authorities:        []*xds_core_v3.Authority{{Name: authority.Name}},
+			ResourceApiVersion: envoy_config_core_v3.ApiVersion_V3,
+			ConfigSourceSpecifier: &envoy_config_core_v3.ConfigSource_ApiConfigSource{
+				ApiConfigSource: &envoy_config_core_v3.ApiConfigSource{
+					ApiType:             envoy_config_core_v3.ApiConfigSource_AGGREGATED_GRPC,
+					TransportApiVersion: envoy_config_core_v3.ApiVersion_V3,
+					GrpcServices: []*envoy_config_core_v3.GrpcService{{
+						TargetSpecifier: &envoy_config_core_v3.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &envoy_config_core_v3.GrpcService_EnvoyGrpc{
+								ClusterName: authority.ClusterName(),
+							},
+						},
+					}},
+				},
+			},
+		})
+	}
+
 	if len(c.Options.Metadata) > 0 {
 		cfg.Node.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{}}
 		for key, value := range c.Options.Metadata {
@@ -213,6 +196,14 @@ func (c *Config) GenerateStatic() (string, error) {
 		}
 	}
 
+	if !c.Options.Locality.IsEmpty() {
+		cfg.Node.Locality = &envoy_config_core_v3.Locality{
+			Region:  c.Options.Locality.Region,
+			Zone:    c.Options.Locality.Zone,
+			SubZone: c.Options.Locality.SubZone,
+		}
+	}
+
 	json, err := envoy_serializer_v3.JSON{}.Marshal(cfg)
 	if err != nil {
 		return "", err
@@ -221,6 +212,52 @@ func (c *Config) GenerateStatic() (string, error) {
 	return string(json), nil
 }
 
+// xdsCluster returns a static cluster to connect to a discovery service
+func xdsCluster(name, host string, port uint32, tlsContext, http2ProtocolOptions *anypb.Any) *envoy_config_cluster_v3.Cluster {
+	return &envoy_config_cluster_v3.Cluster{
+		Name:           name,
+		ConnectTimeout: durationpb.New(1 * time.Second),
+		ClusterDiscoveryType: &envoy_config_cluster_v3.Cluster_Type{
+			Type: envoy_config_cluster_v3.Cluster_STRICT_DNS,
+		},
+		LoadAssignment: &envoy_config_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: []*envoy_config_endpoint_v3.LocalityLbEndpoints{
+				{
+					LbEndpoints: []*envoy_config_endpoint_v3.LbEndpoint{
+						{
+							HostIdentifier: &envoy_config_endpoint_v3.LbEndpoint_Endpoint{
+								Endpoint: &envoy_config_endpoint_v3.Endpoint{
+									Address: &envoy_config_core_v3.Address{
+										Address: &envoy_config_core_v3.Address_SocketAddress{
+											SocketAddress: &envoy_config_core_v3.SocketAddress{
+												Address: host,
+												PortSpecifier: &envoy_config_core_v3.SocketAddress_PortValue{
+													PortValue: port,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		TransportSocket: &envoy_config_core_v3.TransportSocket{
+			Name: wellknown.TransportSocketTls,
+			ConfigType: &envoy_config_core_v3.TransportSocket_TypedConfig{
+				TypedConfig: tlsContext,
+			},
+		},
+		TypedExtensionProtocolOptions: map[string]*anypb.Any{
+			"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": http2ProtocolOptions,
+		},
+	}
+}
+
 // GenerateSdsResources generates the envoy static config required for
 // filesystem discovery of certificates.
 func (c *Config) GenerateSdsResources() (map[string]string, error) {
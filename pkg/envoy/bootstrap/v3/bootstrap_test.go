@@ -32,6 +32,45 @@ func TestConfig_GenerateStatic(t *testing.T) {
 			want:    `{"node":{"id":"some-id","cluster":"some-cluster","metadata":{"key1":"value1","key2":"value2"}},"static_resources":{"clusters":[{"name":"xds_cluster","type":"STRICT_DNS","connect_timeout":"1s","load_assignment":{"cluster_name":"xds_cluster","endpoints":[{"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"localhost","port_value":10000}}}}]}]},"typed_extension_protocol_options":{"envoy.extensions.upstreams.http.v3.HttpProtocolOptions":{"@type":"type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions","explicit_http_config":{"http2_protocol_options":{"connection_keepalive":{"interval":"30s","timeout":"10s","connection_idle_interval":"60s"}}}}},"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext","common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"xds_client_certificate","sds_config":{"path_config_source":{"path":"/sds-config-source.json"},"resource_api_version":"V3"}}]}}}}]},"dynamic_resources":{"lds_config":{"ads":{},"resource_api_version":"V3"},"cds_config":{"ads":{},"resource_api_version":"V3"},"ads_config":{"api_type":"GRPC","transport_api_version":"V3","grpc_services":[{"envoy_grpc":{"cluster_name":"xds_cluster"}}]}},"layered_runtime":{"layers":[{"name":"runtime","rtds_layer":{"name":"runtime","rtds_config":{"ads":{},"resource_api_version":"V3"}}}]},"admin":{"access_log":[{"name":"envoy.access_loggers.file","typed_config":{"@type":"type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog","path":"/dev/null"}}],"address":{"socket_address":{"address":"0.0.0.0","port_value":9001}}}}`,
 			wantErr: false,
 		},
+		{
+			name: "Includes the node locality when set",
+			c: &Config{
+				Options: envoy_bootstrap_options.ConfigOptions{
+					NodeID:                      "some-id",
+					Cluster:                     "some-cluster",
+					XdsHost:                     "localhost",
+					XdsPort:                     10000,
+					XdsClientCertificatePath:    "/tls.crt",
+					XdsClientCertificateKeyPath: "/tls.key",
+					SdsConfigSourcePath:         "/sds-config-source.json",
+					RtdsLayerResourceName:       "runtime",
+					Metadata:                    map[string]string{"key1": "value1", "key2": "value2"},
+					Locality:                    envoy_bootstrap_options.Locality{Region: "region", Zone: "zone"},
+				},
+			},
+			want:    `{"node":{"id":"some-id","cluster":"some-cluster","metadata":{"key1":"value1","key2":"value2"},"locality":{"region":"region","zone":"zone"}},"static_resources":{"clusters":[{"name":"xds_cluster","type":"STRICT_DNS","connect_timeout":"1s","load_assignment":{"cluster_name":"xds_cluster","endpoints":[{"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"localhost","port_value":10000}}}}]}]},"typed_extension_protocol_options":{"envoy.extensions.upstreams.http.v3.HttpProtocolOptions":{"@type":"type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions","explicit_http_config":{"http2_protocol_options":{"connection_keepalive":{"interval":"30s","timeout":"10s","connection_idle_interval":"60s"}}}}},"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext","common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"xds_client_certificate","sds_config":{"path_config_source":{"path":"/sds-config-source.json"},"resource_api_version":"V3"}}]}}}}]},"dynamic_resources":{"lds_config":{"ads":{},"resource_api_version":"V3"},"cds_config":{"ads":{},"resource_api_version":"V3"},"ads_config":{"api_type":"GRPC","transport_api_version":"V3","grpc_services":[{"envoy_grpc":{"cluster_name":"xds_cluster"}}]}},"layered_runtime":{"layers":[{"name":"runtime","rtds_layer":{"name":"runtime","rtds_config":{"ads":{},"resource_api_version":"V3"}}}]},"admin":{"access_log":[{"name":"envoy.access_loggers.file","typed_config":{"@type":"type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog","path":"/dev/null"}}],"address":{"socket_address":{"address":"0.0.0.0","port_value":9001}}}}`,
+			wantErr: false,
+		},
+		{
+			name: "Includes a config source for each authority",
+			c: &Config{
+				Options: envoy_bootstrap_options.ConfigOptions{
+					NodeID:                      "some-id",
+					Cluster:                     "some-cluster",
+					XdsHost:                     "localhost",
+					XdsPort:                     10000,
+					XdsClientCertificatePath:    "/tls.crt",
+					XdsClientCertificateKeyPath: "/tls.key",
+					SdsConfigSourcePath:         "/sds-config-source.json",
+					RtdsLayerResourceName:       "runtime",
+					Authorities: []envoy_bootstrap_options.Authority{
+						{Name: "other.example.com", XdsHost: "xdss.other.example.com", XdsPort: 18000},
+					},
+				},
+			},
+			want:    `{"node":{"id":"some-id","cluster":"some-cluster"},"static_resources":{"clusters":[{"name":"xds_cluster","type":"STRICT_DNS","connect_timeout":"1s","load_assignment":{"cluster_name":"xds_cluster","endpoints":[{"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"localhost","port_value":10000}}}}]}]},"typed_extension_protocol_options":{"envoy.extensions.upstreams.http.v3.HttpProtocolOptions":{"@type":"type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions","explicit_http_config":{"http2_protocol_options":{"connection_keepalive":{"interval":"30s","timeout":"10s","connection_idle_interval":"60s"}}}}},"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext","common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"xds_client_certificate","sds_config":{"path_config_source":{"path":"/sds-config-source.json"},"resource_api_version":"V3"}}]}}}},{"name":"xds_cluster_other.example.com","type":"STRICT_DNS","connect_timeout":"1s","load_assignment":{"cluster_name":"xds_cluster_other.example.com","endpoints":[{"lb_endpoints":[{"endpoint":{"address":{"socket_address":{"address":"xdss.other.example.com","port_value":18000}}}}]}]},"typed_extension_protocol_options":{"envoy.extensions.upstreams.http.v3.HttpProtocolOptions":{"@type":"type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions","explicit_http_config":{"http2_protocol_options":{"connection_keepalive":{"interval":"30s","timeout":"10s","connection_idle_interval":"60s"}}}}},"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext","common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"xds_client_certificate","sds_config":{"path_config_source":{"path":"/sds-config-source.json"},"resource_api_version":"V3"}}]}}}}]},"dynamic_resources":{"lds_config":{"ads":{},"resource_api_version":"V3"},"cds_config":{"ads":{},"resource_api_version":"V3"},"ads_config":{"api_type":"GRPC","transport_api_version":"V3","grpc_services":[{"envoy_grpc":{"cluster_name":"xds_cluster"}}]}},"layered_runtime":{"layers":[{"name":"runtime","rtds_layer":{"name":"runtime","rtds_config":{"ads":{},"resource_api_version":"V3"}}}]},"admin":{"access_log":[{"name":"envoy.access_loggers.file","typed_config":{"@type":"type.googleapis.com/envoy.extensions.access_loggers.file.v3.FileAccessLog","path":"/dev/null"}}],"address":{"socket_address":{"address":"0.0.0.0","port_value":9001}}},"config_sources":[{"authorities":[{"name":"other.example.com"}],"api_config_source":{"api_type":"AGGREGATED_GRPC","transport_api_version":"V3","grpc_services":[{"envoy_grpc":{"cluster_name":"xds_cluster_other.example.com"}}]},"resource_api_version":"V3"}]}`,
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
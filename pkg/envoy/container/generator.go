@@ -38,6 +38,9 @@ type ContainerConfig struct {
 	XdssHost         string
 	XdssPort         int
 	APIVersion       string
+	// XdstpAuthorities are the xDS federation authorities, in the
+	// form '<authority>=<host>:<port>', passed to the init manager
+	XdstpAuthorities []string
 
 	// Shutdown manager container configuration
 	ShutdownManagerEnabled       bool
@@ -216,8 +219,48 @@ func (cc *ContainerConfig) InitContainers() []corev1.Container {
 					},
 				},
 			},
+			{
+				Name: "POD_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath:  "status.podIP",
+						APIVersion: "v1",
+					},
+				},
+			},
+			{
+				Name: "HOST_IP",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath:  "status.hostIP",
+						APIVersion: "v1",
+					},
+				},
+			},
+			// The downward API does not expose the labels of the k8s Node, so the locality is
+			// taken from the well-known topology labels in the Pod. Kubernetes only sets these
+			// labels on Nodes: users must propagate them to the Pods (for example with a mutating
+			// admission policy) for the locality to be populated. It is left empty otherwise.
+			{
+				Name: "LOCALITY_REGION",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath:  fmt.Sprintf("metadata.labels['%s']", corev1.LabelTopologyRegion),
+						APIVersion: "v1",
+					},
+				},
+			},
+			{
+				Name: "LOCALITY_ZONE",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath:  fmt.Sprintf("metadata.labels['%s']", corev1.LabelTopologyZone),
+						APIVersion: "v1",
+					},
+				},
+			},
 		},
-		Args: []string{
+		Args: append([]string{
 			"init-manager",
 			"--admin-access-log-path", cc.AdminAccessLogPath,
 			"--admin-bind-address", fmt.Sprintf("%s:%d", cc.AdminBindAddress, cc.AdminPort),
@@ -229,7 +272,7 @@ func (cc *ContainerConfig) InitContainers() []corev1.Container {
 			"--xdss-host", cc.XdssHost,
 			"--xdss-port", fmt.Sprintf("%d", cc.XdssPort),
 			"--envoy-image", cc.Image,
-		},
+		}, cc.xdstpAuthorityArgs()...),
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      cc.ConfigVolume,
@@ -245,6 +288,14 @@ func (cc *ContainerConfig) InitContainers() []corev1.Container {
 	return containers
 }
 
+func (cc *ContainerConfig) xdstpAuthorityArgs() []string {
+	args := []string{}
+	for _, authority := range cc.XdstpAuthorities {
+		args = append(args, "--xdstp-authority", authority)
+	}
+	return args
+}
+
 func (cc *ContainerConfig) Volumes() []corev1.Volume {
 
 	return []corev1.Volume{
@@ -427,6 +427,42 @@ func TestContainerConfig_InitContainers(t *testing.T) {
 							},
 						},
 					},
+					{
+						Name: "POD_IP",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath:  "status.podIP",
+								APIVersion: "v1",
+							},
+						},
+					},
+					{
+						Name: "HOST_IP",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath:  "status.hostIP",
+								APIVersion: "v1",
+							},
+						},
+					},
+					{
+						Name: "LOCALITY_REGION",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath:  "metadata.labels['topology.kubernetes.io/region']",
+								APIVersion: "v1",
+							},
+						},
+					},
+					{
+						Name: "LOCALITY_ZONE",
+						ValueFrom: &corev1.EnvVarSource{
+							FieldRef: &corev1.ObjectFieldSelector{
+								FieldPath:  "metadata.labels['topology.kubernetes.io/zone']",
+								APIVersion: "v1",
+							},
+						},
+					},
 				},
 				Args: []string{
 					"init-manager",
@@ -462,3 +498,20 @@ func TestContainerConfig_InitContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerConfig_InitContainers_XdstpAuthorities(t *testing.T) {
+	cc := ContainerConfig{
+		Image:            "envoy:test",
+		InitManagerImage: "init-manager:test",
+		XdstpAuthorities: []string{"a.example.com=xdss-a:18000", "b.example.com=xdss-b:18000"},
+	}
+	args := cc.InitContainers()[0].Args
+	want := []string{
+		"--envoy-image", "envoy:test",
+		"--xdstp-authority", "a.example.com=xdss-a:18000",
+		"--xdstp-authority", "b.example.com=xdss-b:18000",
+	}
+	if diff := deep.Equal(args[len(args)-len(want):], want); len(diff) > 0 {
+		t.Errorf("ContainerConfig.InitContainers() = diff %v", diff)
+	}
+}
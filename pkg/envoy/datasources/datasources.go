@@ -0,0 +1,116 @@
+package datasources
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DataSource returns the JSON representation of an envoy DataSource with the content of the
+// ConfigMap key. Keys in data are returned as "inline_string" and keys in binaryData as "inline_bytes".
+func DataSource(cm *corev1.ConfigMap, key string) (map[string]any, error) {
+	if v, ok := cm.Data[key]; ok {
+		return map[string]any{"inline_string": v}, nil
+	}
+	if v, ok := cm.BinaryData[key]; ok {
+		return map[string]any{"inline_bytes": base64.StdEncoding.EncodeToString(v)}, nil
+	}
+	return nil, fmt.Errorf("key '%s' not found in ConfigMap '%s'", key, cm.GetName())
+}
+
+// Inline inlines the DataSource in the value, either at the path or replacing the placeholder
+func Inline(value []byte, ds marin3rv1alpha1.DataSourceFromConfigMap, source map[string]any) ([]byte, error) {
+	if ds.Path != nil {
+		op, err := json.Marshal([]map[string]any{{"op": "add", "path": *ds.Path, "value": source}})
+		if err != nil {
+			return nil, err
+		}
+		patch, err := jsonpatch.DecodePatch(op)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply(value)
+	}
+
+	var doc any
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return nil, err
+	}
+	doc, found := replace(doc, ds.GetPlaceholder(), source)
+	if !found {
+		return nil, fmt.Errorf("placeholder '%s' not found", ds.GetPlaceholder())
+	}
+	return json.Marshal(doc)
+}
+
+// replace walks the JSON document replacing the DataSources whose inline_string is the placeholder
+func replace(doc any, placeholder string, source map[string]any) (any, bool) {
+	found := false
+	switch o := doc.(type) {
+	case map[string]any:
+		if len(o) == 1 && o["inline_string"] == placeholder {
+			return source, true
+		}
+		for k, v := range o {
+			var ok bool
+			if o[k], ok = replace(v, placeholder, source); ok {
+				found = true
+			}
+		}
+	case []any:
+		for i, v := range o {
+			var ok bool
+			if o[i], ok = replace(v, placeholder, source); ok {
+				found = true
+			}
+		}
+	}
+	return doc, found
+}
+
+// InlineEnvoyConfig inlines the data sources of the resources of the EnvoyConfig. The resulting
+// resources replace spec.resources in the given object, which is not persisted, so the content
+// of the ConfigMaps becomes part of the desired version of the EnvoyConfig.
+func InlineEnvoyConfig(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) error {
+	configMaps := map[string]*corev1.ConfigMap{}
+
+	for idx, res := range ec.Spec.Resources {
+		if len(res.DataSourcesFrom) == 0 || res.Value == nil {
+			continue
+		}
+
+		value := res.Value.Raw
+		for i, ds := range res.DataSourcesFrom {
+			cm, ok := configMaps[ds.Name]
+			if !ok {
+				cm = &corev1.ConfigMap{}
+				key := types.NamespacedName{Name: ds.Name, Namespace: ec.GetNamespace()}
+				if err := cl.Get(ctx, key, cm); err != nil {
+					return fmt.Errorf("unable to get ConfigMap '%s' for spec.resources[%d].dataSourcesFrom[%d]: %w", ds.Name, idx, i, err)
+				}
+				configMaps[ds.Name] = cm
+			}
+
+			source, err := DataSource(cm, ds.Key)
+			if err != nil {
+				return fmt.Errorf("spec.resources[%d].dataSourcesFrom[%d]: %w", idx, i, err)
+			}
+			if value, err = Inline(value, ds, source); err != nil {
+				return fmt.Errorf("spec.resources[%d].dataSourcesFrom[%d]: %w", idx, i, err)
+			}
+		}
+
+		ec.Spec.Resources[idx].Value = &runtime.RawExtension{Raw: value}
+		ec.Spec.Resources[idx].DataSourcesFrom = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package datasources
+
+import (
+	"context"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-test/deep"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	marin3rv1alpha1.AddToScheme(scheme.Scheme)
+}
+
+func TestDataSource(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"},
+		Data:       map[string]string{"script": "print('hi')"},
+		BinaryData: map[string][]byte{"module": {0x00, 0x61, 0x73, 0x6d}},
+	}
+	tests := []struct {
+		name    string
+		key     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "Returns an inline_string for data keys",
+			key:  "script",
+			want: map[string]any{"inline_string": "print('hi')"},
+		},
+		{
+			name: "Returns an inline_bytes for binaryData keys",
+			key:  "module",
+			want: map[string]any{"inline_bytes": "AGFzbQ=="},
+		},
+		{
+			name:    "Fails if the key does not exist",
+			key:     "missing",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DataSource(cm, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DataSource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
+				t.Errorf("DataSource() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestInline(t *testing.T) {
+	source := map[string]any{"inline_string": "code"}
+	tests := []struct {
+		name    string
+		value   string
+		ds      marin3rv1alpha1.DataSourceFromConfigMap
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Inlines at a path",
+			value: `{"name":"lua","typed_config":{}}`,
+			ds:    marin3rv1alpha1.DataSourceFromConfigMap{Name: "cm", Key: "k", Path: pointer.New("/typed_config/default_source_code")},
+			want:  `{"name":"lua","typed_config":{"default_source_code":{"inline_string":"code"}}}`,
+		},
+		{
+			name:  "Replaces all the placeholders",
+			value: `{"a":{"inline_string":"$(lua)"},"b":[{"c":{"inline_string":"$(lua)"}},{"inline_string":"$(other)"}]}`,
+			ds:    marin3rv1alpha1.DataSourceFromConfigMap{Name: "cm", Key: "k", Placeholder: pointer.New("lua")},
+			want:  `{"a":{"inline_string":"code"},"b":[{"c":{"inline_string":"code"}},{"inline_string":"$(other)"}]}`,
+		},
+		{
+			name:    "Fails if the parent of the path does not exist",
+			value:   `{"name":"lua"}`,
+			ds:      marin3rv1alpha1.DataSourceFromConfigMap{Name: "cm", Key: "k", Path: pointer.New("/typed_config/default_source_code")},
+			wantErr: true,
+		},
+		{
+			name:    "Fails if the placeholder is not found",
+			value:   `{"name":"lua"}`,
+			ds:      marin3rv1alpha1.DataSourceFromConfigMap{Name: "cm", Key: "k", Placeholder: pointer.New("lua")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Inline([]byte(tt.value), tt.ds, source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Inline() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("Inline() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineEnvoyConfig(t *testing.T) {
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "jwks", Namespace: "default"},
+			Data:       map[string]string{"jwks.json": `{"keys":[]}`},
+		},
+	).Build()
+
+	tests := []struct {
+		name    string
+		spec    marin3rv1alpha1.EnvoyConfigSpec
+		want    []marin3rv1alpha1.Resource
+		wantErr bool
+	}{
+		{
+			name: "Inlines the data sources and clears the references",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				Resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster"}`)},
+					{Type: envoy.ExtensionConfig,
+						Value: k8sutil.StringtoRawExtension(`{"name":"jwt","typed_config":{"providers":{"p":{"local_jwks":{"inline_string":"$(jwks)"}}}}}`),
+						DataSourcesFrom: []marin3rv1alpha1.DataSourceFromConfigMap{
+							{Name: "jwks", Key: "jwks.json", Placeholder: pointer.New("jwks")},
+						}},
+				},
+			},
+			want: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster"}`)},
+				{Type: envoy.ExtensionConfig,
+					Value: k8sutil.StringtoRawExtension(`{"name":"jwt","typed_config":{"providers":{"p":{"local_jwks":{"inline_string":"{\"keys\":[]}"}}}}}`)},
+			},
+		},
+		{
+			name: "Fails if the ConfigMap does not exist",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				Resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster"}`),
+						DataSourcesFrom: []marin3rv1alpha1.DataSourceFromConfigMap{
+							{Name: "missing", Key: "key", Path: pointer.New("/x")},
+						}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &marin3rv1alpha1.EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default"}, Spec: tt.spec}
+			err := InlineEnvoyConfig(context.TODO(), cl, ec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("InlineEnvoyConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := deep.Equal(ec.Spec.Resources, tt.want); len(diff) > 0 {
+				t.Errorf("InlineEnvoyConfig() diff = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package envoy
+
+import (
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_annotations "github.com/envoyproxy/go-control-plane/envoy/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Deprecation is the use of a deprecated field or enum value in an envoy resource
+type Deprecation struct {
+	// Name is the full name of the deprecated field or enum value
+	Name string
+	// Path is the path of the deprecated field, or of the field
+	// that holds the deprecated enum value
+	Path string
+	// Version is the Envoy API version where the field or enum value
+	// was deprecated. It is empty if the proto does not specify it.
+	Version string
+}
+
+// Deprecations returns the deprecated fields and enum values in use in the given
+// resource, including the ones in the messages packed in Any fields
+func Deprecations(res envoy.Resource) []Deprecation {
+	deprecations := []Deprecation{}
+
+	walk(res, "", false, func(m proto.Message, path string, _ bool) {
+		m.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			fieldPath := JoinPath(path, string(fd.Name()))
+
+			if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+				deprecations = append(deprecations, Deprecation{
+					Name:    string(fd.FullName()),
+					Path:    fieldPath,
+					Version: proto.GetExtension(opts, envoy_annotations.E_DeprecatedAtMinorVersion).(string),
+				})
+			}
+
+			if fd.Kind() == protoreflect.EnumKind && !fd.IsMap() {
+				values := []protoreflect.EnumNumber{}
+				if fd.IsList() {
+					for i := 0; i < v.List().Len(); i++ {
+						values = append(values, v.List().Get(i).Enum())
+					}
+				} else {
+					values = append(values, v.Enum())
+				}
+				for _, number := range values {
+					ev := fd.Enum().Values().ByNumber(number)
+					if ev == nil {
+						continue
+					}
+					if opts, ok := ev.Options().(*descriptorpb.EnumValueOptions); ok && opts.GetDeprecated() {
+						deprecations = append(deprecations, Deprecation{
+							Name:    string(ev.FullName()),
+							Path:    fieldPath,
+							Version: proto.GetExtension(opts, envoy_annotations.E_DeprecatedAtMinorVersionEnum).(string),
+						})
+					}
+				}
+			}
+			return true
+		})
+	})
+
+	return deprecations
+}
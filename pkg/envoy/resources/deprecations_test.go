@@ -0,0 +1,63 @@
+package envoy
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/go-test/deep"
+)
+
+func TestDeprecations(t *testing.T) {
+	tests := []struct {
+		name  string
+		rType envoy.Type
+		value string
+		want  []Deprecation
+	}{
+		{
+			name:  "No deprecated fields",
+			rType: envoy.Cluster,
+			value: testEdsCluster,
+			want:  []Deprecation{},
+		},
+		{
+			name:  "Deprecated field",
+			rType: envoy.Cluster,
+			value: `{"name":"cluster","type":"STRICT_DNS","max_requests_per_connection":1}`,
+			want: []Deprecation{
+				{Name: "envoy.config.cluster.v3.Cluster.max_requests_per_connection", Path: "max_requests_per_connection", Version: "3.0"},
+			},
+		},
+		{
+			name:  "Deprecated enum value",
+			rType: envoy.Cluster,
+			value: `{"name":"eds","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{},"resource_api_version":"V2"}}}`,
+			want: []Deprecation{
+				{Name: "envoy.config.core.v3.V2", Path: "eds_cluster_config.eds_config.resource_api_version", Version: "3.0"},
+			},
+		},
+		{
+			name:  "Deprecated field within a typed config",
+			rType: envoy.Listener,
+			value: `{"name":"http","filter_chains":[{"filters":[{"name":"envoy.filters.network.http_connection_manager","typed_config":{
+				"@type":"type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+				"stat_prefix":"http","route_config":{"name":"local","virtual_hosts":[{"name":"vh","domains":["*"],
+				"routes":[{"match":{"prefix":"/","headers":[{"name":"x","exact_match":"y"}]},"route":{"cluster":"a"}}]}]}}}]}]}`,
+			want: []Deprecation{
+				{
+					Name:    "envoy.config.route.v3.HeaderMatcher.exact_match",
+					Path:    "filter_chains[0].filters[0].typed_config.route_config.virtual_hosts[0].routes[0].match.headers[0].exact_match",
+					Version: "3.0",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Deprecations(testResource(t, tt.rType, tt.value))
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
+				t.Errorf("Deprecations() diff = %v", diff)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package envoy
 
 import (
+	"sort"
+
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	"google.golang.org/protobuf/proto"
 )
@@ -21,3 +23,30 @@ func ResourcesEqual(a, b map[string]envoy.Resource) bool {
 
 	return true
 }
+
+// ResourcesDiff compares the given maps of "name - resource" pairs using proto.Equal() and
+// returns the sorted names of the resources that are only in b (added), only in a (removed)
+// and in both maps but with different values (changed).
+func ResourcesDiff(a, b map[string]envoy.Resource) (added, removed, changed []string) {
+
+	for name, resource := range b {
+		old, ok := a[name]
+		if !ok {
+			added = append(added, name)
+		} else if !proto.Equal(old, resource) {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+
+	return added, removed, changed
+}
@@ -1,6 +1,7 @@
 package envoy
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/3scale-ops/marin3r/pkg/envoy"
@@ -87,3 +88,51 @@ func Test_ResourcesEqual(t *testing.T) {
 		})
 	}
 }
+
+func Test_ResourcesDiff(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           map[string]envoy.Resource
+		b           map[string]envoy.Resource
+		wantAdded   []string
+		wantRemoved []string
+		wantChanged []string
+	}{
+		{
+			name: "No differences",
+			a:    map[string]envoy.Resource{"cluster1": &envoy_config_cluster_v3.Cluster{Name: "cluster1"}},
+			b:    map[string]envoy.Resource{"cluster1": &envoy_config_cluster_v3.Cluster{Name: "cluster1"}},
+		},
+		{
+			name: "Added, removed and changed resources",
+			a: map[string]envoy.Resource{
+				"cluster1": &envoy_config_cluster_v3.Cluster{Name: "cluster1"},
+				"cluster2": &envoy_config_cluster_v3.Cluster{Name: "cluster2"},
+				"cluster3": &envoy_config_cluster_v3.Cluster{Name: "cluster3"},
+			},
+			b: map[string]envoy.Resource{
+				"cluster1": &envoy_config_cluster_v3.Cluster{Name: "cluster1"},
+				"cluster3": &envoy_config_cluster_v3.Cluster{Name: "cluster3", AltStatName: "changed"},
+				"cluster5": &envoy_config_cluster_v3.Cluster{Name: "cluster5"},
+				"cluster4": &envoy_config_cluster_v3.Cluster{Name: "cluster4"},
+			},
+			wantAdded:   []string{"cluster4", "cluster5"},
+			wantRemoved: []string{"cluster2"},
+			wantChanged: []string{"cluster3"},
+		},
+		{
+			name:      "Everything is added when there were no resources",
+			a:         nil,
+			b:         map[string]envoy.Resource{"endpoint": &envoy_config_endpoint_v3.ClusterLoadAssignment{ClusterName: "endpoint"}},
+			wantAdded: []string{"endpoint"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed, changed := ResourcesDiff(tt.a, tt.b)
+			if !reflect.DeepEqual(added, tt.wantAdded) || !reflect.DeepEqual(removed, tt.wantRemoved) || !reflect.DeepEqual(changed, tt.wantChanged) {
+				t.Errorf("ResourcesDiff() = (%v, %v, %v), want (%v, %v, %v)", added, removed, changed, tt.wantAdded, tt.wantRemoved, tt.wantChanged)
+			}
+		})
+	}
+}
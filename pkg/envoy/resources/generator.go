@@ -14,6 +14,7 @@ type Generator interface {
 	NewGenericSecret(string, string) envoy.Resource
 	NewTlsSecretFromPath(string, string, string) envoy.Resource
 	NewClusterLoadAssignment(string, ...envoy.UpstreamHost) envoy.Resource
+	NewRuntime(string, map[string]string) envoy.Resource
 }
 
 // NewGenerator returns a generator struct for the given API version
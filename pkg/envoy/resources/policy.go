@@ -0,0 +1,73 @@
+package envoy
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// PolicyVariable is the name of the variable that holds the
+// envoy resource in policy expressions
+const PolicyVariable string = "object"
+
+// policyEnv returns the base CEL environment for policy expressions. All the protobuf types
+// linked in the binary are registered so typed configs (google.protobuf.Any) can be traversed.
+var policyEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.TypeDescs(protoregistry.GlobalFiles))
+})
+
+// Expression is a compiled CEL expression that evaluates
+// envoy resources of a given type
+type Expression struct {
+	program cel.Program
+}
+
+// NewExpression compiles a CEL expression for resources of the given type. The resource is available
+// in the expression as the 'object' variable, with the type of the envoy API proto, and the expression
+// must evaluate to a bool.
+func NewExpression(rType envoy.Type, expression string) (*Expression, error) {
+	res := NewGenerator(envoy.APIv3).New(rType)
+	if res == nil {
+		return nil, fmt.Errorf("unsupported resource type '%s'", rType)
+	}
+
+	base, err := policyEnv()
+	if err != nil {
+		return nil, err
+	}
+	env, err := base.Extend(cel.Variable(PolicyVariable, cel.ObjectType(string(proto.MessageName(res)))))
+	if err != nil {
+		return nil, err
+	}
+
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression must evaluate to bool, got %s", ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{program: program}, nil
+}
+
+// Eval evaluates the expression against the given resource
+func (e *Expression) Eval(res envoy.Resource) (bool, error) {
+	out, _, err := e.program.Eval(map[string]any{PolicyVariable: res})
+	if err != nil {
+		return false, err
+	}
+	result, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must evaluate to bool, got %T", out.Value())
+	}
+	return result, nil
+}
@@ -0,0 +1,123 @@
+package envoy
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+)
+
+func TestNewExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		rType      envoy.Type
+		expression string
+		wantErr    bool
+	}{
+		{
+			name:       "Compiles an expression",
+			rType:      envoy.Cluster,
+			expression: `has(object.connect_timeout) && has(object.circuit_breakers)`,
+			wantErr:    false,
+		},
+		{
+			name:       "Fails for unknown fields",
+			rType:      envoy.Cluster,
+			expression: `has(object.unknown)`,
+			wantErr:    true,
+		},
+		{
+			name:       "Fails for non bool expressions",
+			rType:      envoy.Listener,
+			expression: `object.name`,
+			wantErr:    true,
+		},
+		{
+			name:       "Fails for unsupported resource types",
+			rType:      envoy.Type("unknown"),
+			expression: `true`,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewExpression(tt.rType, tt.expression); (err != nil) != tt.wantErr {
+				t.Errorf("NewExpression() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpression_Eval(t *testing.T) {
+	tests := []struct {
+		name       string
+		rType      envoy.Type
+		expression string
+		value      string
+		want       bool
+		wantErr    bool
+	}{
+		{
+			name:       "Cluster without circuit breakers",
+			rType:      envoy.Cluster,
+			expression: `has(object.connect_timeout) && has(object.circuit_breakers)`,
+			value:      `{"name":"cluster","connect_timeout":"1s"}`,
+			want:       false,
+		},
+		{
+			name:       "Cluster with connect timeout and circuit breakers",
+			rType:      envoy.Cluster,
+			expression: `has(object.connect_timeout) && has(object.circuit_breakers)`,
+			value:      `{"name":"cluster","connect_timeout":"1s","circuit_breakers":{"thresholds":[{"max_connections":100}]}}`,
+			want:       true,
+		},
+		{
+			name:       "Listener bound to an allowed port",
+			rType:      envoy.Listener,
+			expression: `object.address.socket_address.port_value in [8080u, 8443u]`,
+			value:      testHttpListener,
+			want:       true,
+		},
+		{
+			name:       "Listener bound to a forbidden port",
+			rType:      envoy.Listener,
+			expression: `object.address.socket_address.port_value in [8080u, 8443u]`,
+			value:      testTcpListener,
+			want:       false,
+		},
+		{
+			name:  "Http filters within typed configs",
+			rType: envoy.Listener,
+			expression: `!object.filter_chains.exists(fc, fc.filters.exists(f, f.name == 'envoy.filters.network.http_connection_manager' &&
+				f.typed_config.http_filters.exists(hf, hf.name == 'envoy.filters.http.lua')))`,
+			value: `{"name":"http","filter_chains":[{"filters":[{"name":"envoy.filters.network.http_connection_manager","typed_config":{
+				"@type":"type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+				"stat_prefix":"http","route_config":{"name":"local"},"http_filters":[
+				{"name":"envoy.filters.http.lua","typed_config":{"@type":"type.googleapis.com/envoy.extensions.filters.http.lua.v3.Lua"}},
+				{"name":"envoy.filters.http.router","typed_config":{"@type":"type.googleapis.com/envoy.extensions.filters.http.router.v3.Router"}}]}}]}]}`,
+			want: false,
+		},
+		{
+			name:       "Runtime error",
+			rType:      envoy.Listener,
+			expression: `object.filter_chains[1].name == ''`,
+			value:      testTcpListener,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewExpression(tt.rType, tt.expression)
+			if err != nil {
+				t.Fatalf("NewExpression() error = %v", err)
+			}
+			got, err := e.Eval(testResource(t, tt.rType, tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expression.Eval() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Expression.Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package envoy
+
+import (
+	"fmt"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_extensions_filters_network_http_connection_manager_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_extensions_filters_network_tcp_proxy_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+// Reference is a reference from an envoy resource to another resource by name
+type Reference struct {
+	// Type is the type of the referenced resource
+	Type envoy.Type
+	// Name is the name of the referenced resource
+	Name string
+	// Path is the path of the field that holds the reference
+	Path string
+}
+
+// Definition is a resource that can be referenced by other resources. Resource holds the
+// envoy resource, if it is known, and Name the name of the resource if Resource is nil, as
+// happens with the resources that are generated from other Kubernetes objects. Path is the
+// path of the resource definition, which is prepended to the paths of its references.
+type Definition struct {
+	Type     envoy.Type
+	Name     string
+	Resource envoy.Resource
+	Path     string
+}
+
+// References returns the references to other resources found in the given resource: the clusters
+// referenced by routes and tcp proxies, the route configurations loaded through RDS, the secrets
+// loaded through SDS and the endpoints of EDS clusters.
+func References(res envoy.Resource) []Reference {
+	refs := []Reference{}
+	add := func(rType envoy.Type, name, path string) {
+		if name != "" {
+			refs = append(refs, Reference{Type: rType, Name: name, Path: path})
+		}
+	}
+
+	walk(res, "", false, func(m proto.Message, path string, _ bool) {
+		switch o := m.(type) {
+		case *envoy_config_route_v3.RouteAction:
+			add(envoy.Cluster, o.GetCluster(), JoinPath(path, "cluster"))
+			for i, wc := range o.GetWeightedClusters().GetClusters() {
+				add(envoy.Cluster, wc.GetName(), fmt.Sprintf("%s.weighted_clusters.clusters[%d].name", path, i))
+			}
+		case *envoy_extensions_filters_network_tcp_proxy_v3.TcpProxy:
+			add(envoy.Cluster, o.GetCluster(), JoinPath(path, "cluster"))
+			for i, wc := range o.GetWeightedClusters().GetClusters() {
+				add(envoy.Cluster, wc.GetName(), fmt.Sprintf("%s.weighted_clusters.clusters[%d].name", path, i))
+			}
+		case *envoy_extensions_filters_network_http_connection_manager_v3.Rds:
+			add(envoy.Route, o.GetRouteConfigName(), JoinPath(path, "route_config_name"))
+		case *envoy_extensions_transport_sockets_tls_v3.SdsSecretConfig:
+			add(envoy.Secret, o.GetName(), JoinPath(path, "name"))
+		case *envoy_config_cluster_v3.Cluster:
+			if o.GetType() == envoy_config_cluster_v3.Cluster_EDS {
+				if name := o.GetEdsClusterConfig().GetServiceName(); name != "" {
+					add(envoy.Endpoint, name, JoinPath(path, "eds_cluster_config.service_name"))
+				} else {
+					add(envoy.Endpoint, o.GetName(), JoinPath(path, "name"))
+				}
+			}
+		}
+	})
+
+	return refs
+}
+
+// DanglingReferences returns the references of the given resources to resources that
+// are not defined. The paths of the returned references include the path of the
+// definition of the referencing resource.
+func DanglingReferences(definitions []Definition) []Reference {
+	defined := map[envoy.Type]map[string]bool{}
+	for _, def := range definitions {
+		name := def.Name
+		if def.Resource != nil {
+			name = cache_v3.GetResourceName(def.Resource)
+		}
+		if _, ok := defined[def.Type]; !ok {
+			defined[def.Type] = map[string]bool{}
+		}
+		defined[def.Type][name] = true
+	}
+
+	dangling := []Reference{}
+	for _, def := range definitions {
+		if def.Resource == nil {
+			continue
+		}
+		for _, ref := range References(def.Resource) {
+			if !defined[ref.Type][ref.Name] {
+				ref.Path = JoinPath(def.Path, ref.Path)
+				dangling = append(dangling, ref)
+			}
+		}
+	}
+	return dangling
+}
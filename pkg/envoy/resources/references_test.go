@@ -0,0 +1,107 @@
+package envoy
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/go-test/deep"
+)
+
+func testResource(t *testing.T, rType envoy.Type, value string) envoy.Resource {
+	res := NewGenerator(envoy.APIv3).New(rType)
+	if err := envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3).Unmarshal(value, res); err != nil {
+		t.Fatalf("unable to decode resource: %v", err)
+	}
+	return res
+}
+
+const (
+	testHttpListener = `{"name":"http","address":{"socket_address":{"address":"0.0.0.0","port_value":8443}},
+		"filter_chains":[{"filters":[{"name":"envoy.filters.network.http_connection_manager","typed_config":{
+			"@type":"type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+			"stat_prefix":"http","rds":{"route_config_name":"local","config_source":{"ads":{},"resource_api_version":"V3"}},
+			"http_filters":[{"name":"envoy.filters.http.router","typed_config":{"@type":"type.googleapis.com/envoy.extensions.filters.http.router.v3.Router"}}]}}],
+		"transport_socket":{"name":"envoy.transport_sockets.tls","typed_config":{
+			"@type":"type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.DownstreamTlsContext",
+			"common_tls_context":{"tls_certificate_sds_secret_configs":[{"name":"cert","sds_config":{"ads":{},"resource_api_version":"V3"}}]}}}}]}`
+	testTcpListener = `{"name":"tcp","address":{"socket_address":{"address":"0.0.0.0","port_value":9000}},
+		"filter_chains":[{"filters":[{"name":"envoy.filters.network.tcp_proxy","typed_config":{
+			"@type":"type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy","stat_prefix":"tcp","cluster":"backend"}}]}]}`
+	testRoute = `{"name":"local","virtual_hosts":[{"name":"vh","domains":["*"],"routes":[
+		{"match":{"prefix":"/a"},"route":{"cluster":"a"}},
+		{"match":{"prefix":"/"},"route":{"weighted_clusters":{"clusters":[{"name":"b","weight":50},{"name":"c","weight":50}]}}}]}]}`
+	testEdsCluster = `{"name":"eds","type":"EDS","eds_cluster_config":{"eds_config":{"ads":{},"resource_api_version":"V3"}}}`
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		rType envoy.Type
+		value string
+		want  []Reference
+	}{
+		{
+			name:  "Listener with RDS and SDS",
+			rType: envoy.Listener,
+			value: testHttpListener,
+			want: []Reference{
+				{Type: envoy.Route, Name: "local", Path: "filter_chains[0].filters[0].typed_config.rds.route_config_name"},
+				{Type: envoy.Secret, Name: "cert", Path: "filter_chains[0].transport_socket.typed_config.common_tls_context.tls_certificate_sds_secret_configs[0].name"},
+			},
+		},
+		{
+			name:  "Listener with a tcp proxy",
+			rType: envoy.Listener,
+			value: testTcpListener,
+			want:  []Reference{{Type: envoy.Cluster, Name: "backend", Path: "filter_chains[0].filters[0].typed_config.cluster"}},
+		},
+		{
+			name:  "Route configuration",
+			rType: envoy.Route,
+			value: testRoute,
+			want: []Reference{
+				{Type: envoy.Cluster, Name: "a", Path: "virtual_hosts[0].routes[0].route.cluster"},
+				{Type: envoy.Cluster, Name: "b", Path: "virtual_hosts[0].routes[1].route.weighted_clusters.clusters[0].name"},
+				{Type: envoy.Cluster, Name: "c", Path: "virtual_hosts[0].routes[1].route.weighted_clusters.clusters[1].name"},
+			},
+		},
+		{
+			name:  "EDS cluster",
+			rType: envoy.Cluster,
+			value: testEdsCluster,
+			want:  []Reference{{Type: envoy.Endpoint, Name: "eds", Path: "name"}},
+		},
+		{
+			name:  "Static cluster",
+			rType: envoy.Cluster,
+			value: `{"name":"static","type":"STATIC"}`,
+			want:  []Reference{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := deep.Equal(References(testResource(t, tt.rType, tt.value)), tt.want); len(diff) > 0 {
+				t.Errorf("References() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestDanglingReferences(t *testing.T) {
+	definitions := []Definition{
+		{Type: envoy.Listener, Resource: testResource(t, envoy.Listener, testHttpListener), Path: "spec.resources[0].value"},
+		{Type: envoy.Route, Resource: testResource(t, envoy.Route, testRoute), Path: "spec.resources[1].value"},
+		{Type: envoy.Cluster, Resource: testResource(t, envoy.Cluster, `{"name":"a"}`), Path: "spec.resources[2].value"},
+		{Type: envoy.Cluster, Resource: testResource(t, envoy.Cluster, `{"name":"b"}`), Path: "spec.resources[3].value"},
+		{Type: envoy.Cluster, Resource: testResource(t, envoy.Cluster, testEdsCluster), Path: "spec.resources[4].value"},
+		{Type: envoy.Secret, Name: "cert", Path: "spec.resources[5]"},
+	}
+	want := []Reference{
+		{Type: envoy.Cluster, Name: "c", Path: "spec.resources[1].value.virtual_hosts[0].routes[1].route.weighted_clusters.clusters[1].name"},
+		{Type: envoy.Endpoint, Name: "eds", Path: "spec.resources[4].value.name"},
+	}
+	if diff := deep.Equal(DanglingReferences(definitions), want); len(diff) > 0 {
+		t.Errorf("DanglingReferences() diff = %v", diff)
+	}
+}
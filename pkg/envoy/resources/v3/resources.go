@@ -1,6 +1,11 @@
 package envoy
 
 import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_config_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -9,6 +14,8 @@ import (
 	envoy_config_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Generator returns a strcut that implements the envoy_resources.Generator
@@ -116,6 +123,61 @@ func (g Generator) NewTlsSecretFromPath(name, certificateChainPath, privateKeyPa
 	}
 }
 
+// NewRuntime generates a new envoy runtime layer from a set of keys. Values are coerced
+// to booleans ("true", "false"), numbers ("10", "0.5") and fractional percents ("25%",
+// "0.01%"). Any other value is kept as a string.
+func (g Generator) NewRuntime(name string, values map[string]string) envoy.Resource {
+	fields := make(map[string]*structpb.Value, len(values))
+	for k, v := range values {
+		fields[k] = runtimeValue(v)
+	}
+	return &envoy_service_runtime_v3.Runtime{
+		Name:  name,
+		Layer: &structpb.Struct{Fields: fields},
+	}
+}
+
+var (
+	runtimeNumberRegexp  = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	runtimePercentRegexp = regexp.MustCompile(`^[0-9]+(\.([0-9]{1,4}))?%$`)
+)
+
+// runtimeValue coerces a string to the type of value it represents
+func runtimeValue(v string) *structpb.Value {
+	switch {
+	case v == "true" || v == "false":
+		return structpb.NewBoolValue(v == "true")
+
+	case runtimeNumberRegexp.MatchString(v):
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return structpb.NewNumberValue(f)
+		}
+
+	case runtimePercentRegexp.MatchString(v):
+		// use the smallest denominator that represents the percent without loss
+		digits := runtimePercentRegexp.FindStringSubmatch(v)[2]
+		numerator, err := strconv.ParseUint(strings.Replace(strings.TrimSuffix(v, "%"), ".", "", 1), 10, 32)
+		if err != nil {
+			break
+		}
+		denominator := envoy_type_v3.FractionalPercent_HUNDRED
+		switch {
+		case len(digits) > 2:
+			denominator = envoy_type_v3.FractionalPercent_MILLION
+			numerator *= uint64(math.Pow10(4 - len(digits)))
+		case len(digits) > 0:
+			denominator = envoy_type_v3.FractionalPercent_TEN_THOUSAND
+			numerator *= uint64(math.Pow10(2 - len(digits)))
+		}
+		return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"numerator":   structpb.NewNumberValue(float64(numerator)),
+			"denominator": structpb.NewStringValue(denominator.String()),
+		}})
+	}
+
+	return structpb.NewStringValue(v)
+}
+
 func (g Generator) NewClusterLoadAssignment(clusterName string, hosts ...envoy.UpstreamHost) envoy.Resource {
 
 	return &envoy_config_endpoint_v3.ClusterLoadAssignment{
@@ -19,7 +19,9 @@ import (
 
 	envoy_config_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_extensions_transport_sockets_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	envoy_service_runtime_v3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func TestSecretGenerator_New(t *testing.T) {
@@ -66,3 +68,56 @@ func TestSecretGenerator_New(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerator_NewRuntime(t *testing.T) {
+	percent := func(numerator float64, denominator string) *structpb.Value {
+		return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{
+			"numerator":   structpb.NewNumberValue(numerator),
+			"denominator": structpb.NewStringValue(denominator),
+		}})
+	}
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   map[string]*structpb.Value
+	}{
+		{
+			name:   "Coerces booleans",
+			values: map[string]string{"a": "true", "b": "false", "c": "True"},
+			want: map[string]*structpb.Value{
+				"a": structpb.NewBoolValue(true),
+				"b": structpb.NewBoolValue(false),
+				"c": structpb.NewStringValue("True"),
+			},
+		},
+		{
+			name:   "Coerces numbers",
+			values: map[string]string{"a": "10", "b": "-0.5", "c": "1e3", "d": "10s"},
+			want: map[string]*structpb.Value{
+				"a": structpb.NewNumberValue(10),
+				"b": structpb.NewNumberValue(-0.5),
+				"c": structpb.NewStringValue("1e3"),
+				"d": structpb.NewStringValue("10s"),
+			},
+		},
+		{
+			name:   "Coerces fractional percents",
+			values: map[string]string{"a": "25%", "b": "0.5%", "c": "12.25%", "d": "0.001%", "e": "0.00001%"},
+			want: map[string]*structpb.Value{
+				"a": percent(25, "HUNDRED"),
+				"b": percent(50, "TEN_THOUSAND"),
+				"c": percent(1225, "TEN_THOUSAND"),
+				"d": percent(10, "MILLION"),
+				"e": structpb.NewStringValue("0.00001%"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &envoy_service_runtime_v3.Runtime{Name: "runtime", Layer: &structpb.Struct{Fields: tt.want}}
+			if got := (Generator{}).NewRuntime("runtime", tt.values); !proto.Equal(got, want) {
+				t.Errorf("Generator.NewRuntime() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package envoy
 
 import (
+	"fmt"
+
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"google.golang.org/protobuf/proto"
 )
 
 func Validate(resource string, encoding envoy_serializer.Serialization, version envoy.APIVersion, rType envoy.Type) error {
@@ -13,5 +17,37 @@ func Validate(resource string, encoding envoy_serializer.Serialization, version
 		return err
 	}
 
+	if err := validateRules(res); err != nil {
+		return err
+	}
+
+	if name := cache_v3.GetResourceName(res); IsXdstpName(name) {
+		if err := ValidateXdstpName(name, rType, version); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// validateRules runs the validation rules defined in the envoy protos for the
+// resource and for the messages packed in Any fields within the resource, which
+// are not validated along with the message that holds them
+func validateRules(res envoy.Resource) error {
+	var err error
+	walk(res, "", true, func(m proto.Message, path string, unpacked bool) {
+		if err != nil || !unpacked {
+			return
+		}
+		if v, ok := m.(interface{ ValidateAll() error }); ok {
+			if verr := v.ValidateAll(); verr != nil {
+				if path == "" {
+					err = verr
+				} else {
+					err = fmt.Errorf("%s: %w", path, verr)
+				}
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,52 @@
+package envoy
+
+import (
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rType   envoy.Type
+		value   string
+		wantErr bool
+	}{
+		{
+			name:    "Valid resource",
+			rType:   envoy.Listener,
+			value:   testHttpListener,
+			wantErr: false,
+		},
+		{
+			name:    "Does not unmarshal",
+			rType:   envoy.Cluster,
+			value:   `{"giberish":"cluster"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Violates the rules of the resource",
+			rType:   envoy.Route,
+			value:   `{"name":"local","virtual_hosts":[{"name":"vh","domains":[]}]}`,
+			wantErr: true,
+		},
+		{
+			name:  "Violates the rules of a typed config",
+			rType: envoy.Listener,
+			value: `{"name":"http","address":{"socket_address":{"address":"0.0.0.0","port_value":8080}},
+				"filter_chains":[{"filters":[{"name":"envoy.filters.network.http_connection_manager","typed_config":{
+				"@type":"type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+				"rds":{"route_config_name":"local","config_source":{"ads":{}}}}}]}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.value, envoy_serializer.JSON, envoy.APIv3, tt.rType); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
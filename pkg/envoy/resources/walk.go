@@ -0,0 +1,54 @@
+package envoy
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// walk calls fn for the given message and for each of the messages nested in it,
+// along with the path of the nested message. Messages packed in Any fields, like
+// the typed configs of the extensions, are unpacked and walked too, in which case
+// fn is called with 'unpacked' set to true. Any fields with messages of unknown
+// types are skipped.
+func walk(m proto.Message, path string, unpacked bool, fn func(m proto.Message, path string, unpacked bool)) {
+	if a, ok := m.(*anypb.Any); ok {
+		inner, err := a.UnmarshalNew()
+		if err != nil {
+			return
+		}
+		walk(inner, path, true, fn)
+		return
+	}
+
+	fn(m, path, unpacked)
+
+	m.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		fieldPath := JoinPath(path, string(fd.Name()))
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				walk(list.Get(i).Message().Interface(), fmt.Sprintf("%s[%d]", fieldPath, i), false, fn)
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				walk(mv.Message().Interface(), fmt.Sprintf("%s[%s]", fieldPath, k.String()), false, fn)
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			walk(v.Message().Interface(), fieldPath, false, fn)
+		}
+		return true
+	})
+}
+
+// JoinPath appends a field to a path of nested proto fields
+func JoinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
@@ -0,0 +1,125 @@
+package envoy
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+)
+
+const (
+	// XdstpScheme is the scheme of the xDS transport protocol resource names
+	XdstpScheme string = "xdstp"
+	// XdstpGlob is the last path segment of the names that refer
+	// to all the resources within a collection
+	XdstpGlob string = "*"
+)
+
+// XdstpName represents a resource name in the format:
+//
+//	xdstp://{authority}/{resource type}/{id}?{context parameters}
+//
+// See https://github.com/cncf/xds/blob/main/proposals/TP1-xds-transport-next.md
+type XdstpName struct {
+	Authority     string
+	ResourceType  string
+	ID            string
+	ContextParams url.Values
+}
+
+// IsXdstpName returns true if the given name uses the xdstp:// scheme
+func IsXdstpName(name string) bool {
+	return strings.HasPrefix(name, XdstpScheme+"://")
+}
+
+// ParseXdstpName parses an xdstp:// resource name
+func ParseXdstpName(name string) (*XdstpName, error) {
+	if !IsXdstpName(name) {
+		return nil, fmt.Errorf("'%s' is not an xdstp name", name)
+	}
+
+	u, err := url.Parse(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse xdstp name '%s': %w", name, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("xdstp name '%s' has no authority", name)
+	}
+	if u.Fragment != "" {
+		return nil, fmt.Errorf("xdstp name '%s' cannot have a fragment", name)
+	}
+
+	rType, id, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if rType == "" || id == "" {
+		return nil, fmt.Errorf("xdstp name '%s' must be in the form 'xdstp://{authority}/{resource type}/{id}'", name)
+	}
+
+	return &XdstpName{
+		Authority:     u.Host,
+		ResourceType:  rType,
+		ID:            id,
+		ContextParams: u.Query(),
+	}, nil
+}
+
+// String returns the canonical representation of the name, with
+// the context parameters sorted by key
+func (n *XdstpName) String() string {
+	s := fmt.Sprintf("%s://%s/%s/%s", XdstpScheme, n.Authority, n.ResourceType, n.ID)
+	if len(n.ContextParams) > 0 {
+		keys := make([]string, 0, len(n.ContextParams))
+		for k := range n.ContextParams {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		params := []string{}
+		for _, k := range keys {
+			for _, v := range n.ContextParams[k] {
+				params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+			}
+		}
+		s = s + "?" + strings.Join(params, "&")
+	}
+	return s
+}
+
+// IsGlob returns true if the name refers to all the resources of a collection
+func (n *XdstpName) IsGlob() bool {
+	return n.ID == XdstpGlob || strings.HasSuffix(n.ID, "/"+XdstpGlob)
+}
+
+// Matches returns true if the given name is equal to this one or, if this
+// name is a glob, if the given name belongs to the collection.
+func (n *XdstpName) Matches(other *XdstpName) bool {
+	if n.Authority != other.Authority || n.ResourceType != other.ResourceType ||
+		n.ContextParams.Encode() != other.ContextParams.Encode() {
+		return false
+	}
+
+	if !n.IsGlob() {
+		return n.ID == other.ID
+	}
+
+	// only direct children of the collection are matched
+	collection := strings.TrimSuffix(n.ID, XdstpGlob)
+	id, ok := strings.CutPrefix(other.ID, collection)
+	return ok && id != "" && id != XdstpGlob && !strings.Contains(id, "/")
+}
+
+// ValidateXdstpName validates that the given xdstp name is well formed
+// and that the resource type matches the given one
+func ValidateXdstpName(name string, rType envoy.Type, version envoy.APIVersion) error {
+	n, err := ParseXdstpName(name)
+	if err != nil {
+		return err
+	}
+	if n.IsGlob() {
+		return fmt.Errorf("xdstp name '%s' of a resource cannot be a glob", name)
+	}
+	if expected := strings.TrimPrefix(TypeURL(rType, version), "type.googleapis.com/"); n.ResourceType != expected {
+		return fmt.Errorf("xdstp name '%s' has resource type '%s', expected '%s'", name, n.ResourceType, expected)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package envoy
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+)
+
+func TestParseXdstpName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *XdstpName
+		wantErr bool
+	}{
+		{
+			name:  "Parses an xdstp name",
+			input: "xdstp://authority.example.com/envoy.config.cluster.v3.Cluster/foo/bar",
+			want: &XdstpName{
+				Authority:     "authority.example.com",
+				ResourceType:  "envoy.config.cluster.v3.Cluster",
+				ID:            "foo/bar",
+				ContextParams: url.Values{},
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Parses an xdstp name with context params",
+			input: "xdstp://authority.example.com/envoy.config.cluster.v3.Cluster/foo?b=2&a=1",
+			want: &XdstpName{
+				Authority:     "authority.example.com",
+				ResourceType:  "envoy.config.cluster.v3.Cluster",
+				ID:            "foo",
+				ContextParams: url.Values{"a": []string{"1"}, "b": []string{"2"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Fails if the name does not use the xdstp scheme",
+			input:   "cluster",
+			wantErr: true,
+		},
+		{
+			name:    "Fails if the name has no authority",
+			input:   "xdstp:///envoy.config.cluster.v3.Cluster/foo",
+			wantErr: true,
+		},
+		{
+			name:    "Fails if the name has no id",
+			input:   "xdstp://authority/envoy.config.cluster.v3.Cluster",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseXdstpName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseXdstpName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseXdstpName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXdstpName_String(t *testing.T) {
+	n, _ := ParseXdstpName("xdstp://authority/envoy.config.cluster.v3.Cluster/foo?b=2&a=1")
+	if got, want := n.String(), "xdstp://authority/envoy.config.cluster.v3.Cluster/foo?a=1&b=2"; got != want {
+		t.Errorf("XdstpName.String() = %v, want %v", got, want)
+	}
+}
+
+func TestXdstpName_Matches(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     string
+		other string
+		want  bool
+	}{
+		{
+			name:  "Matches the same name",
+			n:     "xdstp://authority/envoy.config.cluster.v3.Cluster/foo",
+			other: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo",
+			want:  true,
+		},
+		{
+			name:  "Matches with context params in different order",
+			n:     "xdstp://authority/envoy.config.cluster.v3.Cluster/foo?a=1&b=2",
+			other: "xdstp://authority/envoy.config.cluster.v3.Cluster/foo?b=2&a=1",
+			want:  true,
+		},
+		{
+			name:  "Does not match different authorities",
+			n:     "xdstp://authority/envoy.config.cluster.v3.Cluster/foo",
+			other: "xdstp://other/envoy.config.cluster.v3.Cluster/foo",
+			want:  false,
+		},
+		{
+			name:  "Glob matches resources in the collection",
+			n:     "xdstp://authority/envoy.config.listener.v3.Listener/foo/*",
+			other: "xdstp://authority/envoy.config.listener.v3.Listener/foo/bar",
+			want:  true,
+		},
+		{
+			name:  "Glob does not match resources in nested collections",
+			n:     "xdstp://authority/envoy.config.listener.v3.Listener/foo/*",
+			other: "xdstp://authority/envoy.config.listener.v3.Listener/foo/bar/baz",
+			want:  false,
+		},
+		{
+			name:  "Glob does not match resources with different context params",
+			n:     "xdstp://authority/envoy.config.listener.v3.Listener/*?a=1",
+			other: "xdstp://authority/envoy.config.listener.v3.Listener/foo",
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, _ := ParseXdstpName(tt.n)
+			other, _ := ParseXdstpName(tt.other)
+			if got := n.Matches(other); got != tt.want {
+				t.Errorf("XdstpName.Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateXdstpName(t *testing.T) {
+	type args struct {
+		name    string
+		rType   envoy.Type
+		version envoy.APIVersion
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "Validates a correct name",
+			args:    args{"xdstp://authority/envoy.config.cluster.v3.Cluster/foo", envoy.Cluster, envoy.APIv3},
+			wantErr: false,
+		},
+		{
+			name:    "Fails if the resource type does not match",
+			args:    args{"xdstp://authority/envoy.config.listener.v3.Listener/foo", envoy.Cluster, envoy.APIv3},
+			wantErr: true,
+		},
+		{
+			name:    "Fails if the name is a glob",
+			args:    args{"xdstp://authority/envoy.config.cluster.v3.Cluster/*", envoy.Cluster, envoy.APIv3},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateXdstpName(tt.args.name, tt.args.rType, tt.args.version); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateXdstpName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
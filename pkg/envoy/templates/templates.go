@@ -0,0 +1,129 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Parameters returns the template parameters of the EnvoyConfig, merging the keys of
+// the ConfigMaps and Secrets in spec.parametersFrom, in order, and spec.parameters.
+func Parameters(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) (map[string]string, error) {
+	params := map[string]string{}
+
+	for idx, src := range ec.Spec.ParametersFrom {
+		switch {
+		case src.ConfigMapRef != nil:
+			cm := &corev1.ConfigMap{}
+			key := types.NamespacedName{Name: src.ConfigMapRef.Name, Namespace: ec.GetNamespace()}
+			if err := cl.Get(ctx, key, cm); err != nil {
+				return nil, fmt.Errorf("unable to get ConfigMap '%s' for spec.parametersFrom[%d]: %w", key.Name, idx, err)
+			}
+			for k, v := range cm.Data {
+				params[k] = v
+			}
+
+		case src.SecretRef != nil:
+			secret := &corev1.Secret{}
+			key := types.NamespacedName{Name: src.SecretRef.Name, Namespace: ec.GetNamespace()}
+			if err := cl.Get(ctx, key, secret); err != nil {
+				return nil, fmt.Errorf("unable to get Secret '%s' for spec.parametersFrom[%d]: %w", key.Name, idx, err)
+			}
+			for k, v := range secret.Data {
+				params[k] = string(v)
+			}
+		}
+	}
+
+	for k, v := range ec.Spec.Parameters {
+		params[k] = v
+	}
+
+	return params, nil
+}
+
+// SecretValues returns the values of the keys of the Secrets in spec.parametersFrom,
+// so they can be redacted from the rendered resources when these are displayed
+func SecretValues(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) ([]string, error) {
+	values := []string{}
+
+	for idx, src := range ec.Spec.ParametersFrom {
+		if src.SecretRef == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{Name: src.SecretRef.Name, Namespace: ec.GetNamespace()}
+		if err := cl.Get(ctx, key, secret); err != nil {
+			return nil, fmt.Errorf("unable to get Secret '%s' for spec.parametersFrom[%d]: %w", key.Name, idx, err)
+		}
+		for _, v := range secret.Data {
+			if len(v) > 0 {
+				values = append(values, string(v))
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// Render renders the value of each resource as a Go template with the given parameters. Referencing
+// a parameter that does not exist is an error, as is a rendered value that is not valid JSON.
+func Render(resources []marin3rv1alpha1.Resource, params map[string]string) ([]marin3rv1alpha1.Resource, error) {
+	rendered := make([]marin3rv1alpha1.Resource, 0, len(resources))
+
+	for idx, res := range resources {
+		res := *res.DeepCopy()
+		if res.Value != nil {
+			name := fmt.Sprintf("spec.resources[%d].value", idx)
+			tpl, err := template.New(name).Option("missingkey=error").Parse(string(res.Value.Raw))
+			if err != nil {
+				return nil, err
+			}
+			b := bytes.Buffer{}
+			if err := tpl.Execute(&b, params); err != nil {
+				return nil, err
+			}
+			if !json.Valid(b.Bytes()) {
+				return nil, fmt.Errorf("%s: the rendered value is not valid JSON", name)
+			}
+			res.Value = &runtime.RawExtension{Raw: b.Bytes()}
+		}
+		rendered = append(rendered, res)
+	}
+
+	return rendered, nil
+}
+
+// RenderEnvoyConfig renders the resources of a templated EnvoyConfig. The rendered
+// resources replace spec.resources in the given object, which is not persisted.
+func RenderEnvoyConfig(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) error {
+	if !ec.IsTemplated() {
+		return nil
+	}
+
+	params, err := Parameters(ctx, cl, ec)
+	if err != nil {
+		return err
+	}
+
+	resources, err := ec.GetResources()
+	if err != nil {
+		return err
+	}
+
+	rendered, err := Render(resources, params)
+	if err != nil {
+		return err
+	}
+	ec.Spec.Resources = rendered
+	ec.Spec.EnvoyResources = nil
+	return nil
+}
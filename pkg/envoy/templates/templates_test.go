@@ -0,0 +1,175 @@
+package templates
+
+import (
+	"context"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-test/deep"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func init() {
+	marin3rv1alpha1.AddToScheme(scheme.Scheme)
+}
+
+func testClient() client.Client {
+	return fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "params", Namespace: "default"},
+			Data:       map[string]string{"host": "cm-host", "timeout": "1s"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "overrides", Namespace: "default"},
+			Data:       map[string]string{"host": "override-host", "port": "8080"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "params", Namespace: "default"},
+			Data:       map[string][]byte{"host": []byte("secret-host"), "token": []byte("xxxx")},
+		},
+	).Build()
+}
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    marin3rv1alpha1.EnvoyConfigSpec
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Merges the sources in order",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				Parameters: map[string]string{"timeout": "5s"},
+				ParametersFrom: []marin3rv1alpha1.ParametersSource{
+					{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}},
+					{ConfigMapRef: &corev1.LocalObjectReference{Name: "overrides"}},
+				},
+			},
+			want: map[string]string{"host": "override-host", "timeout": "5s", "port": "8080"},
+		},
+		{
+			name: "Takes parameters from Secrets",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				ParametersFrom: []marin3rv1alpha1.ParametersSource{
+					{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}},
+					{SecretRef: &corev1.LocalObjectReference{Name: "params"}},
+				},
+			},
+			want: map[string]string{"host": "secret-host", "timeout": "1s", "token": "xxxx"},
+		},
+		{
+			name: "Fails if a source does not exist",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				ParametersFrom: []marin3rv1alpha1.ParametersSource{
+					{ConfigMapRef: &corev1.LocalObjectReference{Name: "missing"}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &marin3rv1alpha1.EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default"}, Spec: tt.spec}
+			got, err := Parameters(context.TODO(), testClient(), ec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parameters() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
+				t.Errorf("Parameters() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	params := map[string]string{"name": "cluster", "timeout": "1s"}
+	tests := []struct {
+		name      string
+		resources []marin3rv1alpha1.Resource
+		want      []marin3rv1alpha1.Resource
+		wantErr   bool
+	}{
+		{
+			name: "Renders the values",
+			resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"{{ .name }}","connect_timeout":"{{ .timeout }}"}`)},
+				{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("cert")},
+			},
+			want: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"cluster","connect_timeout":"1s"}`)},
+				{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("cert")},
+			},
+		},
+		{
+			name: "Fails for missing parameters",
+			resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"{{ .unknown }}"}`)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fails if the rendered value is not JSON",
+			resources: []marin3rv1alpha1.Resource{
+				{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"{{ .name }}}`)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.resources, params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
+				t.Errorf("Render() diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestRenderEnvoyConfig(t *testing.T) {
+	value := `{"name":"{{ .host }}"}`
+	tests := []struct {
+		name string
+		spec marin3rv1alpha1.EnvoyConfigSpec
+		want string
+	}{
+		{
+			name: "Does not render EnvoyConfigs without parameters",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(value)}},
+			},
+			want: value,
+		},
+		{
+			name: "Renders templated EnvoyConfigs",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{
+				ParametersFrom: []marin3rv1alpha1.ParametersSource{{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}}},
+				Resources:      []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(value)}},
+			},
+			want: `{"name":"cm-host"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &marin3rv1alpha1.EnvoyConfig{ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default"}, Spec: tt.spec}
+			if err := RenderEnvoyConfig(context.TODO(), testClient(), ec); err != nil {
+				t.Fatalf("RenderEnvoyConfig() error = %v", err)
+			}
+			if got := string(ec.Spec.Resources[0].Value.Raw); got != tt.want {
+				t.Errorf("RenderEnvoyConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package reconcilers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// maxArchiveSize is the maximum size of the data stored in the archive
+	// ConfigMap, leaving some room below the 1MiB limit of ConfigMaps
+	maxArchiveSize int = 900 << 10
+	// archiveKeySuffix is the suffix of the keys in the archive ConfigMap
+	archiveKeySuffix string = ".json.gz"
+)
+
+// archiveRevisions stores the given revisions in the revision archive ConfigMap of the
+// EnvoyConfig. Each revision is stored as gzip compressed json under a key that starts with
+// the unix time of the archival so the oldest entries can be removed first when the
+// archive exceeds its maximum number of revisions or size.
+func (r *RevisionReconciler) archiveRevisions(ecrs []marin3rv1alpha1.EnvoyConfigRevision, now time.Time) error {
+	cm := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: r.Instance().GetRevisionArchiveConfigMapName(), Namespace: r.Namespace()}
+	create := false
+	if err := r.client.Get(r.ctx, key, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		create = true
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace}}
+		if err := controllerutil.SetControllerReference(r.Instance(), cm, r.scheme); err != nil {
+			return err
+		}
+	}
+
+	if cm.BinaryData == nil {
+		cm.BinaryData = map[string][]byte{}
+	}
+	for _, ecr := range ecrs {
+		data, err := compressRevision(ecr)
+		if err != nil {
+			return err
+		}
+		cm.BinaryData[fmt.Sprintf("%d-%s%s", now.Unix(), ecr.Spec.Version, archiveKeySuffix)] = data
+	}
+	trimArchive(cm.BinaryData, r.Instance().GetMaxArchivedRevisions(), maxArchiveSize)
+
+	if create {
+		return r.client.Create(r.ctx, cm)
+	}
+	return r.client.Update(r.ctx, cm)
+}
+
+// compressRevision returns the gzip compressed json of an EnvoyConfigRevision
+func compressRevision(ecr marin3rv1alpha1.EnvoyConfigRevision) ([]byte, error) {
+	ecr.ObjectMeta = metav1.ObjectMeta{
+		Name:              ecr.GetName(),
+		Namespace:         ecr.GetNamespace(),
+		Labels:            ecr.GetLabels(),
+		Annotations:       ecr.GetAnnotations(),
+		CreationTimestamp: ecr.GetCreationTimestamp(),
+	}
+	ecr.TypeMeta = metav1.TypeMeta{Kind: "EnvoyConfigRevision", APIVersion: marin3rv1alpha1.GroupVersion.String()}
+
+	j, err := json.Marshal(ecr)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(j); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// trimArchive removes the oldest entries from the archive until it holds
+// at most 'maxEntries' entries and its size is below 'maxSize' bytes
+func trimArchive(archive map[string][]byte, maxEntries, maxSize int) {
+	keys := make([]string, 0, len(archive))
+	size := 0
+	for k, v := range archive {
+		keys = append(keys, k)
+		size += len(k) + len(v)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if len(archive) <= maxEntries && size <= maxSize {
+			return
+		}
+		size -= len(k) + len(archive[k])
+		delete(archive, k)
+	}
+}
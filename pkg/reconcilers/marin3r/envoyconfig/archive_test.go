@@ -0,0 +1,146 @@
+package reconcilers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func testArchiveEnvoyConfig() *marin3rv1alpha1.EnvoyConfig {
+	return &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default"},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID: "node",
+			RevisionHistory: &marin3rv1alpha1.RevisionHistory{
+				Archive: &marin3rv1alpha1.RevisionArchive{MaxRevisions: pointer.New(2)},
+			},
+		},
+	}
+}
+
+func TestRevisionReconciler_archiveRevisions(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		ecrs     []marin3rv1alpha1.EnvoyConfigRevision
+		wantKeys []string
+	}{
+		{
+			name: "Creates the archive ConfigMap",
+			ecrs: []marin3rv1alpha1.EnvoyConfigRevision{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ecr1", Namespace: "default"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v1"}},
+			},
+			wantKeys: []string{"1700000000-v1.json.gz"},
+		},
+		{
+			name: "Adds revisions to the archive, removing the oldest ones",
+			objs: []client.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "ec-revision-archive", Namespace: "default"},
+				BinaryData: map[string][]byte{"1600000000-v0.json.gz": {}, "1650000000-v1.json.gz": {}},
+			}},
+			ecrs: []marin3rv1alpha1.EnvoyConfigRevision{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ecr2", Namespace: "default"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v2"}},
+			},
+			wantKeys: []string{"1650000000-v1.json.gz", "1700000000-v2.json.gz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRevisionReconcilerBuilder(s, testArchiveEnvoyConfig(), tt.objs...)
+			if err := r.archiveRevisions(tt.ecrs, now); err != nil {
+				t.Errorf("RevisionReconciler.archiveRevisions() error = %v", err)
+				return
+			}
+			cm := &corev1.ConfigMap{}
+			if err := r.client.Get(context.TODO(), types.NamespacedName{Name: "ec-revision-archive", Namespace: "default"}, cm); err != nil {
+				t.Errorf("RevisionReconciler.archiveRevisions() error = %v", err)
+				return
+			}
+			keys := []string{}
+			for k := range cm.BinaryData {
+				keys = append(keys, k)
+			}
+			if diff := cmp.Diff(keys, tt.wantKeys, cmpopts.SortSlices(func(a, b string) bool { return a < b })); len(diff) > 0 {
+				t.Errorf("RevisionReconciler.archiveRevisions() keys diff = %v", diff)
+			}
+		})
+	}
+}
+
+func Test_compressRevision(t *testing.T) {
+	ecr := marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default", ResourceVersion: "100"},
+		Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v1"},
+	}
+	data, err := compressRevision(ecr)
+	if err != nil {
+		t.Fatalf("compressRevision() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("compressRevision() error = %v", err)
+	}
+	j, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("compressRevision() error = %v", err)
+	}
+	got := marin3rv1alpha1.EnvoyConfigRevision{}
+	if err := json.Unmarshal(j, &got); err != nil {
+		t.Fatalf("compressRevision() error = %v", err)
+	}
+	want := marin3rv1alpha1.EnvoyConfigRevision{
+		TypeMeta:   metav1.TypeMeta{Kind: "EnvoyConfigRevision", APIVersion: marin3rv1alpha1.GroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default"},
+		Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v1"},
+	}
+	if diff := cmp.Diff(got, want); len(diff) > 0 {
+		t.Errorf("compressRevision() diff = %v", diff)
+	}
+}
+
+func Test_trimArchive(t *testing.T) {
+	tests := []struct {
+		name       string
+		archive    map[string][]byte
+		maxEntries int
+		maxSize    int
+		want       map[string][]byte
+	}{
+		{
+			name:       "Removes the oldest entries over 'maxEntries'",
+			archive:    map[string][]byte{"1-a": {}, "2-b": {}, "3-c": {}},
+			maxEntries: 2,
+			maxSize:    100,
+			want:       map[string][]byte{"2-b": {}, "3-c": {}},
+		},
+		{
+			name:       "Removes the oldest entries over 'maxSize'",
+			archive:    map[string][]byte{"1-a": []byte("xxxxx"), "2-b": []byte("xxxxx"), "3-c": []byte("xxxxx")},
+			maxEntries: 10,
+			maxSize:    16,
+			want:       map[string][]byte{"2-b": []byte("xxxxx"), "3-c": []byte("xxxxx")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trimArchive(tt.archive, tt.maxEntries, tt.maxSize)
+			if diff := cmp.Diff(tt.archive, tt.want); len(diff) > 0 {
+				t.Errorf("trimArchive() diff = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package reconcilers
+
+import (
+	"fmt"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	cache_v3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+)
+
+// resourceTypes are the resource types that can be published
+var resourceTypes = []envoy.Type{envoy.Endpoint, envoy.Cluster, envoy.Route, envoy.ScopedRoute,
+	envoy.Listener, envoy.Secret, envoy.Runtime, envoy.ExtensionConfig}
+
+// resourcesByType returns the resources of the revision grouped by type
+func resourcesByType(ecr *marin3rv1alpha1.EnvoyConfigRevision) (map[envoy.Type][]marin3rv1alpha1.Resource, error) {
+	resources, err := ecr.GetResources()
+	if err != nil {
+		return nil, err
+	}
+	byType := map[envoy.Type][]marin3rv1alpha1.Resource{}
+	for _, res := range resources {
+		byType[res.Type] = append(byType[res.Type], res)
+	}
+	return byType, nil
+}
+
+// reference is a reference from a resource of type 'from' to a resource of type 'to'
+type reference struct {
+	from envoy.Type
+	to   envoy.Type
+	name string
+}
+
+// checkReferences verifies that combining resource types from different revisions does not
+// break the references between types, like routes that reference clusters or listeners that
+// reference route configurations (see envoy_resources.References).
+// 'combined' holds the resources to publish for each type, and 'origins' holds, for each type,
+// all the resources of the revision the type is taken from. References that are already
+// unsatisfied in the revision the referencing resources are taken from are ignored, as those
+// are expected to be satisfied by resources outside of the EnvoyConfig (i.e. static resources
+// in the Envoy bootstrap).
+func checkReferences(combined map[envoy.Type][]marin3rv1alpha1.Resource,
+	origins map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource, version envoy.APIVersion) error {
+
+	for _, ref := range danglingReferences(combined, version) {
+		if origin, ok := origins[ref.from]; ok && isDangling(ref, origin, version) {
+			continue
+		}
+		return fmt.Errorf("%s references %s %q, which does not exist", ref.from, ref.to, ref.name)
+	}
+	return nil
+}
+
+// isDangling returns true if the reference is not satisfied by the resources
+func isDangling(ref reference, resources map[envoy.Type][]marin3rv1alpha1.Resource, version envoy.APIVersion) bool {
+	for _, r := range danglingReferences(resources, version) {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// danglingReferences returns the references between types that are not satisfied by the resources
+func danglingReferences(resources map[envoy.Type][]marin3rv1alpha1.Resource, version envoy.APIVersion) []reference {
+	definitions := map[envoy.Type][]envoy_resources.Definition{}
+	for _, rType := range resourceTypes {
+		definitions[rType] = marin3rv1alpha1.ResourceDefinitions(resources[rType], version)
+	}
+
+	dangling := []reference{}
+	for _, from := range resourceTypes {
+		// only the resources of the referencing type are passed in full, so
+		// the dangling references found are the ones of that type
+		defs := []envoy_resources.Definition{}
+		for _, rType := range resourceTypes {
+			for _, def := range definitions[rType] {
+				if rType != from && def.Resource != nil {
+					def = envoy_resources.Definition{Type: def.Type, Name: cache_v3.GetResourceName(def.Resource)}
+				}
+				defs = append(defs, def)
+			}
+		}
+		for _, ref := range envoy_resources.DanglingReferences(defs) {
+			dangling = append(dangling, reference{from, ref.Type, ref.Name})
+		}
+	}
+	return dangling
+}
@@ -0,0 +1,151 @@
+package reconcilers
+
+import (
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testResource(rType envoy.Type, value string) marin3rv1alpha1.Resource {
+	return marin3rv1alpha1.Resource{Type: rType, Value: &runtime.RawExtension{Raw: []byte(value)}}
+}
+
+func Test_checkReferences(t *testing.T) {
+	cluster := func(name string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Cluster, `{"name":"`+name+`"}`)
+	}
+	route := func(name, cluster string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Route, `{"name":"`+name+`","virtual_hosts":[{"name":"vh","domains":["*"],`+
+			`"routes":[{"match":{"prefix":"/"},"route":{"cluster":"`+cluster+`"}}]}]}`)
+	}
+	weightedRoute := func(name, cluster string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Route, `{"name":"`+name+`","virtualHosts":[{"name":"vh","domains":["*"],`+
+			`"routes":[{"match":{"prefix":"/"},"route":{"weightedClusters":{"clusters":[{"name":"`+cluster+`","weight":100}]}}}]}]}`)
+	}
+	listener := func(routeConfig string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Listener, `{"name":"http","filter_chains":[{"filters":[{"name":"envoy.filters.network.http_connection_manager",`+
+			`"typed_config":{"@type":"type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",`+
+			`"rds":{"route_config_name":"`+routeConfig+`"}}}]}]}`)
+	}
+	tcpListener := func(cluster string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Listener, `{"name":"tcp","filter_chains":[{"filters":[{"name":"envoy.filters.network.tcp_proxy",`+
+			`"typed_config":{"@type":"type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy","cluster":"`+cluster+`"}}]}]}`)
+	}
+
+	newRevision := map[envoy.Type][]marin3rv1alpha1.Resource{
+		envoy.Cluster:  {cluster("a"), cluster("b")},
+		envoy.Route:    {route("local", "b")},
+		envoy.Listener: {listener("local")},
+	}
+	oldRevision := map[envoy.Type][]marin3rv1alpha1.Resource{
+		envoy.Cluster:  {cluster("a")},
+		envoy.Route:    {route("local", "a")},
+		envoy.Listener: {listener("local")},
+	}
+
+	tests := []struct {
+		name     string
+		combined map[envoy.Type][]marin3rv1alpha1.Resource
+		origins  map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource
+		wantErr  bool
+	}{
+		{
+			name:     "All references are satisfied",
+			combined: newRevision,
+			origins:  map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{envoy.Cluster: newRevision, envoy.Route: newRevision, envoy.Listener: newRevision},
+			wantErr:  false,
+		},
+		{
+			name: "Rolling back the routes keeps the references",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster:  newRevision[envoy.Cluster],
+				envoy.Route:    oldRevision[envoy.Route],
+				envoy.Listener: newRevision[envoy.Listener],
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{envoy.Cluster: newRevision, envoy.Route: oldRevision, envoy.Listener: newRevision},
+			wantErr: false,
+		},
+		{
+			name: "Rolling back the clusters breaks a route",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster:  oldRevision[envoy.Cluster],
+				envoy.Route:    newRevision[envoy.Route],
+				envoy.Listener: newRevision[envoy.Listener],
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{envoy.Cluster: oldRevision, envoy.Route: newRevision, envoy.Listener: newRevision},
+			wantErr: true,
+		},
+		{
+			name: "Rolling back the clusters breaks a weighted route",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster: oldRevision[envoy.Cluster],
+				envoy.Route:   {weightedRoute("local", "b")},
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster: oldRevision,
+				envoy.Route:   {envoy.Cluster: newRevision[envoy.Cluster], envoy.Route: {weightedRoute("local", "b")}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Rolling back the routes breaks a listener",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Route:    {route("other", "a")},
+				envoy.Listener: newRevision[envoy.Listener],
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Route:    {envoy.Route: {route("other", "a")}},
+				envoy.Listener: newRevision,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Rolling back the clusters breaks a tcp proxy",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster:  oldRevision[envoy.Cluster],
+				envoy.Listener: {tcpListener("b")},
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster:  oldRevision,
+				envoy.Listener: {envoy.Cluster: newRevision[envoy.Cluster], envoy.Listener: {tcpListener("b")}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Rolling back the endpoints breaks an EDS cluster",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Endpoint: {testResource(envoy.Endpoint, `{"cluster_name":"a"}`)},
+				envoy.Cluster:  {testResource(envoy.Cluster, `{"name":"b","type":"EDS"}`)},
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Endpoint: {envoy.Endpoint: {testResource(envoy.Endpoint, `{"cluster_name":"a"}`)}},
+				envoy.Cluster: {
+					envoy.Endpoint: {testResource(envoy.Endpoint, `{"cluster_name":"b"}`)},
+					envoy.Cluster:  {testResource(envoy.Cluster, `{"name":"b","type":"EDS"}`)},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Ignores references that are not satisfied in the source revision",
+			combined: map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster: oldRevision[envoy.Cluster],
+				envoy.Route:   {route("local", "static")},
+			},
+			origins: map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{
+				envoy.Cluster: oldRevision,
+				envoy.Route:   {envoy.Cluster: newRevision[envoy.Cluster], envoy.Route: {route("local", "static")}},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkReferences(tt.combined, tt.origins, envoy.APIv3); (err != nil) != tt.wantErr {
+				t.Errorf("checkReferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package reconcilers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// ComposeFragments merges the resources of the EnvoyConfigFragments that target the EnvoyConfig
+// into the spec.resources of the EnvoyConfig. This is an in-memory change, so the fragments become
+// part of the desired version of the EnvoyConfig and new revisions are generated when they change.
+// Fragments are merged in creation order. A fragment is not merged if the name of any of its resources
+// is already in use by the EnvoyConfig or by a previous fragment. The desired status of each fragment,
+// except the published field, is returned in a map indexed by the name of the fragment.
+func ComposeFragments(ec *marin3rv1alpha1.EnvoyConfig, fragments []marin3rv1alpha1.EnvoyConfigFragment) map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus {
+
+	type key struct {
+		rType envoy.Type
+		name  string
+	}
+
+	defined := map[key]string{}
+	for _, res := range ec.Spec.Resources {
+		if name, err := res.GetName(ec.GetEnvoyAPIVersion()); err == nil {
+			defined[key{res.Type, name}] = fmt.Sprintf("EnvoyConfig '%s'", ec.GetName())
+		}
+	}
+
+	items := make([]marin3rv1alpha1.EnvoyConfigFragment, 0, len(fragments))
+	for _, ecf := range fragments {
+		if ecf.GetNamespace() == ec.GetNamespace() && ecf.Spec.NodeID == ec.Spec.NodeID && ecf.GetDeletionTimestamp() == nil {
+			items = append(items, ecf)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].CreationTimestamp.Equal(&items[j].CreationTimestamp) {
+			return items[i].CreationTimestamp.Before(&items[j].CreationTimestamp)
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	statuses := make(map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus, len(items))
+	for _, ecf := range items {
+		conflicts := []string{}
+		keys := map[key]bool{}
+		for idx, res := range ecf.Spec.Resources {
+			name, err := res.GetName(ec.GetEnvoyAPIVersion())
+			if err != nil {
+				conflicts = append(conflicts, fmt.Sprintf("spec.resources[%d]: %s", idx, err))
+				continue
+			}
+			k := key{res.Type, name}
+			if owner, ok := defined[k]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("%s '%s' is already defined in %s", res.Type, name, owner))
+			} else if keys[k] {
+				conflicts = append(conflicts, fmt.Sprintf("%s '%s' is defined more than once", res.Type, name))
+			}
+			keys[k] = true
+		}
+
+		status := marin3rv1alpha1.EnvoyConfigFragmentStatus{
+			EnvoyConfig: pointer.New(ec.GetName()),
+			Included:    pointer.New(len(conflicts) == 0),
+		}
+		if len(conflicts) > 0 {
+			status.Conflicts = conflicts
+		} else {
+			for k := range keys {
+				defined[k] = fmt.Sprintf("EnvoyConfigFragment '%s'", ecf.GetName())
+			}
+			ec.Spec.Resources = append(ec.Spec.Resources, ecf.Spec.Resources...)
+		}
+		statuses[ecf.GetName()] = status
+	}
+
+	return statuses
+}
+
+// IsFragmentStatusReconciled calculates the status of the EnvoyConfigFragment from its desired status, as
+// returned by ComposeFragments, the desired version of the EnvoyConfig and the published revision. The
+// fragment is published if all of its resources are present in the published revision.
+func IsFragmentStatusReconciled(ecf *marin3rv1alpha1.EnvoyConfigFragment, desired marin3rv1alpha1.EnvoyConfigFragmentStatus,
+	desiredVersion string, published *marin3rv1alpha1.EnvoyConfigRevision) bool {
+
+	if desired.IsIncluded() {
+		desired.DesiredVersion = pointer.New(desiredVersion)
+	}
+
+	isPublished := false
+	if published != nil {
+		publishedResources, err := published.GetResources()
+		isPublished = err == nil
+		for _, res := range ecf.Spec.Resources {
+			found := false
+			for _, pres := range publishedResources {
+				if equality.Semantic.DeepEqual(res, pres) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				isPublished = false
+				break
+			}
+		}
+	}
+	desired.Published = pointer.New(isPublished)
+
+	if reflect.DeepEqual(ecf.Status, desired) {
+		return true
+	}
+	ecf.Status = desired
+	return false
+}
@@ -0,0 +1,141 @@
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-test/deep"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testCluster(name string) marin3rv1alpha1.Resource {
+	return marin3rv1alpha1.Resource{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"` + name + `"}`)}
+}
+
+func testFragment(name, nodeID string, created time.Time, resources ...marin3rv1alpha1.Resource) marin3rv1alpha1.EnvoyConfigFragment {
+	return marin3rv1alpha1.EnvoyConfigFragment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", CreationTimestamp: metav1.NewTime(created)},
+		Spec:       marin3rv1alpha1.EnvoyConfigFragmentSpec{NodeID: nodeID, Resources: resources},
+	}
+}
+
+func TestComposeFragments(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		fragments     []marin3rv1alpha1.EnvoyConfigFragment
+		wantResources []marin3rv1alpha1.Resource
+		wantStatuses  map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus
+	}{
+		{
+			name:          "No fragments",
+			fragments:     []marin3rv1alpha1.EnvoyConfigFragment{},
+			wantResources: []marin3rv1alpha1.Resource{testCluster("a")},
+			wantStatuses:  map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus{},
+		},
+		{
+			name: "Merges fragments in creation order",
+			fragments: []marin3rv1alpha1.EnvoyConfigFragment{
+				testFragment("f2", "node", t0.Add(time.Minute), testCluster("c")),
+				testFragment("f1", "node", t0, testCluster("b")),
+				testFragment("other", "other-node", t0, testCluster("d")),
+			},
+			wantResources: []marin3rv1alpha1.Resource{testCluster("a"), testCluster("b"), testCluster("c")},
+			wantStatuses: map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus{
+				"f1": {EnvoyConfig: pointer.New("ec"), Included: pointer.New(true)},
+				"f2": {EnvoyConfig: pointer.New("ec"), Included: pointer.New(true)},
+			},
+		},
+		{
+			name: "Reports name collisions as conflicts",
+			fragments: []marin3rv1alpha1.EnvoyConfigFragment{
+				testFragment("f1", "node", t0, testCluster("a"), testCluster("b")),
+				testFragment("f2", "node", t0.Add(time.Minute), testCluster("c")),
+				testFragment("f3", "node", t0.Add(2*time.Minute), testCluster("c"), testCluster("d"), testCluster("d")),
+			},
+			wantResources: []marin3rv1alpha1.Resource{testCluster("a"), testCluster("c")},
+			wantStatuses: map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus{
+				"f1": {EnvoyConfig: pointer.New("ec"), Included: pointer.New(false),
+					Conflicts: []string{"cluster 'a' is already defined in EnvoyConfig 'ec'"}},
+				"f2": {EnvoyConfig: pointer.New("ec"), Included: pointer.New(true)},
+				"f3": {EnvoyConfig: pointer.New("ec"), Included: pointer.New(false),
+					Conflicts: []string{"cluster 'c' is already defined in EnvoyConfigFragment 'f2'", "cluster 'd' is defined more than once"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &marin3rv1alpha1.EnvoyConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+				Spec:       marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node", Resources: []marin3rv1alpha1.Resource{testCluster("a")}},
+			}
+			got := ComposeFragments(ec, tt.fragments)
+			if diff := deep.Equal(got, tt.wantStatuses); len(diff) > 0 {
+				t.Errorf("ComposeFragments() diff = %v", diff)
+			}
+			if diff := deep.Equal(ec.Spec.Resources, tt.wantResources); len(diff) > 0 {
+				t.Errorf("ComposeFragments() resources diff = %v", diff)
+			}
+		})
+	}
+}
+
+func TestIsFragmentStatusReconciled(t *testing.T) {
+	revision := func(resources ...marin3rv1alpha1.Resource) *marin3rv1alpha1.EnvoyConfigRevision {
+		return &marin3rv1alpha1.EnvoyConfigRevision{Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "xxxx", Resources: resources}}
+	}
+	tests := []struct {
+		name       string
+		status     marin3rv1alpha1.EnvoyConfigFragmentStatus
+		desired    marin3rv1alpha1.EnvoyConfigFragmentStatus
+		published  *marin3rv1alpha1.EnvoyConfigRevision
+		want       bool
+		wantStatus marin3rv1alpha1.EnvoyConfigFragmentStatus
+	}{
+		{
+			name:      "Included and published fragment",
+			status:    marin3rv1alpha1.EnvoyConfigFragmentStatus{},
+			desired:   marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(true)},
+			published: revision(testCluster("a"), testCluster("b")),
+			want:      false,
+			wantStatus: marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(true),
+				DesiredVersion: pointer.New("yyyy"), Published: pointer.New(true)},
+		},
+		{
+			name:      "Included fragment not yet published",
+			status:    marin3rv1alpha1.EnvoyConfigFragmentStatus{},
+			desired:   marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(true)},
+			published: revision(testCluster("a")),
+			want:      false,
+			wantStatus: marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(true),
+				DesiredVersion: pointer.New("yyyy"), Published: pointer.New(false)},
+		},
+		{
+			name: "Status already reconciled",
+			status: marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(false),
+				Conflicts: []string{"conflict"}, Published: pointer.New(false)},
+			desired: marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(false),
+				Conflicts: []string{"conflict"}},
+			published: nil,
+			want:      true,
+			wantStatus: marin3rv1alpha1.EnvoyConfigFragmentStatus{EnvoyConfig: pointer.New("ec"), Included: pointer.New(false),
+				Conflicts: []string{"conflict"}, Published: pointer.New(false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecf := testFragment("f", "node", time.Now(), testCluster("b"))
+			ecf.Status = tt.status
+			if got := IsFragmentStatusReconciled(&ecf, tt.desired, "yyyy", tt.published); got != tt.want {
+				t.Errorf("IsFragmentStatusReconciled() = %v, want %v", got, tt.want)
+			}
+			if diff := deep.Equal(ecf.Status, tt.wantStatus); len(diff) > 0 {
+				t.Errorf("IsFragmentStatusReconciled() status diff = %v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package reconcilers
+
+import (
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// getPublishingHold returns the state of the cache while the publication of new revisions is held,
+// because publishing is paused or outside of the publish schedule, or an empty string if it is not.
+// When outside of the publish schedule, the time at which the next window opens is also returned.
+func (r *RevisionReconciler) getPublishingHold(now time.Time) (string, time.Time) {
+	if r.Instance().IsPaused() {
+		return marin3rv1alpha1.PausedState, time.Time{}
+	}
+
+	if ps := r.Instance().Spec.PublishSchedule; ps != nil {
+		open, next, err := ps.Window(now)
+		if err != nil {
+			r.logger.Error(err, "invalid publish schedule, ignoring it", "Phase", "ReconcilePublishingHold")
+			return "", time.Time{}
+		}
+		if !open {
+			return marin3rv1alpha1.WaitingForScheduleState, next
+		}
+	}
+
+	return "", time.Time{}
+}
+
+// holdVersionToPublish keeps the currently published revision published while the publication of new
+// revisions is held. The given version, state and resource type overrides are the ones that would be
+// published otherwise, and are returned unchanged if they already correspond to the currently published
+// revision or if no revision has been published yet. Revisions newer than the published one are not taken
+// into account, so the published revision can still be rolled back to an older one if it gets tainted.
+// The given held state is returned if the currently published revision keeps being published.
+func (r *RevisionReconciler) holdVersionToPublish(version, state string, overrides []marin3rv1alpha1.TypeRevision,
+	heldState string) (string, string, []marin3rv1alpha1.TypeRevision) {
+
+	items := r.getPublishableRevisions()
+	for idx, ecr := range items {
+		if !meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+			continue
+		}
+		if ecr.Spec.Version == version {
+			return version, state, overrides
+		}
+		version, state, overrides = r.selectVersionToPublish(items[:idx+1])
+		if state == marin3rv1alpha1.InSyncState {
+			state = heldState
+		}
+		return version, state, overrides
+	}
+
+	return version, state, overrides
+}
+
+// getPublishableRevisions returns the revisions that can be published. When the EnvoyConfig requires
+// approval, these are the revisions with an approval recorded by the admission webhook. The status of
+// the revisions is not taken into account, as it is not protected by the webhook, except when approval
+// has just been enabled and no revision has been approved yet: the currently published revision keeps
+// being published until the first approval, so enabling approval does not unpublish the EnvoyConfig.
+func (r *RevisionReconciler) getPublishableRevisions() []marin3rv1alpha1.EnvoyConfigRevision {
+	if !r.Instance().RequiresApproval() {
+		return r.revisionList.Items
+	}
+
+	items := []marin3rv1alpha1.EnvoyConfigRevision{}
+	for _, ecr := range r.revisionList.Items {
+		if ecr.IsApproved() {
+			items = append(items, ecr)
+		}
+	}
+	if len(items) > 0 {
+		return items
+	}
+
+	for _, ecr := range r.revisionList.Items {
+		if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+			items = append(items, ecr)
+		}
+	}
+	return items
+}
+
+// approveVersionToPublish ensures that only approved revisions are published when the EnvoyConfig
+// requires approval. The given version, state and resource type overrides are the ones that would be
+// published otherwise, and are returned unchanged if they correspond to a publishable revision. If
+// not, the version to publish is selected among the publishable revisions, returning the WaitingForApproval
+// state instead of the InSync one.
+func (r *RevisionReconciler) approveVersionToPublish(version, state string,
+	overrides []marin3rv1alpha1.TypeRevision) (string, string, []marin3rv1alpha1.TypeRevision) {
+
+	items := r.getPublishableRevisions()
+	if len(items) == 0 {
+		return "", marin3rv1alpha1.WaitingForApprovalState, nil
+	}
+
+	for _, ecr := range items {
+		if ecr.Spec.Version == version {
+			return version, state, overrides
+		}
+	}
+
+	version, state, overrides = r.selectVersionToPublish(items)
+	if state == marin3rv1alpha1.InSyncState {
+		state = marin3rv1alpha1.WaitingForApprovalState
+	}
+	return version, state, overrides
+}
@@ -0,0 +1,275 @@
+package reconcilers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRevisionReconciler_getPublishingHold(t *testing.T) {
+	// 2024-01-01 is a monday
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	nightly := &marin3rv1alpha1.PublishSchedule{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}}
+
+	tests := []struct {
+		name      string
+		spec      marin3rv1alpha1.EnvoyConfigSpec
+		wantState string
+		wantNext  time.Time
+	}{
+		{
+			name:      "Not held",
+			spec:      marin3rv1alpha1.EnvoyConfigSpec{},
+			wantState: "",
+		},
+		{
+			name:      "Paused",
+			spec:      marin3rv1alpha1.EnvoyConfigSpec{Paused: pointer.New(true), PublishSchedule: nightly},
+			wantState: marin3rv1alpha1.PausedState,
+		},
+		{
+			name:      "Outside of the publish schedule",
+			spec:      marin3rv1alpha1.EnvoyConfigSpec{PublishSchedule: nightly},
+			wantState: marin3rv1alpha1.WaitingForScheduleState,
+			wantNext:  time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Within the publish schedule",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{PublishSchedule: &marin3rv1alpha1.PublishSchedule{
+				Schedule: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 2 * time.Hour}}},
+			wantState: "",
+		},
+		{
+			name: "Ignores an invalid publish schedule",
+			spec: marin3rv1alpha1.EnvoyConfigSpec{PublishSchedule: &marin3rv1alpha1.PublishSchedule{
+				Schedule: "invalid", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantState: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{Spec: tt.spec})
+			gotState, gotNext := r.getPublishingHold(now)
+			if gotState != tt.wantState || !gotNext.Equal(tt.wantNext) {
+				t.Errorf("RevisionReconciler.getPublishingHold() = (%v, %v), want (%v, %v)", gotState, gotNext, tt.wantState, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestRevisionReconciler_holdVersionToPublish(t *testing.T) {
+	published := metav1.Condition{Type: marin3rv1alpha1.RevisionPublishedCondition, Status: metav1.ConditionTrue}
+	tainted := metav1.Condition{Type: marin3rv1alpha1.RevisionTaintedCondition, Status: metav1.ConditionTrue}
+	revision := func(version string, conditions ...metav1.Condition) marin3rv1alpha1.EnvoyConfigRevision {
+		return marin3rv1alpha1.EnvoyConfigRevision{
+			Spec:   marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: version},
+			Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{Conditions: conditions},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		revisions      []marin3rv1alpha1.EnvoyConfigRevision
+		wantVersion    string
+		wantCacheState string
+	}{
+		{
+			name:           "Keeps the published revision while a new one waits",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa"), revision("bbbb", published), revision("xxxx")},
+			wantVersion:    "bbbb",
+			wantCacheState: marin3rv1alpha1.PausedState,
+		},
+		{
+			name:           "Rolls back a tainted revision without publishing the new one",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa"), revision("bbbb", published, tainted), revision("xxxx")},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.RollbackState,
+		},
+		{
+			name:           "Nothing to hold if the newest revision is published",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa"), revision("xxxx", published)},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.InSyncState,
+		},
+		{
+			name:           "Publishes the first revision",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("xxxx")},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.InSyncState,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{Spec: marin3rv1alpha1.EnvoyConfigSpec{Paused: pointer.New(true)}})
+			r.revisionList = &marin3rv1alpha1.EnvoyConfigRevisionList{Items: tt.revisions}
+			version, state, overrides := r.getVersionToPublish()
+			gotVersion, gotCacheState, _ := r.holdVersionToPublish(version, state, overrides, marin3rv1alpha1.PausedState)
+			if gotVersion != tt.wantVersion || gotCacheState != tt.wantCacheState {
+				t.Errorf("RevisionReconciler.holdVersionToPublish() = (%v, %v), want (%v, %v)", gotVersion, gotCacheState, tt.wantVersion, tt.wantCacheState)
+			}
+		})
+	}
+}
+
+func TestRevisionReconciler_approveVersionToPublish(t *testing.T) {
+	published := metav1.Condition{Type: marin3rv1alpha1.RevisionPublishedCondition, Status: metav1.ConditionTrue}
+	tainted := metav1.Condition{Type: marin3rv1alpha1.RevisionTaintedCondition, Status: metav1.ConditionTrue}
+	revision := func(version string, approved bool, conditions ...metav1.Condition) marin3rv1alpha1.EnvoyConfigRevision {
+		ecr := marin3rv1alpha1.EnvoyConfigRevision{
+			Spec:   marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: version},
+			Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{Conditions: conditions},
+		}
+		if approved {
+			ecr.SetAnnotations(map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: version,
+				marin3rv1alpha1.ApprovedByAnnotation:      "approver",
+			})
+		}
+		return ecr
+	}
+
+	tests := []struct {
+		name           string
+		revisions      []marin3rv1alpha1.EnvoyConfigRevision
+		wantVersion    string
+		wantCacheState string
+	}{
+		{
+			name:           "Publishes an approved revision",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", true, published), revision("xxxx", true)},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.InSyncState,
+		},
+		{
+			name:           "Keeps the published revision while a new one waits for approval",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", true, published), revision("xxxx", false)},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.WaitingForApprovalState,
+		},
+		{
+			name: "Does not publish an approval for another version",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", true, published), func() marin3rv1alpha1.EnvoyConfigRevision {
+				ecr := revision("xxxx", true)
+				ecr.Spec.Version = "yyyy"
+				return ecr
+			}()},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.WaitingForApprovalState,
+		},
+		{
+			name:           "Rolls back to approved revisions",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", true), revision("xxxx", true, tainted)},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.RollbackState,
+		},
+		{
+			name:           "Keeps the published revision until the first approval",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", false, published), revision("xxxx", false)},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.WaitingForApprovalState,
+		},
+		{
+			name:           "Does not keep revisions published without approval after the first approval",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("aaaa", false, published), revision("bbbb", true, tainted), revision("xxxx", false)},
+			wantVersion:    "",
+			wantCacheState: marin3rv1alpha1.RollbackFailedState,
+		},
+		{
+			name: "Does not roll back to revisions with a forged published status",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{func() marin3rv1alpha1.EnvoyConfigRevision {
+				ecr := revision("aaaa", false, published)
+				ecr.Status.LastPublishedAt = &metav1.Time{}
+				return ecr
+			}(), revision("xxxx", true, tainted)},
+			wantVersion:    "",
+			wantCacheState: marin3rv1alpha1.RollbackFailedState,
+		},
+		{
+			name:           "Waits for the first revision to be approved",
+			revisions:      []marin3rv1alpha1.EnvoyConfigRevision{revision("xxxx", false)},
+			wantVersion:    "",
+			wantCacheState: marin3rv1alpha1.WaitingForApprovalState,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{Spec: marin3rv1alpha1.EnvoyConfigSpec{ApprovalRequired: pointer.New(true)}})
+			r.revisionList = &marin3rv1alpha1.EnvoyConfigRevisionList{Items: tt.revisions}
+			version, state, overrides := r.getVersionToPublish()
+			gotVersion, gotCacheState, _ := r.approveVersionToPublish(version, state, overrides)
+			if gotVersion != tt.wantVersion || gotCacheState != tt.wantCacheState {
+				t.Errorf("RevisionReconciler.approveVersionToPublish() = (%v, %v), want (%v, %v)", gotVersion, gotCacheState, tt.wantVersion, tt.wantCacheState)
+			}
+		})
+	}
+}
+
+func TestRevisionReconciler_Reconcile_enableApproval(t *testing.T) {
+	published := &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "published", Namespace: "test",
+			Labels: map[string]string{
+				filters.NodeIDTag:   "node",
+				filters.EnvoyAPITag: envoy.APIv3.String(),
+				filters.VersionTag:  "aaaa",
+			},
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+			NodeID:    "node",
+			Version:   "aaaa",
+			EnvoyAPI:  pointer.New(envoy.APIv3),
+			Resources: []marin3rv1alpha1.Resource{testCluster("a")},
+		},
+		Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+			Conditions: []metav1.Condition{{Type: marin3rv1alpha1.RevisionPublishedCondition, Status: metav1.ConditionTrue, Reason: "Published"}},
+		},
+	}
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		TypeMeta:   metav1.TypeMeta{Kind: "EnvoyConfig", APIVersion: "v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID:           "node",
+			EnvoyAPI:         pointer.New(envoy.APIv3),
+			Resources:        []marin3rv1alpha1.Resource{testCluster("b")},
+			ApprovalRequired: pointer.New(true),
+			RevisionHistory:  &marin3rv1alpha1.RevisionHistory{MaxRevisions: pointer.New(1)},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(s).WithObjects(published).
+		WithStatusSubresource(&marin3rv1alpha1.EnvoyConfig{}).
+		WithStatusSubresource(&marin3rv1alpha1.EnvoyConfigRevision{}).
+		Build()
+
+	// the first reconcile creates the revision for the new resources
+	for i := 0; i < 2; i++ {
+		r := &RevisionReconciler{ctx: context.TODO(), logger: ctrl.Log.WithName("test"), client: cl, scheme: s, ec: ec}
+		result, err := r.Reconcile()
+		if err != nil {
+			t.Fatalf("RevisionReconciler.Reconcile() error = %v", err)
+		}
+		if result.Requeue {
+			continue
+		}
+		if r.PublishedVersion() != "aaaa" || r.GetCacheState() != marin3rv1alpha1.WaitingForApprovalState {
+			t.Errorf("RevisionReconciler.Reconcile() = (%v, %v), want (aaaa, %v)", r.PublishedVersion(), r.GetCacheState(), marin3rv1alpha1.WaitingForApprovalState)
+		}
+	}
+
+	got := &marin3rv1alpha1.EnvoyConfigRevision{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "published", Namespace: "test"}, got); err != nil {
+		t.Fatalf("the published revision has been deleted: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+		t.Errorf("the published revision has been unpublished")
+	}
+}
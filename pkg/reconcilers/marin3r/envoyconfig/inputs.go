@@ -0,0 +1,79 @@
+package reconcilers
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+)
+
+// Inputs holds a hash of the spec of each of the objects the resources of a revision
+// are generated from, the EnvoyConfig and the EnvoyConfigFragments merged into it, along
+// with the users that last changed them. It is recorded in each new revision so the users
+// that made the changes since the previous revision can be told apart.
+type Inputs struct {
+	hashes  map[string]string
+	authors map[string]string
+}
+
+// NewInputs returns the Inputs of the EnvoyConfig. It has to be called before the
+// EnvoyConfig is preprocessed, as preprocessing modifies its spec in place.
+func NewInputs(ec *marin3rv1alpha1.EnvoyConfig) *Inputs {
+	in := &Inputs{hashes: map[string]string{}, authors: map[string]string{}}
+	in.add("EnvoyConfig/"+ec.GetName(), ec.InputsHash(), ec.GetAnnotations()[marin3rv1alpha1.ChangedByAnnotation])
+	return in
+}
+
+// AddFragments adds the EnvoyConfigFragments that have been merged into the EnvoyConfig,
+// as signaled by the desired statuses returned by ComposeFragments
+func (in *Inputs) AddFragments(fragments []marin3rv1alpha1.EnvoyConfigFragment,
+	statuses map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus) {
+
+	for _, ecf := range fragments {
+		if status, ok := statuses[ecf.GetName()]; !ok || status.Included == nil || !*status.Included {
+			continue
+		}
+		in.add("EnvoyConfigFragment/"+ecf.GetName(), ecf.InputsHash(), ecf.GetAnnotations()[marin3rv1alpha1.ChangedByAnnotation])
+	}
+}
+
+func (in *Inputs) add(key, hash, author string) {
+	in.hashes[key] = hash
+	in.authors[key] = author
+}
+
+// Annotation returns the value of the inputs annotation of the revision
+func (in *Inputs) Annotation() string {
+	b, _ := json.Marshal(in.hashes)
+	return string(b)
+}
+
+// ChangedBy returns the comma separated list of the users that changed the inputs
+// since the previous revision, given its inputs annotation. An empty string is returned
+// when the authors are unknown, like when none of the inputs has changed because the
+// change comes from a ConfigMap.
+func (in *Inputs) ChangedBy(previous string) string {
+	hashes := map[string]string{}
+	if previous != "" {
+		// an invalid annotation is treated as if all the inputs had changed
+		_ = json.Unmarshal([]byte(previous), &hashes)
+	}
+
+	authors := map[string]bool{}
+	for key, hash := range in.hashes {
+		if hashes[key] == hash {
+			continue
+		}
+		if author := in.authors[key]; author != "" {
+			authors[author] = true
+		}
+	}
+
+	list := make([]string, 0, len(authors))
+	for author := range authors {
+		list = append(list, author)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,73 @@
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PolicyError is returned by EnforcePolicies when the resources
+// of the EnvoyConfig do not satisfy a rule with the 'Deny' action
+type PolicyError struct {
+	Err error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("the resources do not satisfy the EnvoyConfigPolicies: %s", e.Err)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// SelectPolicies returns the EnvoyConfigPolicies that select the given namespace
+func SelectPolicies(ctx context.Context, cl client.Client, namespace string) ([]marin3rv1alpha1.EnvoyConfigPolicy, error) {
+	list := &marin3rv1alpha1.EnvoyConfigPolicyList{}
+	if err := cl.List(ctx, list); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := cl.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+
+	policies := []marin3rv1alpha1.EnvoyConfigPolicy{}
+	for _, policy := range list.Items {
+		applies, err := policy.AppliesTo(ns.GetLabels())
+		if err != nil {
+			return nil, err
+		}
+		if applies {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// EnforcePolicies evaluates the EnvoyConfigPolicies that select the namespace of the
+// EnvoyConfig against its resources, which should have already been preprocessed, so
+// the resources that are published in the revisions are the ones that are evaluated.
+// A *PolicyError is returned if any resource does not satisfy a rule with the 'Deny'
+// action. The violations of rules with the 'Warn' action are returned as warnings.
+func EnforcePolicies(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) ([]string, error) {
+	policies, err := SelectPolicies(ctx, cl, ec.GetNamespace())
+	if err != nil {
+		return nil, fmt.Errorf("unable to select EnvoyConfigPolicies: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	warnings, err := ec.ValidatePolicies(policies)
+	if err != nil {
+		return nil, &PolicyError{Err: err}
+	}
+	return warnings, nil
+}
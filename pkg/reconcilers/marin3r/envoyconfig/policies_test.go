@@ -0,0 +1,84 @@
+package reconcilers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEnforcePolicies(t *testing.T) {
+	policy := func(name string, selector *metav1.LabelSelector, action marin3rv1alpha1.PolicyAction) *marin3rv1alpha1.EnvoyConfigPolicy {
+		return &marin3rv1alpha1.EnvoyConfigPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: marin3rv1alpha1.EnvoyConfigPolicySpec{
+				NamespaceSelector: selector,
+				Rules: []marin3rv1alpha1.PolicyRule{{
+					Name:       "alt-stat-name",
+					Type:       envoy.Cluster,
+					Expression: "has(object.alt_stat_name)",
+					Action:     ptr.To(action),
+				}},
+			},
+		}
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{"env": "production"}}}
+	ec := func() *marin3rv1alpha1.EnvoyConfig {
+		return &marin3rv1alpha1.EnvoyConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+			Spec: marin3rv1alpha1.EnvoyConfigSpec{
+				NodeID:    "test",
+				Resources: []marin3rv1alpha1.Resource{testCluster("a")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		objs         []client.Object
+		wantWarnings int
+		wantDenied   bool
+	}{
+		{
+			name: "No policies",
+			objs: []client.Object{namespace},
+		},
+		{
+			name:       "Denies resources that do not satisfy a 'Deny' rule",
+			objs:       []client.Object{namespace, policy("deny", nil, marin3rv1alpha1.DenyPolicyAction)},
+			wantDenied: true,
+		},
+		{
+			name:         "Returns warnings for 'Warn' rules",
+			objs:         []client.Object{namespace, policy("warn", nil, marin3rv1alpha1.WarnPolicyAction)},
+			wantWarnings: 1,
+		},
+		{
+			name: "Ignores policies that do not select the namespace",
+			objs: []client.Object{namespace, policy("deny", &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}}, marin3rv1alpha1.DenyPolicyAction)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objs...).Build()
+			warnings, err := EnforcePolicies(context.TODO(), cl, ec())
+			var perr *PolicyError
+			if denied := errors.As(err, &perr); denied != tt.wantDenied {
+				t.Fatalf("EnforcePolicies() error = %v, wantDenied %v", err, tt.wantDenied)
+			}
+			if !tt.wantDenied && err != nil {
+				t.Fatalf("EnforcePolicies() error = %v", err)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("EnforcePolicies() warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy/datasources"
+	"github.com/3scale-ops/marin3r/pkg/envoy/templates"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PreprocessError is returned when one of the steps of Preprocess
+// fails. Reason is a short, CamelCase identifier of the failed step
+// that can be used as the reason of an event.
+type PreprocessError struct {
+	Reason  string
+	Message string
+	Err     error
+}
+
+func (e *PreprocessError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Message, e.Err)
+}
+
+func (e *PreprocessError) Unwrap() error {
+	return e.Err
+}
+
+// Preprocess turns the spec of the EnvoyConfig into the resources that are
+// published in its revisions: the resources are rendered with the template
+// parameters, the data sources are inlined, the patches are applied and the
+// resources of the EnvoyConfigFragments that target the EnvoyConfig are
+// merged. The EnvoyConfig is modified in place. The fragments and their
+// desired statuses are returned so the caller can update them.
+func Preprocess(ctx context.Context, cl client.Client, ec *marin3rv1alpha1.EnvoyConfig) (
+	[]marin3rv1alpha1.EnvoyConfigFragment, map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus, error) {
+
+	// render the resources with the template parameters
+	if err := templates.RenderEnvoyConfig(ctx, cl, ec); err != nil {
+		return nil, nil, &PreprocessError{Reason: "RenderFailed", Message: "unable to render the resources", Err: err}
+	}
+
+	// inline the data sources from ConfigMaps
+	if err := datasources.InlineEnvoyConfig(ctx, cl, ec); err != nil {
+		return nil, nil, &PreprocessError{Reason: "DataSourcesFailed", Message: "unable to inline the data sources", Err: err}
+	}
+
+	// apply the patches to the resources
+	if err := ec.ApplyPatches(); err != nil {
+		return nil, nil, &PreprocessError{Reason: "PatchFailed", Message: "unable to patch the resources", Err: err}
+	}
+
+	// merge the resources of the EnvoyConfigFragments that target this nodeID
+	fragments := &marin3rv1alpha1.EnvoyConfigFragmentList{}
+	if err := cl.List(ctx, fragments, client.InNamespace(ec.GetNamespace())); err != nil {
+		return nil, nil, fmt.Errorf("unable to list EnvoyConfigFragments: %w", err)
+	}
+
+	return fragments.Items, ComposeFragments(ec, fragments.Items), nil
+}
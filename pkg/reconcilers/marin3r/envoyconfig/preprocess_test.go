@@ -0,0 +1,57 @@
+package reconcilers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	"github.com/go-test/deep"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPreprocess(t *testing.T) {
+	ec := func(patch string) *marin3rv1alpha1.EnvoyConfig {
+		return &marin3rv1alpha1.EnvoyConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+			Spec: marin3rv1alpha1.EnvoyConfigSpec{
+				NodeID:    "test",
+				Resources: []marin3rv1alpha1.Resource{testCluster("a")},
+				Patches:   []marin3rv1alpha1.ResourcePatch{{Type: envoy.Cluster, Name: "a", Patch: patch}},
+			},
+		}
+	}
+	fragment := testFragment("fragment", "test", time.Now(), testCluster("b"))
+
+	t.Run("Patches the resources and merges the fragments", func(t *testing.T) {
+		got := ec(`{"alt_stat_name": "patched"}`)
+		cl := fake.NewClientBuilder().WithScheme(s).WithObjects(&fragment).Build()
+		fragments, statuses, err := Preprocess(context.TODO(), cl, got)
+		if err != nil {
+			t.Fatalf("Preprocess() error = %v", err)
+		}
+		if len(fragments) != 1 || len(statuses) != 1 {
+			t.Errorf("Preprocess() fragments = %v, statuses = %v", fragments, statuses)
+		}
+		want := []marin3rv1alpha1.Resource{
+			{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"a","alt_stat_name":"patched"}`)},
+			testCluster("b"),
+		}
+		if diff := deep.Equal(got.Spec.Resources, want); len(diff) > 0 {
+			t.Errorf("Preprocess() resources diff = %v", diff)
+		}
+	})
+
+	t.Run("Returns the reason of the failed step", func(t *testing.T) {
+		cl := fake.NewClientBuilder().WithScheme(s).Build()
+		_, _, err := Preprocess(context.TODO(), cl, ec(`{"giberish": "patched"}`))
+		var perr *PreprocessError
+		if !errors.As(err, &perr) || perr.Reason != "PatchFailed" {
+			t.Errorf("Preprocess() error = %v, want a PatchFailed PreprocessError", err)
+		}
+	})
+}
@@ -3,9 +3,13 @@ package reconcilers
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
+	"time"
 
 	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
 	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
 	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/revisions"
@@ -19,10 +23,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-const (
-	maxRevisions = 10
-)
-
 // RevisionReconciler is a struct with methods to reconcile EnvoyConfig revisions
 type RevisionReconciler struct {
 	ctx    context.Context
@@ -30,6 +30,8 @@ type RevisionReconciler struct {
 	client client.Client
 	scheme *runtime.Scheme
 	ec     *marin3rv1alpha1.EnvoyConfig
+	dStats *stats.Stats
+	inputs *Inputs
 
 	// This fields are only available once Reconcile()
 	// has been succesfully run
@@ -37,13 +39,22 @@ type RevisionReconciler struct {
 	publishedVersion *string
 	cacheState       *string
 	revisionList     *marin3rv1alpha1.EnvoyConfigRevisionList
+	rollout          *marin3rv1alpha1.RolloutStatus
+	typeRevisions    []marin3rv1alpha1.TypeRevision
 }
 
-// NewRevisionReconciler returns a new RevisionReconciler
+// NewRevisionReconciler returns a new RevisionReconciler. The discovery stats are used
+// to gate the steps of canary rollouts and can be nil if no rollout strategy is used.
 func NewRevisionReconciler(ctx context.Context, logger logr.Logger, client client.Client,
-	s *runtime.Scheme, ec *marin3rv1alpha1.EnvoyConfig) RevisionReconciler {
+	s *runtime.Scheme, ec *marin3rv1alpha1.EnvoyConfig, dStats *stats.Stats) RevisionReconciler {
+
+	return RevisionReconciler{ctx, logger, client, s, ec, dStats, nil, nil, nil, nil, nil, nil, nil}
+}
 
-	return RevisionReconciler{ctx, logger, client, s, ec, nil, nil, nil, nil}
+// SetInputs sets the inputs the resources of the EnvoyConfig have been generated from,
+// which are used to attribute the new revisions to the users that changed them
+func (r *RevisionReconciler) SetInputs(inputs *Inputs) {
+	r.inputs = inputs
 }
 
 // Instance returns the EnvoyConfig the reconciler has been instantiated with
@@ -99,6 +110,17 @@ func (r *RevisionReconciler) GetCacheState() string {
 	return *r.cacheState
 }
 
+// GetRollout returns the status of the canary rollout in progress, nil if there is none
+func (r *RevisionReconciler) GetRollout() *marin3rv1alpha1.RolloutStatus {
+	return r.rollout
+}
+
+// GetTypeRevisions returns the version of the revision each resource type is published
+// from when the resources are published per type, nil otherwise
+func (r *RevisionReconciler) GetTypeRevisions() []marin3rv1alpha1.TypeRevision {
+	return r.typeRevisions
+}
+
 // Reconcile progresses EnvoyConfig revisions to match the desired state. It does so
 // by creating/updating/deleting EnvoyConfigRevision API resources.
 func (r *RevisionReconciler) Reconcile() (ctrl.Result, error) {
@@ -106,9 +128,24 @@ func (r *RevisionReconciler) Reconcile() (ctrl.Result, error) {
 
 	_, err := revisions.Get(r.ctx, r.client, r.Namespace(),
 		filters.ByNodeID(r.NodeID()), filters.ByVersion(r.DesiredVersion()), filters.ByEnvoyAPI(r.EnvoyAPI()))
+	if err != nil && revisions.ErrorIsNoMatchesForFilter(err) {
+		adopted, aErr := r.adoptEquivalentRevision()
+		if aErr != nil {
+			log.Error(aErr, "unable to list revisions", "Phase", "ReconcileRevisionForCurrentResources")
+			return ctrl.Result{}, aErr
+		}
+		if adopted {
+			log.Info("adopted EnvoyConfigRevision with the current resources", "version", r.DesiredVersion())
+			err = nil
+		}
+	}
 	if err != nil {
 		if revisions.ErrorIsNoMatchesForFilter(err) {
-			ecr := r.newRevisionForCurrentResources()
+			ecr, err := r.newRevisionForCurrentResources()
+			if err != nil {
+				log.Error(err, "unable to generate the new EnvoyConfigRevision resource", "Phase", "ReconcileRevisionForCurrentResources")
+				return ctrl.Result{}, err
+			}
 			if err := controllerutil.SetControllerReference(r.Instance(), ecr, r.scheme); err != nil {
 				log.Error(err, "unable to SetControllerReference for new EnvoyConfigRevision resource", "Phase", "ReconcileRevisionForCurrentResources")
 				return ctrl.Result{}, err
@@ -135,7 +172,48 @@ func (r *RevisionReconciler) Reconcile() (ctrl.Result, error) {
 		return ctrl.Result{}, err
 	}
 	r.revisionList = revisions.SortByPublication(r.DesiredVersion(), list)
-	publishedVersion, cacheState := r.getVersionToPublish()
+
+	if err := r.reconcileUntaint(time.Now()); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	publishedVersion, cacheState, overrides := r.getVersionToPublish()
+
+	var requeueAfter time.Duration
+	if cacheState != marin3rv1alpha1.PinnedState {
+		if r.Instance().RequiresApproval() {
+			publishedVersion, cacheState, overrides = r.approveVersionToPublish(publishedVersion, cacheState, overrides)
+		}
+		if heldState, nextWindow := r.getPublishingHold(time.Now()); heldState != "" {
+			publishedVersion, cacheState, overrides = r.holdVersionToPublish(publishedVersion, cacheState, overrides, heldState)
+			if cacheState == heldState && !nextWindow.IsZero() {
+				requeueAfter = time.Until(nextWindow)
+			}
+		}
+	}
+
+	if cacheState == marin3rv1alpha1.InSyncState {
+		if publishedVersion, cacheState, requeueAfter, err = r.reconcileRollout(publishedVersion, time.Now()); err != nil {
+			log.Error(err, "unable to reconcile rollout", "Phase", "ReconcileRollout")
+			return ctrl.Result{}, err
+		}
+	} else if err := r.reconcileCanaryCondition("", nil); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if cacheState == marin3rv1alpha1.RollingOutState {
+		// the currently published revision keeps publishing the same types while the rollout is in progress
+		overrides = nil
+		for _, ecr := range r.revisionList.Items {
+			if ecr.Spec.Version == publishedVersion {
+				overrides = ecr.Status.TypeRevisions
+			}
+		}
+	}
+	if err := r.reconcileTypeRevisions(publishedVersion, overrides); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	r.cacheState = &cacheState
 	r.publishedVersion = &publishedVersion
 
@@ -157,7 +235,14 @@ func (r *RevisionReconciler) Reconcile() (ctrl.Result, error) {
 		log.Info("updated the published EnvoyConfigRevision", "Namespace/Name", reconcilerutil.ObjectKey(shouldBeTrue))
 	}
 
-	shouldBeDeleted := r.isRevisionRetentionReconciled(maxRevisions)
+	shouldBeDeleted := r.isRevisionRetentionReconciled(r.Instance().GetMaxRevisions(),
+		r.Instance().GetMaxRevisionAge(), r.Instance().KeepTaintedRevisions(), time.Now())
+	if len(shouldBeDeleted) > 0 && r.Instance().IsRevisionArchiveEnabled() {
+		if err := r.archiveRevisions(shouldBeDeleted, time.Now()); err != nil {
+			log.Error(err, "unable to archive revisions", "Phase", "ApplyRevisionRetention")
+			return ctrl.Result{}, err
+		}
+	}
 	for _, ecr := range shouldBeDeleted {
 		if err := r.client.Delete(r.ctx, &ecr); err != nil {
 			log.Error(err, "unable to delete revision", "Phase", "ApplyRevisionRetention", "Name/Namespace", reconcilerutil.ObjectKey(&ecr))
@@ -167,21 +252,43 @@ func (r *RevisionReconciler) Reconcile() (ctrl.Result, error) {
 	}
 
 	log.Info(fmt.Sprintf("CacheState is %s after revision reconcile", cacheState))
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // getVersionToPublish takes an EnvoyConfigRevisionList and returns the version that should be
 // published. It also returns the state of the cache based on the position of the revision
-// with the returned version in the list of revisions.
-func (r *RevisionReconciler) getVersionToPublish() (string, string) {
+// with the returned version in the list of revisions. A pinned version that exists in the
+// list of revisions is always returned, with the Pinned state. When the resources are published
+// per type, the resource types that have to be taken from other revisions are also returned.
+func (r *RevisionReconciler) getVersionToPublish() (string, string, []marin3rv1alpha1.TypeRevision) {
+	if pinned := r.Instance().GetPinnedVersion(); pinned != "" {
+		for _, ecr := range r.revisionList.Items {
+			if ecr.Spec.Version == pinned {
+				return pinned, marin3rv1alpha1.PinnedState, nil
+			}
+		}
+		r.logger.Info("pinned version does not match any revision, ignoring it", "version", pinned)
+	}
+
+	return r.selectVersionToPublish(r.revisionList.Items)
+}
+
+// selectVersionToPublish returns the version that should be published from the given list of
+// revisions, sorted by publication, along with the state of the cache and, when the resources
+// are published per type, the resource types that have to be taken from other revisions.
+func (r *RevisionReconciler) selectVersionToPublish(items []marin3rv1alpha1.EnvoyConfigRevision) (string, string, []marin3rv1alpha1.TypeRevision) {
 	var versionToPublish string
 
-	topIdx := len(r.revisionList.Items) - 1
+	if r.Instance().GetPublishingMode() == marin3rv1alpha1.PerTypePublishingMode {
+		return r.getPerTypeVersionToPublish(items)
+	}
+
+	topIdx := len(items) - 1
 
 	// Starting from the highest index in the list and going
 	// down, take the first version found that is not tainted
 	for idx := topIdx; idx >= 0; idx-- {
-		ecr := r.revisionList.Items[idx]
+		ecr := items[idx]
 		if !meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition) {
 			versionToPublish = ecr.Spec.Version
 			break
@@ -189,14 +296,176 @@ func (r *RevisionReconciler) getVersionToPublish() (string, string) {
 	}
 
 	if versionToPublish == "" {
-		return "", marin3rv1alpha1.RollbackFailedState
+		return "", marin3rv1alpha1.RollbackFailedState, nil
+
+	} else if versionToPublish != items[topIdx].Spec.Version {
+		return versionToPublish, marin3rv1alpha1.RollbackState, nil
+	}
+
+	return versionToPublish, marin3rv1alpha1.InSyncState, nil
+
+}
+
+// getPerTypeVersionToPublish returns the version that should be published when the resources are
+// published per type, along with the state of the cache and the resource types that have to be taken
+// from other revisions. Starting from the newest revision, revisions tainted as a whole are skipped,
+// and the types that are being rejected in a revision are taken from the newest older revision where
+// they are healthy, as long as the references between types are kept. Otherwise the next older
+// revision is tried.
+func (r *RevisionReconciler) getPerTypeVersionToPublish(items []marin3rv1alpha1.EnvoyConfigRevision) (string, string, []marin3rv1alpha1.TypeRevision) {
+	topIdx := len(items) - 1
+
+	for idx := topIdx; idx >= 0; idx-- {
+		ecr := items[idx]
+		if !meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition) {
+			if idx != topIdx {
+				return ecr.Spec.Version, marin3rv1alpha1.RollbackState, nil
+			}
+			return ecr.Spec.Version, marin3rv1alpha1.InSyncState, nil
+		}
+		if len(ecr.Status.TaintedTypes) == 0 {
+			continue
+		}
+		overrides, err := getTypeOverrides(items, idx)
+		if err != nil {
+			r.logger.Info("unable to roll back the rejected resource types of revision", "version", ecr.Spec.Version, "reason", err.Error())
+			continue
+		}
+		if idx != topIdx {
+			return ecr.Spec.Version, marin3rv1alpha1.RollbackState, overrides
+		}
+		return ecr.Spec.Version, marin3rv1alpha1.PartialRollbackState, overrides
+	}
+
+	return "", marin3rv1alpha1.RollbackFailedState, nil
+}
+
+// getTypeOverrides returns, for each resource type that is being rejected in the revision
+// at the given index of the list of revisions, the newest older revision where the type is
+// healthy. An error is returned if there is no such revision for any of the types or if
+// the combination breaks the references between types.
+func getTypeOverrides(items []marin3rv1alpha1.EnvoyConfigRevision, idx int) ([]marin3rv1alpha1.TypeRevision, error) {
+	ecr := &items[idx]
+	resources, err := resourcesByType(ecr)
+	if err != nil {
+		return nil, err
+	}
+
+	combined := map[envoy.Type][]marin3rv1alpha1.Resource{}
+	origins := map[envoy.Type]map[envoy.Type][]marin3rv1alpha1.Resource{}
+	for rType, res := range resources {
+		combined[rType] = res
+		origins[rType] = resources
+	}
+
+	overrides := []marin3rv1alpha1.TypeRevision{}
+	for _, rType := range ecr.Status.TaintedTypes {
+		var source *marin3rv1alpha1.EnvoyConfigRevision
+		var sourceResources map[envoy.Type][]marin3rv1alpha1.Resource
+		for j := idx - 1; j >= 0; j-- {
+			candidate := &items[j]
+			if candidate.Status.IsTypeTainted(rType) {
+				continue
+			}
+			if sourceResources, err = resourcesByType(candidate); err == nil {
+				source = candidate
+				break
+			}
+		}
+		if source == nil {
+			return nil, fmt.Errorf("no healthy revision found for resources of type '%s'", rType)
+		}
+		combined[rType] = sourceResources[rType]
+		origins[rType] = sourceResources
+		overrides = append(overrides, marin3rv1alpha1.TypeRevision{Type: rType, Version: source.Spec.Version})
+	}
+
+	if err := checkReferences(combined, origins, ecr.GetEnvoyAPIVersion()); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// reconcileTypeRevisions sets in the status of the revision with the given version the resource types
+// it has to take from other revisions, and clears them from any other revision. The revisions are
+// updated in the API and in the list of revisions. When the resources are published per type, it
+// also computes the revision each resource type is published from.
+func (r *RevisionReconciler) reconcileTypeRevisions(version string, overrides []marin3rv1alpha1.TypeRevision) error {
+	if len(overrides) == 0 {
+		overrides = nil
+	}
+
+	var published *marin3rv1alpha1.EnvoyConfigRevision
+	for idx := range r.revisionList.Items {
+		ecr := &r.revisionList.Items[idx]
+		var want []marin3rv1alpha1.TypeRevision
+		if ecr.Spec.Version == version {
+			published = ecr
+			want = overrides
+		}
+		if reflect.DeepEqual(ecr.Status.TypeRevisions, want) {
+			continue
+		}
+		ecr.Status.TypeRevisions = want
+		if err := r.client.Status().Update(r.ctx, ecr); err != nil {
+			r.logger.Error(err, "unable to update revision", "Phase", "ReconcileTypeRevisions", "Name/Namespace", reconcilerutil.ObjectKey(ecr))
+			return err
+		}
+	}
 
-	} else if versionToPublish != r.revisionList.Items[topIdx].Spec.Version {
-		return versionToPublish, marin3rv1alpha1.RollbackState
+	r.typeRevisions = nil
+	if r.Instance().GetPublishingMode() != marin3rv1alpha1.PerTypePublishingMode || published == nil {
+		return nil
+	}
+	resources, err := resourcesByType(published)
+	if err != nil {
+		return nil
+	}
+	r.typeRevisions = []marin3rv1alpha1.TypeRevision{}
+	for _, rType := range resourceTypes {
+		typeVersion := ""
+		if len(resources[rType]) > 0 {
+			typeVersion = version
+		}
+		for _, o := range overrides {
+			if o.Type == rType {
+				typeVersion = o.Version
+			}
+		}
+		if typeVersion != "" {
+			r.typeRevisions = append(r.typeRevisions, marin3rv1alpha1.TypeRevision{Type: rType, Version: typeVersion})
+		}
 	}
+	return nil
+}
 
-	return versionToPublish, marin3rv1alpha1.InSyncState
+// reconcileUntaint clears the taint of the revisions that were tainted before the time set in the
+// untaint annotation of the EnvoyConfig. The revisions are updated in the API and in the list of revisions.
+func (r *RevisionReconciler) reconcileUntaint(now time.Time) error {
+	at, err := r.Instance().GetUntaintAt()
+	if err != nil {
+		r.logger.Error(err, "unable to process untaint annotation", "Phase", "ReconcileUntaint")
+		return nil
+	}
+	if at == nil || at.After(now) {
+		return nil
+	}
 
+	for idx := range r.revisionList.Items {
+		ecr := &r.revisionList.Items[idx]
+		cond := meta.FindStatusCondition(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition)
+		if cond == nil || cond.Status != metav1.ConditionTrue || !cond.LastTransitionTime.Time.Before(*at) {
+			continue
+		}
+		ecr.Status.Untaint(now)
+		ecr.Status.TaintRetries = nil
+		if err := r.client.Status().Update(r.ctx, ecr); err != nil {
+			r.logger.Error(err, "unable to update revision", "Phase", "ReconcileUntaint", "Name/Namespace", reconcilerutil.ObjectKey(ecr))
+			return err
+		}
+		r.logger.Info("cleared taint of revision", "Version", ecr.Spec.Version)
+	}
+	return nil
 }
 
 // isRevisionPublishedConditionReconciled returns the revisions that need the RevisionPublished condition reconciled.
@@ -231,33 +500,84 @@ func (r *RevisionReconciler) isRevisionPublishedConditionReconciled(versionToPub
 	return shouldBeTrue, shouldBeFalse
 }
 
-// isRevisionRetentionReconciled removes items from the revisionList until the list holds the number of items
-// determined by the 'retention' parameter
-func (r *RevisionReconciler) isRevisionRetentionReconciled(retention int) []marin3rv1alpha1.EnvoyConfigRevision {
+// isRevisionRetentionReconciled removes items from the revisionList, starting from the oldest ones, while
+// the list holds more items than determined by the 'retention' parameter or the items are older than
+// 'maxAge' (if not nil). The revision for the current resources and the published revision are never
+// removed, and neither are the tainted revisions if 'keepTainted' is true. The removed items are returned.
+func (r *RevisionReconciler) isRevisionRetentionReconciled(retention int, maxAge *time.Duration, keepTainted bool,
+	now time.Time) []marin3rv1alpha1.EnvoyConfigRevision {
 
 	var toBeDeleted []marin3rv1alpha1.EnvoyConfigRevision = []marin3rv1alpha1.EnvoyConfigRevision{}
 	var revisionList *[]marin3rv1alpha1.EnvoyConfigRevision = &(r.GetRevisionList().Items)
 
-	for len(*revisionList) > retention {
-		toBeDeleted = append(toBeDeleted, popRevision(revisionList))
+	count := len(*revisionList)
+	toBeKept := make([]marin3rv1alpha1.EnvoyConfigRevision, 0, count)
+	for idx, ecr := range *revisionList {
+		switch {
+		// the last item in the list is the revision for the current resources
+		case idx == len(*revisionList)-1:
+		case r.publishedVersion != nil && ecr.Spec.Version == *r.publishedVersion:
+		case keepTainted && meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition):
+		case count > retention || (maxAge != nil && now.Sub(revisionTimestamp(ecr)) > *maxAge):
+			toBeDeleted = append(toBeDeleted, ecr)
+			count--
+			continue
+		}
+		toBeKept = append(toBeKept, ecr)
 	}
+	*revisionList = toBeKept
 
 	return toBeDeleted
 }
 
-// popRevision removes an EnvoyConfigRevision from a list of EnvoyConfigRevision resources, starting from
-// the lowest index in the slice. The removed element is returned as a return value and the list is
-// modified "in place".
-func popRevision(list *[]marin3rv1alpha1.EnvoyConfigRevision) marin3rv1alpha1.EnvoyConfigRevision {
-	item := (*list)[0]
-	*list = (*list)[1:]
-	return item
+// revisionTimestamp returns the last time the revision was published or,
+// if it has never been published, the time it was created
+func revisionTimestamp(ecr marin3rv1alpha1.EnvoyConfigRevision) time.Time {
+	if ecr.Status.LastPublishedAt != nil {
+		return ecr.Status.LastPublishedAt.Time
+	}
+	return ecr.GetCreationTimestamp().Time
+}
+
+// adoptEquivalentRevision looks for a revision that holds the current resources under
+// a different version, which is the case for the revisions created before the version
+// was calculated from the JSON serialization of the resources. The version of the revision
+// is adopted as the desired version so the same resources are not published again.
+func (r *RevisionReconciler) adoptEquivalentRevision() (bool, error) {
+	list, err := revisions.List(r.ctx, r.client, r.Namespace(), filters.ByNodeID(r.NodeID()), filters.ByEnvoyAPI(r.EnvoyAPI()))
+	if err != nil {
+		if revisions.ErrorIsNoMatchesForFilter(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var adopted *marin3rv1alpha1.EnvoyConfigRevision
+	for idx, ecr := range list.Items {
+		if ecr.Spec.Version == r.DesiredVersion() || marin3rv1alpha1.ResourcesVersion(ecr.Spec.Resources) != r.DesiredVersion() {
+			continue
+		}
+		// prefer the published revision if there are several
+		if adopted == nil || meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
+			adopted = &list.Items[idx]
+		}
+	}
+	if adopted == nil {
+		return false, nil
+	}
+
+	r.desiredVersion = pointer.New(adopted.Spec.Version)
+	return true, nil
 }
 
 // newRevisionForCurrentResources generates an EnvoyConfigRevision resource for the current
-// resources in the spec.EnvoyResources field of the EnvoyConfig resource
-func (r *RevisionReconciler) newRevisionForCurrentResources() *marin3rv1alpha1.EnvoyConfigRevision {
-	return &marin3rv1alpha1.EnvoyConfigRevision{
+// resources in the spec.EnvoyResources field of the EnvoyConfig resource. The provenance
+// annotations of the EnvoyConfig are copied to the revision, and the revision is marked if
+// the resources have been rendered with parameters from Secrets. When the inputs are known,
+// the revision is attributed to the users that changed them since the previous revision
+// instead of the user that last changed the EnvoyConfig.
+func (r *RevisionReconciler) newRevisionForCurrentResources() (*marin3rv1alpha1.EnvoyConfigRevision, error) {
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s-%s", r.NodeID(), r.EnvoyAPI(), r.DesiredVersion()),
 			Namespace: r.Namespace(),
@@ -268,10 +588,53 @@ func (r *RevisionReconciler) newRevisionForCurrentResources() *marin3rv1alpha1.E
 			},
 		},
 		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
-			NodeID:    r.NodeID(),
-			EnvoyAPI:  pointer.New(r.EnvoyAPI()),
-			Version:   r.DesiredVersion(),
-			Resources: r.Instance().Spec.Resources,
+			NodeID:      r.NodeID(),
+			EnvoyAPI:    pointer.New(r.EnvoyAPI()),
+			Version:     r.DesiredVersion(),
+			Resources:   r.Instance().Spec.Resources,
+			TaintPolicy: r.Instance().Spec.TaintPolicy.DeepCopy(),
 		},
 	}
+	annotations := r.Instance().GetProvenance()
+	if r.inputs != nil {
+		previous, err := r.previousInputs()
+		if err != nil {
+			return nil, err
+		}
+		delete(annotations, marin3rv1alpha1.ChangedByAnnotation)
+		if changedBy := r.inputs.ChangedBy(previous); changedBy != "" {
+			annotations[marin3rv1alpha1.ChangedByAnnotation] = changedBy
+		}
+		annotations[marin3rv1alpha1.InputsAnnotation] = r.inputs.Annotation()
+	}
+	if secrets := r.Instance().GetSecretParametersSources(); len(secrets) > 0 {
+		annotations[marin3rv1alpha1.SecretParametersAnnotation] = strings.Join(secrets, ",")
+	}
+	if len(annotations) > 0 {
+		ecr.SetAnnotations(annotations)
+	}
+	return ecr, nil
+}
+
+// previousInputs returns the inputs annotation of the newest revision
+// of the EnvoyConfig, or an empty string if there are no revisions
+func (r *RevisionReconciler) previousInputs() (string, error) {
+	list, err := revisions.List(r.ctx, r.client, r.Namespace(), filters.ByNodeID(r.NodeID()), filters.ByEnvoyAPI(r.EnvoyAPI()))
+	if err != nil {
+		if revisions.ErrorIsNoMatchesForFilter(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var newest *marin3rv1alpha1.EnvoyConfigRevision
+	for idx, ecr := range list.Items {
+		if newest == nil || newest.CreationTimestamp.Before(&ecr.CreationTimestamp) {
+			newest = &list.Items[idx]
+		}
+	}
+	if newest == nil {
+		return "", nil
+	}
+	return newest.GetAnnotations()[marin3rv1alpha1.InputsAnnotation], nil
 }
@@ -4,15 +4,17 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
-	reconcilerutil "github.com/3scale-ops/basereconciler/util"
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
 	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
 	"github.com/go-logr/logr"
 	"github.com/go-test/deep"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,13 +32,17 @@ func init() {
 		&marin3rv1alpha1.EnvoyConfigRevision{},
 		&marin3rv1alpha1.EnvoyConfigRevisionList{},
 		&marin3rv1alpha1.EnvoyConfig{},
+		&marin3rv1alpha1.EnvoyConfigFragment{},
+		&marin3rv1alpha1.EnvoyConfigFragmentList{},
+		&marin3rv1alpha1.EnvoyConfigPolicy{},
+		&marin3rv1alpha1.EnvoyConfigPolicyList{},
 	)
 }
 
 func testRevisionReconcilerBuilder(s *runtime.Scheme, instance *marin3rv1alpha1.EnvoyConfig, objs ...client.Object) RevisionReconciler {
 	return RevisionReconciler{context.TODO(), ctrl.Log.WithName("test"),
 		fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).WithStatusSubresource(&marin3rv1alpha1.EnvoyConfig{}).WithStatusSubresource(&marin3rv1alpha1.EnvoyConfigRevision{}).Build(),
-		s, instance, nil, nil, nil, nil}
+		s, instance, nil, nil, nil, nil, nil, nil, nil, nil}
 }
 
 func TestNewRevisionReconciler(t *testing.T) {
@@ -46,6 +52,7 @@ func TestNewRevisionReconciler(t *testing.T) {
 		client client.Client
 		s      *runtime.Scheme
 		ec     *marin3rv1alpha1.EnvoyConfig
+		dStats *stats.Stats
 	}
 	tests := []struct {
 		name string
@@ -54,13 +61,13 @@ func TestNewRevisionReconciler(t *testing.T) {
 	}{
 		{
 			name: "Returns a RevisionReconciler",
-			args: args{context.TODO(), logr.Logger{}, fake.NewFakeClient(), s, nil},
-			want: RevisionReconciler{context.TODO(), logr.Logger{}, fake.NewFakeClient(), s, nil, nil, nil, nil, nil},
+			args: args{context.TODO(), logr.Logger{}, fake.NewFakeClient(), s, nil, nil},
+			want: RevisionReconciler{context.TODO(), logr.Logger{}, fake.NewFakeClient(), s, nil, nil, nil, nil, nil, nil, nil, nil, nil},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewRevisionReconciler(tt.args.ctx, tt.args.logger, tt.args.client, tt.args.s, tt.args.ec); !reflect.DeepEqual(got, tt.want) {
+			if got := NewRevisionReconciler(tt.args.ctx, tt.args.logger, tt.args.client, tt.args.s, tt.args.ec, tt.args.dStats); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewRevisionReconciler() = %v, want %v", got, tt.want)
 			}
 		})
@@ -362,10 +369,11 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 		ec     *marin3rv1alpha1.EnvoyConfig
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		want    ctrl.Result
-		wantErr bool
+		name        string
+		fields      fields
+		want        ctrl.Result
+		wantErr     bool
+		wantVersion string
 	}{
 		{
 			name: "Creates a new EnvoyConfigRevision, no error and requeue",
@@ -399,7 +407,7 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 							Labels: map[string]string{
 								filters.NodeIDTag:   "node",
 								filters.EnvoyAPITag: envoy.APIv3.String(),
-								filters.VersionTag:  reconcilerutil.Hash([]marin3rv1alpha1.Resource{}),
+								filters.VersionTag:  marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
 							},
 						},
 						Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{},
@@ -411,7 +419,7 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 							Labels: map[string]string{
 								filters.NodeIDTag:   "node",
 								filters.EnvoyAPITag: envoy.APIv3.String(),
-								filters.VersionTag:  reconcilerutil.Hash([]marin3rv1alpha1.Resource{}),
+								filters.VersionTag:  marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
 							},
 						},
 						Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{},
@@ -447,7 +455,7 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 							Labels: map[string]string{
 								filters.NodeIDTag:   "node",
 								filters.EnvoyAPITag: envoy.APIv3.String(),
-								filters.VersionTag:  reconcilerutil.Hash([]marin3rv1alpha1.Resource{}),
+								filters.VersionTag:  marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
 							},
 						},
 						Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{},
@@ -470,6 +478,43 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 			want:    ctrl.Result{},
 			wantErr: false,
 		},
+		{
+			name: "EnvoyConfigRevision with the current resources under another version is adopted",
+			fields: fields{
+				ctx:    context.TODO(),
+				logger: ctrl.Log.WithName("test"),
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					&marin3rv1alpha1.EnvoyConfigRevision{
+						TypeMeta: metav1.TypeMeta{Kind: "EnvoyConfigRevision", APIVersion: "v1alpha1"},
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "ecr1", Namespace: "test",
+							Labels: map[string]string{
+								filters.NodeIDTag:   "node",
+								filters.EnvoyAPITag: envoy.APIv3.String(),
+								filters.VersionTag:  "legacy",
+							},
+						},
+						Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "legacy", Resources: []marin3rv1alpha1.Resource{}},
+					},
+				).
+					WithStatusSubresource(&marin3rv1alpha1.EnvoyConfig{}).
+					WithStatusSubresource(&marin3rv1alpha1.EnvoyConfigRevision{}).
+					Build(),
+				scheme: s,
+				ec: &marin3rv1alpha1.EnvoyConfig{
+					TypeMeta:   metav1.TypeMeta{Kind: "EnvoyConfig", APIVersion: "v1alpha1"},
+					ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigSpec{
+						NodeID:    "node",
+						EnvoyAPI:  pointer.New(envoy.APIv3),
+						Resources: []marin3rv1alpha1.Resource{},
+					},
+				},
+			},
+			want:        ctrl.Result{},
+			wantErr:     false,
+			wantVersion: "legacy",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -488,6 +533,9 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("RevisionReconciler.Reconcile() = %v, want %v", got, tt.want)
 			}
+			if tt.wantVersion != "" && r.DesiredVersion() != tt.wantVersion {
+				t.Errorf("RevisionReconciler.DesiredVersion() = %v, want %v", r.DesiredVersion(), tt.wantVersion)
+			}
 		})
 	}
 }
@@ -495,6 +543,7 @@ func TestRevisionReconciler_Reconcile(t *testing.T) {
 func TestRevisionReconciler_getVersionToPublish(t *testing.T) {
 	tests := []struct {
 		name           string
+		pinnedVersion  *string
 		revisionList   *marin3rv1alpha1.EnvoyConfigRevisionList
 		wantVersion    string
 		wantCacheState string
@@ -547,12 +596,42 @@ func TestRevisionReconciler_getVersionToPublish(t *testing.T) {
 			wantVersion:    "",
 			wantCacheState: marin3rv1alpha1.RollbackFailedState,
 		},
+		{
+			name:          "Returns the pinned version and Pinned state",
+			pinnedVersion: pointer.New("aaaa"),
+			revisionList: &marin3rv1alpha1.EnvoyConfigRevisionList{
+				Items: []marin3rv1alpha1.EnvoyConfigRevision{
+					{Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "aaaa"},
+						Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+							Conditions: []metav1.Condition{{
+								Type:   marin3rv1alpha1.RevisionTaintedCondition,
+								Status: metav1.ConditionTrue,
+							}}}},
+					{Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "xxxx"}},
+				},
+			},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.PinnedState,
+		},
+		{
+			name:          "Ignores a pinned version that does not exist",
+			pinnedVersion: pointer.New("zzzz"),
+			revisionList: &marin3rv1alpha1.EnvoyConfigRevisionList{
+				Items: []marin3rv1alpha1.EnvoyConfigRevision{
+					{Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "aaaa"}},
+					{Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "xxxx"}},
+				},
+			},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.InSyncState,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{})
+			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{PinnedVersion: tt.pinnedVersion}})
 			r.revisionList = tt.revisionList
-			gotVersion, gotCacheState := r.getVersionToPublish()
+			gotVersion, gotCacheState, _ := r.getVersionToPublish()
 			if gotVersion != tt.wantVersion {
 				t.Errorf("RevisionReconciler.getVersionToPublish() got = %v, want %v", gotVersion, tt.wantVersion)
 			}
@@ -778,18 +857,18 @@ func TestRevisionReconciler_newRevisionForCurrentResources(t *testing.T) {
 			),
 			want: &marin3rv1alpha1.EnvoyConfigRevision{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "node-v3-85cdf4df4",
+					Name:      "node-v3-6665498b4f",
 					Namespace: "test",
 					Labels: map[string]string{
 						filters.EnvoyAPITag: envoy.APIv3.String(),
 						filters.NodeIDTag:   "node",
-						filters.VersionTag:  "85cdf4df4",
+						filters.VersionTag:  "6665498b4f",
 					},
 				},
 				Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
 					NodeID:   "node",
 					EnvoyAPI: pointer.New(envoy.APIv3),
-					Version:  "85cdf4df4",
+					Version:  "6665498b4f",
 					Resources: []marin3rv1alpha1.Resource{
 						{
 							Type:  "endpoint",
@@ -799,10 +878,113 @@ func TestRevisionReconciler_newRevisionForCurrentResources(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Copies the provenance annotations to the EnvoyConfigRevision",
+			r: testRevisionReconcilerBuilder(s,
+				&marin3rv1alpha1.EnvoyConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ec",
+						Namespace: "test",
+						Annotations: map[string]string{
+							marin3rv1alpha1.ChangeCauseAnnotation: "new endpoint",
+							marin3rv1alpha1.ChangedByAnnotation:   "user",
+							marin3rv1alpha1.SourceAnnotation:      "https://example.com/repo.git",
+							"other":                               "annotation",
+						},
+					},
+					Spec: marin3rv1alpha1.EnvoyConfigSpec{
+						NodeID:    "node",
+						Resources: []marin3rv1alpha1.Resource{},
+					},
+				},
+			),
+			want: &marin3rv1alpha1.EnvoyConfigRevision{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "node-v3-" + marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
+					Namespace: "test",
+					Labels: map[string]string{
+						filters.EnvoyAPITag: envoy.APIv3.String(),
+						filters.NodeIDTag:   "node",
+						filters.VersionTag:  marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
+					},
+					Annotations: map[string]string{
+						marin3rv1alpha1.ChangeCauseAnnotation: "new endpoint",
+						marin3rv1alpha1.ChangedByAnnotation:   "user",
+						marin3rv1alpha1.SourceAnnotation:      "https://example.com/repo.git",
+					},
+				},
+				Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+					NodeID:    "node",
+					EnvoyAPI:  pointer.New(envoy.APIv3),
+					Version:   marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{}),
+					Resources: []marin3rv1alpha1.Resource{},
+				},
+			},
+		},
+		{
+			name: "Marks the EnvoyConfigRevisions rendered with parameters from Secrets",
+			r: testRevisionReconcilerBuilder(s,
+				&marin3rv1alpha1.EnvoyConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigSpec{
+						NodeID:    "node",
+						Resources: []marin3rv1alpha1.Resource{},
+						ParametersFrom: []marin3rv1alpha1.ParametersSource{
+							{ConfigMapRef: &corev1.LocalObjectReference{Name: "params"}},
+							{SecretRef: &corev1.LocalObjectReference{Name: "tokens"}},
+							{SecretRef: &corev1.LocalObjectReference{Name: "passwords"}},
+						},
+					},
+				},
+			),
+			want: testRevisionForInputs(map[string]string{
+				marin3rv1alpha1.SecretParametersAnnotation: "tokens,passwords",
+			}),
+		},
+		{
+			name: "Attributes the EnvoyConfigRevision to the authors of the inputs that changed",
+			r: func() RevisionReconciler {
+				ec, ecf := testInputsObjects("ec-author", "ecf-author")
+				previous := NewInputs(ec)
+				r := testRevisionReconcilerBuilder(s, ec, testRevisionWithInputs(previous.Annotation()))
+				r.inputs = NewInputs(ec)
+				r.inputs.AddFragments([]marin3rv1alpha1.EnvoyConfigFragment{*ecf}, map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus{"ecf": {Included: pointer.New(true)}})
+				return r
+			}(),
+			want: testRevisionForInputs(map[string]string{
+				marin3rv1alpha1.ChangedByAnnotation: "ecf-author",
+				marin3rv1alpha1.InputsAnnotation: func() string {
+					ec, ecf := testInputsObjects("ec-author", "ecf-author")
+					in := NewInputs(ec)
+					in.AddFragments([]marin3rv1alpha1.EnvoyConfigFragment{*ecf}, map[string]marin3rv1alpha1.EnvoyConfigFragmentStatus{"ecf": {Included: pointer.New(true)}})
+					return in.Annotation()
+				}(),
+			}),
+		},
+		{
+			name: "Does not attribute the EnvoyConfigRevision if none of the inputs changed",
+			r: func() RevisionReconciler {
+				ec, _ := testInputsObjects("ec-author", "ecf-author")
+				r := testRevisionReconcilerBuilder(s, ec, testRevisionWithInputs(NewInputs(ec).Annotation()))
+				r.inputs = NewInputs(ec)
+				return r
+			}(),
+			want: testRevisionForInputs(map[string]string{
+				marin3rv1alpha1.InputsAnnotation: func() string {
+					ec, _ := testInputsObjects("ec-author", "ecf-author")
+					return NewInputs(ec).Annotation()
+				}(),
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if diff := deep.Equal(tt.r.newRevisionForCurrentResources(), tt.want); len(diff) > 0 {
+			got, err := tt.r.newRevisionForCurrentResources()
+			if err != nil {
+				t.Errorf("RevisionReconciler.newRevisionForCurrentResources() error = %v", err)
+				return
+			}
+			if diff := deep.Equal(got, tt.want); len(diff) > 0 {
 				t.Errorf("RevisionReconciler.newRevisionForCurrentResources() = diff %v", diff)
 			}
 		})
@@ -822,8 +1004,12 @@ func TestRevisionReconciler_isRevisionRetentionReconciled(t *testing.T) {
 		revisionList     *marin3rv1alpha1.EnvoyConfigRevisionList
 	}
 	type args struct {
-		retention int
+		retention   int
+		maxAge      *time.Duration
+		keepTainted bool
 	}
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tainted := []metav1.Condition{{Type: marin3rv1alpha1.RevisionTaintedCondition, Status: metav1.ConditionTrue}}
 	tests := []struct {
 		name        string
 		fields      fields
@@ -870,6 +1056,74 @@ func TestRevisionReconciler_isRevisionRetentionReconciled(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Does not trim the published revision",
+			fields: fields{nil, logr.Logger{}, nil, nil, nil, nil, pointer.New("v1"), nil,
+				&marin3rv1alpha1.EnvoyConfigRevisionList{
+					Items: []marin3rv1alpha1.EnvoyConfigRevision{
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v1"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v2"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v3"}},
+					},
+				},
+			},
+			args: args{retention: 1},
+			wantTrimmed: []marin3rv1alpha1.EnvoyConfigRevision{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v2"}},
+			},
+			wantList: &marin3rv1alpha1.EnvoyConfigRevisionList{
+				Items: []marin3rv1alpha1.EnvoyConfigRevision{
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v1"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}, Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: "v3"}},
+				},
+			},
+		},
+		{
+			name: "Keeps tainted revisions if requested",
+			fields: fields{nil, logr.Logger{}, nil, nil, nil, nil, nil, nil,
+				&marin3rv1alpha1.EnvoyConfigRevisionList{
+					Items: []marin3rv1alpha1.EnvoyConfigRevision{
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}, Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{Conditions: tainted}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}},
+					},
+				},
+			},
+			args: args{retention: 1, keepTainted: true},
+			wantTrimmed: []marin3rv1alpha1.EnvoyConfigRevision{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}},
+			},
+			wantList: &marin3rv1alpha1.EnvoyConfigRevisionList{
+				Items: []marin3rv1alpha1.EnvoyConfigRevision{
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}, Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{Conditions: tainted}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}},
+				},
+			},
+		},
+		{
+			name: "Trims revisions older than 'maxAge'",
+			fields: fields{nil, logr.Logger{}, nil, nil, nil, nil, nil, nil,
+				&marin3rv1alpha1.EnvoyConfigRevisionList{
+					Items: []marin3rv1alpha1.EnvoyConfigRevision{
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr1", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr2", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))},
+							Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{LastPublishedAt: pointer.New(metav1.NewTime(now.Add(-time.Minute)))}},
+						{ObjectMeta: metav1.ObjectMeta{Name: "ecr3", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))}},
+					},
+				},
+			},
+			args: args{retention: 10, maxAge: pointer.New(time.Hour)},
+			wantTrimmed: []marin3rv1alpha1.EnvoyConfigRevision{
+				{ObjectMeta: metav1.ObjectMeta{Name: "ecr1", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))}},
+			},
+			wantList: &marin3rv1alpha1.EnvoyConfigRevisionList{
+				Items: []marin3rv1alpha1.EnvoyConfigRevision{
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr2", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))},
+						Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{LastPublishedAt: pointer.New(metav1.NewTime(now.Add(-time.Minute)))}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "ecr3", CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))}},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -884,7 +1138,7 @@ func TestRevisionReconciler_isRevisionRetentionReconciled(t *testing.T) {
 				cacheState:       tt.fields.cacheState,
 				revisionList:     tt.fields.revisionList,
 			}
-			if got := r.isRevisionRetentionReconciled(tt.args.retention); !reflect.DeepEqual(got, tt.wantTrimmed) {
+			if got := r.isRevisionRetentionReconciled(tt.args.retention, tt.args.maxAge, tt.args.keepTainted, now); !reflect.DeepEqual(got, tt.wantTrimmed) {
 				t.Errorf("RevisionReconciler.isRevisionRetentionReconciled() = %v, want %v", got, tt.wantTrimmed)
 			}
 			if !reflect.DeepEqual(r.GetRevisionList(), tt.wantList) {
@@ -894,38 +1148,254 @@ func TestRevisionReconciler_isRevisionRetentionReconciled(t *testing.T) {
 	}
 }
 
-func Test_popRevision(t *testing.T) {
-	type args struct {
-		list *[]marin3rv1alpha1.EnvoyConfigRevision
+func TestRevisionReconciler_reconcileUntaint(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tainted := func(name string, at time.Time) *marin3rv1alpha1.EnvoyConfigRevision {
+		return &marin3rv1alpha1.EnvoyConfigRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: name},
+			Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+				Tainted: pointer.New(true),
+				Conditions: []metav1.Condition{{
+					Type:               marin3rv1alpha1.RevisionTaintedCondition,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(at),
+				}},
+			},
+		}
+	}
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test",
+			Annotations: map[string]string{marin3rv1alpha1.UntaintAtAnnotation: now.Add(-time.Minute).Format(time.RFC3339)}},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node"},
 	}
+	r := testRevisionReconcilerBuilder(s, ec, tainted("ecr1", now.Add(-time.Hour)), tainted("ecr2", now))
+	r.revisionList = &marin3rv1alpha1.EnvoyConfigRevisionList{}
+	if err := r.client.List(context.TODO(), r.revisionList); err != nil {
+		t.Fatalf("unable to list revisions: %v", err)
+	}
+
+	if err := r.reconcileUntaint(now); err != nil {
+		t.Fatalf("RevisionReconciler.reconcileUntaint() error = %v", err)
+	}
+	for name, want := range map[string]bool{"ecr1": false, "ecr2": true} {
+		ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "test"}, ecr); err != nil {
+			t.Fatalf("unable to get revision: %v", err)
+		}
+		if got := meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition); got != want {
+			t.Errorf("RevisionReconciler.reconcileUntaint() %s tainted = %v, want %v", name, got, want)
+		}
+		if got := ecr.Status.IsTainted(); got != want {
+			t.Errorf("RevisionReconciler.reconcileUntaint() %s status.tainted = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestRevisionReconciler_getPerTypeVersionToPublish(t *testing.T) {
+	tainted := func(types ...envoy.Type) marin3rv1alpha1.EnvoyConfigRevisionStatus {
+		return marin3rv1alpha1.EnvoyConfigRevisionStatus{
+			TaintedTypes: types,
+			Conditions: []metav1.Condition{{
+				Type:   marin3rv1alpha1.RevisionTaintedCondition,
+				Status: metav1.ConditionTrue,
+			}},
+		}
+	}
+	revision := func(version string, status marin3rv1alpha1.EnvoyConfigRevisionStatus, resources ...marin3rv1alpha1.Resource) marin3rv1alpha1.EnvoyConfigRevision {
+		return marin3rv1alpha1.EnvoyConfigRevision{
+			Spec:   marin3rv1alpha1.EnvoyConfigRevisionSpec{Version: version, Resources: resources},
+			Status: status,
+		}
+	}
+	clusters := func(names ...string) []marin3rv1alpha1.Resource {
+		res := []marin3rv1alpha1.Resource{}
+		for _, name := range names {
+			res = append(res, testResource(envoy.Cluster, `{"name":"`+name+`"}`))
+		}
+		return res
+	}
+	routeTo := func(cluster string) marin3rv1alpha1.Resource {
+		return testResource(envoy.Route, `{"name":"local","virtual_hosts":[{"name":"vh","domains":["*"],`+
+			`"routes":[{"match":{"prefix":"/"},"route":{"cluster":"`+cluster+`"}}]}]}`)
+	}
+	listener := testResource(envoy.Listener, `{"name":"http"}`)
+
 	tests := []struct {
-		name     string
-		list     []marin3rv1alpha1.EnvoyConfigRevision
-		wantItem marin3rv1alpha1.EnvoyConfigRevision
-		wantList []marin3rv1alpha1.EnvoyConfigRevision
+		name           string
+		revisions      []marin3rv1alpha1.EnvoyConfigRevision
+		wantVersion    string
+		wantCacheState string
+		wantOverrides  []marin3rv1alpha1.TypeRevision
 	}{
 		{
-			name: "Pops an element from the list",
-			list: []marin3rv1alpha1.EnvoyConfigRevision{
-				{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}},
-				{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}},
-				{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}},
+			name: "Publishes the newest revision if it is healthy",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{
+				revision("aaaa", marin3rv1alpha1.EnvoyConfigRevisionStatus{}, listener),
+				revision("xxxx", marin3rv1alpha1.EnvoyConfigRevisionStatus{}, listener),
 			},
-			wantItem: marin3rv1alpha1.EnvoyConfigRevision{ObjectMeta: metav1.ObjectMeta{Name: "ecr1"}},
-			wantList: []marin3rv1alpha1.EnvoyConfigRevision{
-				{ObjectMeta: metav1.ObjectMeta{Name: "ecr2"}},
-				{ObjectMeta: metav1.ObjectMeta{Name: "ecr3"}},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.InSyncState,
+		},
+		{
+			name: "Rolls back only the rejected types",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{
+				revision("aaaa", marin3rv1alpha1.EnvoyConfigRevisionStatus{}, append(clusters("a"), listener)...),
+				revision("bbbb", tainted(envoy.Cluster), append(clusters("b"), listener)...),
+				revision("xxxx", tainted(envoy.Listener), append(clusters("b"), listener)...),
+			},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.PartialRollbackState,
+			wantOverrides:  []marin3rv1alpha1.TypeRevision{{Type: envoy.Listener, Version: "bbbb"}},
+		},
+		{
+			name: "Skips revisions tainted as a whole",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{
+				revision("aaaa", marin3rv1alpha1.EnvoyConfigRevisionStatus{}, listener),
+				revision("bbbb", tainted(), listener),
+				revision("xxxx", tainted(envoy.Listener), listener),
 			},
+			wantVersion:    "xxxx",
+			wantCacheState: marin3rv1alpha1.PartialRollbackState,
+			wantOverrides:  []marin3rv1alpha1.TypeRevision{{Type: envoy.Listener, Version: "aaaa"}},
+		},
+		{
+			name: "Rolls back the whole revision if the references between types are broken",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{
+				revision("aaaa", marin3rv1alpha1.EnvoyConfigRevisionStatus{}, append(clusters("a"), routeTo("a"))...),
+				revision("xxxx", tainted(envoy.Cluster), append(clusters("b"), routeTo("b"))...),
+			},
+			wantVersion:    "aaaa",
+			wantCacheState: marin3rv1alpha1.RollbackState,
+		},
+		{
+			name: "Fails if there is no healthy revision for a type",
+			revisions: []marin3rv1alpha1.EnvoyConfigRevision{
+				revision("aaaa", tainted(envoy.Listener), listener),
+				revision("xxxx", tainted(envoy.Listener), listener),
+			},
+			wantVersion:    "",
+			wantCacheState: marin3rv1alpha1.RollbackFailedState,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := popRevision(&tt.list); !reflect.DeepEqual(got, tt.wantItem) {
-				t.Errorf("popRevision() = %v, want %v", got, tt.wantItem)
+			r := testRevisionReconcilerBuilder(s, &marin3rv1alpha1.EnvoyConfig{
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{PublishingMode: pointer.New(marin3rv1alpha1.PerTypePublishingMode)}})
+			r.revisionList = &marin3rv1alpha1.EnvoyConfigRevisionList{Items: tt.revisions}
+			gotVersion, gotCacheState, gotOverrides := r.getVersionToPublish()
+			if gotVersion != tt.wantVersion || gotCacheState != tt.wantCacheState {
+				t.Errorf("RevisionReconciler.getVersionToPublish() = (%v, %v), want (%v, %v)", gotVersion, gotCacheState, tt.wantVersion, tt.wantCacheState)
 			}
-			if !reflect.DeepEqual(tt.list, tt.wantList) {
-				t.Errorf("popRevision() = %v, want %v", tt.list, tt.wantList)
+			if len(gotOverrides) != 0 || len(tt.wantOverrides) != 0 {
+				if diff := deep.Equal(gotOverrides, tt.wantOverrides); len(diff) > 0 {
+					t.Errorf("RevisionReconciler.getVersionToPublish() overrides diff = %s", diff)
+				}
 			}
 		})
 	}
 }
+
+func TestRevisionReconciler_reconcileTypeRevisions(t *testing.T) {
+	revision := func(name string, status marin3rv1alpha1.EnvoyConfigRevisionStatus, resources ...marin3rv1alpha1.Resource) *marin3rv1alpha1.EnvoyConfigRevision {
+		return &marin3rv1alpha1.EnvoyConfigRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: name, Resources: resources},
+			Status:     status,
+		}
+	}
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+		Spec:       marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node", PublishingMode: pointer.New(marin3rv1alpha1.PerTypePublishingMode)},
+	}
+	overrides := []marin3rv1alpha1.TypeRevision{{Type: envoy.Listener, Version: "aaaa"}}
+	r := testRevisionReconcilerBuilder(s, ec,
+		revision("aaaa", marin3rv1alpha1.EnvoyConfigRevisionStatus{TypeRevisions: overrides}, testResource(envoy.Listener, `{"name":"http"}`)),
+		revision("xxxx", marin3rv1alpha1.EnvoyConfigRevisionStatus{},
+			testResource(envoy.Cluster, `{"name":"a"}`), testResource(envoy.Listener, `{"name":"http"}`)),
+	)
+	r.revisionList = &marin3rv1alpha1.EnvoyConfigRevisionList{}
+	if err := r.client.List(context.TODO(), r.revisionList); err != nil {
+		t.Fatalf("unable to list revisions: %v", err)
+	}
+
+	if err := r.reconcileTypeRevisions("xxxx", overrides); err != nil {
+		t.Fatalf("RevisionReconciler.reconcileTypeRevisions() error = %v", err)
+	}
+	for name, want := range map[string][]marin3rv1alpha1.TypeRevision{"aaaa": nil, "xxxx": overrides} {
+		ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+		if err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "test"}, ecr); err != nil {
+			t.Fatalf("unable to get revision: %v", err)
+		}
+		if diff := deep.Equal(ecr.Status.TypeRevisions, want); len(diff) > 0 {
+			t.Errorf("RevisionReconciler.reconcileTypeRevisions() %s diff = %s", name, diff)
+		}
+	}
+	want := []marin3rv1alpha1.TypeRevision{{Type: envoy.Cluster, Version: "xxxx"}, {Type: envoy.Listener, Version: "aaaa"}}
+	if diff := deep.Equal(r.GetTypeRevisions(), want); len(diff) > 0 {
+		t.Errorf("RevisionReconciler.GetTypeRevisions() diff = %s", diff)
+	}
+}
+
+func testInputsObjects(ecAuthor, ecfAuthor string) (*marin3rv1alpha1.EnvoyConfig, *marin3rv1alpha1.EnvoyConfigFragment) {
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ec",
+			Namespace:   "test",
+			Annotations: map[string]string{marin3rv1alpha1.ChangedByAnnotation: ecAuthor},
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigSpec{
+			NodeID:    "node",
+			Resources: []marin3rv1alpha1.Resource{},
+		},
+	}
+	ecf := &marin3rv1alpha1.EnvoyConfigFragment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ecf",
+			Namespace:   "test",
+			Annotations: map[string]string{marin3rv1alpha1.ChangedByAnnotation: ecfAuthor},
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigFragmentSpec{
+			Resources: []marin3rv1alpha1.Resource{{Type: "endpoint", Value: k8sutil.StringtoRawExtension("{\"cluster_name\": \"ecf\"}")}},
+		},
+	}
+	return ec, ecf
+}
+
+func testRevisionWithInputs(inputs string) *marin3rv1alpha1.EnvoyConfigRevision {
+	return &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node-v3-xxxx",
+			Namespace: "test",
+			Labels: map[string]string{
+				filters.EnvoyAPITag: envoy.APIv3.String(),
+				filters.NodeIDTag:   "node",
+				filters.VersionTag:  "xxxx",
+			},
+			Annotations: map[string]string{marin3rv1alpha1.InputsAnnotation: inputs},
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", EnvoyAPI: pointer.New(envoy.APIv3), Version: "xxxx"},
+	}
+}
+
+func testRevisionForInputs(annotations map[string]string) *marin3rv1alpha1.EnvoyConfigRevision {
+	version := marin3rv1alpha1.ResourcesVersion([]marin3rv1alpha1.Resource{})
+	return &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node-v3-" + version,
+			Namespace: "test",
+			Labels: map[string]string{
+				filters.EnvoyAPITag: envoy.APIv3.String(),
+				filters.NodeIDTag:   "node",
+				filters.VersionTag:  version,
+			},
+			Annotations: annotations,
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+			NodeID:    "node",
+			EnvoyAPI:  pointer.New(envoy.APIv3),
+			Version:   version,
+			Resources: []marin3rv1alpha1.Resource{},
+		},
+	}
+}
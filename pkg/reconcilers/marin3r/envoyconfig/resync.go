@@ -0,0 +1,54 @@
+package reconcilers
+
+import (
+	"strings"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ResyncEventReason is the reason of the Events that record the resyncs
+	ResyncEventReason string = "Resync"
+)
+
+// Resync closes the xDS streams of the EnvoyConfig's node ID that were opened before the
+// given time, optionally limited to the given Pods, so the Envoy clients reconnect and receive
+// a full push of the resources. An Event is recorded if any stream gets closed. The pod names
+// of the closed streams are returned.
+func Resync(streams xdss.StreamCloser, recorder record.EventRecorder, ec *marin3rv1alpha1.EnvoyConfig,
+	openedBefore time.Time, pods ...string) []string {
+
+	closed := streams.CloseStreams(ec.Spec.NodeID, openedBefore, pods...)
+	if len(closed) > 0 {
+		recorder.Eventf(ec, corev1.EventTypeNormal, ResyncEventReason,
+			"Closed %d xDS stream(s) of node ID '%s' to force a resync (pods: %s)",
+			len(closed), ec.Spec.NodeID, strings.Join(closed, ", "))
+	}
+	return closed
+}
+
+// ReconcileResync performs the resync requested through the annotations of the
+// EnvoyConfig. Streams opened after the time in the annotation are left untouched, so
+// the resync only happens once in each discovery service replica. If the time in the
+// annotation is in the future, the time left until then is returned.
+func ReconcileResync(streams xdss.StreamCloser, recorder record.EventRecorder, ec *marin3rv1alpha1.EnvoyConfig,
+	now time.Time) (time.Duration, error) {
+
+	at, err := ec.GetResyncAt()
+	if err != nil {
+		return 0, err
+	}
+	if at == nil {
+		return 0, nil
+	}
+	if at.After(now) {
+		return at.Sub(now), nil
+	}
+
+	Resync(streams, recorder, ec, *at, ec.GetResyncPods()...)
+	return 0, nil
+}
@@ -0,0 +1,116 @@
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+type fakeStreamCloser struct {
+	pods         []string
+	openedBefore *time.Time
+	filter       []string
+}
+
+func (f *fakeStreamCloser) CloseStreams(nodeID string, openedBefore time.Time, pods ...string) []string {
+	f.openedBefore = &openedBefore
+	f.filter = pods
+	return f.pods
+}
+
+func TestReconcileResync(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		streams          *fakeStreamCloser
+		want             time.Duration
+		wantErr          bool
+		wantOpenedBefore *time.Time
+		wantFilter       []string
+		wantEvents       int
+	}{
+		{
+			name:        "Does nothing if no resync has been requested",
+			annotations: map[string]string{},
+			streams:     &fakeStreamCloser{},
+			want:        0,
+			wantErr:     false,
+		},
+		{
+			name:             "Closes the streams opened before the requested time",
+			annotations:      map[string]string{marin3rv1alpha1.ResyncAtAnnotation: "2024-01-01T09:00:00Z"},
+			streams:          &fakeStreamCloser{pods: []string{"pod-a", "pod-b"}},
+			want:             0,
+			wantErr:          false,
+			wantOpenedBefore: pointer.New(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)),
+			wantEvents:       1,
+		},
+		{
+			name: "Closes the streams of the requested pods",
+			annotations: map[string]string{
+				marin3rv1alpha1.ResyncAtAnnotation:   "2024-01-01T09:00:00Z",
+				marin3rv1alpha1.ResyncPodsAnnotation: "pod-a",
+			},
+			streams:    &fakeStreamCloser{pods: []string{"pod-a"}},
+			want:       0,
+			wantErr:    false,
+			wantFilter: []string{"pod-a"},
+			wantEvents: 1,
+		},
+		{
+			name:        "Does not record an Event if no stream has been closed",
+			annotations: map[string]string{marin3rv1alpha1.ResyncAtAnnotation: "2024-01-01T09:00:00Z"},
+			streams:     &fakeStreamCloser{pods: []string{}},
+			want:        0,
+			wantErr:     false,
+			wantEvents:  0,
+		},
+		{
+			name:        "Waits if the requested time is in the future",
+			annotations: map[string]string{marin3rv1alpha1.ResyncAtAnnotation: "2024-01-01T10:05:00Z"},
+			streams:     &fakeStreamCloser{},
+			want:        5 * time.Minute,
+			wantErr:     false,
+		},
+		{
+			name:        "Fails if the annotation is invalid",
+			annotations: map[string]string{marin3rv1alpha1.ResyncAtAnnotation: "xx"},
+			streams:     &fakeStreamCloser{},
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			ec := &marin3rv1alpha1.EnvoyConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default", Annotations: tt.annotations},
+				Spec:       marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node"},
+			}
+			got, err := ReconcileResync(tt.streams, recorder, ec, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReconcileResync() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ReconcileResync() = %v, want %v", got, tt.want)
+			}
+			if tt.wantOpenedBefore != nil && !tt.streams.openedBefore.Equal(*tt.wantOpenedBefore) {
+				t.Errorf("ReconcileResync() closed streams opened before %v, want %v", tt.streams.openedBefore, tt.wantOpenedBefore)
+			}
+			if diff := cmp.Diff(tt.streams.filter, tt.wantFilter); tt.wantFilter != nil && len(diff) > 0 {
+				t.Errorf("ReconcileResync() pods diff = %v", diff)
+			}
+			if len(recorder.Events) != tt.wantEvents {
+				t.Errorf("ReconcileResync() recorded %v events, want %v", len(recorder.Events), tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package reconcilers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	reconcilerutil "github.com/3scale-ops/basereconciler/util"
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// canaryPollInterval is the interval at which the stats of the
+	// canary clients are checked while a rollout is in progress
+	canaryPollInterval = 10 * time.Second
+)
+
+// reconcileRollout progresses the canary rollout of the given version, which is the version that
+// would be published if there was no rollout strategy. While the rollout is in progress the currently
+// published version is returned along with the RollingOut state. Each step of the rollout is promoted
+// once all the canary clients have acknowledged the version and the bake time of the step has elapsed.
+// The given version is returned with the InSync state once the last step is promoted. If a canary client
+// rejects the version, the revision is tainted and the published version is returned with the Rollback state.
+// The returned duration is the time after which the rollout needs to be reconciled again.
+func (r *RevisionReconciler) reconcileRollout(version string, now time.Time) (string, string, time.Duration, error) {
+	steps := r.Instance().GetCanarySteps()
+	stable := r.getStableVersion()
+	if len(steps) == 0 || stable == "" || stable == version {
+		r.rollout = nil
+		return version, marin3rv1alpha1.InSyncState, 0, r.reconcileCanaryCondition("", nil)
+	}
+
+	rollout := &marin3rv1alpha1.RolloutStatus{Version: version, Step: 0, StepStartedAt: metav1.NewTime(now.Truncate(time.Second))}
+	if current := r.Instance().Status.Rollout; current != nil && current.Version == version && current.Step < len(steps) {
+		rollout = current.DeepCopy()
+	}
+
+	target, err := r.getCanaryTarget(steps[rollout.Step])
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	if ecr := r.getRevision(version); ecr != nil && hasCanaryTarget(ecr, target) {
+		acked, nacked := r.getCanaryStats(ecr, target)
+
+		if nacked {
+			r.rollout = nil
+			msg := fmt.Sprintf("Version '%s' has been rejected by canary clients in step %d of the rollout", version, rollout.Step)
+			if err := r.taintRevision(ecr, "CanaryFailed", msg); err != nil {
+				return "", "", 0, err
+			}
+			r.logger.Info("aborted rollout", "Version", version, "Step", rollout.Step)
+			return stable, marin3rv1alpha1.RollbackState, 0, r.reconcileCanaryCondition("", nil)
+		}
+
+		if acked {
+			if left := rollout.StepStartedAt.Add(steps[rollout.Step].GetBakeTime()).Sub(now); left > 0 {
+				r.rollout = rollout
+				return stable, marin3rv1alpha1.RollingOutState, left, r.reconcileCanaryCondition(version, target)
+			}
+
+			rollout.Step++
+			rollout.StepStartedAt = metav1.NewTime(now.Truncate(time.Second))
+			if rollout.Step == len(steps) {
+				r.rollout = nil
+				r.logger.Info("completed rollout", "Version", version)
+				return version, marin3rv1alpha1.InSyncState, 0, r.reconcileCanaryCondition("", nil)
+			}
+			r.logger.Info("promoted rollout step", "Version", version, "Step", rollout.Step)
+			if target, err = r.getCanaryTarget(steps[rollout.Step]); err != nil {
+				return "", "", 0, err
+			}
+		}
+	}
+
+	r.rollout = rollout
+	return stable, marin3rv1alpha1.RollingOutState, canaryPollInterval, r.reconcileCanaryCondition(version, target)
+}
+
+// getStableVersion returns the version of the revision that is currently
+// published, or an empty string if there is none or it is tainted
+func (r *RevisionReconciler) getStableVersion() string {
+	for _, ecr := range r.revisionList.Items {
+		if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) &&
+			!meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition) {
+			return ecr.Spec.Version
+		}
+	}
+	return ""
+}
+
+// getRevision returns the revision with the given version from the list of revisions
+func (r *RevisionReconciler) getRevision(version string) *marin3rv1alpha1.EnvoyConfigRevision {
+	for idx := range r.revisionList.Items {
+		if r.revisionList.Items[idx].Spec.Version == version {
+			return &r.revisionList.Items[idx]
+		}
+	}
+	return nil
+}
+
+// getCanaryTarget returns the Envoy clients targeted by a step of the rollout
+func (r *RevisionReconciler) getCanaryTarget(step marin3rv1alpha1.CanaryStep) (*marin3rv1alpha1.CanaryTarget, error) {
+	if step.Percentage != nil {
+		return &marin3rv1alpha1.CanaryTarget{Percentage: step.Percentage}, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(step.Selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.PodList{}
+	if err := r.client.List(r.ctx, list, client.InNamespace(r.Namespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	pods := make([]string, 0, len(list.Items))
+	for _, pod := range list.Items {
+		pods = append(pods, pod.GetName())
+	}
+	sort.Strings(pods)
+	return &marin3rv1alpha1.CanaryTarget{Pods: pods}, nil
+}
+
+// getCanaryStats checks the stats of the canary clients connected to this discovery service. It returns whether
+// all the canary clients have acknowledged the canary revision and whether any of them has rejected it, which
+// happens when the NACKs of a client reach the NACK threshold of the taint policy for the resource type. A step
+// is never acknowledged if none of the connected clients is selected as canary.
+func (r *RevisionReconciler) getCanaryStats(ecr *marin3rv1alpha1.EnvoyConfigRevision, target *marin3rv1alpha1.CanaryTarget) (bool, bool) {
+	vt := ecr.Status.ProvidesVersions
+	if vt == nil || r.dStats == nil {
+		return false, false
+	}
+
+	canary := xdss.Canary{Percentage: target.Percentage, Pods: target.Pods}
+	acked := true
+	selected := map[string]struct{}{}
+	for rType, version := range map[envoy.Type]string{
+		envoy.Endpoint:        vt.Endpoints,
+		envoy.Cluster:         vt.Clusters,
+		envoy.Route:           vt.Routes,
+		envoy.ScopedRoute:     vt.ScopedRoutes,
+		envoy.Listener:        vt.Listeners,
+		envoy.Secret:          vt.Secrets,
+		envoy.Runtime:         vt.Runtimes,
+		envoy.ExtensionConfig: vt.ExtensionConfigs,
+	} {
+		typeURL := envoy_resources.TypeURL(rType, ecr.GetEnvoyAPIVersion())
+		for pod := range r.dStats.GetSubscribedPods(ecr.Spec.NodeID, typeURL) {
+			if !canary.Selects(pod) {
+				continue
+			}
+			selected[pod] = struct{}{}
+			if v, err := r.dStats.GetCounter(ecr.Spec.NodeID, typeURL, version, pod, "nack_counter"); err == nil &&
+				v >= r.Instance().Spec.TaintPolicy.ForType(rType).GetNACKThreshold() {
+				return false, true
+			}
+			if v, err := r.dStats.GetCounter(ecr.Spec.NodeID, typeURL, version, pod, "ack_counter"); err != nil || v == 0 {
+				acked = false
+			}
+		}
+	}
+	return acked && len(selected) > 0, false
+}
+
+// reconcileCanaryCondition sets the RevisionCanaryCondition and the canary target of the revision with the
+// given version, and removes them from any other revision. Pass an empty version to remove them from all
+// revisions. The revisions are updated in the API and in the list of revisions.
+func (r *RevisionReconciler) reconcileCanaryCondition(version string, target *marin3rv1alpha1.CanaryTarget) error {
+	for idx := range r.revisionList.Items {
+		ecr := &r.revisionList.Items[idx]
+		update := false
+
+		if ecr.Spec.Version == version {
+			if !hasCanaryTarget(ecr, target) {
+				meta.SetStatusCondition(&ecr.Status.Conditions, metav1.Condition{
+					Type:    marin3rv1alpha1.RevisionCanaryCondition,
+					Status:  metav1.ConditionTrue,
+					Reason:  "RolloutInProgress",
+					Message: fmt.Sprintf("Version '%s' is being rolled out", version),
+				})
+				ecr.Status.Canary = target
+				update = true
+			}
+		} else if meta.FindStatusCondition(ecr.Status.Conditions, marin3rv1alpha1.RevisionCanaryCondition) != nil || ecr.Status.Canary != nil {
+			meta.RemoveStatusCondition(&ecr.Status.Conditions, marin3rv1alpha1.RevisionCanaryCondition)
+			ecr.Status.Canary = nil
+			update = true
+		}
+
+		if update {
+			if err := r.client.Status().Update(r.ctx, ecr); err != nil {
+				r.logger.Error(err, "unable to update revision", "Phase", "ReconcileRollout", "Name/Namespace", reconcilerutil.ObjectKey(ecr))
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// taintRevision sets the RevisionTaintedCondition in the given revision
+func (r *RevisionReconciler) taintRevision(ecr *marin3rv1alpha1.EnvoyConfigRevision, reason, msg string) error {
+	meta.SetStatusCondition(&ecr.Status.Conditions, metav1.Condition{
+		Type:    marin3rv1alpha1.RevisionTaintedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  reason,
+		Message: msg,
+	})
+	// the revision is tainted as a whole
+	ecr.Status.TaintedTypes = nil
+	if err := r.client.Status().Update(r.ctx, ecr); err != nil {
+		r.logger.Error(err, "unable to taint revision", "Phase", "ReconcileRollout", "Name/Namespace", reconcilerutil.ObjectKey(ecr))
+		return err
+	}
+	return nil
+}
+
+// hasCanaryTarget returns true if the revision is
+// marked as the canary for the given canary target
+func hasCanaryTarget(ecr *marin3rv1alpha1.EnvoyConfigRevision, target *marin3rv1alpha1.CanaryTarget) bool {
+	return meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionCanaryCondition) &&
+		reflect.DeepEqual(ecr.Status.Canary, target)
+}
@@ -0,0 +1,249 @@
+package reconcilers
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRevisionReconciler_reconcileRollout(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clusterType := envoy_resources.TypeURL(envoy.Cluster, envoy.APIv3)
+
+	published := metav1.Condition{Type: marin3rv1alpha1.RevisionPublishedCondition, Status: metav1.ConditionTrue}
+	canary := metav1.Condition{Type: marin3rv1alpha1.RevisionCanaryCondition, Status: metav1.ConditionTrue}
+	allPods := &marin3rv1alpha1.CanaryTarget{Percentage: pointer.New(100)}
+	selectedPods := &marin3rv1alpha1.CanaryTarget{Pods: []string{"pod-a"}}
+
+	strategy := &marin3rv1alpha1.RolloutStrategy{Canary: &marin3rv1alpha1.CanaryRolloutStrategy{
+		Steps: []marin3rv1alpha1.CanaryStep{
+			{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"canary": "true"}}, BakeTime: &metav1.Duration{Duration: time.Minute}},
+			{Percentage: pointer.New(100), BakeTime: &metav1.Duration{Duration: time.Minute}},
+		},
+	}}
+
+	revision := func(name, version string, target *marin3rv1alpha1.CanaryTarget, conditions ...metav1.Condition) *marin3rv1alpha1.EnvoyConfigRevision {
+		return &marin3rv1alpha1.EnvoyConfigRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: version, EnvoyAPI: pointer.New(envoy.APIv3)},
+			Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+				Conditions:       conditions,
+				Canary:           target,
+				ProvidesVersions: &marin3rv1alpha1.VersionTracker{Clusters: version},
+			},
+		}
+	}
+	pod := func(name string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	}
+	acked := func() *stats.Stats {
+		s := stats.New()
+		s.ReportRequest("node", clusterType, "pod-a")
+		s.ReportACK("node", clusterType, "v2", "pod-a")
+		return s
+	}
+	nacked := func() *stats.Stats {
+		s := stats.New()
+		s.ReportRequest("node", clusterType, "pod-a")
+		s.WriteResponseNonce("node", clusterType, "v2", "pod-a", "1")
+		s.ReportNACK("node", clusterType, "pod-a", "1")
+		return s
+	}
+
+	nackThreshold := func(threshold int64) *marin3rv1alpha1.TaintPolicy {
+		return &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{NACKThreshold: pointer.New(threshold)}}
+	}
+
+	tests := []struct {
+		name        string
+		strategy    *marin3rv1alpha1.RolloutStrategy
+		policy      *marin3rv1alpha1.TaintPolicy
+		rollout     *marin3rv1alpha1.RolloutStatus
+		objs        []client.Object
+		dStats      *stats.Stats
+		wantVersion string
+		wantState   string
+		wantRequeue time.Duration
+		wantRollout *marin3rv1alpha1.RolloutStatus
+		wantCanary  *marin3rv1alpha1.CanaryTarget
+		wantTainted bool
+	}{
+		{
+			name:        "Publishes the version if there is no rollout strategy",
+			strategy:    nil,
+			objs:        []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", nil)},
+			dStats:      stats.New(),
+			wantVersion: "v2",
+			wantState:   marin3rv1alpha1.InSyncState,
+		},
+		{
+			name:        "Publishes the version if no version has been published yet",
+			strategy:    strategy,
+			objs:        []client.Object{revision("ecr1", "v1", nil), revision("ecr2", "v2", nil)},
+			dStats:      stats.New(),
+			wantVersion: "v2",
+			wantState:   marin3rv1alpha1.InSyncState,
+		},
+		{
+			name:     "Starts a rollout",
+			strategy: strategy,
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", nil),
+				pod("pod-a", map[string]string{"canary": "true"}), pod("pod-b", nil)},
+			dStats:      stats.New(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: canaryPollInterval,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now)},
+			wantCanary:  selectedPods,
+		},
+		{
+			name:     "Waits for the bake time",
+			strategy: strategy,
+			rollout:  &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-30 * time.Second))},
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", selectedPods, canary),
+				pod("pod-a", map[string]string{"canary": "true"})},
+			dStats:      acked(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: 30 * time.Second,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-30 * time.Second))},
+			wantCanary:  selectedPods,
+		},
+		{
+			name:     "Waits for the canary clients to acknowledge the version",
+			strategy: strategy,
+			rollout:  &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", selectedPods, canary),
+				pod("pod-a", map[string]string{"canary": "true"})},
+			dStats: func() *stats.Stats {
+				s := stats.New()
+				s.ReportRequest("node", clusterType, "pod-a")
+				return s
+			}(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: canaryPollInterval,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			wantCanary:  selectedPods,
+		},
+		{
+			name:     "Promotes a step",
+			strategy: strategy,
+			rollout:  &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", selectedPods, canary),
+				pod("pod-a", map[string]string{"canary": "true"})},
+			dStats:      acked(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: canaryPollInterval,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 1, StepStartedAt: metav1.NewTime(now)},
+			wantCanary:  allPods,
+		},
+		{
+			name:        "Completes the rollout",
+			strategy:    strategy,
+			rollout:     &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 1, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs:        []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", allPods, canary)},
+			dStats:      acked(),
+			wantVersion: "v2",
+			wantState:   marin3rv1alpha1.InSyncState,
+			wantRollout: nil,
+			wantCanary:  nil,
+		},
+		{
+			name:        "Does not promote a step without canary clients",
+			strategy:    strategy,
+			rollout:     &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 1, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs:        []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", allPods, canary)},
+			dStats:      stats.New(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: canaryPollInterval,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 1, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			wantCanary:  allPods,
+		},
+		{
+			name:     "Does not abort the rollout below the NACK threshold",
+			strategy: strategy,
+			rollout:  &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", selectedPods, canary),
+				pod("pod-a", map[string]string{"canary": "true"})},
+			policy:      nackThreshold(2),
+			dStats:      nacked(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollingOutState,
+			wantRequeue: canaryPollInterval,
+			wantRollout: &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			wantCanary:  selectedPods,
+		},
+		{
+			name:     "Aborts the rollout if a canary client rejects the version",
+			strategy: strategy,
+			rollout:  &marin3rv1alpha1.RolloutStatus{Version: "v2", Step: 0, StepStartedAt: metav1.NewTime(now.Add(-time.Hour))},
+			objs: []client.Object{revision("ecr1", "v1", nil, published), revision("ecr2", "v2", selectedPods, canary),
+				pod("pod-a", map[string]string{"canary": "true"})},
+			policy:      nackThreshold(1),
+			dStats:      nacked(),
+			wantVersion: "v1",
+			wantState:   marin3rv1alpha1.RollbackState,
+			wantRollout: nil,
+			wantCanary:  nil,
+			wantTainted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := &marin3rv1alpha1.EnvoyConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default"},
+				Spec:       marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node", RolloutStrategy: tt.strategy, TaintPolicy: tt.policy},
+				Status:     marin3rv1alpha1.EnvoyConfigStatus{Rollout: tt.rollout},
+			}
+			r := testRevisionReconcilerBuilder(s, ec, tt.objs...)
+			r.dStats = tt.dStats
+			list := &marin3rv1alpha1.EnvoyConfigRevisionList{}
+			if err := r.client.List(context.TODO(), list); err != nil {
+				t.Fatalf("unable to list revisions: %v", err)
+			}
+			sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+			r.revisionList = list
+
+			gotVersion, gotState, gotRequeue, err := r.reconcileRollout("v2", now)
+			if err != nil {
+				t.Fatalf("RevisionReconciler.reconcileRollout() error = %v", err)
+			}
+			if gotVersion != tt.wantVersion || gotState != tt.wantState || gotRequeue != tt.wantRequeue {
+				t.Errorf("RevisionReconciler.reconcileRollout() = (%v, %v, %v), want (%v, %v, %v)",
+					gotVersion, gotState, gotRequeue, tt.wantVersion, tt.wantState, tt.wantRequeue)
+			}
+			if diff := cmp.Diff(r.GetRollout(), tt.wantRollout); len(diff) > 0 {
+				t.Errorf("RevisionReconciler.reconcileRollout() rollout diff = %v", diff)
+			}
+
+			ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+			if err := r.client.Get(context.TODO(), client.ObjectKey{Name: "ecr2", Namespace: "default"}, ecr); err != nil {
+				t.Fatalf("unable to get revision: %v", err)
+			}
+			if diff := cmp.Diff(ecr.Status.Canary, tt.wantCanary); len(diff) > 0 {
+				t.Errorf("RevisionReconciler.reconcileRollout() canary diff = %v", diff)
+			}
+			if got := meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionCanaryCondition); got != (tt.wantCanary != nil) {
+				t.Errorf("RevisionReconciler.reconcileRollout() canary condition = %v", got)
+			}
+			if got := meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition); got != tt.wantTainted {
+				t.Errorf("RevisionReconciler.reconcileRollout() tainted = %v, want %v", got, tt.wantTainted)
+			}
+		})
+	}
+}
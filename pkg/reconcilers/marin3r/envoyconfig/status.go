@@ -1,6 +1,7 @@
 package reconcilers
 
 import (
+	"fmt"
 	"reflect"
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
@@ -10,7 +11,8 @@ import (
 )
 
 // IsStatusReconciled calculates the status of the resource
-func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVersion string, list *marin3rv1alpha1.EnvoyConfigRevisionList) bool {
+func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, desiredVersion, publishedVersion string, list *marin3rv1alpha1.EnvoyConfigRevisionList,
+	rollout *marin3rv1alpha1.RolloutStatus, typeRevisions []marin3rv1alpha1.TypeRevision) bool {
 
 	ok := true
 
@@ -20,8 +22,6 @@ func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVe
 		ok = false
 	}
 
-	desiredVersion := ec.GetEnvoyResourcesVersion()
-
 	if ec.Status.PublishedVersion == nil || *ec.Status.PublishedVersion != publishedVersion {
 		ec.Status.PublishedVersion = &publishedVersion
 		ok = false
@@ -37,13 +37,25 @@ func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVe
 		ok = false
 	}
 
+	if !reflect.DeepEqual(ec.Status.Rollout, rollout) {
+		ec.Status.Rollout = rollout
+		ok = false
+	}
+
+	if !reflect.DeepEqual(ec.Status.TypeRevisions, typeRevisions) {
+		ec.Status.TypeRevisions = typeRevisions
+		ok = false
+	}
+
 	if ec.Status.Conditions == nil {
 		ec.Status.Conditions = []metav1.Condition{}
 		ok = false
 	}
 
-	// Reconcile the CacheOutOfSyncCondition
-	if desiredVersion != publishedVersion && !meta.IsStatusConditionTrue(ec.Status.Conditions, marin3rv1alpha1.CacheOutOfSyncCondition) {
+	// Reconcile the CacheOutOfSyncCondition. The cache is also out of sync when
+	// some of the resource types of the desired version have been rolled back
+	outOfSync := desiredVersion != publishedVersion || cacheState == marin3rv1alpha1.PartialRollbackState
+	if outOfSync && !meta.IsStatusConditionTrue(ec.Status.Conditions, marin3rv1alpha1.CacheOutOfSyncCondition) {
 		meta.SetStatusCondition(&ec.Status.Conditions, metav1.Condition{
 			Type:    marin3rv1alpha1.CacheOutOfSyncCondition,
 			Status:  metav1.ConditionTrue,
@@ -52,7 +64,7 @@ func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVe
 		})
 		ok = false
 
-	} else if desiredVersion == publishedVersion && meta.IsStatusConditionTrue(ec.Status.Conditions, marin3rv1alpha1.CacheOutOfSyncCondition) {
+	} else if !outOfSync && meta.IsStatusConditionTrue(ec.Status.Conditions, marin3rv1alpha1.CacheOutOfSyncCondition) {
 		meta.SetStatusCondition(&ec.Status.Conditions, metav1.Condition{
 			Type:    marin3rv1alpha1.CacheOutOfSyncCondition,
 			Status:  metav1.ConditionFalse,
@@ -82,6 +94,16 @@ func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVe
 		ok = false
 	}
 
+	// Reconcile the VersionPinnedCondition
+	if !isVersionPinnedConditionReconciled(ec, cacheState) {
+		ok = false
+	}
+
+	// Reconcile the PublishingHeldCondition
+	if !isPublishingHeldConditionReconciled(ec, cacheState, desiredVersion) {
+		ok = false
+	}
+
 	// Temporary fix for RollbackFailedCondition conditions that are missing  the .Message property, which
 	// will be required in an upcoming release
 	if cond := meta.FindStatusCondition(ec.Status.Conditions, marin3rv1alpha1.RollbackFailedCondition); cond != nil && cond.Message == "" {
@@ -93,6 +115,88 @@ func IsStatusReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, publishedVe
 	return ok
 }
 
+// isVersionPinnedConditionReconciled sets the VersionPinnedCondition to true while the pinned
+// version is being published, to false if the pinned version cannot be published and removes
+// the condition once the pin is removed. Returns false if the condition has been updated.
+func isVersionPinnedConditionReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState string) bool {
+	pinned := ec.GetPinnedVersion()
+
+	if pinned == "" {
+		if meta.FindStatusCondition(ec.Status.Conditions, marin3rv1alpha1.VersionPinnedCondition) != nil {
+			meta.RemoveStatusCondition(&ec.Status.Conditions, marin3rv1alpha1.VersionPinnedCondition)
+			return false
+		}
+		return true
+	}
+
+	desired := metav1.Condition{
+		Type:    marin3rv1alpha1.VersionPinnedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PinnedVersionPublished",
+		Message: fmt.Sprintf("Version '%s' is pinned", pinned),
+	}
+	if cacheState != marin3rv1alpha1.PinnedState {
+		desired.Status = metav1.ConditionFalse
+		desired.Reason = "PinnedVersionNotFound"
+		desired.Message = fmt.Sprintf("Version '%s' is pinned but there is no revision with that version", pinned)
+	}
+
+	if cond := meta.FindStatusCondition(ec.Status.Conditions, marin3rv1alpha1.VersionPinnedCondition); cond == nil ||
+		cond.Status != desired.Status || cond.Reason != desired.Reason || cond.Message != desired.Message {
+		meta.SetStatusCondition(&ec.Status.Conditions, desired)
+		return false
+	}
+	return true
+}
+
+// isPublishingHeldConditionReconciled sets the PublishingHeldCondition while a new revision is waiting
+// to be published because publishing is paused, outside of the publish schedule or the revision has
+// not been approved yet, and removes it
+// otherwise. Returns false if the condition has been updated.
+func isPublishingHeldConditionReconciled(ec *marin3rv1alpha1.EnvoyConfig, cacheState, desiredVersion string) bool {
+	var desired *metav1.Condition
+
+	switch cacheState {
+	case marin3rv1alpha1.PausedState:
+		desired = &metav1.Condition{
+			Type:    marin3rv1alpha1.PublishingHeldCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Paused",
+			Message: fmt.Sprintf("Version '%s' is waiting to be published because publishing is paused", desiredVersion),
+		}
+	case marin3rv1alpha1.WaitingForScheduleState:
+		desired = &metav1.Condition{
+			Type:    marin3rv1alpha1.PublishingHeldCondition,
+			Status:  metav1.ConditionTrue,
+			Reason:  "OutsidePublishSchedule",
+			Message: fmt.Sprintf("Version '%s' is waiting for the next window of the publish schedule", desiredVersion),
+		}
+	case marin3rv1alpha1.WaitingForApprovalState:
+		desired = &metav1.Condition{
+			Type:   marin3rv1alpha1.PublishingHeldCondition,
+			Status: metav1.ConditionTrue,
+			Reason: "WaitingForApproval",
+			Message: fmt.Sprintf("Version '%s' is waiting to be approved with the '%s' annotation",
+				desiredVersion, marin3rv1alpha1.ApprovedVersionAnnotation),
+		}
+	}
+
+	cond := meta.FindStatusCondition(ec.Status.Conditions, marin3rv1alpha1.PublishingHeldCondition)
+	if desired == nil {
+		if cond != nil {
+			meta.RemoveStatusCondition(&ec.Status.Conditions, marin3rv1alpha1.PublishingHeldCondition)
+			return false
+		}
+		return true
+	}
+
+	if cond == nil || cond.Status != desired.Status || cond.Reason != desired.Reason || cond.Message != desired.Message {
+		meta.SetStatusCondition(&ec.Status.Conditions, *desired)
+		return false
+	}
+	return true
+}
+
 func generateRevisionList(list *marin3rv1alpha1.EnvoyConfigRevisionList) []marin3rv1alpha1.ConfigRevisionRef {
 
 	revisionList := make([]marin3rv1alpha1.ConfigRevisionRef, len(list.Items))
@@ -106,6 +210,11 @@ func generateRevisionList(list *marin3rv1alpha1.EnvoyConfigRevisionList) []marin
 				Namespace:  ecr.GetNamespace(),
 				UID:        ecr.GetUID(),
 			},
+			PublishedAt:  ecr.Status.LastPublishedAt,
+			ChangeCause:  ecr.GetAnnotations()[marin3rv1alpha1.ChangeCauseAnnotation],
+			ChangedBy:    ecr.GetAnnotations()[marin3rv1alpha1.ChangedByAnnotation],
+			Source:       ecr.GetAnnotations()[marin3rv1alpha1.SourceAnnotation],
+			SourceCommit: ecr.GetAnnotations()[marin3rv1alpha1.SourceCommitAnnotation],
 		}
 	}
 
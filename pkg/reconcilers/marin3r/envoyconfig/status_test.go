@@ -3,10 +3,12 @@ package reconcilers
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -16,6 +18,7 @@ func TestIsStatusReconciled(t *testing.T) {
 		cacheState       string
 		publishedVersion string
 		list             *marin3rv1alpha1.EnvoyConfigRevisionList
+		rollout          *marin3rv1alpha1.RolloutStatus
 	}
 	tests := []struct {
 		name string
@@ -245,13 +248,145 @@ func TestIsStatusReconciled(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := IsStatusReconciled(tt.args.ec, tt.args.cacheState, tt.args.publishedVersion, tt.args.list); got != tt.want {
+			if got := IsStatusReconciled(tt.args.ec, tt.args.cacheState, "6758fd786c", tt.args.publishedVersion, tt.args.list, tt.args.rollout, nil); got != tt.want {
 				t.Errorf("IsStatusReconciled() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_isVersionPinnedConditionReconciled(t *testing.T) {
+	tests := []struct {
+		name       string
+		ec         *marin3rv1alpha1.EnvoyConfig
+		cacheState string
+		want       bool
+		wantCond   *metav1.Condition
+	}{
+		{
+			name:       "No pinned version, returns true",
+			ec:         &marin3rv1alpha1.EnvoyConfig{},
+			cacheState: marin3rv1alpha1.InSyncState,
+			want:       true,
+			wantCond:   nil,
+		},
+		{
+			name: "Pinned version published, sets the condition to true",
+			ec: &marin3rv1alpha1.EnvoyConfig{
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{PinnedVersion: pointer.New("xxxx")},
+			},
+			cacheState: marin3rv1alpha1.PinnedState,
+			want:       false,
+			wantCond: &metav1.Condition{Type: marin3rv1alpha1.VersionPinnedCondition, Status: metav1.ConditionTrue,
+				Reason: "PinnedVersionPublished", Message: "Version 'xxxx' is pinned"},
+		},
+		{
+			name: "Pinned version not found, sets the condition to false",
+			ec: &marin3rv1alpha1.EnvoyConfig{
+				Spec: marin3rv1alpha1.EnvoyConfigSpec{PinnedVersion: pointer.New("xxxx")},
+				Status: marin3rv1alpha1.EnvoyConfigStatus{Conditions: []metav1.Condition{
+					{Type: marin3rv1alpha1.VersionPinnedCondition, Status: metav1.ConditionTrue,
+						Reason: "PinnedVersionPublished", Message: "Version 'xxxx' is pinned"},
+				}},
+			},
+			cacheState: marin3rv1alpha1.InSyncState,
+			want:       false,
+			wantCond: &metav1.Condition{Type: marin3rv1alpha1.VersionPinnedCondition, Status: metav1.ConditionFalse,
+				Reason: "PinnedVersionNotFound", Message: "Version 'xxxx' is pinned but there is no revision with that version"},
+		},
+		{
+			name: "Pin removed, removes the condition",
+			ec: &marin3rv1alpha1.EnvoyConfig{
+				Status: marin3rv1alpha1.EnvoyConfigStatus{Conditions: []metav1.Condition{
+					{Type: marin3rv1alpha1.VersionPinnedCondition, Status: metav1.ConditionTrue,
+						Reason: "PinnedVersionPublished", Message: "Version 'xxxx' is pinned"},
+				}},
+			},
+			cacheState: marin3rv1alpha1.InSyncState,
+			want:       false,
+			wantCond:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVersionPinnedConditionReconciled(tt.ec, tt.cacheState); got != tt.want {
+				t.Errorf("isVersionPinnedConditionReconciled() = %v, want %v", got, tt.want)
+			}
+			cond := meta.FindStatusCondition(tt.ec.Status.Conditions, marin3rv1alpha1.VersionPinnedCondition)
+			if cond != nil {
+				cond.LastTransitionTime = metav1.Time{}
+			}
+			if !reflect.DeepEqual(cond, tt.wantCond) {
+				t.Errorf("isVersionPinnedConditionReconciled() condition = %v, want %v", cond, tt.wantCond)
+			}
+		})
+	}
+}
+
+func Test_isPublishingHeldConditionReconciled(t *testing.T) {
+	heldCond := metav1.Condition{Type: marin3rv1alpha1.PublishingHeldCondition, Status: metav1.ConditionTrue,
+		Reason: "Paused", Message: "Version 'xxxx' is waiting to be published because publishing is paused"}
+	tests := []struct {
+		name       string
+		ec         *marin3rv1alpha1.EnvoyConfig
+		cacheState string
+		want       bool
+		wantCond   *metav1.Condition
+	}{
+		{
+			name:       "Nothing held, returns true",
+			ec:         &marin3rv1alpha1.EnvoyConfig{},
+			cacheState: marin3rv1alpha1.InSyncState,
+			want:       true,
+			wantCond:   nil,
+		},
+		{
+			name:       "Paused, sets the condition",
+			ec:         &marin3rv1alpha1.EnvoyConfig{},
+			cacheState: marin3rv1alpha1.PausedState,
+			want:       false,
+			wantCond:   &heldCond,
+		},
+		{
+			name:       "Waiting for the publish schedule, sets the condition",
+			ec:         &marin3rv1alpha1.EnvoyConfig{Status: marin3rv1alpha1.EnvoyConfigStatus{Conditions: []metav1.Condition{heldCond}}},
+			cacheState: marin3rv1alpha1.WaitingForScheduleState,
+			want:       false,
+			wantCond: &metav1.Condition{Type: marin3rv1alpha1.PublishingHeldCondition, Status: metav1.ConditionTrue,
+				Reason: "OutsidePublishSchedule", Message: "Version 'xxxx' is waiting for the next window of the publish schedule"},
+		},
+		{
+			name:       "Waiting for approval, sets the condition",
+			ec:         &marin3rv1alpha1.EnvoyConfig{},
+			cacheState: marin3rv1alpha1.WaitingForApprovalState,
+			want:       false,
+			wantCond: &metav1.Condition{Type: marin3rv1alpha1.PublishingHeldCondition, Status: metav1.ConditionTrue,
+				Reason: "WaitingForApproval", Message: "Version 'xxxx' is waiting to be approved with the 'marin3r.3scale.net/approved-version' annotation"},
+		},
+		{
+			name:       "Revision published, removes the condition",
+			ec:         &marin3rv1alpha1.EnvoyConfig{Status: marin3rv1alpha1.EnvoyConfigStatus{Conditions: []metav1.Condition{heldCond}}},
+			cacheState: marin3rv1alpha1.InSyncState,
+			want:       false,
+			wantCond:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPublishingHeldConditionReconciled(tt.ec, tt.cacheState, "xxxx"); got != tt.want {
+				t.Errorf("isPublishingHeldConditionReconciled() = %v, want %v", got, tt.want)
+			}
+			cond := meta.FindStatusCondition(tt.ec.Status.Conditions, marin3rv1alpha1.PublishingHeldCondition)
+			if cond != nil {
+				cond.LastTransitionTime = metav1.Time{}
+			}
+			if !reflect.DeepEqual(cond, tt.wantCond) {
+				t.Errorf("isPublishingHeldConditionReconciled() condition = %v, want %v", cond, tt.wantCond)
+			}
+		})
+	}
+}
+
 func Test_generateRevisionList(t *testing.T) {
 	type args struct {
 		list *marin3rv1alpha1.EnvoyConfigRevisionList
@@ -278,10 +413,18 @@ func Test_generateRevisionList(t *testing.T) {
 						ObjectMeta: metav1.ObjectMeta{
 							Name:      "ecr2",
 							Namespace: "test",
+							Annotations: map[string]string{
+								marin3rv1alpha1.ChangeCauseAnnotation:  "new cluster",
+								marin3rv1alpha1.ChangedByAnnotation:    "user",
+								marin3rv1alpha1.SourceCommitAnnotation: "abcdef",
+							},
 						},
 						Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
 							Version: "2",
 						},
+						Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+							LastPublishedAt: &metav1.Time{Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+						},
 					},
 				},
 			}},
@@ -299,6 +442,10 @@ func Test_generateRevisionList(t *testing.T) {
 						Name:      "ecr2",
 						Namespace: "test",
 					},
+					PublishedAt:  &metav1.Time{Time: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)},
+					ChangeCause:  "new cluster",
+					ChangedBy:    "user",
+					SourceCommit: "abcdef",
 				},
 			},
 		},
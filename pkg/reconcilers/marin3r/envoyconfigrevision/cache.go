@@ -3,8 +3,10 @@ package reconcilers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
 	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
 	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
 	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
@@ -14,6 +16,8 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -23,8 +27,17 @@ import (
 const (
 	secretCertificate = "tls.crt"
 	secretPrivateKey  = "tls.key"
+	secretCA          = "ca.crt"
 )
 
+// certManagerCertificateGVK is the GroupVersionKind of cert-manager Certificates. They are
+// read as unstructured objects so cert-manager is not required to run the discovery service.
+var certManagerCertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// snapshotTypes are the resource types held in the xDS snapshots
+var snapshotTypes = []envoy.Type{envoy.Endpoint, envoy.Cluster, envoy.Route, envoy.ScopedRoute,
+	envoy.Listener, envoy.Secret, envoy.Runtime, envoy.ExtensionConfig}
+
 type CacheReconciler struct {
 	ctx       context.Context
 	logger    logr.Logger
@@ -83,7 +96,11 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 	extensionConfigs := make([]envoy.Resource, 0, len(resources))
 	secrets := make([]envoy.Resource, 0, len(resources))
 
+	ttls := map[envoy.Type]map[envoy.Resource]time.Duration{}
+
 	for idx, resourceDefinition := range resources {
+		var generated envoy.Resource
+
 		switch resourceDefinition.Type {
 
 		case envoy.Endpoint:
@@ -99,6 +116,7 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					return nil, err
 				}
 				endpoints = append(endpoints, endpoint)
+				generated = endpoint
 
 			} else {
 				// Raw value provided
@@ -111,6 +129,7 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 						)
 				}
 				endpoints = append(endpoints, res)
+				generated = res
 			}
 
 		case envoy.Cluster:
@@ -123,6 +142,7 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					)
 			}
 			clusters = append(clusters, res)
+			generated = res
 
 		case envoy.Route:
 			res := r.generator.New(envoy.Route)
@@ -134,6 +154,7 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					)
 			}
 			routes = append(routes, res)
+			generated = res
 
 		case envoy.ScopedRoute:
 			res := r.generator.New(envoy.ScopedRoute)
@@ -145,6 +166,7 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					)
 			}
 			scopedRoutes = append(scopedRoutes, res)
+			generated = res
 
 		case envoy.Listener:
 			res := r.generator.New(envoy.Listener)
@@ -156,16 +178,16 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					)
 			}
 			listeners = append(listeners, res)
+			generated = res
 
 		case envoy.Secret:
 			var res envoy.Resource
-			s := &corev1.Secret{}
 
 			if resourceDefinition.GenerateFromTlsSecret != nil {
 				name := *resourceDefinition.GenerateFromTlsSecret
-				key := types.NamespacedName{Name: name, Namespace: req.Namespace}
-				if err := r.client.Get(r.ctx, key, s); err != nil {
-					return nil, fmt.Errorf("%s", err.Error())
+				s, err := r.getSecret(req, resourceDefinition, name)
+				if err != nil {
+					return nil, err
 				}
 				if s.Type != corev1.SecretTypeTLS {
 					return nil, fmt.Errorf("expected Secret of '%s' type", corev1.SecretTypeTLS)
@@ -179,9 +201,9 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 
 			} else if resourceDefinition.GenerateFromOpaqueSecret != nil {
 				name := resourceDefinition.GenerateFromOpaqueSecret.Name
-				key := types.NamespacedName{Name: name, Namespace: req.Namespace}
-				if err := r.client.Get(r.ctx, key, s); err != nil {
-					return nil, fmt.Errorf("%s", err.Error())
+				s, err := r.getSecret(req, resourceDefinition, name)
+				if err != nil {
+					return nil, err
 				}
 				if s.Type != corev1.SecretTypeOpaque {
 					return nil, fmt.Errorf("expected Secret of '%s' type", corev1.SecretTypeOpaque)
@@ -193,25 +215,74 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 				spew.Dump(yaml)
 				fmt.Println("###################################")
 
+			} else if resourceDefinition.GenerateFromCertificate != nil {
+				name := resourceDefinition.GenerateFromCertificate.Name
+				s, err := r.getCertificateSecret(req, *resourceDefinition.GenerateFromCertificate)
+				if err != nil {
+					return nil, err
+				}
+				ca, hasCA := s.Data[secretCA]
+				switch resourceDefinition.GetBlueprint() {
+				case marin3rv1alpha1.TlsCertificate:
+					res = r.generator.NewTlsCertificateSecret(name, string(s.Data[secretPrivateKey]), string(s.Data[secretCertificate]))
+					// the issuing CA is published alongside the certificate
+					if hasCA {
+						caRes := r.generator.NewValidationContextSecret(name+marin3rv1alpha1.CertificateAuthoritySuffix, string(ca))
+						secrets = append(secrets, caRes)
+						if resourceDefinition.TTL != nil {
+							if _, ok := ttls[envoy.Secret]; !ok {
+								ttls[envoy.Secret] = map[envoy.Resource]time.Duration{}
+							}
+							ttls[envoy.Secret][caRes] = resourceDefinition.TTL.Duration
+						}
+					}
+				case marin3rv1alpha1.TlsValidationContext:
+					if !hasCA {
+						ca = s.Data[secretCertificate]
+					}
+					res = r.generator.NewValidationContextSecret(name, string(ca))
+				}
+
 			} else {
 				return nil, resourceLoaderError(
 					req, resourceDefinition, field.NewPath("spec", "resources").Index(idx),
-					"one of 'generateFromOpaqueSecret', 'generateFromTlsSecret' must be set",
+					"one of 'generateFromOpaqueSecret', 'generateFromTlsSecret', 'generateFromCertificate' must be set",
 				)
 			}
 
 			secrets = append(secrets, res)
+			generated = res
 
 		case envoy.Runtime:
-			res := r.generator.New(envoy.Runtime)
-			if err := r.decoder.Unmarshal(string(resourceDefinition.Value.Raw), res); err != nil {
-				return nil,
-					resourceLoaderError(
-						req, string(resourceDefinition.Value.Raw), field.NewPath("spec", "resources").Index(idx).Child("value"),
-						fmt.Sprintf("Invalid envoy resource value: '%s'", err),
+
+			if resourceDefinition.GenerateFromConfigMap != nil {
+				// Runtime layer generated from a ConfigMap
+				name := *resourceDefinition.GenerateFromConfigMap
+				cm := &corev1.ConfigMap{}
+				key := types.NamespacedName{Name: name, Namespace: req.Namespace}
+				if err := r.client.Get(r.ctx, key, cm); err != nil {
+					return nil, resourceLoaderError(
+						req, resourceDefinition, field.NewPath("spec", "resources").Index(idx),
+						fmt.Sprintf("unable to get ConfigMap '%s': %s", name, err),
 					)
+				}
+				res := r.generator.NewRuntime(name, cm.Data)
+				runtimes = append(runtimes, res)
+				generated = res
+
+			} else {
+				// Raw value provided
+				res := r.generator.New(envoy.Runtime)
+				if err := r.decoder.Unmarshal(string(resourceDefinition.Value.Raw), res); err != nil {
+					return nil,
+						resourceLoaderError(
+							req, string(resourceDefinition.Value.Raw), field.NewPath("spec", "resources").Index(idx).Child("value"),
+							fmt.Sprintf("Invalid envoy resource value: '%s'", err),
+						)
+				}
+				runtimes = append(runtimes, res)
+				generated = res
 			}
-			runtimes = append(runtimes, res)
 
 		case envoy.ExtensionConfig:
 			res := r.generator.New(envoy.ExtensionConfig)
@@ -223,11 +294,18 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 					)
 			}
 			extensionConfigs = append(extensionConfigs, res)
+			generated = res
 
 		default:
 
 		}
 
+		if resourceDefinition.TTL != nil && generated != nil {
+			if _, ok := ttls[resourceDefinition.Type]; !ok {
+				ttls[resourceDefinition.Type] = map[envoy.Resource]time.Duration{}
+			}
+			ttls[resourceDefinition.Type][generated] = resourceDefinition.TTL.Duration
+		}
 	}
 
 	snap.SetResources(envoy.Endpoint, endpoints)
@@ -239,9 +317,102 @@ func (r *CacheReconciler) GenerateSnapshot(req types.NamespacedName, resources [
 	snap.SetResources(envoy.Runtime, runtimes)
 	snap.SetResources(envoy.ExtensionConfig, extensionConfigs)
 
+	for rType, resources := range ttls {
+		for res, ttl := range resources {
+			snap.SetTTL(rType, res, ttl)
+		}
+	}
+
 	return snap, nil
 }
 
+// getSecret returns the Kubernetes Secret a resource is generated from. Secrets from other
+// namespaces require a SecretReferenceGrant in the namespace of the Secret that allows it.
+func (r *CacheReconciler) getSecret(req types.NamespacedName, res marin3rv1alpha1.Resource, name string) (*corev1.Secret, error) {
+	key := types.NamespacedName{Name: name, Namespace: res.GetSecretNamespace(req.Namespace)}
+
+	if key.Namespace != req.Namespace {
+		grants := &marin3rv1alpha1.SecretReferenceGrantList{}
+		if err := r.client.List(r.ctx, grants, client.InNamespace(key.Namespace)); err != nil {
+			return nil, err
+		}
+		permitted := false
+		for _, grant := range grants.Items {
+			if grant.Permits(req.Namespace, name) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			return nil, fmt.Errorf("reference to Secret '%s' is not allowed by any SecretReferenceGrant in namespace '%s'", key, key.Namespace)
+		}
+	}
+
+	s := &corev1.Secret{}
+	if err := r.client.Get(r.ctx, key, s); err != nil {
+		return nil, fmt.Errorf("%s", err.Error())
+	}
+	return s, nil
+}
+
+// getCertificateSecret returns the Kubernetes Secret a certificate is written to. It fails
+// if the certificate is not ready yet, so the snapshot is generated again once it is.
+func (r *CacheReconciler) getCertificateSecret(req types.NamespacedName, ref marin3rv1alpha1.CertificateReference) (*corev1.Secret, error) {
+	key := types.NamespacedName{Name: ref.Name, Namespace: req.Namespace}
+	var secretName string
+
+	switch ref.Kind {
+	case marin3rv1alpha1.CertManagerCertificate:
+		cert := &unstructured.Unstructured{}
+		cert.SetGroupVersionKind(certManagerCertificateGVK)
+		if err := r.client.Get(r.ctx, key, cert); err != nil {
+			return nil, err
+		}
+		if !isCertManagerCertificateReady(cert) {
+			return nil, fmt.Errorf("%s '%s' is not ready", ref.Kind, key)
+		}
+		secretName, _, _ = unstructured.NestedString(cert.Object, "spec", "secretName")
+
+	case marin3rv1alpha1.DiscoveryServiceCertificateKind:
+		dsc := &operatorv1alpha1.DiscoveryServiceCertificate{}
+		if err := r.client.Get(r.ctx, key, dsc); err != nil {
+			return nil, err
+		}
+		if !dsc.Status.IsReady() {
+			return nil, fmt.Errorf("%s '%s' is not ready", ref.Kind, key)
+		}
+		secretName = dsc.Spec.SecretRef.Name
+
+	default:
+		return nil, fmt.Errorf("unsupported certificate kind '%s'", ref.Kind)
+	}
+
+	if secretName == "" {
+		return nil, fmt.Errorf("%s '%s' does not specify a Secret", ref.Kind, key)
+	}
+	s := &corev1.Secret{}
+	if err := r.client.Get(r.ctx, types.NamespacedName{Name: secretName, Namespace: req.Namespace}, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// isCertManagerCertificateReady returns true if the "Ready" condition
+// of the cert-manager Certificate is "True"
+func isCertManagerCertificateReady(cert *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			return condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
 func resourceLoaderError(req types.NamespacedName, value interface{}, resPath *field.Path, msg string) error {
 	return errors.NewInvalid(
 		schema.GroupKind{Group: "envoy", Kind: "EnvoyConfig"},
@@ -251,8 +422,7 @@ func resourceLoaderError(req types.NamespacedName, value interface{}, resPath *f
 }
 
 func areDifferent(a, b xdss.Snapshot) bool {
-	for _, rType := range []envoy.Type{envoy.Endpoint, envoy.Cluster, envoy.Route, envoy.ScopedRoute,
-		envoy.Listener, envoy.Secret, envoy.Runtime, envoy.ExtensionConfig} {
+	for _, rType := range snapshotTypes {
 		if a.GetVersion(rType) != b.GetVersion(rType) {
 			return true
 		}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
 	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
 	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
 	"github.com/3scale-ops/marin3r/pkg/envoy"
@@ -26,7 +28,10 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -160,6 +165,23 @@ func TestCacheReconciler_Reconcile(t *testing.T) {
 }
 
 func TestCacheReconciler_GenerateSnapshot(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	marin3rv1alpha1.AddToScheme(s)
+	operatorv1alpha1.AddToScheme(s)
+
+	certificate := func(ready string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata":   map[string]interface{}{"name": "cert", "namespace": "xx"},
+			"spec":       map[string]interface{}{"secretName": "cert-tls"},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": ready}},
+			},
+		}}
+	}
+
 	type fields struct {
 		ctx       context.Context
 		logger    logr.Logger
@@ -221,6 +243,34 @@ func TestCacheReconciler_GenerateSnapshot(t *testing.T) {
 				}),
 			wantErr: false,
 		},
+		{
+			name: "Loads resources with TTL into the snapshot",
+			fields: fields{
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				client:    fake.NewClientBuilder().Build(),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension("{\"name\": \"cluster\"}")},
+					{Type: envoy.Runtime, Value: k8sutil.StringtoRawExtension("{\"name\": \"runtime\"}"),
+						TTL: &metav1.Duration{Duration: 30 * time.Second}},
+				},
+			},
+			want: xdss_v3.NewSnapshot().
+				SetResources(envoy.Cluster, []envoy.Resource{
+					&envoy_config_cluster_v3.Cluster{Name: "cluster"},
+				}).
+				SetResources(envoy.Runtime, []envoy.Resource{
+					&envoy_service_runtime_v3.Runtime{Name: "runtime"},
+				}).
+				SetTTL(envoy.Runtime, &envoy_service_runtime_v3.Runtime{Name: "runtime"}, 30*time.Second),
+			wantErr: false,
+		},
 		{
 			name: "Error, bad endpoint value",
 			fields: fields{
@@ -335,6 +385,50 @@ func TestCacheReconciler_GenerateSnapshot(t *testing.T) {
 			wantErr: true,
 			want:    xdss_v3.NewSnapshot(),
 		},
+		{
+			name: "Loads runtime resources from ConfigMaps into the snapshot (v3)",
+			fields: fields{
+				ctx:    context.TODO(),
+				logger: ctrl.Log.WithName("test"),
+				client: fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "flags", Namespace: "xx"},
+					Data:       map[string]string{"feature.enabled": "true", "feature.rate": "5%"},
+				}).Build(),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Runtime, GenerateFromConfigMap: pointer.New("flags")},
+				},
+			},
+			wantErr: false,
+			want: xdss_v3.NewSnapshot().
+				SetResources(envoy.Runtime, []envoy.Resource{
+					envoy_resources_v3.Generator{}.NewRuntime("flags", map[string]string{"feature.enabled": "true", "feature.rate": "5%"}),
+				}),
+		},
+		{
+			name: "Fails when the runtime ConfigMap does not exist",
+			fields: fields{
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				client:    fake.NewClientBuilder().Build(),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Runtime, GenerateFromConfigMap: pointer.New("flags")},
+				},
+			},
+			wantErr: true,
+			want:    xdss_v3.NewSnapshot(),
+		},
 		{
 			name: "Loads secret:tlsCertificate resources into the snapshot (v3)",
 			fields: fields{
@@ -449,6 +543,167 @@ func TestCacheReconciler_GenerateSnapshot(t *testing.T) {
 			wantErr: true,
 			want:    xdss_v3.NewSnapshot(),
 		},
+		{
+			name: "Loads secrets from other namespaces allowed by a SecretReferenceGrant",
+			fields: fields{
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "certs"},
+						Type:       corev1.SecretTypeTLS,
+						Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+					},
+					&marin3rv1alpha1.SecretReferenceGrant{
+						ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "certs"},
+						Spec: marin3rv1alpha1.SecretReferenceGrantSpec{
+							From: []marin3rv1alpha1.SecretReferenceGrantFrom{{Namespace: "xx"}},
+							To:   []marin3rv1alpha1.SecretReferenceGrantTo{{Name: "secret"}},
+						},
+					},
+				).Build(),
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("secret"), SecretNamespace: pointer.New("certs")},
+				},
+			},
+			wantErr: false,
+			want: xdss_v3.NewSnapshot().
+				SetResources(envoy.Secret, []envoy.Resource{
+					envoy_resources_v3.Generator{}.NewTlsCertificateSecret("secret", "key", "cert"),
+				}),
+		},
+		{
+			name: "Fails for secrets from other namespaces without a SecretReferenceGrant",
+			fields: fields{
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "certs"},
+						Type:       corev1.SecretTypeTLS,
+						Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+					},
+					&marin3rv1alpha1.SecretReferenceGrant{
+						ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "certs"},
+						Spec: marin3rv1alpha1.SecretReferenceGrantSpec{
+							From: []marin3rv1alpha1.SecretReferenceGrantFrom{{Namespace: "other"}},
+						},
+					},
+				).Build(),
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Secret, GenerateFromTlsSecret: pointer.New("secret"), SecretNamespace: pointer.New("certs")},
+				},
+			},
+			wantErr: true,
+			want:    xdss_v3.NewSnapshot(),
+		},
+		{
+			name: "Loads secrets from a cert-manager Certificate, including the issuing CA",
+			fields: fields{
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					certificate("True"),
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "cert-tls", Namespace: "xx"},
+						Type:       corev1.SecretTypeTLS,
+						Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key"), "ca.crt": []byte("ca")},
+					},
+				).Build(),
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Secret, GenerateFromCertificate: &marin3rv1alpha1.CertificateReference{
+						Kind: marin3rv1alpha1.CertManagerCertificate, Name: "cert"}},
+				},
+			},
+			wantErr: false,
+			want: xdss_v3.NewSnapshot().
+				SetResources(envoy.Secret, []envoy.Resource{
+					envoy_resources_v3.Generator{}.NewValidationContextSecret("cert-ca", "ca"),
+					envoy_resources_v3.Generator{}.NewTlsCertificateSecret("cert", "key", "cert"),
+				}),
+		},
+		{
+			name: "Fails if the cert-manager Certificate is not ready",
+			fields: fields{
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					certificate("False"),
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "cert-tls", Namespace: "xx"},
+						Type:       corev1.SecretTypeTLS,
+						Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+					},
+				).Build(),
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Secret, GenerateFromCertificate: &marin3rv1alpha1.CertificateReference{
+						Kind: marin3rv1alpha1.CertManagerCertificate, Name: "cert"}},
+				},
+			},
+			wantErr: true,
+			want:    xdss_v3.NewSnapshot(),
+		},
+		{
+			name: "Loads secret:validationContext resources from a DiscoveryServiceCertificate",
+			fields: fields{
+				client: fake.NewClientBuilder().WithScheme(s).WithObjects(
+					&operatorv1alpha1.DiscoveryServiceCertificate{
+						ObjectMeta: metav1.ObjectMeta{Name: "dsc", Namespace: "xx"},
+						Spec: operatorv1alpha1.DiscoveryServiceCertificateSpec{
+							SecretRef: corev1.SecretReference{Name: "dsc-tls"},
+						},
+						Status: operatorv1alpha1.DiscoveryServiceCertificateStatus{Ready: pointer.New(true)},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "dsc-tls", Namespace: "xx"},
+						Type:       corev1.SecretTypeTLS,
+						Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+					},
+				).Build(),
+				ctx:       context.TODO(),
+				logger:    ctrl.Log.WithName("test"),
+				xdsCache:  xdss_v3.NewCache(),
+				decoder:   envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3),
+				generator: envoy_resources_v3.Generator{},
+			},
+			args: args{
+				req: types.NamespacedName{Name: "xx", Namespace: "xx"},
+				resources: []marin3rv1alpha1.Resource{
+					{Type: envoy.Secret, Blueprint: pointer.New(marin3rv1alpha1.TlsValidationContext),
+						GenerateFromCertificate: &marin3rv1alpha1.CertificateReference{
+							Kind: marin3rv1alpha1.DiscoveryServiceCertificateKind, Name: "dsc"}},
+				},
+			},
+			wantErr: false,
+			want: xdss_v3.NewSnapshot().
+				SetResources(envoy.Secret, []envoy.Resource{
+					envoy_resources_v3.Generator{}.NewValidationContextSecret("dsc", "cert"),
+				}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
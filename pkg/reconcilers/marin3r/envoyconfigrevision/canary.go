@@ -0,0 +1,50 @@
+package reconcilers
+
+import (
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// IsCanary returns true if the revision is the canary of a rollout
+func IsCanary(ecr *marin3rv1alpha1.EnvoyConfigRevision) bool {
+	return !meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) &&
+		meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionCanaryCondition) &&
+		ecr.Status.Canary != nil
+}
+
+// ReconcileCanary routes the canary clients of the revision to the canary snapshot of the node ID. If
+// the canary clients change, the streams of the node ID opened before 'now' are closed so the clients
+// reconnect and get served from the snapshot that corresponds to them.
+func ReconcileCanary(router xdss.CanaryRouter, streams xdss.StreamCloser, ecr *marin3rv1alpha1.EnvoyConfigRevision,
+	now time.Time, log logr.Logger) {
+
+	canary := xdss.Canary{
+		Version:    ecr.Spec.Version,
+		Percentage: ecr.Status.Canary.Percentage,
+		Pods:       ecr.Status.Canary.Pods,
+	}
+	if router.SetCanary(ecr.Spec.NodeID, canary) && streams != nil {
+		closed := streams.CloseStreams(ecr.Spec.NodeID, now)
+		log.Info("updated canary clients", "Version", ecr.Spec.Version, "ClosedStreams", len(closed))
+	}
+}
+
+// CleanupCanary removes the canary snapshot of the node ID if the revision was its canary, and
+// closes the streams of the node ID opened before 'now' so the canary clients reconnect and get
+// served from the snapshot of the node ID.
+func CleanupCanary(router xdss.CanaryRouter, streams xdss.StreamCloser, xdssCache xdss.Cache,
+	ecr *marin3rv1alpha1.EnvoyConfigRevision, now time.Time, log logr.Logger) {
+
+	if !router.ClearCanary(ecr.Spec.NodeID, ecr.Spec.Version) {
+		return
+	}
+	xdssCache.ClearSnapshot(xdss.CanarySnapshotKey(ecr.Spec.NodeID))
+	if streams != nil {
+		closed := streams.CloseStreams(ecr.Spec.NodeID, now)
+		log.Info("removed canary", "Version", ecr.Spec.Version, "ClosedStreams", len(closed))
+	}
+}
@@ -0,0 +1,101 @@
+package reconcilers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+type fakeStreamCloser struct {
+	calls int
+}
+
+func (f *fakeStreamCloser) CloseStreams(nodeID string, openedBefore time.Time, pods ...string) []string {
+	f.calls++
+	return []string{}
+}
+
+func testCanaryRevision(conditions ...metav1.Condition) *marin3rv1alpha1.EnvoyConfigRevision {
+	return &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default"},
+		Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v2"},
+		Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+			Canary:     &marin3rv1alpha1.CanaryTarget{Pods: []string{"pod-a"}},
+			Conditions: conditions,
+		},
+	}
+}
+
+func TestIsCanary(t *testing.T) {
+	canary := metav1.Condition{Type: marin3rv1alpha1.RevisionCanaryCondition, Status: metav1.ConditionTrue}
+	published := metav1.Condition{Type: marin3rv1alpha1.RevisionPublishedCondition, Status: metav1.ConditionTrue}
+	tests := []struct {
+		name string
+		ecr  *marin3rv1alpha1.EnvoyConfigRevision
+		want bool
+	}{
+		{"Returns true for a canary revision", testCanaryRevision(canary), true},
+		{"Returns false without the canary condition", testCanaryRevision(), false},
+		{"Returns false for a published revision", testCanaryRevision(canary, published), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCanary(tt.ecr); got != tt.want {
+				t.Errorf("IsCanary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileCanary(t *testing.T) {
+	router := xdss_v3.NewCanaryHash()
+	streams := &fakeStreamCloser{}
+	ecr := testCanaryRevision()
+
+	ReconcileCanary(router, streams, ecr, time.Now(), ctrl.Log)
+	if !router.IsCanary("node", "pod-a") || router.IsCanary("node", "pod-b") {
+		t.Errorf("ReconcileCanary() did not route the canary pods")
+	}
+	if streams.calls != 1 {
+		t.Errorf("ReconcileCanary() closed streams %d times, want 1", streams.calls)
+	}
+
+	// the streams are not closed again if the canary does not change
+	ReconcileCanary(router, streams, ecr, time.Now(), ctrl.Log)
+	if streams.calls != 1 {
+		t.Errorf("ReconcileCanary() closed streams %d times, want 1", streams.calls)
+	}
+}
+
+func TestCleanupCanary(t *testing.T) {
+	router := xdss_v3.NewCanaryHash()
+	streams := &fakeStreamCloser{}
+	cache := xdss_v3.NewCache()
+	ecr := testCanaryRevision()
+
+	router.SetCanary("node", xdss.Canary{Version: "v2", Pods: []string{"pod-a"}})
+	cache.SetSnapshot(context.TODO(), xdss.CanarySnapshotKey("node"), xdss_v3.NewSnapshot())
+
+	CleanupCanary(router, streams, cache, ecr, time.Now(), ctrl.Log)
+	if router.IsCanary("node", "pod-a") {
+		t.Errorf("CleanupCanary() did not remove the canary")
+	}
+	if _, err := cache.GetSnapshot(xdss.CanarySnapshotKey("node")); err == nil {
+		t.Errorf("CleanupCanary() did not remove the canary snapshot")
+	}
+	if streams.calls != 1 {
+		t.Errorf("CleanupCanary() closed streams %d times, want 1", streams.calls)
+	}
+
+	// nothing to do if the revision is not the canary
+	CleanupCanary(router, streams, cache, ecr, time.Now(), ctrl.Log)
+	if streams.calls != 1 {
+		t.Errorf("CleanupCanary() closed streams %d times, want 1", streams.calls)
+	}
+}
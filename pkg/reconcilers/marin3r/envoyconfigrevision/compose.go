@@ -0,0 +1,57 @@
+package reconcilers
+
+import (
+	"context"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/revisions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ComposeResources returns the resources that have to be published for the revision. These are
+// the resources of the revision, except for the resource types listed in status.typeRevisions,
+// which are taken from the revisions with the listed versions.
+func ComposeResources(ctx context.Context, k8sClient client.Client, ecr *marin3rv1alpha1.EnvoyConfigRevision) ([]marin3rv1alpha1.Resource, error) {
+
+	own, err := ecr.GetResources()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ecr.Status.TypeRevisions) == 0 {
+		return own, nil
+	}
+
+	overridden := map[envoy.Type]bool{}
+	for _, tr := range ecr.Status.TypeRevisions {
+		overridden[tr.Type] = true
+	}
+
+	resources := []marin3rv1alpha1.Resource{}
+	for _, res := range own {
+		if !overridden[res.Type] {
+			resources = append(resources, res)
+		}
+	}
+
+	for _, tr := range ecr.Status.TypeRevisions {
+		source, err := revisions.Get(ctx, k8sClient, ecr.GetNamespace(),
+			filters.ByNodeID(ecr.Spec.NodeID), filters.ByVersion(tr.Version), filters.ByEnvoyAPI(ecr.GetEnvoyAPIVersion()))
+		if err != nil {
+			return nil, err
+		}
+		sourceResources, err := source.GetResources()
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range sourceResources {
+			if res.Type == tr.Type {
+				resources = append(resources, res)
+			}
+		}
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,69 @@
+package reconcilers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestComposeResources(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	marin3rv1alpha1.AddToScheme(s)
+
+	resource := func(rType envoy.Type, value string) marin3rv1alpha1.Resource {
+		return marin3rv1alpha1.Resource{Type: rType, Value: &runtime.RawExtension{Raw: []byte(value)}}
+	}
+	revision := func(version string, resources ...marin3rv1alpha1.Resource) *marin3rv1alpha1.EnvoyConfigRevision {
+		return &marin3rv1alpha1.EnvoyConfigRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "node-v3-" + version,
+				Namespace: "test",
+				Labels: map[string]string{
+					filters.NodeIDTag:   "node",
+					filters.VersionTag:  version,
+					filters.EnvoyAPITag: envoy.APIv3.String(),
+				},
+			},
+			Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+				NodeID: "node", Version: version, EnvoyAPI: pointer.New(envoy.APIv3), Resources: resources,
+			},
+		}
+	}
+
+	old := revision("aaaa", resource(envoy.Cluster, `{"name":"a"}`), resource(envoy.Listener, `{"name":"old"}`))
+	ecr := revision("xxxx", resource(envoy.Cluster, `{"name":"b"}`), resource(envoy.Listener, `{"name":"new"}`))
+	k8sClient := fake.NewClientBuilder().WithScheme(s).WithObjects(old).Build()
+
+	got, err := ComposeResources(context.TODO(), k8sClient, ecr)
+	if err != nil {
+		t.Fatalf("ComposeResources() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, ecr.Spec.Resources) {
+		t.Errorf("ComposeResources() = %v, want %v", got, ecr.Spec.Resources)
+	}
+
+	ecr.Status.TypeRevisions = []marin3rv1alpha1.TypeRevision{{Type: envoy.Listener, Version: "aaaa"}}
+	got, err = ComposeResources(context.TODO(), k8sClient, ecr)
+	if err != nil {
+		t.Fatalf("ComposeResources() error = %v", err)
+	}
+	want := []marin3rv1alpha1.Resource{resource(envoy.Cluster, `{"name":"b"}`), resource(envoy.Listener, `{"name":"old"}`)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ComposeResources() = %v, want %v", got, want)
+	}
+
+	ecr.Status.TypeRevisions = []marin3rv1alpha1.TypeRevision{{Type: envoy.Listener, Version: "zzzz"}}
+	if _, err := ComposeResources(context.TODO(), k8sClient, ecr); err == nil {
+		t.Errorf("ComposeResources() expected an error for a missing revision")
+	}
+}
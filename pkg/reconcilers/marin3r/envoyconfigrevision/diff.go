@@ -0,0 +1,140 @@
+package reconcilers
+
+import (
+	"context"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss"
+	envoy "github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/revisions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SnapshotDiff holds, for each resource type with differences, the
+// resources that differ between two snapshots
+type SnapshotDiff map[envoy.Type]ResourcesDiff
+
+// ResourcesDiff holds the names of the resources of a type that have
+// been added, removed or changed
+type ResourcesDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// DiffSnapshots compares the resources of the 'to' snapshot with the ones
+// of the 'from' snapshot. A nil 'from' snapshot is considered empty.
+func DiffSnapshots(from, to xdss.Snapshot) SnapshotDiff {
+	diff := SnapshotDiff{}
+	for _, rType := range snapshotTypes {
+		var old map[string]envoy.Resource
+		if from != nil {
+			old = from.GetResources(rType)
+		}
+		added, removed, changed := envoy_resources.ResourcesDiff(old, to.GetResources(rType))
+		if len(added)+len(removed)+len(changed) > 0 {
+			diff[rType] = ResourcesDiff{Added: added, Removed: removed, Changed: changed}
+		}
+	}
+	return diff
+}
+
+// Summary returns the summary of the diff that is stored in the status of the revision
+func (d SnapshotDiff) Summary(previousVersion string, publishedAt metav1.Time) *marin3rv1alpha1.RevisionDiffSummary {
+	summary := &marin3rv1alpha1.RevisionDiffSummary{PreviousVersion: previousVersion, PublishedAt: publishedAt}
+	for _, rType := range snapshotTypes {
+		if rd, ok := d[rType]; ok {
+			summary.Added += len(rd.Added)
+			summary.Removed += len(rd.Removed)
+			summary.Changed += len(rd.Changed)
+			summary.ChangedTypes = append(summary.ChangedTypes, rType)
+		}
+	}
+	return summary
+}
+
+// PreviousRevision returns the revision that was published for the same node ID and Envoy API
+// before the given one, or nil if no other revision has been published before
+func PreviousRevision(ctx context.Context, k8sClient client.Client, ecr *marin3rv1alpha1.EnvoyConfigRevision) (*marin3rv1alpha1.EnvoyConfigRevision, error) {
+	list, err := revisions.List(ctx, k8sClient, ecr.GetNamespace(),
+		filters.ByNodeID(ecr.Spec.NodeID), filters.ByEnvoyAPI(ecr.GetEnvoyAPIVersion()))
+	if err != nil {
+		if revisions.ErrorIsNoMatchesForFilter(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var previous *marin3rv1alpha1.EnvoyConfigRevision
+	for idx := range list.Items {
+		item := &list.Items[idx]
+		if item.GetName() == ecr.GetName() || item.Status.LastPublishedAt == nil {
+			continue
+		}
+		if ecr.Status.LastPublishedAt != nil && !item.Status.LastPublishedAt.Before(ecr.Status.LastPublishedAt) {
+			continue
+		}
+		if previous == nil || previous.Status.LastPublishedAt.Before(item.Status.LastPublishedAt) {
+			previous = item
+		}
+	}
+	return previous, nil
+}
+
+// GenerateRevisionSnapshot generates the snapshot with the resources that are
+// published for the revision, without writing it to the xDS cache
+func (r *CacheReconciler) GenerateRevisionSnapshot(ecr *marin3rv1alpha1.EnvoyConfigRevision) (xdss.Snapshot, error) {
+	resources, err := ComposeResources(r.ctx, r.client, ecr)
+	if err != nil {
+		return nil, err
+	}
+	return r.GenerateSnapshot(types.NamespacedName{Name: ecr.GetName(), Namespace: ecr.GetNamespace()}, resources)
+}
+
+// DiffRevisions compares the snapshots of two revisions. A nil 'from' revision is considered empty.
+func (r *CacheReconciler) DiffRevisions(from, to *marin3rv1alpha1.EnvoyConfigRevision) (SnapshotDiff, error) {
+	toSnap, err := r.GenerateRevisionSnapshot(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromSnap xdss.Snapshot
+	if from != nil {
+		if fromSnap, err = r.GenerateRevisionSnapshot(from); err != nil {
+			return nil, err
+		}
+	}
+
+	return DiffSnapshots(fromSnap, toSnap), nil
+}
+
+// IsDiffReconciled calculates the diff of a published revision with the revision that was
+// published before it, once for each publication of the revision. It returns false if the
+// diff has been updated in the status of the revision.
+func (r *CacheReconciler) IsDiffReconciled(ecr *marin3rv1alpha1.EnvoyConfigRevision) (bool, error) {
+	if ecr.Status.LastPublishedAt == nil ||
+		(ecr.Status.Diff != nil && ecr.Status.Diff.PublishedAt.Equal(ecr.Status.LastPublishedAt)) {
+		return true, nil
+	}
+
+	previous, err := PreviousRevision(r.ctx, r.client, ecr)
+	if err != nil {
+		return true, err
+	}
+
+	diff, err := r.DiffRevisions(previous, ecr)
+	if err != nil {
+		return true, err
+	}
+
+	previousVersion := ""
+	if previous != nil {
+		previousVersion = previous.Spec.Version
+	}
+	ecr.Status.Diff = diff.Summary(previousVersion, *ecr.Status.LastPublishedAt)
+	return false, nil
+}
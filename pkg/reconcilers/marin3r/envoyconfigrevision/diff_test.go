@@ -0,0 +1,169 @@
+package reconcilers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	xdss_v3 "github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/v3"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	envoy_serializer "github.com/3scale-ops/marin3r/pkg/envoy/serializer"
+	"github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig/filters"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-test/deep"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testDiffRevision(version string, publishedAt *time.Time, resources ...string) *marin3rv1alpha1.EnvoyConfigRevision {
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node-v3-" + version,
+			Namespace: "test",
+			Labels: map[string]string{
+				filters.NodeIDTag:   "node",
+				filters.VersionTag:  version,
+				filters.EnvoyAPITag: envoy.APIv3.String(),
+			},
+		},
+		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: version, EnvoyAPI: pointer.New(envoy.APIv3)},
+	}
+	for _, value := range resources {
+		ecr.Spec.Resources = append(ecr.Spec.Resources,
+			marin3rv1alpha1.Resource{Type: envoy.Cluster, Value: &runtime.RawExtension{Raw: []byte(value)}})
+	}
+	if publishedAt != nil {
+		ecr.Status.LastPublishedAt = &metav1.Time{Time: *publishedAt}
+	}
+	return ecr
+}
+
+func TestPreviousRevision(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	marin3rv1alpha1.AddToScheme(s)
+
+	t0 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t1, t2 := t0.Add(time.Hour), t0.Add(2*time.Hour)
+
+	tests := []struct {
+		name    string
+		objs    []client.Object
+		ecr     *marin3rv1alpha1.EnvoyConfigRevision
+		want    string
+		wantNil bool
+	}{
+		{
+			name:    "No other revisions",
+			objs:    []client.Object{},
+			ecr:     testDiffRevision("cccc", &t2),
+			wantNil: true,
+		},
+		{
+			name:    "No other published revisions",
+			objs:    []client.Object{testDiffRevision("aaaa", nil)},
+			ecr:     testDiffRevision("cccc", &t2),
+			wantNil: true,
+		},
+		{
+			name: "Returns the last revision published before",
+			objs: []client.Object{testDiffRevision("aaaa", &t0), testDiffRevision("bbbb", &t1)},
+			ecr:  testDiffRevision("cccc", &t2),
+			want: "bbbb",
+		},
+		{
+			name: "Ignores revisions published later",
+			objs: []client.Object{testDiffRevision("aaaa", &t0), testDiffRevision("cccc", &t2)},
+			ecr:  testDiffRevision("bbbb", &t1),
+			want: "aaaa",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objs...).Build()
+			got, err := PreviousRevision(context.TODO(), cl, tt.ecr)
+			if err != nil {
+				t.Fatalf("PreviousRevision() error = %v", err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("PreviousRevision() = %v, want nil", got.Spec.Version)
+				}
+				return
+			}
+			if got == nil || got.Spec.Version != tt.want {
+				t.Errorf("PreviousRevision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheReconciler_IsDiffReconciled(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	marin3rv1alpha1.AddToScheme(s)
+
+	t0 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	previous := testDiffRevision("aaaa", &t0, `{"name":"a"}`, `{"name":"b"}`, `{"name":"c"}`)
+
+	tests := []struct {
+		name   string
+		ecr    *marin3rv1alpha1.EnvoyConfigRevision
+		want   bool
+		wantDS *marin3rv1alpha1.RevisionDiffSummary
+	}{
+		{
+			name:   "Not published",
+			ecr:    testDiffRevision("xxxx", nil, `{"name":"a"}`),
+			want:   true,
+			wantDS: nil,
+		},
+		{
+			name: "Calculates the diff with the previous revision",
+			ecr:  testDiffRevision("xxxx", &t1, `{"name":"a"}`, `{"name":"b","connect_timeout":"1s"}`, `{"name":"d"}`),
+			want: false,
+			wantDS: &marin3rv1alpha1.RevisionDiffSummary{
+				PreviousVersion: "aaaa",
+				PublishedAt:     metav1.Time{Time: t1},
+				Added:           1,
+				Removed:         1,
+				Changed:         1,
+				ChangedTypes:    []envoy.Type{envoy.Cluster},
+			},
+		},
+		{
+			name: "Diff already calculated for the publication",
+			ecr: func() *marin3rv1alpha1.EnvoyConfigRevision {
+				ecr := testDiffRevision("xxxx", &t1, `{"name":"a"}`)
+				ecr.Status.Diff = &marin3rv1alpha1.RevisionDiffSummary{PreviousVersion: "aaaa", PublishedAt: metav1.Time{Time: t1}}
+				return ecr
+			}(),
+			want:   true,
+			wantDS: &marin3rv1alpha1.RevisionDiffSummary{PreviousVersion: "aaaa", PublishedAt: metav1.Time{Time: t1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewCacheReconciler(context.TODO(), ctrl.Log.WithName("test"),
+				fake.NewClientBuilder().WithScheme(s).WithObjects(previous).Build(), xdss_v3.NewCache(),
+				envoy_serializer.NewResourceUnmarshaller(envoy_serializer.JSON, envoy.APIv3), envoy_resources.NewGenerator(envoy.APIv3))
+			got, err := r.IsDiffReconciled(tt.ecr)
+			if err != nil {
+				t.Fatalf("CacheReconciler.IsDiffReconciled() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CacheReconciler.IsDiffReconciled() = %v, want %v", got, tt.want)
+			}
+			if diff := deep.Equal(tt.ecr.Status.Diff, tt.wantDS); len(diff) > 0 {
+				t.Errorf("CacheReconciler.IsDiffReconciled() got diff: %v", diff)
+			}
+		})
+	}
+}
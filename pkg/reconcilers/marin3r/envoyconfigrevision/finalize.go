@@ -12,8 +12,10 @@ import (
 func CleanupLogic(ecr *marin3rv1alpha1.EnvoyConfigRevision, xdssCache xdss.Cache, discoveryStats *stats.Stats, log logr.Logger) {
 
 	if meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionPublishedCondition) {
-		discoveryStats.DeleteKeysByFilter(ecr.Spec.NodeID)
+		discoveryStats.DeleteKeysByFilter(stats.EscapeKeyValue(ecr.Spec.NodeID))
 		xdssCache.ClearSnapshot(ecr.Spec.NodeID)
 		log.Info("Successfully cleared xDS server cache", "XDSS", string(ecr.GetEnvoyAPIVersion()), "NodeID", ecr.Spec.NodeID)
+	} else {
+		discoveryStats.DeleteKeysByFilter(stats.EscapeKeyValue(ecr.Spec.NodeID), stats.EscapeKeyValue(ecr.Spec.Version), untaintedAtStat)
 	}
 }
@@ -2,7 +2,6 @@ package reconcilers
 
 import (
 	"fmt"
-	"math"
 	"reflect"
 
 	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
@@ -29,16 +28,26 @@ func IsStatusReconciled(ecr *marin3rv1alpha1.EnvoyConfigRevision, vt *marin3rv1a
 	// Note: tainted condition is never automatically removed to avoid retrying a bad config in the case of
 	// loss of statistics (i.e. a restart)
 	var taintedCond *metav1.Condition
+	var failingTypes []envoy.Type
 	if vt != nil {
-		taintedCond = calculateRevisionTaintedCondition(ecr, ecr.Status.ProvidesVersions, dStats, 1)
+		failingTypes = calculateFailingTypes(ecr, ecr.Status.ProvidesVersions, dStats)
+		taintedCond = calculateRevisionTaintedCondition(ecr, ecr.Status.ProvidesVersions, dStats)
 	}
 
-	if taintedCond != nil {
-		equal := k8sutil.ConditionsEqual(taintedCond, meta.FindStatusCondition(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition))
+	// A revision tainted for any other reason is tainted as a whole, and
+	// is not downgraded to a taint of just some of its resource types
+	current := meta.FindStatusCondition(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition)
+	if taintedCond != nil && (current == nil || current.Status != metav1.ConditionTrue || current.Reason == taintedCond.Reason) {
+		equal := k8sutil.ConditionsEqual(taintedCond, current)
 		if !equal {
 			meta.SetStatusCondition(&ecr.Status.Conditions, *taintedCond)
 			ok = false
 		}
+		// keep track of all the types that have been rejected since the revision was tainted
+		if tainted := mergeTypes(ecr.Status.TaintedTypes, failingTypes); !reflect.DeepEqual(tainted, ecr.Status.TaintedTypes) {
+			ecr.Status.TaintedTypes = tainted
+			ok = false
+		}
 	}
 
 	inSyncCond := calculateResourcesInSyncCondition(ecr, xdssCache)
@@ -103,23 +112,80 @@ func calculateResourcesInSyncCondition(ecr *marin3rv1alpha1.EnvoyConfigRevision,
 	return nil
 }
 
-func calculateRevisionTaintedCondition(ecr *marin3rv1alpha1.EnvoyConfigRevision, vt *marin3rv1alpha1.VersionTracker, dStats *stats.Stats, threshold float64) *metav1.Condition {
+// calculateRevisionTaintedCondition returns a RevisionTainted condition if, for any resource type, the percentage
+// of the Envoy clients that are rejecting the version of the type is equal or higher than the threshold set in the
+// taint policy of the revision. Clients are considered to be rejecting a version once they have sent the number of
+// NACKs set in the policy, and the revision is not tainted until the minimum number of clients set in the policy
+// has been observed.
+func calculateRevisionTaintedCondition(ecr *marin3rv1alpha1.EnvoyConfigRevision, vt *marin3rv1alpha1.VersionTracker, dStats *stats.Stats) *metav1.Condition {
 
-	if dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Endpoint, ecr.GetEnvoyAPIVersion()), vt.Endpoints) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Cluster, ecr.GetEnvoyAPIVersion()), vt.Clusters) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Route, ecr.GetEnvoyAPIVersion()), vt.Routes) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.ScopedRoute, ecr.GetEnvoyAPIVersion()), vt.ScopedRoutes) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Listener, ecr.GetEnvoyAPIVersion()), vt.Listeners) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Secret, ecr.GetEnvoyAPIVersion()), vt.Secrets) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.Runtime, ecr.GetEnvoyAPIVersion()), vt.Runtimes) == threshold ||
-		dStats.GetPercentageFailing(ecr.Spec.NodeID, envoy_resources.TypeURL(envoy.ExtensionConfig, ecr.GetEnvoyAPIVersion()), vt.ExtensionConfigs) == threshold {
-		return &metav1.Condition{
-			Type:    marin3rv1alpha1.RevisionTaintedCondition,
-			Reason:  "ResourcesFailing",
-			Status:  metav1.ConditionTrue,
-			Message: fmt.Sprintf("EnvoyConfigRevision resources are being rejected by more than %d%% of the Envoy clients", int(math.Round(threshold*100))),
+	failing := calculateFailingTypes(ecr, vt, dStats)
+	if len(failing) == 0 {
+		return nil
+	}
+
+	policy := ecr.Spec.TaintPolicy.ForType(failing[0])
+	return &metav1.Condition{
+		Type:   marin3rv1alpha1.RevisionTaintedCondition,
+		Reason: "ResourcesFailing",
+		Status: metav1.ConditionTrue,
+		Message: fmt.Sprintf("EnvoyConfigRevision resources of type '%s' are being rejected by %d%% or more of the Envoy clients "+
+			"(taint policy: %d or more NACKs per client, at least %d clients observed)",
+			failing[0], policy.GetFailingPodsPercentage(), policy.GetNACKThreshold(), policy.GetMinObservedPods()),
+	}
+}
+
+// calculateFailingTypes returns the resource types of the revision that are being rejected
+// by the Envoy clients, according to the taint policy of the revision
+func calculateFailingTypes(ecr *marin3rv1alpha1.EnvoyConfigRevision, vt *marin3rv1alpha1.VersionTracker, dStats *stats.Stats) []envoy.Type {
+
+	failing := []envoy.Type{}
+	for _, item := range []struct {
+		rType   envoy.Type
+		version string
+	}{
+		{envoy.Endpoint, vt.Endpoints},
+		{envoy.Cluster, vt.Clusters},
+		{envoy.Route, vt.Routes},
+		{envoy.ScopedRoute, vt.ScopedRoutes},
+		{envoy.Listener, vt.Listeners},
+		{envoy.Secret, vt.Secrets},
+		{envoy.Runtime, vt.Runtimes},
+		{envoy.ExtensionConfig, vt.ExtensionConfigs},
+	} {
+		policy := ecr.Spec.TaintPolicy.ForType(item.rType)
+		rejecting, observed := dStats.GetFailingPods(ecr.Spec.NodeID,
+			envoy_resources.TypeURL(item.rType, ecr.GetEnvoyAPIVersion()), item.version, policy.GetNACKThreshold())
+
+		if observed == 0 || observed < policy.GetMinObservedPods() {
+			continue
+		}
+		if rejecting*100 >= policy.GetFailingPodsPercentage()*observed {
+			failing = append(failing, item.rType)
 		}
 	}
 
-	return nil
+	return failing
+}
+
+// mergeTypes returns the union of the given lists of resource types,
+// preserving the order in which the types first appear
+func mergeTypes(a, b []envoy.Type) []envoy.Type {
+	if len(b) == 0 {
+		return a
+	}
+	merged := append([]envoy.Type{}, a...)
+	for _, t := range b {
+		found := false
+		for _, m := range merged {
+			if m == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, t)
+		}
+	}
+	return merged
 }
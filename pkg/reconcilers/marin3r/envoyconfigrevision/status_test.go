@@ -2,6 +2,7 @@ package reconcilers
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -379,10 +380,10 @@ func Test_calculateResourcesInSyncCondition(t *testing.T) {
 
 func Test_calculateRevisionTaintedCondition(t *testing.T) {
 	type args struct {
-		ecr        *marin3rv1alpha1.EnvoyConfigRevision
-		vt         *marin3rv1alpha1.VersionTracker
-		dStats     *stats.Stats
-		thresshold float64
+		ecr    *marin3rv1alpha1.EnvoyConfigRevision
+		vt     *marin3rv1alpha1.VersionTracker
+		dStats *stats.Stats
+		policy *marin3rv1alpha1.TaintPolicy
 	}
 	tests := []struct {
 		name string
@@ -417,7 +418,6 @@ func Test_calculateRevisionTaintedCondition(t *testing.T) {
 					"node:" + resource_v3.EndpointType + ":xxxx:pod-cccc:nack_counter":          {Object: int64(10), Expiration: int64(0)},
 					"node:" + resource_v3.EndpointType + ":xxxx:pod-dddd:nack_counter":          {Object: int64(10), Expiration: int64(0)},
 				}, time.Now()),
-				thresshold: 1,
 			},
 			want: corev1.ConditionTrue,
 		},
@@ -447,7 +447,7 @@ func Test_calculateRevisionTaintedCondition(t *testing.T) {
 					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
 					"node:" + resource_v3.EndpointType + ":xxxx:pod-bbbb:nack_counter":          {Object: int64(10), Expiration: int64(0)},
 				}, time.Now()),
-				thresshold: 0.5,
+				policy: &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{FailingPodsPercentage: pointer.New(50)}},
 			},
 			want: corev1.ConditionTrue,
 		},
@@ -476,10 +476,112 @@ func Test_calculateRevisionTaintedCondition(t *testing.T) {
 					"node:" + resource_v3.EndpointType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(2), Expiration: int64(0)},
 					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(1), Expiration: int64(0)},
 				}, time.Now()),
-				thresshold: 0.5,
+				policy: &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{FailingPodsPercentage: pointer.New(50)}},
 			},
 			want: corev1.ConditionFalse,
 		},
+		{
+			name: "More than the failing percentage fail, return taint",
+			args: args{
+				ecr: &marin3rv1alpha1.EnvoyConfigRevision{
+					ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+						NodeID:   "node",
+						EnvoyAPI: pointer.New(envoy.APIv3),
+					},
+				},
+				vt: &marin3rv1alpha1.VersionTracker{Endpoints: "xxxx"},
+				dStats: stats.NewWithItems(map[string]cache.Item{
+					"node:" + resource_v3.EndpointType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(2), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-bbbb:request_counter:stream_2": {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-cccc:request_counter:stream_3": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-dddd:request_counter:stream_4": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-bbbb:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-cccc:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+				}, time.Now()),
+				policy: &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{FailingPodsPercentage: pointer.New(50)}},
+			},
+			want: corev1.ConditionTrue,
+		},
+		{
+			name: "Less NACKs than the threshold, return nil",
+			args: args{
+				ecr: &marin3rv1alpha1.EnvoyConfigRevision{
+					ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+						NodeID:   "node",
+						EnvoyAPI: pointer.New(envoy.APIv3),
+					},
+				},
+				vt: &marin3rv1alpha1.VersionTracker{Endpoints: "xxxx"},
+				dStats: stats.NewWithItems(map[string]cache.Item{
+					"node:" + resource_v3.EndpointType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(2), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-bbbb:request_counter:stream_2": {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-cccc:request_counter:stream_3": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-dddd:request_counter:stream_4": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-bbbb:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-cccc:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-dddd:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+				}, time.Now()),
+				policy: &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{NACKThreshold: pointer.New(int64(10))}},
+			},
+			want: corev1.ConditionFalse,
+		},
+		{
+			name: "Less pods observed than the minimum, return nil",
+			args: args{
+				ecr: &marin3rv1alpha1.EnvoyConfigRevision{
+					ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+						NodeID:   "node",
+						EnvoyAPI: pointer.New(envoy.APIv3),
+					},
+				},
+				vt: &marin3rv1alpha1.VersionTracker{Endpoints: "xxxx"},
+				dStats: stats.NewWithItems(map[string]cache.Item{
+					"node:" + resource_v3.EndpointType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(2), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-bbbb:request_counter:stream_2": {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-cccc:request_counter:stream_3": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-dddd:request_counter:stream_4": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-bbbb:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-cccc:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-dddd:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+				}, time.Now()),
+				policy: &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{MinObservedPods: pointer.New(5)}},
+			},
+			want: corev1.ConditionFalse,
+		},
+		{
+			name: "Per type override, return taint",
+			args: args{
+				ecr: &marin3rv1alpha1.EnvoyConfigRevision{
+					ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+					Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+						NodeID:   "node",
+						EnvoyAPI: pointer.New(envoy.APIv3),
+					},
+				},
+				vt: &marin3rv1alpha1.VersionTracker{Endpoints: "xxxx"},
+				dStats: stats.NewWithItems(map[string]cache.Item{
+					"node:" + resource_v3.EndpointType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(2), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-bbbb:request_counter:stream_2": {Object: int64(5), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-cccc:request_counter:stream_3": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":*:pod-dddd:request_counter:stream_4": {Object: int64(1), Expiration: int64(0)},
+					"node:" + resource_v3.EndpointType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(1), Expiration: int64(0)},
+				}, time.Now()),
+				policy: &marin3rv1alpha1.TaintPolicy{
+					TaintThresholds: marin3rv1alpha1.TaintThresholds{MinObservedPods: pointer.New(5)},
+					Overrides: []marin3rv1alpha1.TaintPolicyOverride{{
+						Type:            envoy.Endpoint,
+						TaintThresholds: marin3rv1alpha1.TaintThresholds{NACKThreshold: pointer.New(int64(1)), FailingPodsPercentage: pointer.New(25), MinObservedPods: pointer.New(4)},
+					}},
+				},
+			},
+			want: corev1.ConditionTrue,
+		},
 		{
 			name: "No data, return nil",
 			args: args{
@@ -490,15 +592,15 @@ func Test_calculateRevisionTaintedCondition(t *testing.T) {
 						EnvoyAPI: pointer.New(envoy.APIv3),
 					},
 				}, vt: &marin3rv1alpha1.VersionTracker{},
-				dStats:     stats.NewWithItems(map[string]cache.Item{}, time.Now()),
-				thresshold: 1,
+				dStats: stats.NewWithItems(map[string]cache.Item{}, time.Now()),
 			},
 			want: corev1.ConditionFalse,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calculateRevisionTaintedCondition(tt.args.ecr, tt.args.vt, tt.args.dStats, tt.args.thresshold)
+			tt.args.ecr.Spec.TaintPolicy = tt.args.policy
+			got := calculateRevisionTaintedCondition(tt.args.ecr, tt.args.vt, tt.args.dStats)
 			if tt.want == corev1.ConditionFalse && got != nil {
 				t.Errorf("calculateRevisionTaintedCondition() = %v, want %v", got, tt.want)
 				return
@@ -510,3 +612,84 @@ func Test_calculateRevisionTaintedCondition(t *testing.T) {
 		})
 	}
 }
+
+func Test_calculateRevisionTaintedCondition_Message(t *testing.T) {
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+		Spec: marin3rv1alpha1.EnvoyConfigRevisionSpec{
+			NodeID:   "node",
+			EnvoyAPI: pointer.New(envoy.APIv3),
+			TaintPolicy: &marin3rv1alpha1.TaintPolicy{
+				TaintThresholds: marin3rv1alpha1.TaintThresholds{FailingPodsPercentage: pointer.New(50), MinObservedPods: pointer.New(2)},
+			},
+		},
+	}
+	dStats := stats.NewWithItems(map[string]cache.Item{
+		"node:" + resource_v3.ClusterType + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(1), Expiration: int64(0)},
+		"node:" + resource_v3.ClusterType + ":*:pod-bbbb:request_counter:stream_2": {Object: int64(1), Expiration: int64(0)},
+		"node:" + resource_v3.ClusterType + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+	}, time.Now())
+
+	got := calculateRevisionTaintedCondition(ecr, &marin3rv1alpha1.VersionTracker{Clusters: "xxxx"}, dStats)
+	want := "EnvoyConfigRevision resources of type 'cluster' are being rejected by 50% or more of the Envoy clients " +
+		"(taint policy: 5 or more NACKs per client, at least 2 clients observed)"
+	if got == nil || got.Message != want {
+		t.Errorf("calculateRevisionTaintedCondition() = %v, want message %q", got, want)
+	}
+}
+
+func TestIsStatusReconciled_TaintedTypes(t *testing.T) {
+	rejecting := func(typeURL string) *stats.Stats {
+		return stats.NewWithItems(map[string]cache.Item{
+			"node:" + typeURL + ":*:pod-aaaa:request_counter:stream_1": {Object: int64(1), Expiration: int64(0)},
+			"node:" + typeURL + ":xxxx:pod-aaaa:nack_counter":          {Object: int64(5), Expiration: int64(0)},
+		}, time.Now())
+	}
+	tainted := func(reason string, types ...envoy.Type) marin3rv1alpha1.EnvoyConfigRevisionStatus {
+		return marin3rv1alpha1.EnvoyConfigRevisionStatus{
+			TaintedTypes: types,
+			Conditions: []metav1.Condition{{
+				Type:   marin3rv1alpha1.RevisionTaintedCondition,
+				Status: metav1.ConditionTrue,
+				Reason: reason,
+			}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		status    marin3rv1alpha1.EnvoyConfigRevisionStatus
+		dStats    *stats.Stats
+		wantTypes []envoy.Type
+	}{
+		{
+			name:      "Records the rejected types",
+			dStats:    rejecting(resource_v3.ListenerType),
+			wantTypes: []envoy.Type{envoy.Listener},
+		},
+		{
+			name:      "Keeps the types that were rejected before",
+			status:    tainted("ResourcesFailing", envoy.Cluster),
+			dStats:    rejecting(resource_v3.ListenerType),
+			wantTypes: []envoy.Type{envoy.Cluster, envoy.Listener},
+		},
+		{
+			name:      "Does not record types for revisions tainted as a whole",
+			status:    tainted("FailedLoadingResources"),
+			dStats:    rejecting(resource_v3.ListenerType),
+			wantTypes: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+				Spec:   marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "xxxx"},
+				Status: tt.status,
+			}
+			IsStatusReconciled(ecr, &marin3rv1alpha1.VersionTracker{Clusters: "xxxx", Listeners: "xxxx"}, xdss_v3.NewCache(), tt.dStats)
+			if !reflect.DeepEqual(ecr.Status.TaintedTypes, tt.wantTypes) {
+				t.Errorf("IsStatusReconciled() taintedTypes = %v, want %v", ecr.Status.TaintedTypes, tt.wantTypes)
+			}
+		})
+	}
+}
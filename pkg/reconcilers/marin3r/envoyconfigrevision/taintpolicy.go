@@ -0,0 +1,34 @@
+package reconcilers
+
+import (
+	"context"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TaintPolicyFromOwner replaces, in memory, the taint policy of the revision with the taint
+// policy of the EnvoyConfig that owns it, so changes to the policy of the EnvoyConfig apply
+// to all of its revisions without having to update them. Revisions without an owning
+// EnvoyConfig keep the policy of their spec.
+func TaintPolicyFromOwner(ctx context.Context, cl client.Client, o client.Object) error {
+	ecr := o.(*marin3rv1alpha1.EnvoyConfigRevision)
+
+	owner := metav1.GetControllerOf(ecr)
+	if owner == nil || owner.Kind != "EnvoyConfig" {
+		return nil
+	}
+
+	ec := &marin3rv1alpha1.EnvoyConfig{}
+	if err := cl.Get(ctx, types.NamespacedName{Name: owner.Name, Namespace: ecr.GetNamespace()}, ec); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	ecr.Spec.TaintPolicy = ec.Spec.TaintPolicy
+	return nil
+}
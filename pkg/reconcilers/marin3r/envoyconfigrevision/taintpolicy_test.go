@@ -0,0 +1,72 @@
+package reconcilers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTaintPolicyFromOwner(t *testing.T) {
+	s := runtime.NewScheme()
+	scheme.AddToScheme(s)
+	marin3rv1alpha1.AddToScheme(s)
+
+	ownerPolicy := &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{NACKThreshold: pointer.New(int64(1))}}
+	revisionPolicy := &marin3rv1alpha1.TaintPolicy{TaintThresholds: marin3rv1alpha1.TaintThresholds{NACKThreshold: pointer.New(int64(10))}}
+	ec := &marin3rv1alpha1.EnvoyConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "test"},
+		Spec:       marin3rv1alpha1.EnvoyConfigSpec{NodeID: "node", TaintPolicy: ownerPolicy},
+	}
+	revision := func(owner string) *marin3rv1alpha1.EnvoyConfigRevision {
+		ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+			ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "test"},
+			Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v1", TaintPolicy: revisionPolicy},
+		}
+		if owner != "" {
+			ecr.SetOwnerReferences([]metav1.OwnerReference{{
+				APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfig", Name: owner, Controller: pointer.New(true),
+			}})
+		}
+		return ecr
+	}
+
+	tests := []struct {
+		name string
+		ecr  *marin3rv1alpha1.EnvoyConfigRevision
+		want *marin3rv1alpha1.TaintPolicy
+	}{
+		{
+			name: "Uses the policy of the owning EnvoyConfig",
+			ecr:  revision("ec"),
+			want: ownerPolicy,
+		},
+		{
+			name: "Keeps the policy of revisions without owner",
+			ecr:  revision(""),
+			want: revisionPolicy,
+		},
+		{
+			name: "Keeps the policy if the owner does not exist",
+			ecr:  revision("other"),
+			want: revisionPolicy,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := fake.NewClientBuilder().WithScheme(s).WithObjects(ec).Build()
+			if err := TaintPolicyFromOwner(context.TODO(), cl, tt.ecr); err != nil {
+				t.Fatalf("TaintPolicyFromOwner() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.ecr.Spec.TaintPolicy, tt.want) {
+				t.Errorf("TaintPolicyFromOwner() = %v, want %v", tt.ecr.Spec.TaintPolicy, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package reconcilers
+
+import (
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// untaintedAtStat is the stat that records, in each discovery service replica, the
+	// last untaint of a revision for which the stats of the revision have been reset
+	untaintedAtStat string = "untainted_at"
+)
+
+// IsUntaintReconciled clears the taint of the revision if it was tainted before the time set in
+// its untaint annotation, or if the retry backoff of its taint policy has elapsed since it was
+// tainted. It returns false if the status of the revision has been modified. The returned duration
+// is the time left until the taint has to be cleared, zero if there is nothing scheduled.
+func IsUntaintReconciled(ecr *marin3rv1alpha1.EnvoyConfigRevision, now time.Time, log logr.Logger) (bool, time.Duration) {
+
+	cond := meta.FindStatusCondition(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return true, 0
+	}
+	taintedAt := cond.LastTransitionTime.Time
+
+	var requeueAfter time.Duration
+	at, err := ecr.GetUntaintAt()
+	if err != nil {
+		log.Error(err, "unable to process untaint annotation")
+	} else if at != nil && taintedAt.Before(*at) {
+		if at.After(now) {
+			requeueAfter = at.Sub(now)
+		} else {
+			ecr.Status.Untaint(now)
+			ecr.Status.TaintRetries = nil
+			log.Info("cleared taint of revision", "Version", ecr.Spec.Version, "Reason", "UntaintAnnotation")
+			return false, 0
+		}
+	}
+
+	if backoff, ok := ecr.Spec.TaintPolicy.GetRetryBackoff(ecr.Status.GetTaintRetries()); ok {
+		if left := taintedAt.Add(backoff).Sub(now); left > 0 {
+			if requeueAfter == 0 || left < requeueAfter {
+				requeueAfter = left
+			}
+		} else {
+			retries := ecr.Status.GetTaintRetries() + 1
+			ecr.Status.Untaint(now)
+			ecr.Status.TaintRetries = pointer.New(retries)
+			log.Info("cleared taint of revision", "Version", ecr.Spec.Version, "Reason", "RetryAfter", "Retries", retries)
+			return false, 0
+		}
+	}
+
+	return true, requeueAfter
+}
+
+// ReconcileStatsReset resets the ACK and NACK counters of the versions provided by the revision
+// the first time this discovery service replica sees that the taint of the revision has been cleared,
+// so the revision is evaluated again from scratch. It returns true if the counters have been reset.
+func ReconcileStatsReset(ecr *marin3rv1alpha1.EnvoyConfigRevision, dStats *stats.Stats) bool {
+
+	vt := ecr.Status.ProvidesVersions
+	if ecr.Status.UntaintedAt == nil || vt == nil {
+		return false
+	}
+
+	untaintedAt := ecr.Status.UntaintedAt.UTC().Format(time.RFC3339)
+	if v, err := dStats.GetString(ecr.Spec.NodeID, "*", ecr.Spec.Version, "*", untaintedAtStat); err == nil && v == untaintedAt {
+		return false
+	}
+
+	for rType, version := range map[envoy.Type]string{
+		envoy.Endpoint:        vt.Endpoints,
+		envoy.Cluster:         vt.Clusters,
+		envoy.Route:           vt.Routes,
+		envoy.ScopedRoute:     vt.ScopedRoutes,
+		envoy.Listener:        vt.Listeners,
+		envoy.Secret:          vt.Secrets,
+		envoy.Runtime:         vt.Runtimes,
+		envoy.ExtensionConfig: vt.ExtensionConfigs,
+	} {
+		dStats.ResetCounters(ecr.Spec.NodeID, envoy_resources.TypeURL(rType, ecr.GetEnvoyAPIVersion()), version)
+	}
+	dStats.SetString(ecr.Spec.NodeID, "*", ecr.Spec.Version, "*", untaintedAtStat, untaintedAt)
+	return true
+}
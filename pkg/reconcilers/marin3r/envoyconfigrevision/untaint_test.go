@@ -0,0 +1,161 @@
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/discoveryservice/xdss/stats"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	envoy_resources "github.com/3scale-ops/marin3r/pkg/envoy/resources"
+	"github.com/3scale-ops/marin3r/pkg/util/pointer"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestIsUntaintReconciled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	tainted := func(at time.Time) []metav1.Condition {
+		return []metav1.Condition{{
+			Type:               marin3rv1alpha1.RevisionTaintedCondition,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(at),
+		}}
+	}
+	retryPolicy := &marin3rv1alpha1.TaintPolicy{RetryAfter: &metav1.Duration{Duration: time.Minute}}
+
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		policy           *marin3rv1alpha1.TaintPolicy
+		conditions       []metav1.Condition
+		retries          *int
+		wantOK           bool
+		wantRequeueAfter time.Duration
+		wantTainted      bool
+		wantRetries      int
+	}{
+		{
+			name:       "Nothing to do if the revision is not tainted",
+			policy:     retryPolicy,
+			conditions: nil,
+			wantOK:     true,
+		},
+		{
+			name:        "Clears the taint if it was set before the time in the annotation",
+			annotations: map[string]string{marin3rv1alpha1.UntaintAtAnnotation: now.Format(time.RFC3339)},
+			conditions:  tainted(now.Add(-time.Hour)),
+			retries:     pointer.New(2),
+			wantOK:      false,
+			wantTainted: false,
+		},
+		{
+			name:        "Keeps the taint if it was set after the time in the annotation",
+			annotations: map[string]string{marin3rv1alpha1.UntaintAtAnnotation: now.Add(-2 * time.Hour).Format(time.RFC3339)},
+			conditions:  tainted(now.Add(-time.Hour)),
+			wantOK:      true,
+			wantTainted: true,
+		},
+		{
+			name:             "Waits until the time in the annotation",
+			annotations:      map[string]string{marin3rv1alpha1.UntaintAtAnnotation: now.Add(time.Hour).Format(time.RFC3339)},
+			conditions:       tainted(now.Add(-time.Hour)),
+			wantOK:           true,
+			wantRequeueAfter: time.Hour,
+			wantTainted:      true,
+		},
+		{
+			name:             "Waits for the retry backoff",
+			policy:           retryPolicy,
+			conditions:       tainted(now.Add(-30 * time.Second)),
+			wantOK:           true,
+			wantRequeueAfter: 30 * time.Second,
+			wantTainted:      true,
+		},
+		{
+			name:        "Retries once the backoff has elapsed",
+			policy:      retryPolicy,
+			conditions:  tainted(now.Add(-time.Minute)),
+			wantOK:      false,
+			wantTainted: false,
+			wantRetries: 1,
+		},
+		{
+			name:             "Doubles the backoff with each retry",
+			policy:           retryPolicy,
+			conditions:       tainted(now.Add(-time.Minute)),
+			retries:          pointer.New(2),
+			wantOK:           true,
+			wantRequeueAfter: 3 * time.Minute,
+			wantTainted:      true,
+			wantRetries:      2,
+		},
+		{
+			name:        "Does not retry without a retry policy",
+			conditions:  tainted(now.Add(-24 * time.Hour)),
+			wantOK:      true,
+			wantTainted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+				ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default", Annotations: tt.annotations},
+				Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v1", TaintPolicy: tt.policy},
+				Status:     marin3rv1alpha1.EnvoyConfigRevisionStatus{Conditions: tt.conditions, TaintRetries: tt.retries},
+			}
+			gotOK, gotRequeueAfter := IsUntaintReconciled(ecr, now, ctrl.Log)
+			if gotOK != tt.wantOK || gotRequeueAfter != tt.wantRequeueAfter {
+				t.Errorf("IsUntaintReconciled() = (%v, %v), want (%v, %v)", gotOK, gotRequeueAfter, tt.wantOK, tt.wantRequeueAfter)
+			}
+			if got := meta.IsStatusConditionTrue(ecr.Status.Conditions, marin3rv1alpha1.RevisionTaintedCondition); got != tt.wantTainted {
+				t.Errorf("IsUntaintReconciled() tainted = %v, want %v", got, tt.wantTainted)
+			}
+			if got := ecr.Status.GetTaintRetries(); got != tt.wantRetries {
+				t.Errorf("IsUntaintReconciled() retries = %v, want %v", got, tt.wantRetries)
+			}
+			if !gotOK && (ecr.Status.UntaintedAt == nil || !ecr.Status.UntaintedAt.Time.Equal(now)) {
+				t.Errorf("IsUntaintReconciled() untaintedAt = %v, want %v", ecr.Status.UntaintedAt, now)
+			}
+		})
+	}
+}
+
+func TestReconcileStatsReset(t *testing.T) {
+	clusterType := envoy_resources.TypeURL(envoy.Cluster, envoy.APIv3)
+	dStats := stats.New()
+	dStats.ReportRequest("node", clusterType, "pod-a")
+	dStats.WriteResponseNonce("node", clusterType, "xxxx", "pod-a", "1")
+	dStats.ReportNACK("node", clusterType, "pod-a", "1")
+
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default"},
+		Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "v1", EnvoyAPI: pointer.New(envoy.APIv3)},
+		Status: marin3rv1alpha1.EnvoyConfigRevisionStatus{
+			ProvidesVersions: &marin3rv1alpha1.VersionTracker{Clusters: "xxxx"},
+		},
+	}
+
+	if ReconcileStatsReset(ecr, dStats) {
+		t.Errorf("ReconcileStatsReset() = true for a revision that has never been untainted")
+	}
+
+	ecr.Status.UntaintedAt = &metav1.Time{Time: time.Now()}
+	if !ReconcileStatsReset(ecr, dStats) {
+		t.Errorf("ReconcileStatsReset() = false for an untainted revision")
+	}
+	if _, err := dStats.GetCounter("node", clusterType, "xxxx", "pod-a", "nack_counter"); err == nil {
+		t.Errorf("ReconcileStatsReset() did not reset the NACK counter")
+	}
+
+	// the stats are only reset once for each untaint
+	dStats.WriteResponseNonce("node", clusterType, "xxxx", "pod-a", "2")
+	dStats.ReportNACK("node", clusterType, "pod-a", "2")
+	if ReconcileStatsReset(ecr, dStats) {
+		t.Errorf("ReconcileStatsReset() = true for an untaint that has already been processed")
+	}
+	if v, _ := dStats.GetCounter("node", clusterType, "xxxx", "pod-a", "nack_counter"); v != 1 {
+		t.Errorf("ReconcileStatsReset() nack_counter = %v, want 1", v)
+	}
+}
@@ -0,0 +1,32 @@
+package generators
+
+import (
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterRole returns the ClusterRole with the permissions the discovery service needs
+// over cluster scoped resources, used to select the EnvoyConfigPolicies of each namespace
+func (cfg *GeneratorOptions) ClusterRole() *rbacv1.ClusterRole {
+
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cfg.ClusterResourceName(),
+			Labels: cfg.labels(),
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{marin3rv1alpha1.GroupVersion.Group},
+				Resources: []string{"envoyconfigpolicies"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+			{
+				APIGroups: []string{corev1.SchemeGroupVersion.Group},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	}
+}
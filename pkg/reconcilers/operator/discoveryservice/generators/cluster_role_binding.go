@@ -0,0 +1,28 @@
+package generators
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (cfg *GeneratorOptions) ClusterRoleBinding() *rbacv1.ClusterRoleBinding {
+
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   cfg.ClusterResourceName(),
+			Labels: cfg.labels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     "ClusterRole",
+			Name:     cfg.ClusterResourceName(),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      cfg.ResourceName(),
+				Namespace: cfg.Namespace,
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package generators
+
+import (
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGeneratorOptions_ClusterRoleBinding(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GeneratorOptions
+		want *rbacv1.ClusterRoleBinding
+	}{
+		{"Generates a ClusterRoleBinding",
+			GeneratorOptions{
+				InstanceName:                      "test",
+				Namespace:                         "default",
+				RootCertificateNamePrefix:         "ca-cert",
+				RootCertificateCommonNamePrefix:   "test",
+				RootCertificateDuration:           time.Duration(10 * time.Second),
+				ServerCertificateNamePrefix:       "server-cert",
+				ServerCertificateCommonNamePrefix: "test",
+				ServerCertificateDuration:         time.Duration(10 * time.Second),
+				ClientCertificateDuration:         time.Duration(10 * time.Second),
+				XdsServerPort:                     1000,
+				MetricsServerPort:                 1001,
+				ServiceType:                       operatorv1alpha1.ClusterIPType,
+				DeploymentImage:                   "test:latest",
+				DeploymentResources:               corev1.ResourceRequirements{},
+				Debug:                             true,
+			},
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "marin3r-default-test",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "marin3r",
+						"app.kubernetes.io/managed-by": "marin3r-operator",
+						"app.kubernetes.io/component":  "discovery-service",
+						"app.kubernetes.io/instance":   "test",
+					},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.SchemeGroupVersion.Group,
+					Kind:     "ClusterRole",
+					Name:     "marin3r-default-test",
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind:      rbacv1.ServiceAccountKind,
+						Name:      "marin3r-test",
+						Namespace: "default",
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.opts.ClusterRoleBinding(), tt.want); len(diff) > 0 {
+				t.Errorf("GeneratorOptions.ClusterRoleBinding() DIFF:\n %v", diff)
+			}
+		})
+	}
+}
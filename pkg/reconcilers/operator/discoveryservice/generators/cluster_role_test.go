@@ -0,0 +1,71 @@
+package generators
+
+import (
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGeneratorOptions_ClusterRole(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GeneratorOptions
+		want *rbacv1.ClusterRole
+	}{
+		{"Generates a ClusterRole",
+			GeneratorOptions{
+				InstanceName:                      "test",
+				Namespace:                         "default",
+				RootCertificateNamePrefix:         "ca-cert",
+				RootCertificateCommonNamePrefix:   "test",
+				RootCertificateDuration:           time.Duration(10 * time.Second),
+				ServerCertificateNamePrefix:       "server-cert",
+				ServerCertificateCommonNamePrefix: "test",
+				ServerCertificateDuration:         time.Duration(10 * time.Second),
+				ClientCertificateDuration:         time.Duration(10 * time.Second),
+				XdsServerPort:                     1000,
+				MetricsServerPort:                 1001,
+				ServiceType:                       operatorv1alpha1.ClusterIPType,
+				DeploymentImage:                   "test:latest",
+				DeploymentResources:               corev1.ResourceRequirements{},
+				Debug:                             true,
+			},
+			&rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "marin3r-default-test",
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "marin3r",
+						"app.kubernetes.io/managed-by": "marin3r-operator",
+						"app.kubernetes.io/component":  "discovery-service",
+						"app.kubernetes.io/instance":   "test",
+					},
+				},
+				Rules: []rbacv1.PolicyRule{
+					{
+						APIGroups: []string{marin3rv1alpha1.GroupVersion.Group},
+						Resources: []string{"envoyconfigpolicies"},
+						Verbs:     []string{"get", "list", "watch"},
+					},
+					{
+						APIGroups: []string{corev1.SchemeGroupVersion.Group},
+						Resources: []string{"namespaces"},
+						Verbs:     []string{"get", "list", "watch"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.opts.ClusterRole(), tt.want); len(diff) > 0 {
+				t.Errorf("GeneratorOptions.ClusterRole() DIFF:\n %v", diff)
+			}
+		})
+	}
+}
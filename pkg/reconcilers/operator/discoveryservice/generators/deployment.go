@@ -2,6 +2,7 @@ package generators
 
 import (
 	"fmt"
+	"strings"
 
 	operatorv1alpha1 "github.com/3scale-ops/marin3r/apis/operator.marin3r/v1alpha1"
 	"github.com/3scale-ops/marin3r/pkg/util/pointer"
@@ -78,10 +79,17 @@ func (cfg *GeneratorOptions) Deployment(hash string) func() *appsv1.Deployment {
 										fmt.Sprintf("--xdss-port=%v", cfg.XdsServerPort),
 										fmt.Sprintf("--metrics-bind-address=:%v", cfg.MetricsServerPort),
 										fmt.Sprintf("--health-probe-bind-address=:%v", cfg.ProbePort),
+										fmt.Sprintf("--debug-api-port=%v", cfg.DebugAPIPort),
 									}
 									if cfg.Debug {
 										args = append(args, "--debug")
 									}
+									if len(cfg.DebugAPIClients) > 0 {
+										args = append(args, fmt.Sprintf("--debug-api-clients=%s", strings.Join(cfg.DebugAPIClients, ",")))
+									}
+									if len(cfg.SecretNamespaces) > 0 {
+										args = append(args, fmt.Sprintf("--secret-namespaces=%s", strings.Join(cfg.SecretNamespaces, ",")))
+									}
 									return
 								}(),
 								Ports: []corev1.ContainerPort{
@@ -95,6 +103,11 @@ func (cfg *GeneratorOptions) Deployment(hash string) func() *appsv1.Deployment {
 										ContainerPort: int32(cfg.MetricsServerPort),
 										Protocol:      corev1.ProtocolTCP,
 									},
+									{
+										Name:          "debug-api",
+										ContainerPort: int32(cfg.DebugAPIPort),
+										Protocol:      corev1.ProtocolTCP,
+									},
 								},
 								Env: []corev1.EnvVar{
 									{Name: "WATCH_NAMESPACE", Value: cfg.Namespace},
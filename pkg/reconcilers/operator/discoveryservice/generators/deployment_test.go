@@ -37,11 +37,14 @@ func TestGeneratorOptions_Deployment(t *testing.T) {
 				XdsServerPort:                     1000,
 				MetricsServerPort:                 1001,
 				ProbePort:                         1002,
+				DebugAPIPort:                      1003,
+				DebugAPIClients:                   []string{"debug-client"},
 				ServiceType:                       operatorv1alpha1.ClusterIPType,
 				DeploymentImage:                   "test:latest",
 				DeploymentResources:               corev1.ResourceRequirements{},
 				Debug:                             true,
 				PodPriorityClass:                  pointer.New("highest"),
+				SecretNamespaces:                  []string{"certs", "shared"},
 			},
 			args{hash: "hash"},
 			&appsv1.Deployment{
@@ -117,7 +120,10 @@ func TestGeneratorOptions_Deployment(t *testing.T) {
 										"--xdss-port=1000",
 										"--metrics-bind-address=:1001",
 										"--health-probe-bind-address=:1002",
+										"--debug-api-port=1003",
 										"--debug",
+										"--debug-api-clients=debug-client",
+										"--secret-namespaces=certs,shared",
 									},
 									Ports: []corev1.ContainerPort{
 										{
@@ -130,6 +136,11 @@ func TestGeneratorOptions_Deployment(t *testing.T) {
 											ContainerPort: int32(1001),
 											Protocol:      corev1.ProtocolTCP,
 										},
+										{
+											Name:          "debug-api",
+											ContainerPort: int32(1003),
+											Protocol:      corev1.ProtocolTCP,
+										},
 									},
 									Env: []corev1.EnvVar{
 										{Name: "WATCH_NAMESPACE", Value: "default"},
@@ -22,11 +22,14 @@ type GeneratorOptions struct {
 	XdsServerPort                     int32
 	MetricsServerPort                 int32
 	ProbePort                         int32
+	DebugAPIPort                      int32
+	DebugAPIClients                   []string
 	ServiceType                       operatorv1alpha1.ServiceType
 	DeploymentImage                   string
 	DeploymentResources               corev1.ResourceRequirements
 	Debug                             bool
 	PodPriorityClass                  *string
+	SecretNamespaces                  []string
 }
 
 func (cfg *GeneratorOptions) labels() map[string]string {
@@ -53,3 +56,9 @@ func (cfg *GeneratorOptions) ClientCertName() string {
 func (cfg *GeneratorOptions) ResourceName() string {
 	return fmt.Sprintf("%s-%s", "marin3r", cfg.InstanceName)
 }
+
+// ClusterResourceName returns the name of the cluster scoped resources, which
+// includes the namespace so the ones of each discovery service do not collide
+func (cfg *GeneratorOptions) ClusterResourceName() string {
+	return fmt.Sprintf("%s-%s-%s", "marin3r", cfg.Namespace, cfg.InstanceName)
+}
@@ -69,6 +69,26 @@ func TestGeneratorOptions_Role(t *testing.T) {
 						Resources: []string{"endpointslices"},
 						Verbs:     []string{"get", "list", "watch"},
 					},
+					{
+						APIGroups: []string{operatorv1alpha1.GroupVersion.Group},
+						Resources: []string{"discoveryservicecertificates"},
+						Verbs:     []string{"get", "list", "watch"},
+					},
+					{
+						APIGroups: []string{"cert-manager.io"},
+						Resources: []string{"certificates"},
+						Verbs:     []string{"get"},
+					},
+					{
+						APIGroups: []string{corev1.SchemeGroupVersion.Group},
+						Resources: []string{"events"},
+						Verbs:     []string{"create", "patch"},
+					},
+					{
+						APIGroups: []string{corev1.SchemeGroupVersion.Group},
+						Resources: []string{"configmaps"},
+						Verbs:     []string{"get", "list", "watch", "create", "update", "patch"},
+					},
 				},
 			},
 		},
@@ -43,6 +43,12 @@ func (cfg *GeneratorOptions) Service() *corev1.Service {
 					Protocol:   corev1.ProtocolTCP,
 					TargetPort: intstr.FromString("metrics"),
 				},
+				{
+					Name:       "debug-api",
+					Port:       cfg.DebugAPIPort,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromString("debug-api"),
+				},
 			},
 		},
 	}
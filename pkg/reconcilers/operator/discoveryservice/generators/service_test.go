@@ -34,6 +34,7 @@ func TestGeneratorOptions_Service(t *testing.T) {
 				ClientCertificateDuration:         time.Duration(10 * time.Second),
 				XdsServerPort:                     1000,
 				MetricsServerPort:                 1001,
+				DebugAPIPort:                      1003,
 				ServiceType:                       operatorv1alpha1.ClusterIPType,
 				DeploymentImage:                   "test:latest",
 				DeploymentResources:               corev1.ResourceRequirements{},
@@ -74,6 +75,12 @@ func TestGeneratorOptions_Service(t *testing.T) {
 							Protocol:   corev1.ProtocolTCP,
 							TargetPort: intstr.FromString("metrics"),
 						},
+						{
+							Name:       "debug-api",
+							Port:       1003,
+							Protocol:   corev1.ProtocolTCP,
+							TargetPort: intstr.FromString("debug-api"),
+						},
 					},
 				},
 			},
@@ -91,6 +98,7 @@ func TestGeneratorOptions_Service(t *testing.T) {
 				ClientCertificateDuration:         time.Duration(10 * time.Second),
 				XdsServerPort:                     1000,
 				MetricsServerPort:                 1001,
+				DebugAPIPort:                      1003,
 				ServiceType:                       operatorv1alpha1.HeadlessType,
 				DeploymentImage:                   "test:latest",
 				DeploymentResources:               corev1.ResourceRequirements{},
@@ -131,6 +139,12 @@ func TestGeneratorOptions_Service(t *testing.T) {
 							Protocol:   corev1.ProtocolTCP,
 							TargetPort: intstr.FromString("metrics"),
 						},
+						{
+							Name:       "debug-api",
+							Port:       1003,
+							Protocol:   corev1.ProtocolTCP,
+							TargetPort: intstr.FromString("debug-api"),
+						},
 					},
 				},
 			},
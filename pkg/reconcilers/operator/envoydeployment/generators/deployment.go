@@ -62,6 +62,7 @@ func (cfg *GeneratorOptions) Deployment() *appsv1.Deployment {
 
 	if cfg.InitManager != nil {
 		cc.InitManagerImage = cfg.InitManager.GetImage()
+		cc.XdstpAuthorities = cfg.InitManager.XdstpAuthorities
 	}
 
 	dep := &appsv1.Deployment{
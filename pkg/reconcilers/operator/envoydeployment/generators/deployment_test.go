@@ -121,6 +121,42 @@ func TestGeneratorOptions_Deployment(t *testing.T) {
 											},
 										},
 									},
+									{
+										Name: "POD_IP",
+										ValueFrom: &corev1.EnvVarSource{
+											FieldRef: &corev1.ObjectFieldSelector{
+												FieldPath:  "status.podIP",
+												APIVersion: "v1",
+											},
+										},
+									},
+									{
+										Name: "HOST_IP",
+										ValueFrom: &corev1.EnvVarSource{
+											FieldRef: &corev1.ObjectFieldSelector{
+												FieldPath:  "status.hostIP",
+												APIVersion: "v1",
+											},
+										},
+									},
+									{
+										Name: "LOCALITY_REGION",
+										ValueFrom: &corev1.EnvVarSource{
+											FieldRef: &corev1.ObjectFieldSelector{
+												FieldPath:  "metadata.labels['topology.kubernetes.io/region']",
+												APIVersion: "v1",
+											},
+										},
+									},
+									{
+										Name: "LOCALITY_ZONE",
+										ValueFrom: &corev1.EnvVarSource{
+											FieldRef: &corev1.ObjectFieldSelector{
+												FieldPath:  "metadata.labels['topology.kubernetes.io/zone']",
+												APIVersion: "v1",
+											},
+										},
+									},
 								},
 								Args: []string{
 									"init-manager",
@@ -0,0 +1,155 @@
+// Package cron implements parsing of standard 5 field cron expressions
+// (minute, hour, day of month, month and day of week) and the calculation
+// of the times at which they are activated.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch is how far in the future Next looks for an activation time
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are true when the day of month or
+	// day of week fields are unrestricted ('*')
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minutes = bounds{0, 59}
+	hours   = bounds{0, 23}
+	doms    = bounds{1, 31}
+	months  = bounds{1, 12}
+	// both 0 and 7 are sunday
+	dows = bounds{0, 7}
+)
+
+// Parse parses a standard 5 field cron expression. Each field supports
+// '*', single values, ranges ('1-5'), lists ('1,3,5') and steps ('*/15', '0-30/10').
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, found %d", spec, len(fields))
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], doms); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dows); err != nil {
+		return nil, err
+	}
+	// sunday can be expressed both as 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+// parseField returns a bitmask with the values allowed by a field of a cron expression
+func parseField(field string, b bounds) (uint64, error) {
+	var mask uint64
+	for _, expr := range strings.Split(field, ",") {
+		rng, step := expr, 1
+		if i := strings.Index(expr, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(expr[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in cron field %q", field)
+			}
+			rng = expr[:i]
+		}
+
+		start, end := b.min, b.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			parts := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(parts[0])
+			end, err2 = strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range in cron field %q", field)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in cron field %q", field)
+			}
+			start = v
+			// a single value with a step means from the value to the max
+			if step == 1 {
+				end = v
+			}
+		}
+
+		if start < b.min || end > b.max || start > end {
+			return 0, fmt.Errorf("value out of range [%d-%d] in cron field %q", b.min, b.max, field)
+		}
+		for v := start; v <= end; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// Next returns the first activation time of the schedule after t, in the
+// location of t. The zero time is returned if there is none in the next 5 years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxSearch)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches returns true if the day of t is allowed by the schedule. As in standard cron,
+// if both the day of month and the day of week are restricted, either of them can match.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{"Every minute", "* * * * *", false},
+		{"Lists, ranges and steps", "0,30 22-23 */2 1-6/2 1-5", false},
+		{"Sunday as 7", "0 0 * * 7", false},
+		{"Too few fields", "0 0 * *", true},
+		{"Too many fields", "0 0 * * * *", true},
+		{"Value out of range", "60 * * * *", true},
+		{"Inverted range", "* 10-2 * * *", true},
+		{"Invalid step", "*/0 * * * *", true},
+		{"Not a number", "a * * * *", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.spec); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// 2024-01-01 is a monday
+	from := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"Every minute", "* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"Later the same day", "0 22 * * *", time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)},
+		{"Next day", "0 2 * * *", time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"Day of week", "0 2 * * 6", time.Date(2024, 1, 6, 2, 0, 0, 0, time.UTC)},
+		{"Sunday as 7", "0 2 * * 7", time.Date(2024, 1, 7, 2, 0, 0, 0, time.UTC)},
+		{"Day of month or day of week", "0 2 15 * 3", time.Date(2024, 1, 3, 2, 0, 0, 0, time.UTC)},
+		{"Next month", "0 0 1 2 *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"Leap day", "0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"Step", "*/20 * * * *", time.Date(2024, 1, 1, 10, 40, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Schedule.Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s, _ := Parse("0 22 * * *")
+	got := s.Next(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC).In(loc))
+	if want := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Schedule.Next() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package changerecorder
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MutatePath is the path where the webhook server listens
+	// for admission requests
+	MutatePath string = "/envoyconfig-v1alpha1-record-change"
+)
+
+// ChangeRecorder records the user that changes the resources of EnvoyConfigs and EnvoyConfigFragments
+type ChangeRecorder struct {
+	Decoder *admission.Decoder
+}
+
+// ChangeRecorder implements admission.Handler.
+var _ admission.Handler = &ChangeRecorder{}
+
+//+kubebuilder:webhook:path=/envoyconfig-v1alpha1-record-change,mutating=true,failurePolicy=fail,sideEffects=None,groups=marin3r.3scale.net,resources=envoyconfigs;envoyconfigfragments,verbs=create;update,versions=v1alpha1,name=envoyconfig-change.marin3r.3scale.net,admissionReviewVersions=v1
+
+// Handle records the user that creates an EnvoyConfig, or changes its resources, parameters or
+// patches, in the changed-by annotation. The same is done for the resources of EnvoyConfigFragments.
+// The EnvoyConfig controller uses the annotation to record the authors of each EnvoyConfigRevision.
+// The annotation can only be set by this webhook: any other change to it is reverted.
+func (a *ChangeRecorder) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var obj, old client.Object = &marin3rv1alpha1.EnvoyConfig{}, &marin3rv1alpha1.EnvoyConfig{}
+	if req.Kind.Kind == "EnvoyConfigFragment" {
+		obj, old = &marin3rv1alpha1.EnvoyConfigFragment{}, &marin3rv1alpha1.EnvoyConfigFragment{}
+	}
+	if err := a.Decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		if err := a.Decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if req.Operation == admissionv1.Create || !resourcesEqual(old, obj) {
+		annotations[marin3rv1alpha1.ChangedByAnnotation] = req.UserInfo.Username
+	} else if value, ok := old.GetAnnotations()[marin3rv1alpha1.ChangedByAnnotation]; ok {
+		annotations[marin3rv1alpha1.ChangedByAnnotation] = value
+	} else {
+		delete(annotations, marin3rv1alpha1.ChangedByAnnotation)
+	}
+	obj.SetAnnotations(annotations)
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// resourcesEqual returns true if the resources of both objects, and for EnvoyConfigs
+// the parameters and patches used to render them, which determine the
+// EnvoyConfigRevision that is published, are the same
+func resourcesEqual(a, b client.Object) bool {
+	switch a := a.(type) {
+	case *marin3rv1alpha1.EnvoyConfigFragment:
+		return equality.Semantic.DeepEqual(a.Spec, b.(*marin3rv1alpha1.EnvoyConfigFragment).Spec)
+	case *marin3rv1alpha1.EnvoyConfig:
+		b := b.(*marin3rv1alpha1.EnvoyConfig)
+		return equality.Semantic.DeepEqual(a.Spec.Resources, b.Spec.Resources) &&
+			equality.Semantic.DeepEqual(a.Spec.EnvoyResources, b.Spec.EnvoyResources) &&
+			equality.Semantic.DeepEqual(a.Spec.Parameters, b.Spec.Parameters) &&
+			equality.Semantic.DeepEqual(a.Spec.ParametersFrom, b.Spec.ParametersFrom) &&
+			equality.Semantic.DeepEqual(a.Spec.Patches, b.Spec.Patches)
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package changerecorder
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func init() {
+	marin3rv1alpha1.AddToScheme(scheme.Scheme)
+}
+
+func TestChangeRecorder_Handle(t *testing.T) {
+	config := func(cluster string, annotations map[string]string, modifiers ...func(*marin3rv1alpha1.EnvoyConfigSpec)) runtime.RawExtension {
+		ec := &marin3rv1alpha1.EnvoyConfig{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfig"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "default", Annotations: annotations},
+			Spec: marin3rv1alpha1.EnvoyConfigSpec{
+				NodeID:    "node",
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"` + cluster + `"}`)}},
+			},
+		}
+		for _, fn := range modifiers {
+			fn(&ec.Spec)
+		}
+		raw, _ := json.Marshal(ec)
+		return runtime.RawExtension{Raw: raw}
+	}
+	parameters := func(value string) func(*marin3rv1alpha1.EnvoyConfigSpec) {
+		return func(spec *marin3rv1alpha1.EnvoyConfigSpec) { spec.Parameters = map[string]string{"key": value} }
+	}
+	patch := func(value string) func(*marin3rv1alpha1.EnvoyConfigSpec) {
+		return func(spec *marin3rv1alpha1.EnvoyConfigSpec) {
+			spec.Patches = []marin3rv1alpha1.ResourcePatch{{Type: envoy.Cluster, Name: "a", Patch: `{"alt_stat_name":"` + value + `"}`}}
+		}
+	}
+	fragment := func(cluster string, annotations map[string]string) runtime.RawExtension {
+		raw, _ := json.Marshal(&marin3rv1alpha1.EnvoyConfigFragment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfigFragment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ecf", Namespace: "default", Annotations: annotations},
+			Spec: marin3rv1alpha1.EnvoyConfigFragmentSpec{
+				NodeID:    "node",
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(`{"name":"` + cluster + `"}`)}},
+			},
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+	changedBy := func(user string) map[string]string {
+		return map[string]string{marin3rv1alpha1.ChangedByAnnotation: user}
+	}
+
+	tests := []struct {
+		name            string
+		kind            string
+		operation       admissionv1.Operation
+		object          runtime.RawExtension
+		oldObject       runtime.RawExtension
+		wantAnnotations map[string]string
+	}{
+		{
+			name:            "Records the user that creates the EnvoyConfig",
+			operation:       admissionv1.Create,
+			object:          config("a", changedBy("someone-else")),
+			wantAnnotations: changedBy("user"),
+		},
+		{
+			name:            "Records the user that changes the resources",
+			operation:       admissionv1.Update,
+			object:          config("b", changedBy("creator")),
+			oldObject:       config("a", changedBy("creator")),
+			wantAnnotations: changedBy("user"),
+		},
+		{
+			name:            "Records the user that changes the parameters",
+			operation:       admissionv1.Update,
+			object:          config("a", changedBy("creator"), parameters("b")),
+			oldObject:       config("a", changedBy("creator"), parameters("a")),
+			wantAnnotations: changedBy("user"),
+		},
+		{
+			name:            "Records the user that changes the patches",
+			operation:       admissionv1.Update,
+			object:          config("a", changedBy("creator"), patch("b")),
+			oldObject:       config("a", changedBy("creator")),
+			wantAnnotations: changedBy("user"),
+		},
+		{
+			name:            "Records the user that changes the resources of a fragment",
+			kind:            "EnvoyConfigFragment",
+			operation:       admissionv1.Update,
+			object:          fragment("b", changedBy("creator")),
+			oldObject:       fragment("a", changedBy("creator")),
+			wantAnnotations: changedBy("user"),
+		},
+		{
+			name:            "Keeps the recorded user if the resources of a fragment do not change",
+			kind:            "EnvoyConfigFragment",
+			operation:       admissionv1.Update,
+			object:          fragment("a", changedBy("someone-else")),
+			oldObject:       fragment("a", changedBy("creator")),
+			wantAnnotations: changedBy("creator"),
+		},
+		{
+			name:            "Keeps the recorded user if the resources do not change",
+			operation:       admissionv1.Update,
+			object:          config("a", nil),
+			oldObject:       config("a", changedBy("creator")),
+			wantAnnotations: changedBy("creator"),
+		},
+		{
+			name:            "Does not allow forging the user",
+			operation:       admissionv1.Update,
+			object:          config("a", changedBy("someone-else")),
+			oldObject:       config("a", nil),
+			wantAnnotations: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &ChangeRecorder{Decoder: admission.NewDecoder(scheme.Scheme)}
+			kind := tt.kind
+			if kind == "" {
+				kind = "EnvoyConfig"
+			}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "xxxx",
+				Kind:      metav1.GroupVersionKind{Group: "marin3r.3scale.net", Version: "v1alpha1", Kind: kind},
+				Namespace: "default",
+				Operation: tt.operation,
+				UserInfo:  authenticationv1.UserInfo{Username: "user"},
+				Object:    tt.object,
+				OldObject: tt.oldObject,
+			}}
+
+			resp := a.Handle(context.TODO(), req)
+			if !resp.Allowed {
+				t.Fatalf("ChangeRecorder.Handle() not allowed (%v)", resp.Result)
+			}
+
+			patch, _ := json.Marshal(resp.Patches)
+			decoded, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				t.Fatalf("unable to decode patch: %v", err)
+			}
+			patched, err := decoded.Apply(req.Object.Raw)
+			if err != nil {
+				t.Fatalf("unable to apply patch: %v", err)
+			}
+			ec := &marin3rv1alpha1.EnvoyConfig{}
+			if err := json.Unmarshal(patched, ec); err != nil {
+				t.Fatalf("unable to decode patched EnvoyConfig: %v", err)
+			}
+			if got := ec.GetAnnotations(); !reflect.DeepEqual(got, tt.wantAnnotations) {
+				t.Errorf("ChangeRecorder.Handle() annotations = %v, want %v", got, tt.wantAnnotations)
+			}
+		})
+	}
+}
@@ -86,7 +86,7 @@ func TestPodMutator_Handle(t *testing.T) {
 					},
 				},
 			},
-			want: []byte(`[{"op":"add","path":"/spec/containers/1","value":{"args":["-c","/etc/envoy/bootstrap/config.json","--service-node","test","--service-cluster","test"],"command":["envoy"],"image":"` + defaults.Image + `","imagePullPolicy":"IfNotPresent","livenessProbe":{"failureThreshold":10,"httpGet":{"path":"/ready","port":9901,"scheme":"HTTP"},"initialDelaySeconds":30,"periodSeconds":10,"successThreshold":1,"timeoutSeconds":1},"name":"envoy-sidecar","ports":[{"containerPort":9901,"name":"admin","protocol":"TCP"}],"readinessProbe":{"failureThreshold":1,"httpGet":{"path":"/ready","port":9901,"scheme":"HTTP"},"initialDelaySeconds":15,"periodSeconds":5,"successThreshold":1,"timeoutSeconds":1},"resources":{},"terminationMessagePath":"/dev/termination-log","terminationMessagePolicy":"File","volumeMounts":[{"mountPath":"/etc/envoy/tls/client","name":"envoy-sidecar-tls","readOnly":true},{"mountPath":"/etc/envoy/bootstrap","name":"envoy-sidecar-bootstrap","readOnly":true}]}},{"op":"add","path":"/spec/initContainers","value":[{"args":["init-manager","--admin-access-log-path","/dev/null","--admin-bind-address","0.0.0.0:9901","--api-version","v3","--client-certificate-path","/etc/envoy/tls/client","--config-file","/etc/envoy/bootstrap/config.json","--resources-path","/etc/envoy/bootstrap","--rtds-resource-name","runtime","--xdss-host","marin3r-instance.default.svc","--xdss-port","18000","--envoy-image","` + defaults.Image + `"],"env":[{"name":"POD_NAME","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.name"}}},{"name":"POD_NAMESPACE","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.namespace"}}},{"name":"HOST_NAME","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"spec.nodeName"}}}],"image":"` + defaults.InitMgrImage() + `","imagePullPolicy":"IfNotPresent","name":"envoy-init-mgr","resources":{},"terminationMessagePath":"/dev/termination-log","terminationMessagePolicy":"File","volumeMounts":[{"mountPath":"/etc/envoy/bootstrap","name":"envoy-sidecar-bootstrap"}]}]},{"op":"add","path":"/spec/volumes","value":[{"name":"envoy-sidecar-tls","secret":{"defaultMode":420,"secretName":"envoy-sidecar-client-cert"}},{"emptyDir":{},"name":"envoy-sidecar-bootstrap"}]}]`),
+			want: []byte(`[{"op":"add","path":"/spec/containers/1","value":{"args":["-c","/etc/envoy/bootstrap/config.json","--service-node","test","--service-cluster","test"],"command":["envoy"],"image":"` + defaults.Image + `","imagePullPolicy":"IfNotPresent","livenessProbe":{"failureThreshold":10,"httpGet":{"path":"/ready","port":9901,"scheme":"HTTP"},"initialDelaySeconds":30,"periodSeconds":10,"successThreshold":1,"timeoutSeconds":1},"name":"envoy-sidecar","ports":[{"containerPort":9901,"name":"admin","protocol":"TCP"}],"readinessProbe":{"failureThreshold":1,"httpGet":{"path":"/ready","port":9901,"scheme":"HTTP"},"initialDelaySeconds":15,"periodSeconds":5,"successThreshold":1,"timeoutSeconds":1},"resources":{},"terminationMessagePath":"/dev/termination-log","terminationMessagePolicy":"File","volumeMounts":[{"mountPath":"/etc/envoy/tls/client","name":"envoy-sidecar-tls","readOnly":true},{"mountPath":"/etc/envoy/bootstrap","name":"envoy-sidecar-bootstrap","readOnly":true}]}},{"op":"add","path":"/spec/initContainers","value":[{"args":["init-manager","--admin-access-log-path","/dev/null","--admin-bind-address","0.0.0.0:9901","--api-version","v3","--client-certificate-path","/etc/envoy/tls/client","--config-file","/etc/envoy/bootstrap/config.json","--resources-path","/etc/envoy/bootstrap","--rtds-resource-name","runtime","--xdss-host","marin3r-instance.default.svc","--xdss-port","18000","--envoy-image","` + defaults.Image + `"],"env":[{"name":"POD_NAME","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.name"}}},{"name":"POD_NAMESPACE","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.namespace"}}},{"name":"HOST_NAME","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"spec.nodeName"}}},{"name":"POD_IP","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"status.podIP"}}},{"name":"HOST_IP","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"status.hostIP"}}},{"name":"LOCALITY_REGION","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.labels['topology.kubernetes.io/region']"}}},{"name":"LOCALITY_ZONE","valueFrom":{"fieldRef":{"apiVersion":"v1","fieldPath":"metadata.labels['topology.kubernetes.io/zone']"}}}],"image":"` + defaults.InitMgrImage() + `","imagePullPolicy":"IfNotPresent","name":"envoy-init-mgr","resources":{},"terminationMessagePath":"/dev/termination-log","terminationMessagePolicy":"File","volumeMounts":[{"mountPath":"/etc/envoy/bootstrap","name":"envoy-sidecar-bootstrap"}]}]},{"op":"add","path":"/spec/volumes","value":[{"name":"envoy-sidecar-tls","secret":{"defaultMode":420,"secretName":"envoy-sidecar-client-cert"}},{"emptyDir":{},"name":"envoy-sidecar-bootstrap"}]}]`),
 		},
 	}
 	for _, tt := range tests {
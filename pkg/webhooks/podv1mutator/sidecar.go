@@ -74,7 +74,8 @@ const (
 
 	// Annotations to allow configuration of the init manager for
 	// Envoy sidecards
-	paramInitMgrImage = "init-manager.image"
+	paramInitMgrImage            = "init-manager.image"
+	paramInitMgrXdstpAuthorities = "init-manager.xdstp-authorities"
 )
 
 type envoySidecarConfig struct {
@@ -134,6 +135,7 @@ func (esc *envoySidecarConfig) PopulateFromAnnotations(ctx context.Context, clnt
 	esc.generator.ShutdownManagerDrainStrategy = getDrainStrategy(annotations)
 
 	esc.generator.InitManagerImage = getStringParam(paramInitMgrImage, annotations)
+	esc.generator.XdstpAuthorities = parseXdstpAuthoritiesAnnotation(annotations)
 
 	xdssHost, xdssPort, err := getDiscoveryServiceAddress(ctx, clnt, namespace, annotations)
 	if err != nil {
@@ -429,6 +431,14 @@ func parseExtraLifecycleHooksAnnotation(annotations map[string]string) []string
 	return hooks
 }
 
+func parseXdstpAuthoritiesAnnotation(annotations map[string]string) []string {
+	c := getStringParam(paramInitMgrXdstpAuthorities, annotations)
+	if c == "" {
+		return nil
+	}
+	return strings.Split(c, ",")
+}
+
 func getContainerByName(name string, containers []corev1.Container) (corev1.Container, int, error) {
 	for pos, c := range containers {
 		if c.Name == name {
@@ -945,6 +945,41 @@ func Test_parseExtraLifecycleHooksAnnotation(t *testing.T) {
 	}
 }
 
+func Test_parseXdstpAuthoritiesAnnotation(t *testing.T) {
+	type args struct {
+		annotations map[string]string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "Parses a list of authorities",
+			args: args{
+				annotations: map[string]string{
+					"marin3r.3scale.net/init-manager.xdstp-authorities": "a.example.com=xdss-a:18000,b.example.com=xdss-b:18000",
+				},
+			},
+			want: []string{"a.example.com=xdss-a:18000", "b.example.com=xdss-b:18000"},
+		},
+		{
+			name: "Returns nil if unset",
+			args: args{
+				annotations: map[string]string{},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseXdstpAuthoritiesAnnotation(tt.args.annotations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseXdstpAuthoritiesAnnotation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_getContainerByName(t *testing.T) {
 	type args struct {
 		name       string
@@ -0,0 +1,79 @@
+package policyenforcer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy/datasources"
+	"github.com/3scale-ops/marin3r/pkg/envoy/templates"
+	envoyconfig "github.com/3scale-ops/marin3r/pkg/reconcilers/marin3r/envoyconfig"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ValidatePath is the path where the webhook server listens
+	// for admission requests
+	ValidatePath string = "/envoyconfig-v1alpha1-enforce-policies"
+)
+
+// PolicyEnforcer enforces the EnvoyConfigPolicies on EnvoyConfigs and EnvoyConfigFragments
+type PolicyEnforcer struct {
+	Client  client.Client
+	Decoder *admission.Decoder
+}
+
+// PolicyEnforcer implements admission.Handler.
+var _ admission.Handler = &PolicyEnforcer{}
+
+//+kubebuilder:webhook:path=/envoyconfig-v1alpha1-enforce-policies,mutating=false,failurePolicy=fail,sideEffects=None,groups=marin3r.3scale.net,resources=envoyconfigs;envoyconfigfragments,verbs=create;update,versions=v1alpha1,name=envoyconfig-policy.marin3r.3scale.net,admissionReviewVersions=v1
+//+kubebuilder:rbac:groups=marin3r.3scale.net,namespace=placeholder,resources=envoyconfigpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",namespace=placeholder,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="core",namespace=placeholder,resources=configmaps;secrets,verbs=get;list;watch
+
+// Handle evaluates the EnvoyConfigPolicies that select the namespace of the EnvoyConfig, or
+// EnvoyConfigFragment, against its resources. The object is denied if any resource does not
+// satisfy a rule with the 'Deny' action, and violations of rules with the 'Warn' action are
+// returned as warnings. The resources of templated EnvoyConfigs are rendered, and data sources
+// inlined, with the ConfigMaps and Secrets available at admission time. The EnvoyConfig
+// controller evaluates the policies again before publishing the resources, so later changes
+// to them, or to the fragments, cannot bypass the policies.
+func (a *PolicyEnforcer) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ec := &marin3rv1alpha1.EnvoyConfig{}
+	if req.Kind.Kind == "EnvoyConfigFragment" {
+		ecf := &marin3rv1alpha1.EnvoyConfigFragment{}
+		if err := a.Decoder.Decode(req, ecf); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		ec = ecf.AsEnvoyConfig()
+	} else if err := a.Decoder.Decode(req, ec); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	policies, err := envoyconfig.SelectPolicies(ctx, a.Client, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(policies) == 0 {
+		return admission.Allowed("")
+	}
+
+	// policies are evaluated against the rendered and patched resources
+	if err := templates.RenderEnvoyConfig(ctx, a.Client, ec); err != nil {
+		return admission.Denied(fmt.Sprintf("unable to render the resources to evaluate the policies: %s", err))
+	}
+	if err := datasources.InlineEnvoyConfig(ctx, a.Client, ec); err != nil {
+		return admission.Denied(fmt.Sprintf("unable to inline the data sources to evaluate the policies: %s", err))
+	}
+	if err := ec.ApplyPatches(); err != nil {
+		return admission.Denied(fmt.Sprintf("unable to patch the resources to evaluate the policies: %s", err))
+	}
+
+	warnings, err := ec.ValidatePolicies(policies)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
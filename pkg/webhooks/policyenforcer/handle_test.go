@@ -0,0 +1,172 @@
+package policyenforcer
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	"github.com/3scale-ops/marin3r/pkg/envoy"
+	k8sutil "github.com/3scale-ops/marin3r/pkg/util/k8s"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func init() {
+	marin3rv1alpha1.AddToScheme(scheme.Scheme)
+}
+
+func TestPolicyEnforcer_Handle(t *testing.T) {
+	config := func(cluster string) runtime.RawExtension {
+		raw, _ := json.Marshal(&marin3rv1alpha1.EnvoyConfig{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfig"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "prod"},
+			Spec: marin3rv1alpha1.EnvoyConfigSpec{
+				NodeID:    "node",
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(cluster)}},
+			},
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+	policy := func(name string, selector *metav1.LabelSelector, action marin3rv1alpha1.PolicyAction) *marin3rv1alpha1.EnvoyConfigPolicy {
+		return &marin3rv1alpha1.EnvoyConfigPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: marin3rv1alpha1.EnvoyConfigPolicySpec{
+				NamespaceSelector: selector,
+				Rules: []marin3rv1alpha1.PolicyRule{{
+					Name:       "connect-timeout",
+					Type:       envoy.Cluster,
+					Expression: "has(object.connect_timeout)",
+					Message:    ptr.To("clusters must set connect_timeout"),
+					Action:     ptr.To(action),
+				}},
+			},
+		}
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "production"}}}
+
+	fragment := func(cluster string) runtime.RawExtension {
+		raw, _ := json.Marshal(&marin3rv1alpha1.EnvoyConfigFragment{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfigFragment"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ecf", Namespace: "prod"},
+			Spec: marin3rv1alpha1.EnvoyConfigFragmentSpec{
+				NodeID:    "node",
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster, Value: k8sutil.StringtoRawExtension(cluster)}},
+			},
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	templated := func(timeout string) runtime.RawExtension {
+		raw, _ := json.Marshal(&marin3rv1alpha1.EnvoyConfig{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfig"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ec", Namespace: "prod"},
+			Spec: marin3rv1alpha1.EnvoyConfigSpec{
+				NodeID:     "node",
+				Parameters: map[string]string{"name": "cluster"},
+				Resources: []marin3rv1alpha1.Resource{{Type: envoy.Cluster,
+					Value: k8sutil.StringtoRawExtension(`{"name":"{{ .name }}"` + timeout + `}`)}},
+			},
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+
+	tests := []struct {
+		name         string
+		objs         []client.Object
+		kind         string
+		object       runtime.RawExtension
+		wantAllowed  bool
+		wantMessage  string
+		wantWarnings []string
+	}{
+		{
+			name:        "Allows when there are no policies",
+			objs:        []client.Object{namespace},
+			object:      config(`{"name":"cluster"}`),
+			wantAllowed: true,
+		},
+		{
+			name:        "Denies resources that do not satisfy a rule",
+			objs:        []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.DenyPolicyAction)},
+			object:      config(`{"name":"cluster"}`),
+			wantAllowed: false,
+			wantMessage: "policy 'clusters', rule 'connect-timeout': cluster 'cluster': clusters must set connect_timeout",
+		},
+		{
+			name:        "Allows resources that satisfy the rules",
+			objs:        []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.DenyPolicyAction)},
+			object:      config(`{"name":"cluster","connect_timeout":"1s"}`),
+			wantAllowed: true,
+		},
+		{
+			name:         "Warns for rules with the 'Warn' action",
+			objs:         []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.WarnPolicyAction)},
+			object:       config(`{"name":"cluster"}`),
+			wantAllowed:  true,
+			wantWarnings: []string{"policy 'clusters', rule 'connect-timeout': cluster 'cluster': clusters must set connect_timeout"},
+		},
+		{
+			name:        "Denies fragments that do not satisfy a rule",
+			objs:        []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.DenyPolicyAction)},
+			kind:        "EnvoyConfigFragment",
+			object:      fragment(`{"name":"cluster"}`),
+			wantAllowed: false,
+			wantMessage: "policy 'clusters', rule 'connect-timeout': cluster 'cluster': clusters must set connect_timeout",
+		},
+		{
+			name:        "Evaluates the rendered resources of templated EnvoyConfigs",
+			objs:        []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.DenyPolicyAction)},
+			object:      templated(""),
+			wantAllowed: false,
+			wantMessage: "policy 'clusters', rule 'connect-timeout': cluster 'cluster': clusters must set connect_timeout",
+		},
+		{
+			name:        "Allows templated EnvoyConfigs that satisfy the rules",
+			objs:        []client.Object{namespace, policy("clusters", nil, marin3rv1alpha1.DenyPolicyAction)},
+			object:      templated(`,"connect_timeout":"1s"`),
+			wantAllowed: true,
+		},
+		{
+			name: "Ignores policies that do not select the namespace",
+			objs: []client.Object{namespace, policy("clusters",
+				&metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}}, marin3rv1alpha1.DenyPolicyAction)},
+			object:      config(`{"name":"cluster"}`),
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &PolicyEnforcer{
+				Client:  fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tt.objs...).Build(),
+				Decoder: admission.NewDecoder(scheme.Scheme),
+			}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "xxxx",
+				Kind:      metav1.GroupVersionKind{Group: marin3rv1alpha1.GroupVersion.Group, Version: marin3rv1alpha1.GroupVersion.Version, Kind: tt.kind},
+				Operation: admissionv1.Create,
+				Namespace: "prod",
+				Object:    tt.object,
+			}}
+			got := a.Handle(context.TODO(), req)
+			if got.Allowed != tt.wantAllowed {
+				t.Fatalf("PolicyEnforcer.Handle() allowed = %v, want %v (%v)", got.Allowed, tt.wantAllowed, got.Result)
+			}
+			if tt.wantMessage != "" && !strings.Contains(got.Result.Message, tt.wantMessage) {
+				t.Errorf("PolicyEnforcer.Handle() message = %v, want %v", got.Result.Message, tt.wantMessage)
+			}
+			if !reflect.DeepEqual([]string(got.Warnings), tt.wantWarnings) {
+				t.Errorf("PolicyEnforcer.Handle() warnings = %v, want %v", got.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package revisionapproval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MutatePath is the path where the webhook server listens
+	// for admission requests
+	MutatePath string = "/envoyconfigrevision-v1alpha1-approve"
+)
+
+// RevisionApprover records the approvals of EnvoyConfigRevisions
+type RevisionApprover struct {
+	Decoder  *admission.Decoder
+	Recorder record.EventRecorder
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// RevisionApprover implements admission.Handler.
+var _ admission.Handler = &RevisionApprover{}
+
+//+kubebuilder:webhook:path=/envoyconfigrevision-v1alpha1-approve,mutating=true,failurePolicy=fail,sideEffects=NoneOnDryRun,groups=marin3r.3scale.net,resources=envoyconfigrevisions,verbs=create;update,versions=v1alpha1,name=envoyconfigrevision-approval.marin3r.3scale.net,admissionReviewVersions=v1
+
+// Handle records the user that approves an EnvoyConfigRevision in the revision, and in an Event. The
+// approval annotation must match the version of the revision, and the approver cannot be any of the
+// users that made the change. The annotations that record the approval can only be set by this webhook: any change
+// to them not caused by a new approval is reverted. The author of the change is recorded when the
+// revision is created and any later change to it is reverted too.
+func (a *RevisionApprover) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+	if err := a.Decoder.Decode(req, ecr); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	old := &marin3rv1alpha1.EnvoyConfigRevision{}
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		if err := a.Decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	annotations := ecr.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	approved, isApproved := annotations[marin3rv1alpha1.ApprovedVersionAnnotation]
+	previous, wasApproved := old.GetAnnotations()[marin3rv1alpha1.ApprovedVersionAnnotation]
+
+	// the author of the change is set when the revision is created, and cannot be changed later
+	changedBy := annotations[marin3rv1alpha1.ChangedByAnnotation]
+	if req.Operation == admissionv1.Update {
+		var ok bool
+		if changedBy, ok = old.GetAnnotations()[marin3rv1alpha1.ChangedByAnnotation]; ok {
+			annotations[marin3rv1alpha1.ChangedByAnnotation] = changedBy
+		} else {
+			delete(annotations, marin3rv1alpha1.ChangedByAnnotation)
+		}
+	}
+
+	if isApproved && (!wasApproved || approved != previous) {
+		if approved != ecr.Spec.Version {
+			return admission.Denied(fmt.Sprintf("the '%s' annotation must match the version of the revision ('%s')",
+				marin3rv1alpha1.ApprovedVersionAnnotation, ecr.Spec.Version))
+		}
+		for _, author := range strings.Split(changedBy, ",") {
+			if author != "" && author == req.UserInfo.Username {
+				return admission.Denied(fmt.Sprintf("version '%s' was changed by '%s', who cannot approve it", approved, author))
+			}
+		}
+		annotations[marin3rv1alpha1.ApprovedByAnnotation] = req.UserInfo.Username
+		annotations[marin3rv1alpha1.ApprovedAtAnnotation] = a.now().UTC().Format(time.RFC3339)
+		if req.DryRun == nil || !*req.DryRun {
+			a.Recorder.Eventf(ecr, corev1.EventTypeNormal, "Approved", "Version '%s' approved by '%s'", approved, req.UserInfo.Username)
+		}
+
+	} else {
+		for _, key := range []string{marin3rv1alpha1.ApprovedByAnnotation, marin3rv1alpha1.ApprovedAtAnnotation} {
+			if value, ok := old.GetAnnotations()[key]; ok && isApproved {
+				annotations[key] = value
+			} else {
+				delete(annotations, key)
+			}
+		}
+	}
+	ecr.SetAnnotations(annotations)
+
+	marshaled, err := json.Marshal(ecr)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+func (a *RevisionApprover) now() time.Time {
+	if a.Now == nil {
+		return time.Now()
+	}
+	return a.Now()
+}
@@ -0,0 +1,210 @@
+package revisionapproval
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	marin3rv1alpha1 "github.com/3scale-ops/marin3r/apis/marin3r/v1alpha1"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func init() {
+	marin3rv1alpha1.AddToScheme(scheme.Scheme)
+}
+
+func TestRevisionApprover_Handle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	revision := func(annotations map[string]string) runtime.RawExtension {
+		raw, _ := json.Marshal(&marin3rv1alpha1.EnvoyConfigRevision{
+			TypeMeta:   metav1.TypeMeta{APIVersion: marin3rv1alpha1.GroupVersion.String(), Kind: "EnvoyConfigRevision"},
+			ObjectMeta: metav1.ObjectMeta{Name: "ecr", Namespace: "default", Annotations: annotations},
+			Spec:       marin3rv1alpha1.EnvoyConfigRevisionSpec{NodeID: "node", Version: "xxxx"},
+		})
+		return runtime.RawExtension{Raw: raw}
+	}
+	approvedBy := func(user string) map[string]string {
+		return map[string]string{
+			marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+			marin3rv1alpha1.ApprovedByAnnotation:      user,
+			marin3rv1alpha1.ApprovedAtAnnotation:      now.Add(-time.Hour).Format(time.RFC3339),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		operation       admissionv1.Operation
+		dryRun          bool
+		object          map[string]string
+		oldObject       map[string]string
+		wantAllowed     bool
+		wantAnnotations map[string]string
+		wantEvent       bool
+	}{
+		{
+			name:            "Allows revisions without approval",
+			operation:       admissionv1.Create,
+			object:          nil,
+			wantAllowed:     true,
+			wantAnnotations: nil,
+		},
+		{
+			name:        "Records the approver",
+			operation:   admissionv1.Update,
+			object:      map[string]string{marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx"},
+			wantAllowed: true,
+			wantAnnotations: map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+				marin3rv1alpha1.ApprovedByAnnotation:      "approver",
+				marin3rv1alpha1.ApprovedAtAnnotation:      now.Format(time.RFC3339),
+			},
+			wantEvent: true,
+		},
+		{
+			name:        "Does not send events in dry runs",
+			operation:   admissionv1.Update,
+			dryRun:      true,
+			object:      map[string]string{marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx"},
+			wantAllowed: true,
+			wantAnnotations: map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+				marin3rv1alpha1.ApprovedByAnnotation:      "approver",
+				marin3rv1alpha1.ApprovedAtAnnotation:      now.Format(time.RFC3339),
+			},
+		},
+		{
+			name:        "Denies an approval for another version",
+			operation:   admissionv1.Update,
+			object:      map[string]string{marin3rv1alpha1.ApprovedVersionAnnotation: "aaaa"},
+			wantAllowed: false,
+		},
+		{
+			name:      "Denies approvals from the user that made the change",
+			operation: admissionv1.Update,
+			object: map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+				marin3rv1alpha1.ChangedByAnnotation:       "approver",
+			},
+			oldObject:   map[string]string{marin3rv1alpha1.ChangedByAnnotation: "approver"},
+			wantAllowed: false,
+		},
+		{
+			name:      "Denies approvals from any of the users that made the change",
+			operation: admissionv1.Update,
+			object: map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+				marin3rv1alpha1.ChangedByAnnotation:       "author,approver",
+			},
+			oldObject:   map[string]string{marin3rv1alpha1.ChangedByAnnotation: "author,approver"},
+			wantAllowed: false,
+		},
+		{
+			name:        "Denies approvals from the author that removes the recorded author",
+			operation:   admissionv1.Update,
+			object:      map[string]string{marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx"},
+			oldObject:   map[string]string{marin3rv1alpha1.ChangedByAnnotation: "approver"},
+			wantAllowed: false,
+		},
+		{
+			name:      "Denies approvals from the author that changes the recorded author",
+			operation: admissionv1.Update,
+			object: map[string]string{
+				marin3rv1alpha1.ApprovedVersionAnnotation: "xxxx",
+				marin3rv1alpha1.ChangedByAnnotation:       "someone-else",
+			},
+			oldObject:   map[string]string{marin3rv1alpha1.ChangedByAnnotation: "approver"},
+			wantAllowed: false,
+		},
+		{
+			name:            "Does not allow changing the recorded author",
+			operation:       admissionv1.Update,
+			object:          map[string]string{marin3rv1alpha1.ChangedByAnnotation: "someone-else"},
+			oldObject:       map[string]string{marin3rv1alpha1.ChangedByAnnotation: "author"},
+			wantAllowed:     true,
+			wantAnnotations: map[string]string{marin3rv1alpha1.ChangedByAnnotation: "author"},
+		},
+		{
+			name:            "Keeps the recorded approver",
+			operation:       admissionv1.Update,
+			object:          approvedBy("someone-else"),
+			oldObject:       approvedBy("approver"),
+			wantAllowed:     true,
+			wantAnnotations: approvedBy("approver"),
+		},
+		{
+			name:            "Does not allow forging the approver",
+			operation:       admissionv1.Create,
+			object:          map[string]string{marin3rv1alpha1.ApprovedByAnnotation: "someone-else"},
+			wantAllowed:     true,
+			wantAnnotations: nil,
+		},
+		{
+			name:            "Removes the approver when the approval is removed",
+			operation:       admissionv1.Update,
+			object:          map[string]string{marin3rv1alpha1.ApprovedByAnnotation: "approver"},
+			oldObject:       approvedBy("approver"),
+			wantAllowed:     true,
+			wantAnnotations: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			a := &RevisionApprover{
+				Decoder:  admission.NewDecoder(scheme.Scheme),
+				Recorder: recorder,
+				Now:      func() time.Time { return now },
+			}
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UID:       "xxxx",
+				Kind:      metav1.GroupVersionKind{Group: "marin3r.3scale.net", Version: "v1alpha1", Kind: "EnvoyConfigRevision"},
+				Namespace: "default",
+				Operation: tt.operation,
+				UserInfo:  authenticationv1.UserInfo{Username: "approver"},
+				DryRun:    ptr.To(tt.dryRun),
+				Object:    revision(tt.object),
+			}}
+			if tt.operation == admissionv1.Update {
+				req.OldObject = revision(tt.oldObject)
+			}
+
+			resp := a.Handle(context.TODO(), req)
+			if resp.Allowed != tt.wantAllowed {
+				t.Fatalf("RevisionApprover.Handle() allowed = %v, want %v (%v)", resp.Allowed, tt.wantAllowed, resp.Result)
+			}
+			if !resp.Allowed {
+				return
+			}
+
+			patch, _ := json.Marshal(resp.Patches)
+			decoded, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				t.Fatalf("unable to decode patch: %v", err)
+			}
+			patched, err := decoded.Apply(req.Object.Raw)
+			if err != nil {
+				t.Fatalf("unable to apply patch: %v", err)
+			}
+			ecr := &marin3rv1alpha1.EnvoyConfigRevision{}
+			if err := json.Unmarshal(patched, ecr); err != nil {
+				t.Fatalf("unable to decode patched revision: %v", err)
+			}
+			if got := ecr.GetAnnotations(); !reflect.DeepEqual(got, tt.wantAnnotations) {
+				t.Errorf("RevisionApprover.Handle() annotations = %v, want %v", got, tt.wantAnnotations)
+			}
+			if gotEvent := len(recorder.Events) > 0; gotEvent != tt.wantEvent {
+				t.Errorf("RevisionApprover.Handle() event = %v, want %v", gotEvent, tt.wantEvent)
+			}
+		})
+	}
+}